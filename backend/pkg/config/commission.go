@@ -0,0 +1,98 @@
+package config
+
+import (
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"sniper-bot/pkg/eth"
+)
+
+// CommissionConfig is the operator's cut of each snipe. It is sent from the
+// sniper's wallet to Treasury in a plain transfer with the nonce right after
+// the snipe, so it is charged whether or not the snipe succeeds.
+type CommissionConfig struct {
+	Treasury string
+
+	Flat       *big.Int // wei; set for a flat commission
+	PercentPPM *big.Int // parts per million of the swap amount; set for a percent commission
+
+	raw string // As configured, for display
+}
+
+// loadCommissionConfig reads COMMISSION ("0.001" ETH flat, or "1%" of the swap
+// amount) and COMMISSION_TREASURY. An invalid setting disables the commission.
+func loadCommissionConfig() CommissionConfig {
+	value := strings.TrimSpace(os.Getenv("COMMISSION"))
+	treasury := os.Getenv("COMMISSION_TREASURY")
+	if value == "" {
+		return CommissionConfig{}
+	}
+
+	if !isHexAddress(treasury) {
+		log.Printf("Warning: COMMISSION is set but COMMISSION_TREASURY=%q is not a valid address, commission disabled", treasury)
+		return CommissionConfig{}
+	}
+
+	commission := CommissionConfig{Treasury: treasury, raw: value}
+
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		// 4 decimals of a percent are parts per million
+		ppm, err := eth.ParseUnits(percent, 4)
+		if err != nil || ppm.Sign() <= 0 || ppm.Cmp(big.NewInt(100*1e4)) >= 0 {
+			log.Printf("Warning: invalid COMMISSION=%q, commission disabled", value)
+			return CommissionConfig{}
+		}
+		commission.PercentPPM = ppm
+		return commission
+	}
+
+	flat, err := eth.ParseEther(value)
+	if err != nil || flat.Sign() <= 0 {
+		log.Printf("Warning: invalid COMMISSION=%q, commission disabled", value)
+		return CommissionConfig{}
+	}
+	commission.Flat = flat
+	return commission
+}
+
+// Enabled reports whether a commission is charged
+func (c CommissionConfig) Enabled() bool {
+	return c.Flat != nil || c.PercentPPM != nil
+}
+
+// Amount returns the commission in wei for a snipe swapping swapAmount
+func (c CommissionConfig) Amount(swapAmount *big.Int) *big.Int {
+	switch {
+	case c.Flat != nil:
+		return new(big.Int).Set(c.Flat)
+	case c.PercentPPM != nil:
+		amount := new(big.Int).Mul(swapAmount, c.PercentPPM)
+		return amount.Div(amount, big.NewInt(1e6))
+	default:
+		return big.NewInt(0)
+	}
+}
+
+// Describe renders the commission for users, e.g. "1% of the swap amount"
+func (c CommissionConfig) Describe() string {
+	if c.PercentPPM != nil {
+		return c.raw + " of the swap amount"
+	}
+	return c.raw + " ETH"
+}
+
+// isHexAddress reports whether s is a 0x-prefixed 20-byte hex address
+func isHexAddress(s string) bool {
+	hexPart, ok := strings.CutPrefix(s, "0x")
+	if !ok || len(hexPart) != 40 {
+		return false
+	}
+	for _, c := range hexPart {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -1,7 +1,199 @@
 package config
 
 import (
+	"fmt"
+	"log"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BribeMode selects where a sniper's competitive bid goes
+type BribeMode string
+
+const (
+	// BribeModeContract passes the bribe to snipeWithBribe, which pays it to the
+	// token creator. Transaction fees only encode the bribe ranking: every snipe
+	// gets the same priority fee and the max fee steps down 1 wei per position.
+	BribeModeContract BribeMode = "contract"
+
+	// BribeModeTip spends the bribe on the transaction priority fee instead
+	// (bribe / gas limit per gas) and passes a zero bribe to the contract, so
+	// the sequencer's fee ordering and the bid ranking are the same thing.
+	BribeModeTip BribeMode = "tip"
+)
+
+// CreatorSource selects which address of an addLiquidityETH transaction is
+// treated as the token creator, i.e. the recipient of snipe bribes
+type CreatorSource string
+
+const (
+	// CreatorSourceSender uses the address that signed the LP_ADD transaction
+	CreatorSourceSender CreatorSource = "sender"
+
+	// CreatorSourceRecipient uses the `to` argument of addLiquidityETH, which
+	// receives the LP tokens. Deployers often fund the LP_ADD from a separate
+	// EOA, in which case this is the address that actually owns the launch.
+	CreatorSourceRecipient CreatorSource = "recipient"
+)
+
+// SenderFallback selects what happens to a launch transaction whose signer
+// can't be recovered (unsupported transaction type, bad signature)
+type SenderFallback string
+
+const (
+	// SenderFallbackRecipient uses the addLiquidityETH `to` recipient as the
+	// creator. createPair has no recipient and is notified without a creator.
+	SenderFallbackRecipient SenderFallback = "recipient"
+
+	// SenderFallbackNone notifies without a creator; the bot service then
+	// refunds bribes to the snipers instead of paying them out
+	SenderFallbackNone SenderFallback = "none"
+
+	// SenderFallbackSkip drops the notification and forwards the transaction
+	SenderFallbackSkip SenderFallback = "skip"
+)
+
+// TriggerStrategy selects which launch transaction fires the snipe bundle
+type TriggerStrategy string
+
+const (
+	// TriggerAddLiquidity fires on addLiquidityETH: the bundle is the LP_ADD
+	// followed by the snipes, so the snipes are guaranteed to see liquidity
+	TriggerAddLiquidity TriggerStrategy = "add_liquidity"
+
+	// TriggerCreatePair fires on the factory's createPair, for deployers who
+	// create the pair and add liquidity in separate transactions. The snipes
+	// are submitted right behind createPair, speculatively: they only succeed
+	// if the liquidity lands before them, and revert (paying gas) otherwise.
+	TriggerCreatePair TriggerStrategy = "create_pair"
+)
+
+// DuplicateSnipePolicy selects what happens when a user queues a snipe for a
+// token they already have a pending snipe for
+type DuplicateSnipePolicy string
+
+const (
+	// DuplicateSnipeMerge folds the new snipe into the pending one: amounts
+	// are summed and the higher bribe is kept
+	DuplicateSnipeMerge DuplicateSnipePolicy = "merge"
+
+	// DuplicateSnipeReject refuses the new snipe
+	DuplicateSnipeReject DuplicateSnipePolicy = "reject"
+
+	// DuplicateSnipeAllow queues both; they compete in the bundle as separate txs
+	DuplicateSnipeAllow DuplicateSnipePolicy = "allow"
+)
+
+// SellApproval selects how much of the token a sell approves the router for
+// when its allowance is too low
+type SellApproval string
+
+const (
+	// SellApprovalExact approves the amount being sold, so every sell that
+	// finds the allowance spent sends its own approve
+	SellApprovalExact SellApproval = "exact"
+
+	// SellApprovalMax approves the maximum uint256 once, and later sells of
+	// the token skip the approve
+	SellApprovalMax SellApproval = "max"
+)
+
+// TokenBuildPolicy selects what happens to an LP_ADD notification that
+// arrives while a bundle for the same token is still being built
+type TokenBuildPolicy string
+
+const (
+	// TokenBuildQueue waits up to BundleQueueTimeout for the running build to
+	// finish, then builds from whatever snipes are still pending
+	TokenBuildQueue TokenBuildPolicy = "queue"
+
+	// TokenBuildSkip submits only the second launch tx, without snipes
+	TokenBuildSkip TokenBuildPolicy = "skip"
+)
+
+// LaunchRevertPolicy selects what happens to a bundle's snipes when its
+// LP_ADD is mined but reverts
+type LaunchRevertPolicy string
+
+const (
+	// LaunchRevertRequeue places each snipe that didn't land again as a new
+	// pending snipe, which catches the creator's next LP_ADD
+	LaunchRevertRequeue LaunchRevertPolicy = "requeue"
+
+	// LaunchRevertKeep leaves the snipes to revert, or be cancelled, like any
+	// other bundle
+	LaunchRevertKeep LaunchRevertPolicy = "keep"
+)
+
+// BribeGasCheck selects what /snipe does when the bribe can't cover the
+// snipe's own gas cost at current fees
+type BribeGasCheck string
+
+const (
+	// BribeGasCheckOff skips the check
+	BribeGasCheckOff BribeGasCheck = "off"
+
+	// BribeGasCheckWarn adds a warning to the snipe confirmation
+	BribeGasCheckWarn BribeGasCheck = "warn"
+
+	// BribeGasCheckBlock refuses the snipe
+	BribeGasCheckBlock BribeGasCheck = "block"
+)
+
+// BundleSelection selects which snipes get the slots of a bundle that can't
+// hold all of them
+type BundleSelection string
+
+const (
+	// BundleSelectionBribe fills the bundle with the highest bribes
+	BundleSelectionBribe BundleSelection = "bribe"
+
+	// BundleSelectionMixed reserves BundleEarlyShare percent of the bundle for
+	// the earliest-placed snipes and gives the rest to the highest bribes
+	BundleSelectionMixed BundleSelection = "mixed"
+)
+
+// NonceStrategy selects how the next nonce of a wallet is read
+type NonceStrategy string
+
+const (
+	// NonceStrategyPending trusts the node's pending nonce
+	NonceStrategyPending NonceStrategy = "pending"
+
+	// NonceStrategyReconcile cross-checks the pending nonce with the latest
+	// block's and uses the latest when the pending one is stale or more than
+	// NonceMaxGap ahead
+	NonceStrategyReconcile NonceStrategy = "reconcile"
+
+	// NonceStrategyLatest always uses the latest block's nonce
+	NonceStrategyLatest NonceStrategy = "latest"
+)
+
+// TxType selects the kind of transaction the bot signs on a chain
+type TxType string
+
+const (
+	// TxTypeDynamicFee signs EIP-1559 transactions with a tip and a fee cap
+	TxTypeDynamicFee TxType = "1559"
+
+	// TxTypeLegacy signs transactions with a single gas price, for chains
+	// without a base fee
+	TxTypeLegacy TxType = "legacy"
+)
+
+// SnipeCooldownPolicy selects what /snipe does for a token whose launch was
+// submitted less than SnipeCooldown ago
+type SnipeCooldownPolicy string
+
+const (
+	// SnipeCooldownWarn adds a warning to the snipe confirmation
+	SnipeCooldownWarn SnipeCooldownPolicy = "warn"
+
+	// SnipeCooldownBlock refuses the snipe
+	SnipeCooldownBlock SnipeCooldownPolicy = "block"
 )
 
 // Config holds all configuration for the application
@@ -25,30 +217,733 @@ type Config struct {
 	UniswapV2Router  string
 	UniswapV2Factory string
 
-	// Sniper contract
+	// Sniper contract. The ABI is read from SniperABI (inline JSON) or
+	// SniperABIPath, falling back to the embedded one, so a redeployed
+	// contract doesn't require a new build.
 	SniperContract string
+	SniperABI      string
+	SniperABIPath  string
+
+	// Auth. AdminAuthKey is the bearer token of the bot API's operator
+	// endpoints; AuthKey only grants service-level access.
+	AuthKey      string
+	AdminAuthKey string
+
+	// Telegram user IDs allowed to run operator commands such as /pause
+	AdminUserIDs []string
+
+	// HD wallet seed (BIP39). When set, new wallets are derived from it
+	// instead of being generated from independent random keys.
+	HDWalletMnemonic   string
+	HDWalletPassphrase string
+
+	// Swap deadline: bundle snipes must land in the block right after the
+	// LP_ADD, so the on-chain deadline is a few block times past the head
+	// block plus a small buffer rather than a wall-clock window.
+	BlockTime          time.Duration
+	SwapDeadlineBlocks int
+	SwapDeadlineBuffer time.Duration
+
+	// No swap deadline is set more than MaxSwapDeadline past the current
+	// time, bounding how long a stale signed snipe can still execute
+	MaxSwapDeadline time.Duration
+
+	// Where the bribe goes (see BribeMode)
+	BribeMode BribeMode
+
+	// Gas parameters of snipe transactions
+	Gas GasConfig
+
+	// Bundle processing: at most MaxConcurrentBundles builds run at once; an
+	// excess build waits up to BundleQueueTimeout for a slot and is then dropped
+	MaxConcurrentBundles int
+	BundleQueueTimeout   time.Duration
+
+	// Only one bundle build runs per token at a time; a second LP_ADD for the
+	// token meanwhile is handled per TokenBuildPolicy
+	TokenBuildPolicy TokenBuildPolicy
+
+	// Bundles are skipped when the addLiquidityETH deadline is less than
+	// LaunchDeadlineBuffer past the latest block, e.g. after a delayed
+	// notification: the launch would revert and take the snipes with it
+	LaunchDeadlineBuffer time.Duration
+
+	// How long after submission a bundle's LP_ADD may take to land before
+	// the bundle's unmined transactions are cancelled (0 disables)
+	LaunchLandTimeout time.Duration
+
+	// What happens to a bundle's snipes when its LP_ADD reverts, checked
+	// LaunchLandTimeout after submission
+	LaunchRevertPolicy LaunchRevertPolicy
+
+	// How long after submission the node is asked whether it knows each
+	// accepted snipe; those it doesn't become submit_failed (0 disables)
+	SubmitVerifyDelay time.Duration
+
+	// With the bundle_archive feature, the raw transactions of every submitted
+	// bundle are kept for BundleArchiveRetention (0 keeps them forever)
+	BundleArchiveRetention time.Duration
+
+	// Max slippage in percent for bundle snipes that don't set their own. The
+	// amountOutMin is quoted against the reserves the LP_ADD creates (0 accepts
+	// any output).
+	BundleSlippage float64
+
+	// Private order flow: with the private_only feature, snipe bundles are
+	// only sent to PrivateSubmitURL (falling back to the sequencer) and never
+	// to the public RPC, and the proxy never relays sniper-contract txs publicly
+	PrivateSubmitURL string
+
+	// Features switched on or off with FEATURES; read with Enabled
+	Features FeatureFlags
+
+	// Handling of a second pending snipe by the same user for the same token
+	DuplicateSnipePolicy DuplicateSnipePolicy
+
+	// Handling of a snipe whose bribe is below its estimated gas cost
+	BribeGasCheck BribeGasCheck
+
+	// How long after a token's launch was submitted new snipes for it are
+	// warned about or refused (0 disables). The launch has already happened,
+	// so they would only fire on another LP_ADD.
+	SnipeCooldown       time.Duration
+	SnipeCooldownPolicy SnipeCooldownPolicy
+
+	// Which launch transaction fires the snipe bundle (see TriggerStrategy)
+	TriggerStrategy TriggerStrategy
+
+	// Which LP_ADD address receives snipe bribes (see CreatorSource)
+	CreatorSource CreatorSource
+
+	// What to do when the LP_ADD signer can't be recovered (see SenderFallback)
+	SenderFallback SenderFallback
+
+	// Bundles are submitted to all of these endpoints in parallel; defaults to
+	// the sequencer
+	SubmitEndpoints []string
+
+	// Most snipes in one bundle (0 leaves only the MaxBundleGas cap), and how
+	// the snipes that get in are picked when there are more (see
+	// BundleSelection)
+	MaxBundleSnipes  int
+	BundleSelection  BundleSelection
+	BundleEarlyShare int // Percent of the gas and slots, for BundleSelectionMixed
+
+	// How wallet nonces are read (see NonceStrategy), and how far the pending
+	// nonce may be ahead of the latest under NonceStrategyReconcile
+	NonceStrategy NonceStrategy
+	NonceMaxGap   uint64
 
-	// Auth
-	AuthKey string
+	// Margin over the highest competing tip, in percent of it per competing
+	// transaction, that the competitor_bump feature aims the bundle's tips at
+	CompetitorBumpStep int
+
+	// Kind of transaction signed on the node's chain: its ChainTxTypes entry,
+	// keyed by decimal chain ID, or TxType otherwise (see TxTypeFor)
+	TxType       TxType
+	ChainTxTypes map[string]TxType
+
+	// How often the nonce and balance of wallets with pending snipes are
+	// pre-fetched so bundle construction can skip those RPC calls (0 disables)
+	PrewarmInterval time.Duration
+
+	// How often receipts of submitted snipes are checked to record whether
+	// they landed and what gas they used (0 disables)
+	ConfirmInterval time.Duration
+
+	// How often landed snipes with a take-profit or stop-loss are priced
+	// against the pool and sold once a target is reached (0 disables)
+	AutoSellInterval time.Duration
+
+	// How much a sell approves the router for when its allowance is short,
+	// and whether a nonzero allowance is first reset to zero, which tokens
+	// like USDT require before approving a new amount
+	SellApproval      SellApproval
+	SellApprovalReset bool
+
+	// Outbound webhook POSTed a JSON event, signed with HMAC-SHA256 of
+	// StatusWebhookSecret, whenever a snipe changes status. Deliveries are
+	// attempted up to WebhookMaxAttempts times with exponential backoff.
+	StatusWebhookURL    string
+	StatusWebhookSecret string
+	WebhookMaxAttempts  int
+
+	// Tokens the snipe funnel metrics count separately; snipes of later
+	// tokens are counted together under token="other" (0 aggregates all)
+	MetricsMaxTokens int
+
+	// Most snipe rows one POST /api/snipes/bulk request may import
+	BulkSnipeMaxRows int
+
+	// How many times key database operations are attempted on transient
+	// MySQL errors (deadlocks, lost connections), and the backoff before the
+	// first retry, doubled after each
+	DBRetryAttempts int
+	DBRetryBackoff  time.Duration
+
+	// How often the pools of tokens with landed positions are read to price
+	// them (0 disables), and the most RPC calls one poll may make. Tokens
+	// that don't fit in the budget are priced first on the next poll.
+	PricePollInterval time.Duration
+	PriceRPCBudget    int
+
+	// How often the API service retries connecting to the node and sniper
+	// contract when they were unreachable at startup
+	ConnectRetryInterval time.Duration
+
+	// How far back the bribe report looks at landed and reverted snipes
+	BribeReportWindow time.Duration
+
+	// Operator commission added to every snipe (see CommissionConfig)
+	Commission CommissionConfig
+
+	// Extra token addresses that can't be sniped, on top of WETH, the router,
+	// the factory and the sniper contract
+	BlockedSnipeTokens []string
+
+	// JSON-RPC methods the RPC proxy serves. When RPCAllowedMethods is set
+	// only those are forwarded; RPCDeniedMethods are always refused.
+	RPCAllowedMethods []string
+	RPCDeniedMethods  []string
+
+	// How long the RPC proxy caches the result of each parameterless read
+	// method (0 disables caching of that method)
+	RPCCacheTTLs map[string]time.Duration
+
+	// LP_ADD notifications the RPC proxy fails to deliver stay in its outbox
+	// and are retried every OutboxRetryInterval (0 disables retries) until
+	// they are OutboxMaxAge old. Older launches aren't worth sniping.
+	OutboxRetryInterval time.Duration
+	OutboxMaxAge        time.Duration
+
+	// How long the bot service remembers the LP_ADD transactions it was
+	// notified of, so a redelivered or out-of-order notification isn't built
+	// into a second bundle (0 disables the check)
+	LPAddDedupWindow time.Duration
+
+	// When the RPC proxy forwards a launch transaction to the sequencer
+	// itself, it makes up to LaunchForwardAttempts attempts of at most
+	// LaunchForwardTimeout each while the sequencer times out or answers with
+	// a transient error, waiting LaunchForwardBackoff before the first retry
+	// and twice as long before each later one. It gives up once
+	// LaunchForwardDeadline has passed, whatever attempts are left.
+	LaunchForwardAttempts int
+	LaunchForwardTimeout  time.Duration
+	LaunchForwardBackoff  time.Duration
+	LaunchForwardDeadline time.Duration
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
 	config := &Config{
-		TelegramBotToken:    os.Getenv("TELEGRAM_BOT_TOKEN"),
-		BaseRPCURL:          os.Getenv("BASE_RPC_URL"),
-		BaseSequencerRPCURL: os.Getenv("BASE_SEQUENCER_URL"),
-		BaseWSURL:           os.Getenv("BASE_WS_URL"),
-		DatabaseURL:         os.Getenv("DATABASE_URL"),
-		UniswapV2Router:     os.Getenv("UNISWAP_V2_ROUTER"),
-		UniswapV2Factory:    os.Getenv("UNISWAP_V2_FACTORY"),
-		AuthKey:             os.Getenv("AUTH_KEY"),
-		SniperContract:      "0xa71940cb90C8F3634DD3AB6a992D0EFF056Db48d",
+		TelegramBotToken:       os.Getenv("TELEGRAM_BOT_TOKEN"),
+		BaseRPCURL:             os.Getenv("BASE_RPC_URL"),
+		BaseSequencerRPCURL:    os.Getenv("BASE_SEQUENCER_URL"),
+		BaseWSURL:              os.Getenv("BASE_WS_URL"),
+		DatabaseURL:            os.Getenv("DATABASE_URL"),
+		UniswapV2Router:        os.Getenv("UNISWAP_V2_ROUTER"),
+		UniswapV2Factory:       os.Getenv("UNISWAP_V2_FACTORY"),
+		AuthKey:                os.Getenv("AUTH_KEY"),
+		AdminAuthKey:           os.Getenv("ADMIN_AUTH_KEY"),
+		AdminUserIDs:           getEnvList("ADMIN_USER_IDS"),
+		HDWalletMnemonic:       os.Getenv("HD_WALLET_MNEMONIC"),
+		HDWalletPassphrase:     os.Getenv("HD_WALLET_PASSPHRASE"),
+		BlockTime:              getEnvDuration("BLOCK_TIME", 2*time.Second),
+		SwapDeadlineBlocks:     getEnvInt("SWAP_DEADLINE_BLOCKS", 3),
+		SwapDeadlineBuffer:     getEnvDuration("SWAP_DEADLINE_BUFFER", 10*time.Second),
+		MaxSwapDeadline:        getEnvDuration("MAX_SWAP_DEADLINE", 30*time.Minute),
+		BribeMode:              BribeMode(os.Getenv("BRIBE_MODE")),
+		Gas:                    loadGasConfig(),
+		MaxConcurrentBundles:   getEnvInt("MAX_CONCURRENT_BUNDLES", 4),
+		BundleQueueTimeout:     getEnvDuration("BUNDLE_QUEUE_TIMEOUT", 5*time.Second),
+		TokenBuildPolicy:       TokenBuildPolicy(os.Getenv("TOKEN_BUILD_POLICY")),
+		LaunchDeadlineBuffer:   getEnvDuration("LAUNCH_DEADLINE_BUFFER", 2*time.Second),
+		LaunchLandTimeout:      getEnvDuration("LAUNCH_LAND_TIMEOUT", 6*time.Second),
+		LaunchRevertPolicy:     LaunchRevertPolicy(os.Getenv("LAUNCH_REVERT_POLICY")),
+		SubmitVerifyDelay:      getEnvDuration("SUBMIT_VERIFY_DELAY", 0),
+		BundleSlippage:         getEnvFloat("BUNDLE_SLIPPAGE", 0),
+		BundleArchiveRetention: getEnvDuration("BUNDLE_ARCHIVE_RETENTION", 7*24*time.Hour),
+		PrivateSubmitURL:       os.Getenv("PRIVATE_SUBMIT_URL"),
+		Features:               loadFeatureFlags(),
+		SubmitEndpoints:        getEnvList("SUBMIT_ENDPOINTS"),
+		CreatorSource:          CreatorSource(os.Getenv("CREATOR_SOURCE")),
+		SenderFallback:         SenderFallback(os.Getenv("SENDER_FALLBACK")),
+		TriggerStrategy:        TriggerStrategy(os.Getenv("TRIGGER_STRATEGY")),
+		DuplicateSnipePolicy:   DuplicateSnipePolicy(os.Getenv("DUPLICATE_SNIPE_POLICY")),
+		BribeGasCheck:          BribeGasCheck(os.Getenv("BRIBE_GAS_CHECK")),
+		SnipeCooldown:          getEnvDuration("SNIPE_COOLDOWN", 10*time.Minute),
+		SnipeCooldownPolicy:    SnipeCooldownPolicy(os.Getenv("SNIPE_COOLDOWN_POLICY")),
+		MaxBundleSnipes:        getEnvInt("MAX_BUNDLE_SNIPES", 0),
+		BundleSelection:        BundleSelection(os.Getenv("BUNDLE_SELECTION")),
+		BundleEarlyShare:       getEnvInt("BUNDLE_EARLY_SHARE", 25),
+		NonceStrategy:          NonceStrategy(os.Getenv("NONCE_STRATEGY")),
+		NonceMaxGap:            getEnvUint64("NONCE_MAX_GAP", 4),
+		CompetitorBumpStep:     getEnvInt("COMPETITOR_BUMP_STEP", 10),
+		TxType:                 TxType(os.Getenv("TX_TYPE")),
+		ChainTxTypes:           getEnvTxTypes("TX_TYPES"),
+		PrewarmInterval:        getEnvDuration("PREWARM_INTERVAL", 2*time.Second),
+		ConfirmInterval:        getEnvDuration("CONFIRM_INTERVAL", 5*time.Second),
+		AutoSellInterval:       getEnvDuration("AUTO_SELL_INTERVAL", 10*time.Second),
+		SellApproval:           SellApproval(os.Getenv("SELL_APPROVAL")),
+		SellApprovalReset:      getEnvBool("SELL_APPROVAL_RESET", true),
+		PricePollInterval:      getEnvDuration("PRICE_POLL_INTERVAL", 5*time.Second),
+		DBRetryAttempts:        getEnvInt("DB_RETRY_ATTEMPTS", 3),
+		StatusWebhookURL:       os.Getenv("STATUS_WEBHOOK_URL"),
+		StatusWebhookSecret:    os.Getenv("STATUS_WEBHOOK_SECRET"),
+		WebhookMaxAttempts:     getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		MetricsMaxTokens:       getEnvInt("METRICS_MAX_TOKENS", 200),
+		BulkSnipeMaxRows:       getEnvInt("BULK_SNIPE_MAX_ROWS", 500),
+		DBRetryBackoff:         getEnvDuration("DB_RETRY_BACKOFF", 100*time.Millisecond),
+		PriceRPCBudget:         getEnvInt("PRICE_RPC_BUDGET", 100),
+		BribeReportWindow:      getEnvDuration("BRIBE_REPORT_WINDOW", 7*24*time.Hour),
+		ConnectRetryInterval:   getEnvDuration("CONNECT_RETRY_INTERVAL", 5*time.Second),
+		Commission:             loadCommissionConfig(),
+		BlockedSnipeTokens:     getEnvList("SNIPE_BLOCKED_TOKENS"),
+		RPCAllowedMethods:      getEnvList("RPC_ALLOWED_METHODS"),
+		RPCDeniedMethods:       getEnvList("RPC_DENIED_METHODS"),
+		RPCCacheTTLs:           getEnvDurationMap("RPC_CACHE_TTLS", DefaultRPCCacheTTLs()),
+		OutboxRetryInterval:    getEnvDuration("LP_OUTBOX_RETRY_INTERVAL", time.Second),
+		OutboxMaxAge:           getEnvDuration("LP_OUTBOX_MAX_AGE", 30*time.Second),
+		LPAddDedupWindow:       getEnvDuration("LP_ADD_DEDUP_WINDOW", 10*time.Minute),
+		LaunchForwardAttempts:  getEnvInt("LAUNCH_FORWARD_ATTEMPTS", 3),
+		LaunchForwardTimeout:   getEnvDuration("LAUNCH_FORWARD_TIMEOUT", 2*time.Second),
+		LaunchForwardBackoff:   getEnvDuration("LAUNCH_FORWARD_BACKOFF", 200*time.Millisecond),
+		LaunchForwardDeadline:  getEnvDuration("LAUNCH_FORWARD_DEADLINE", 5*time.Second),
+		SniperContract:         "0xa71940cb90C8F3634DD3AB6a992D0EFF056Db48d",
+		SniperABI:              os.Getenv("SNIPER_ABI"),
+		SniperABIPath:          os.Getenv("SNIPER_ABI_PATH"),
 	}
 
 	if config.DatabaseURL == "" {
 		config.DatabaseURL = "root:admin@tcp(localhost:3306)/sniper?charset=utf8mb4&parseTime=True&loc=Local"
 	}
 
+	config.Gas.validate()
+
+	if config.MaxSwapDeadline <= 0 {
+		log.Printf("Warning: MAX_SWAP_DEADLINE must be positive, using 30m")
+		config.MaxSwapDeadline = 30 * time.Minute
+	}
+	if window := time.Duration(config.SwapDeadlineBlocks)*config.BlockTime + config.SwapDeadlineBuffer; window > config.MaxSwapDeadline {
+		log.Printf("Warning: swap deadline window %s (SWAP_DEADLINE_BLOCKS block times plus SWAP_DEADLINE_BUFFER) is over MAX_SWAP_DEADLINE=%s, capping it",
+			window, config.MaxSwapDeadline)
+	}
+
+	switch config.BribeMode {
+	case "":
+		config.BribeMode = BribeModeContract
+	case BribeModeContract, BribeModeTip:
+	default:
+		log.Printf("Warning: invalid BRIBE_MODE=%q, using %q", config.BribeMode, BribeModeContract)
+		config.BribeMode = BribeModeContract
+	}
+
+	switch config.CreatorSource {
+	case "":
+		config.CreatorSource = CreatorSourceSender
+	case CreatorSourceSender, CreatorSourceRecipient:
+	default:
+		log.Printf("Warning: invalid CREATOR_SOURCE=%q, using %q", config.CreatorSource, CreatorSourceSender)
+		config.CreatorSource = CreatorSourceSender
+	}
+
+	switch config.SenderFallback {
+	case "":
+		config.SenderFallback = SenderFallbackRecipient
+	case SenderFallbackRecipient, SenderFallbackNone, SenderFallbackSkip:
+	default:
+		log.Printf("Warning: invalid SENDER_FALLBACK=%q, using %q", config.SenderFallback, SenderFallbackRecipient)
+		config.SenderFallback = SenderFallbackRecipient
+	}
+
+	switch config.TriggerStrategy {
+	case "":
+		config.TriggerStrategy = TriggerAddLiquidity
+	case TriggerAddLiquidity, TriggerCreatePair:
+	default:
+		log.Printf("Warning: invalid TRIGGER_STRATEGY=%q, using %q", config.TriggerStrategy, TriggerAddLiquidity)
+		config.TriggerStrategy = TriggerAddLiquidity
+	}
+	if config.TriggerStrategy == TriggerCreatePair && config.Enabled(FeatureSimulateSnipes) {
+		log.Printf("Warning: simulate_snipes drops every snipe behind a createPair trigger, since the liquidity they need isn't added yet")
+	}
+
+	switch config.DuplicateSnipePolicy {
+	case "":
+		config.DuplicateSnipePolicy = DuplicateSnipeMerge
+	case DuplicateSnipeMerge, DuplicateSnipeReject, DuplicateSnipeAllow:
+	default:
+		log.Printf("Warning: invalid DUPLICATE_SNIPE_POLICY=%q, using %q", config.DuplicateSnipePolicy, DuplicateSnipeMerge)
+		config.DuplicateSnipePolicy = DuplicateSnipeMerge
+	}
+
+	switch config.SellApproval {
+	case "":
+		config.SellApproval = SellApprovalExact
+	case SellApprovalExact, SellApprovalMax:
+	default:
+		log.Printf("Warning: invalid SELL_APPROVAL=%q, using %q", config.SellApproval, SellApprovalExact)
+		config.SellApproval = SellApprovalExact
+	}
+
+	switch config.TokenBuildPolicy {
+	case "":
+		config.TokenBuildPolicy = TokenBuildQueue
+	case TokenBuildQueue, TokenBuildSkip:
+	default:
+		log.Printf("Warning: invalid TOKEN_BUILD_POLICY=%q, using %q", config.TokenBuildPolicy, TokenBuildQueue)
+		config.TokenBuildPolicy = TokenBuildQueue
+	}
+
+	switch config.LaunchRevertPolicy {
+	case "":
+		config.LaunchRevertPolicy = LaunchRevertRequeue
+	case LaunchRevertRequeue, LaunchRevertKeep:
+	default:
+		log.Printf("Warning: invalid LAUNCH_REVERT_POLICY=%q, using %q", config.LaunchRevertPolicy, LaunchRevertRequeue)
+		config.LaunchRevertPolicy = LaunchRevertRequeue
+	}
+
+	switch config.BribeGasCheck {
+	case "":
+		config.BribeGasCheck = BribeGasCheckWarn
+	case BribeGasCheckOff, BribeGasCheckWarn, BribeGasCheckBlock:
+	default:
+		log.Printf("Warning: invalid BRIBE_GAS_CHECK=%q, using %q", config.BribeGasCheck, BribeGasCheckWarn)
+		config.BribeGasCheck = BribeGasCheckWarn
+	}
+
+	switch config.BundleSelection {
+	case "":
+		config.BundleSelection = BundleSelectionBribe
+	case BundleSelectionBribe, BundleSelectionMixed:
+	default:
+		log.Printf("Warning: invalid BUNDLE_SELECTION=%q, using %q", config.BundleSelection, BundleSelectionBribe)
+		config.BundleSelection = BundleSelectionBribe
+	}
+	if config.BundleEarlyShare < 0 || config.BundleEarlyShare > 100 {
+		log.Printf("Warning: BUNDLE_EARLY_SHARE=%d is outside 0-100, using 25", config.BundleEarlyShare)
+		config.BundleEarlyShare = 25
+	}
+	if config.MaxBundleSnipes < 0 {
+		log.Printf("Warning: MAX_BUNDLE_SNIPES must not be negative, using 0")
+		config.MaxBundleSnipes = 0
+	}
+
+	if config.CompetitorBumpStep < 0 {
+		log.Printf("Warning: COMPETITOR_BUMP_STEP must not be negative, using 10")
+		config.CompetitorBumpStep = 10
+	}
+
+	switch config.NonceStrategy {
+	case "":
+		config.NonceStrategy = NonceStrategyReconcile
+	case NonceStrategyPending, NonceStrategyReconcile, NonceStrategyLatest:
+	default:
+		log.Printf("Warning: invalid NONCE_STRATEGY=%q, using %q", config.NonceStrategy, NonceStrategyReconcile)
+		config.NonceStrategy = NonceStrategyReconcile
+	}
+
+	switch config.TxType {
+	case "":
+		config.TxType = TxTypeDynamicFee
+	case TxTypeDynamicFee, TxTypeLegacy:
+	default:
+		log.Printf("Warning: invalid TX_TYPE=%q, using %q", config.TxType, TxTypeDynamicFee)
+		config.TxType = TxTypeDynamicFee
+	}
+
+	switch config.SnipeCooldownPolicy {
+	case "":
+		config.SnipeCooldownPolicy = SnipeCooldownWarn
+	case SnipeCooldownWarn, SnipeCooldownBlock:
+	default:
+		log.Printf("Warning: invalid SNIPE_COOLDOWN_POLICY=%q, using %q", config.SnipeCooldownPolicy, SnipeCooldownWarn)
+		config.SnipeCooldownPolicy = SnipeCooldownWarn
+	}
+
+	if config.MaxConcurrentBundles < 1 {
+		log.Printf("Warning: MAX_CONCURRENT_BUNDLES must be at least 1, using 1")
+		config.MaxConcurrentBundles = 1
+	}
+
+	if config.ConnectRetryInterval <= 0 {
+		log.Printf("Warning: CONNECT_RETRY_INTERVAL must be positive, using 5s")
+		config.ConnectRetryInterval = 5 * time.Second
+	}
+
+	// Unsigned events could be forged by anyone who finds the receiver
+	if config.StatusWebhookURL != "" && config.StatusWebhookSecret == "" {
+		log.Printf("Warning: STATUS_WEBHOOK_URL is set without STATUS_WEBHOOK_SECRET, the status webhook is disabled")
+		config.StatusWebhookURL = ""
+	}
+	if config.WebhookMaxAttempts < 1 {
+		log.Printf("Warning: WEBHOOK_MAX_ATTEMPTS must be at least 1, using 1")
+		config.WebhookMaxAttempts = 1
+	}
+	if config.MetricsMaxTokens < 0 {
+		log.Printf("Warning: METRICS_MAX_TOKENS must not be negative, using 0")
+		config.MetricsMaxTokens = 0
+	}
+	if config.LPAddDedupWindow < 0 {
+		log.Printf("Warning: LP_ADD_DEDUP_WINDOW must not be negative, disabling it")
+		config.LPAddDedupWindow = 0
+	} else if config.LPAddDedupWindow > 0 && config.LPAddDedupWindow < config.OutboxMaxAge {
+		log.Printf("Warning: LP_ADD_DEDUP_WINDOW %s is shorter than LP_OUTBOX_MAX_AGE %s; late redeliveries can build a second bundle", config.LPAddDedupWindow, config.OutboxMaxAge)
+	}
+	if config.LaunchForwardAttempts < 1 {
+		log.Printf("Warning: LAUNCH_FORWARD_ATTEMPTS must be at least 1, using 1")
+		config.LaunchForwardAttempts = 1
+	}
+	if config.LaunchForwardTimeout <= 0 {
+		log.Printf("Warning: LAUNCH_FORWARD_TIMEOUT must be positive, using 2s")
+		config.LaunchForwardTimeout = 2 * time.Second
+	}
+	if config.LaunchForwardBackoff < 0 {
+		log.Printf("Warning: LAUNCH_FORWARD_BACKOFF must not be negative, using 0")
+		config.LaunchForwardBackoff = 0
+	}
+	if config.LaunchForwardDeadline <= 0 {
+		log.Printf("Warning: LAUNCH_FORWARD_DEADLINE must be positive, using 5s")
+		config.LaunchForwardDeadline = 5 * time.Second
+	}
+	if config.BulkSnipeMaxRows < 1 {
+		log.Printf("Warning: BULK_SNIPE_MAX_ROWS must be at least 1, using 500")
+		config.BulkSnipeMaxRows = 500
+	}
+
 	return config
 }
+
+// SniperABIJSON returns the configured sniper contract ABI JSON, or "" to use
+// the embedded ABI
+func (c *Config) SniperABIJSON() (string, error) {
+	if c.SniperABI != "" {
+		return c.SniperABI, nil
+	}
+	if c.SniperABIPath == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(c.SniperABIPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SNIPER_ABI_PATH: %v", err)
+	}
+	return string(data), nil
+}
+
+// IsAdmin reports whether a Telegram user may run operator commands
+func (c *Config) IsAdmin(userID string) bool {
+	for _, id := range c.AdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// WETHAddress is WETH on Base
+const WETHAddress = "0x4200000000000000000000000000000000000006"
+
+// BlockedSnipeTarget reports whether a token address is a system contract or
+// otherwise blocked from sniping, and names it for the user
+func (c *Config) BlockedSnipeTarget(token string) (string, bool) {
+	system := []struct {
+		address string
+		name    string
+	}{
+		{WETHAddress, "WETH"},
+		{c.UniswapV2Router, "the Uniswap router"},
+		{c.UniswapV2Factory, "the Uniswap factory"},
+		{c.SniperContract, "the sniper contract"},
+	}
+	for _, entry := range system {
+		if entry.address != "" && strings.EqualFold(entry.address, token) {
+			return entry.name, true
+		}
+	}
+
+	for _, blocked := range c.BlockedSnipeTokens {
+		if strings.EqualFold(blocked, token) {
+			return "a blocked token", true
+		}
+	}
+	return "", false
+}
+
+// RPCMethodAllowed reports whether the RPC proxy serves a JSON-RPC method
+func (c *Config) RPCMethodAllowed(method string) bool {
+	for _, denied := range c.RPCDeniedMethods {
+		if denied == method {
+			return false
+		}
+	}
+
+	if len(c.RPCAllowedMethods) == 0 {
+		return true
+	}
+	for _, allowed := range c.RPCAllowedMethods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// getEnvInt reads an integer environment variable, falling back to def
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvBool reads a boolean environment variable (e.g. "true", "1"), falling back to def
+func getEnvBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %t", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvList reads a comma-separated environment variable, skipping empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// DefaultRPCCacheTTLs returns the RPC proxy's cache TTLs unless overridden by
+// RPC_CACHE_TTLS
+func DefaultRPCCacheTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		"eth_chainId":              time.Hour,
+		"net_version":              time.Hour,
+		"eth_gasPrice":             time.Second,
+		"eth_maxPriorityFeePerGas": time.Second,
+		"eth_blockNumber":          500 * time.Millisecond,
+	}
+}
+
+// getEnvDurationMap reads comma-separated key=duration pairs
+// (e.g. "eth_gasPrice=1s,eth_chainId=0") over the entries of def
+func getEnvDurationMap(key string, def map[string]time.Duration) map[string]time.Duration {
+	for _, item := range getEnvList(key) {
+		name, value, ok := strings.Cut(item, "=")
+		parsed, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || parsed < 0 {
+			log.Printf("Warning: invalid %s entry %q, ignoring", key, item)
+			continue
+		}
+		def[strings.TrimSpace(name)] = parsed
+	}
+	return def
+}
+
+// getEnvDuration reads a duration environment variable (e.g. "10s"), falling back to def
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %s", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvTxTypes reads comma-separated chainID=type pairs
+// (e.g. "56=legacy,8453=1559")
+func getEnvTxTypes(key string) map[string]TxType {
+	types := make(map[string]TxType)
+	for _, item := range getEnvList(key) {
+		chainID, value, ok := strings.Cut(item, "=")
+		chainID, txType := strings.TrimSpace(chainID), TxType(strings.TrimSpace(value))
+		if _, err := strconv.ParseUint(chainID, 10, 64); !ok || err != nil || (txType != TxTypeDynamicFee && txType != TxTypeLegacy) {
+			log.Printf("Warning: invalid %s entry %q, ignoring", key, item)
+			continue
+		}
+		types[chainID] = txType
+	}
+	return types
+}
+
+// TxTypeFor returns the kind of transaction to sign on chainID
+func (c *Config) TxTypeFor(chainID *big.Int) TxType {
+	if txType, ok := c.ChainTxTypes[chainID.String()]; ok {
+		return txType
+	}
+	return c.TxType
+}
+
+// SwapDeadlineWindow returns how far past the head block a snipe's swap
+// deadline is set: SwapDeadlineBlocks block times plus SwapDeadlineBuffer, at
+// most MaxSwapDeadline
+func (c *Config) SwapDeadlineWindow() time.Duration {
+	window := time.Duration(c.SwapDeadlineBlocks)*c.BlockTime + c.SwapDeadlineBuffer
+	if window > c.MaxSwapDeadline {
+		return c.MaxSwapDeadline
+	}
+	return window
+}
+
+// SubmitURLs returns the endpoints snipe bundles are submitted to: the
+// configured submission endpoints, or the sequencer if none are set. In
+// private-only mode the private endpoint takes precedence, and the public RPC
+// is never among them.
+func (c *Config) SubmitURLs() ([]string, error) {
+	urls := c.SubmitEndpoints
+	if len(urls) == 0 && c.BaseSequencerRPCURL != "" {
+		urls = []string{c.BaseSequencerRPCURL}
+	}
+
+	if !c.Enabled(FeaturePrivateOnly) {
+		return urls, nil
+	}
+
+	if c.PrivateSubmitURL != "" {
+		urls = []string{c.PrivateSubmitURL}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("private_only is enabled but no private submission endpoint is configured")
+	}
+	for _, url := range urls {
+		if url == c.BaseRPCURL {
+			return nil, fmt.Errorf("private_only is enabled but submission endpoint %s is the public RPC", url)
+		}
+	}
+	return urls, nil
+}
+
+// SubmitURL returns the primary submission endpoint (see SubmitURLs)
+func (c *Config) SubmitURL() (string, error) {
+	urls, err := c.SubmitURLs()
+	if err != nil {
+		return "", err
+	}
+	if len(urls) == 0 {
+		return "", fmt.Errorf("no submission endpoint is configured")
+	}
+	return urls[0], nil
+}
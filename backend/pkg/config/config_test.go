@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestIsAdmin(t *testing.T) {
+	c := &Config{AdminUserIDs: []string{"1001", "1002"}}
+
+	tests := []struct {
+		userID string
+		want   bool
+	}{
+		{"1001", true},
+		{"1002", true},
+		{"1003", false},
+		{"100", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := c.IsAdmin(tt.userID); got != tt.want {
+			t.Errorf("IsAdmin(%q) = %v, want %v", tt.userID, got, tt.want)
+		}
+	}
+
+	if (&Config{}).IsAdmin("1001") {
+		t.Error("IsAdmin with no ADMIN_USER_IDS accepted a user")
+	}
+}
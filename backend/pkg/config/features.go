@@ -0,0 +1,100 @@
+package config
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Feature names a behaviour operators can switch on or off with FEATURES
+type Feature string
+
+const (
+	FeatureAutoSell       Feature = "auto_sell"       // Sell landed snipes at their take-profit / stop-loss
+	FeaturePriceMonitor   Feature = "price_monitor"   // Price landed positions for /positions and auto-sell
+	FeaturePrivateOnly    Feature = "private_only"    // Keep snipe bundles and sniper txs off the public RPC
+	FeatureRPCCache       Feature = "rpc_cache"       // Serve parameterless reads from the proxy cache
+	FeatureL1Fee          Feature = "l1_fee"          // Count the L1 data fee in snipe costs and the bribe gas check
+	FeatureBundleArchive  Feature = "bundle_archive"  // Keep the raw transactions of every submitted bundle
+	FeatureCompetitorBump Feature = "competitor_bump" // Raise the bundle's tips over competing snipes seen pending
+	FeatureLaunchAlerts   Feature = "launch_alerts"   // DM users the moment a token they snipe gets its LP_ADD
+	FeatureSimulateSnipes Feature = "simulate_snipes" // Simulate every snipe behind its launch and drop the ones that revert
+)
+
+// featureDefaults are the features and whether each is on when FEATURES
+// doesn't mention it
+var featureDefaults = map[Feature]bool{
+	FeatureAutoSell:       true,
+	FeaturePriceMonitor:   true,
+	FeaturePrivateOnly:    false,
+	FeatureRPCCache:       true,
+	FeatureL1Fee:          true,
+	FeatureBundleArchive:  true,
+	FeatureCompetitorBump: false,
+	FeatureLaunchAlerts:   true,
+	FeatureSimulateSnipes: false,
+}
+
+// FeatureFlags holds whether each feature is on
+type FeatureFlags map[Feature]bool
+
+// loadFeatureFlags reads FEATURES, a comma-separated list of features to turn
+// on, each prefixed with "-" to turn it off instead (e.g.
+// "private_only,-auto_sell"). PRIVATE_ONLY is still honoured as the default
+// of private_only.
+func loadFeatureFlags() FeatureFlags {
+	flags := make(FeatureFlags, len(featureDefaults))
+	for feature, on := range featureDefaults {
+		flags[feature] = on
+	}
+	flags[FeaturePrivateOnly] = getEnvBool("PRIVATE_ONLY", featureDefaults[FeaturePrivateOnly])
+
+	for _, item := range strings.Split(os.Getenv("FEATURES"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, off := strings.CutPrefix(item, "-")
+		feature := Feature(strings.ToLower(name))
+		if _, ok := featureDefaults[feature]; !ok {
+			log.Printf("Warning: unknown feature %q in FEATURES, ignoring", name)
+			continue
+		}
+		flags[feature] = !off
+	}
+
+	return flags
+}
+
+// Active returns the names of the features that are on, sorted
+func (f FeatureFlags) Active() []string {
+	var active []string
+	for feature, on := range f {
+		if on {
+			active = append(active, string(feature))
+		}
+	}
+	sort.Strings(active)
+	return active
+}
+
+// String lists every feature as name=on or name=off, sorted by name
+func (f FeatureFlags) String() string {
+	parts := make([]string, 0, len(f))
+	for feature, on := range f {
+		state := "off"
+		if on {
+			state = "on"
+		}
+		parts = append(parts, string(feature)+"="+state)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// Enabled reports whether a feature is on
+func (c *Config) Enabled(feature Feature) bool {
+	return c.Features[feature]
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GasSource selects where the gas price snipes are priced on top of comes from
+type GasSource string
+
+const (
+	// GasSourceNode uses the latest base fee, or the node's eth_gasPrice
+	// suggestion on chains without one
+	GasSourceNode GasSource = "node"
+
+	// GasSourceBaseFee uses a fixed multiple of the latest base fee, for
+	// headroom when the base fee spikes between blocks
+	GasSourceBaseFee GasSource = "base_fee"
+
+	// GasSourceOracle asks an external gas oracle, falling back to the node
+	GasSourceOracle GasSource = "oracle"
+)
+
+// GasConfig holds the gas parameters used to build snipe transactions. Fee
+// values are in wei.
+type GasConfig struct {
+	// Priority fee (tip) of every snipe before any tip-mode bribe. With
+	// PriorityFeePercentile set it is only the floor: the tip follows that
+	// percentile of the last PriorityFeeBlocks blocks' tips, up to
+	// MaxPriorityFee, resampled every PriorityFeeTTL.
+	PriorityFee           *big.Int
+	PriorityFeePercentile float64
+	PriorityFeeBlocks     uint64
+	MaxPriorityFee        *big.Int
+	PriorityFeeTTL        time.Duration
+
+	// Headroom added to base fee + priority fee for the first snipe's max fee
+	FeeBuffer *big.Int
+
+	// Cap on the first snipe's max fee per gas
+	MaxFee *big.Int
+
+	// Floor for legacy gas prices
+	MinGasPrice *big.Int
+
+	// How much the bribe transfer outbids its swap when the bribe is sent as
+	// a separate transaction instead of through the sniper contract
+	BribeGasPriceBump *big.Int
+
+	// Most gas a snipeWithBribe transaction is signed with: the ceiling of its
+	// gas estimate, and the limit it gets when estimation fails
+	SnipeGasLimit uint64
+
+	// Cap on a bundle's total gas limit, launch transaction included; the
+	// lowest-bribe snipes that don't fit are dropped
+	MaxBundleGas uint64
+
+	// Where the gas price under the priority fee comes from (see GasSource),
+	// the base fee multiple for GasSourceBaseFee, and the oracle endpoint for
+	// GasSourceOracle with how long each of its answers is reused
+	PriceSource       GasSource
+	BaseFeeMultiplier float64
+	OracleURL         string
+	OracleTTL         time.Duration
+}
+
+// loadGasConfig reads the gas parameters from the environment
+func loadGasConfig() GasConfig {
+	return GasConfig{
+		PriorityFee:           getEnvWei("GAS_PRIORITY_FEE_WEI", big.NewInt(2000000)),
+		PriorityFeePercentile: getEnvFloat("GAS_PRIORITY_FEE_PERCENTILE", 0),
+		PriorityFeeBlocks:     getEnvUint64("GAS_PRIORITY_FEE_BLOCKS", 20),
+		MaxPriorityFee:        getEnvWei("GAS_MAX_PRIORITY_FEE_WEI", big.NewInt(1000000000)),
+		PriorityFeeTTL:        getEnvDuration("GAS_PRIORITY_FEE_TTL", 2*time.Second),
+		FeeBuffer:             getEnvWei("GAS_FEE_BUFFER_WEI", big.NewInt(1000000)),
+		MaxFee:                getEnvWei("GAS_MAX_FEE_WEI", big.NewInt(20000000000)),
+		MinGasPrice:           getEnvWei("GAS_MIN_PRICE_WEI", big.NewInt(1000000000)),
+		BribeGasPriceBump:     getEnvWei("GAS_BRIBE_PRICE_BUMP_WEI", big.NewInt(1000000000)),
+		SnipeGasLimit:         getEnvUint64("SNIPE_GAS_LIMIT", 300000),
+		MaxBundleGas:          getEnvUint64("MAX_BUNDLE_GAS", 25000000),
+		PriceSource:           GasSource(os.Getenv("GAS_PRICE_SOURCE")),
+		BaseFeeMultiplier:     getEnvFloat("GAS_BASE_FEE_MULTIPLIER", 1.25),
+		OracleURL:             os.Getenv("GAS_ORACLE_URL"),
+		OracleTTL:             getEnvDuration("GAS_ORACLE_TTL", 2*time.Second),
+	}
+}
+
+// validate replaces invalid gas price source settings with defaults
+func (g *GasConfig) validate() {
+	switch g.PriceSource {
+	case "":
+		g.PriceSource = GasSourceNode
+	case GasSourceNode, GasSourceBaseFee:
+	case GasSourceOracle:
+		if g.OracleURL == "" {
+			log.Printf("Warning: GAS_PRICE_SOURCE=%q needs GAS_ORACLE_URL, using %q", g.PriceSource, GasSourceNode)
+			g.PriceSource = GasSourceNode
+		}
+	default:
+		log.Printf("Warning: invalid GAS_PRICE_SOURCE=%q, using %q", g.PriceSource, GasSourceNode)
+		g.PriceSource = GasSourceNode
+	}
+
+	if g.PriorityFeePercentile < 0 || g.PriorityFeePercentile > 100 {
+		log.Printf("Warning: GAS_PRIORITY_FEE_PERCENTILE=%g is outside 0-100, using a fixed priority fee", g.PriorityFeePercentile)
+		g.PriorityFeePercentile = 0
+	}
+	if g.PriorityFeeBlocks < 1 || g.PriorityFeeBlocks > 1024 {
+		log.Printf("Warning: GAS_PRIORITY_FEE_BLOCKS=%d is outside 1-1024, using 20", g.PriorityFeeBlocks)
+		g.PriorityFeeBlocks = 20
+	}
+	if g.MaxPriorityFee.Cmp(g.PriorityFee) < 0 {
+		log.Printf("Warning: GAS_MAX_PRIORITY_FEE_WEI is below GAS_PRIORITY_FEE_WEI, using %s", g.PriorityFee)
+		g.MaxPriorityFee = new(big.Int).Set(g.PriorityFee)
+	}
+
+	if g.BaseFeeMultiplier < 1 {
+		log.Printf("Warning: GAS_BASE_FEE_MULTIPLIER=%g is below 1, using 1", g.BaseFeeMultiplier)
+		g.BaseFeeMultiplier = 1
+	}
+}
+
+// getEnvWei reads a non-negative integer wei amount, falling back to def
+func getEnvWei(key string, def *big.Int) *big.Int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok || parsed.Sign() < 0 {
+		log.Printf("Warning: invalid %s=%q, using default %s", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvFloat reads a floating point environment variable, falling back to def
+func getEnvFloat(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %g", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvUint64 reads a positive integer environment variable, falling back to def
+func getEnvUint64(key string, def uint64) uint64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || parsed == 0 {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, value, def)
+		return def
+	}
+	return parsed
+}
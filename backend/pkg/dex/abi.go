@@ -99,6 +99,19 @@ const UniswapV2RouterABI = `[
 		],
 		"stateMutability": "payable",
 		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "WETH",
+		"outputs": [
+			{
+				"internalType": "address",
+				"name": "",
+				"type": "address"
+			}
+		],
+		"stateMutability": "pure",
+		"type": "function"
 	}
 ]`
 
@@ -182,3 +195,141 @@ const UniswapV2PairABI = `[
 		"type": "function"
 	}
 ]`
+
+// ERC20PermitABI is the part of the ERC20 and EIP-2612 ABI used for balances
+// and approvals
+const ERC20PermitABI = `[
+	{
+		"inputs": [],
+		"name": "decimals",
+		"outputs": [{"internalType": "uint8", "name": "", "type": "uint8"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "address", "name": "account", "type": "address"}],
+		"name": "balanceOf",
+		"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "owner", "type": "address"},
+			{"internalType": "address", "name": "spender", "type": "address"}
+		],
+		"name": "allowance",
+		"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "spender", "type": "address"},
+			{"internalType": "uint256", "name": "value", "type": "uint256"}
+		],
+		"name": "approve",
+		"outputs": [{"internalType": "bool", "name": "", "type": "bool"}],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "value", "type": "uint256"}
+		],
+		"name": "transfer",
+		"outputs": [{"internalType": "bool", "name": "", "type": "bool"}],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "owner", "type": "address"},
+			{"internalType": "address", "name": "spender", "type": "address"},
+			{"internalType": "uint256", "name": "value", "type": "uint256"},
+			{"internalType": "uint256", "name": "deadline", "type": "uint256"},
+			{"internalType": "uint8", "name": "v", "type": "uint8"},
+			{"internalType": "bytes32", "name": "r", "type": "bytes32"},
+			{"internalType": "bytes32", "name": "s", "type": "bytes32"}
+		],
+		"name": "permit",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "address", "name": "owner", "type": "address"}],
+		"name": "nonces",
+		"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "DOMAIN_SEPARATOR",
+		"outputs": [{"internalType": "bytes32", "name": "", "type": "bytes32"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// UniswapV2RouterSellABI is the router function used to sell tokens for ETH.
+// The fee-on-transfer variant also works for plain tokens.
+const UniswapV2RouterSellABI = `[
+	{
+		"inputs": [
+			{"internalType": "uint256", "name": "amountIn", "type": "uint256"},
+			{"internalType": "uint256", "name": "amountOutMin", "type": "uint256"},
+			{"internalType": "address[]", "name": "path", "type": "address[]"},
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactTokensForETHSupportingFeeOnTransferTokens",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	}
+]`
+
+// UniswapV2RouterBuyABI is the router functions that buy tokens with ETH, one
+// per SwapMode. swapETHForExactTokens takes the exact amount out where the
+// others take a minimum, and refunds the ETH it doesn't spend.
+const UniswapV2RouterBuyABI = `[
+	{
+		"inputs": [
+			{"internalType": "uint256", "name": "amountOutMin", "type": "uint256"},
+			{"internalType": "address[]", "name": "path", "type": "address[]"},
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactETHForTokens",
+		"outputs": [{"internalType": "uint256[]", "name": "amounts", "type": "uint256[]"}],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "uint256", "name": "amountOutMin", "type": "uint256"},
+			{"internalType": "address[]", "name": "path", "type": "address[]"},
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactETHForTokensSupportingFeeOnTransferTokens",
+		"outputs": [],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "uint256", "name": "amountOut", "type": "uint256"},
+			{"internalType": "address[]", "name": "path", "type": "address[]"},
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "deadline", "type": "uint256"}
+		],
+		"name": "swapETHForExactTokens",
+		"outputs": [{"internalType": "uint256[]", "name": "amounts", "type": "uint256[]"}],
+		"stateMutability": "payable",
+		"type": "function"
+	}
+]`
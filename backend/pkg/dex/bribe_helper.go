@@ -3,8 +3,11 @@ package dex
 import (
 	"context"
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
 
+	"sniper-bot/pkg/eth"
+
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -12,40 +15,80 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// BribeHelper handles bribe transactions without custom contracts
+// BribeHelper handles bribe transactions without custom contracts. Both
+// transactions are of txType, like the snipes the API service builds: the fee
+// cap (or legacy gas price) is the latest base fee plus the tip, and the bribe
+// transfer's tip is raised by tipBump. Legacy chains have no base fee, so the
+// node's suggested gas price stands in for it.
 type BribeHelper struct {
-	client  *ethclient.Client
-	chainID *big.Int
+	client    *ethclient.Client
+	txs       *eth.TxBuilder
+	tipBump   *big.Int                    // How much the bribe transfer's tip outbids the swap's
+	swapModes map[common.Address]SwapMode // Per-token swap modes set by SetSwapMode
 }
 
-// NewBribeHelper creates a new bribe helper
-func NewBribeHelper(client *ethclient.Client) (*BribeHelper, error) {
+// NewBribeHelper creates a new bribe helper signing transactions of txType
+func NewBribeHelper(client *ethclient.Client, txType eth.TxType) (*BribeHelper, error) {
 	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
 	return &BribeHelper{
-		client:  client,
-		chainID: chainID,
+		client:    client,
+		txs:       eth.NewTxBuilder(txType, chainID),
+		tipBump:   big.NewInt(0), // The nonce already orders the transfer after the swap
+		swapModes: make(map[common.Address]SwapMode),
 	}, nil
 }
 
-// CreateSwapWithBribeTxs creates both a swap transaction and a bribe transaction
+// SetTipBump sets how much higher the bribe transfer's priority fee is than the swap's
+func (b *BribeHelper) SetTipBump(bump *big.Int) {
+	b.tipBump = bump
+}
+
+// SetSwapMode sets the swap mode of token's swaps that don't select one
+func (b *BribeHelper) SetSwapMode(token common.Address, mode SwapMode) {
+	b.swapModes[token] = mode
+}
+
+// swapMode returns mode, or if it is empty the mode set for token, or
+// DefaultSwapMode
+func (b *BribeHelper) swapMode(token common.Address, mode SwapMode) SwapMode {
+	if mode != "" {
+		return mode
+	}
+	if mode, ok := b.swapModes[token]; ok {
+		return mode
+	}
+	return DefaultSwapMode
+}
+
+// CreateSwapWithBribeTxs creates both a swap transaction and a bribe
+// transaction, paying priorityFee per gas as the swap's tip. The swap goes
+// through the router function mode selects ("" for the token's); amountOut is
+// the minimum output of the exact-in modes and the exact output of
+// SwapExactOut, which spends at most swapAmount.
 func (b *BribeHelper) CreateSwapWithBribeTxs(
 	ctx context.Context,
 	privateKey *ecdsa.PrivateKey,
 	routerAddress common.Address,
 	token common.Address,
 	creator common.Address,
+	mode SwapMode,
 	swapAmount *big.Int,
 	bribeAmount *big.Int,
-	amountOutMin *big.Int,
+	amountOut *big.Int,
 	deadline *big.Int,
-	baseGasPrice *big.Int,
+	priorityFee *big.Int,
 ) ([]*types.Transaction, error) {
 	from := crypto.PubkeyToAddress(privateKey.PublicKey)
 
+	baseFee, err := b.baseFee(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get nonce for the first transaction
 	nonce, err := b.client.PendingNonceAt(ctx, from)
 	if err != nil {
@@ -58,39 +101,41 @@ func (b *BribeHelper) CreateSwapWithBribeTxs(
 	swapTx, err := b.createSwapTransaction(
 		routerAddress,
 		token,
+		b.swapMode(token, mode),
 		swapAmount,
-		amountOutMin,
+		amountOut,
 		deadline,
 		from,
 		nonce,
-		baseGasPrice,
+		priorityFee,
+		new(big.Int).Add(baseFee, priorityFee),
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	// Sign swap transaction
-	signedSwapTx, err := types.SignTx(swapTx, types.NewEIP155Signer(b.chainID), privateKey)
+	signedSwapTx, err := types.SignTx(swapTx, b.txs.Signer(), privateKey)
 	if err != nil {
 		return nil, err
 	}
 	transactions = append(transactions, signedSwapTx)
 
-	// 2. Create bribe transaction (higher gas price for priority)
-	bribeGasPrice := new(big.Int).Add(baseGasPrice, big.NewInt(1000000000)) // +1 gwei
+	// 2. Create bribe transaction (higher tip for priority)
+	bribeTip := new(big.Int).Add(priorityFee, b.tipBump)
 	bribeTx, err := b.createBribeTransaction(
 		creator,
 		bribeAmount,
-		from,
 		nonce+1,
-		bribeGasPrice,
+		bribeTip,
+		new(big.Int).Add(baseFee, bribeTip),
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	// Sign bribe transaction
-	signedBribeTx, err := types.SignTx(bribeTx, types.NewEIP155Signer(b.chainID), privateKey)
+	signedBribeTx, err := types.SignTx(bribeTx, b.txs.Signer(), privateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -99,102 +144,87 @@ func (b *BribeHelper) CreateSwapWithBribeTxs(
 	return transactions, nil
 }
 
+// baseFee returns the latest block's base fee, or on legacy chains the node's
+// suggested gas price
+func (b *BribeHelper) baseFee(ctx context.Context) (*big.Int, error) {
+	if b.txs.Type() == eth.TxTypeLegacy {
+		gasPrice, err := b.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %v", err)
+		}
+		return gasPrice, nil
+	}
+
+	header, err := b.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %v", err)
+	}
+	if header.BaseFee == nil {
+		return nil, fmt.Errorf("latest block has no base fee")
+	}
+	return header.BaseFee, nil
+}
+
 // createSwapTransaction creates a swap transaction
 func (b *BribeHelper) createSwapTransaction(
 	routerAddress common.Address,
 	token common.Address,
+	mode SwapMode,
 	swapAmount *big.Int,
-	amountOutMin *big.Int,
+	amountOut *big.Int,
 	deadline *big.Int,
 	from common.Address,
 	nonce uint64,
-	gasPrice *big.Int,
+	gasTipCap *big.Int,
+	gasFeeCap *big.Int,
 ) (*types.Transaction, error) {
-	// Create the swap function call data
-	// swapExactETHForTokens(uint amountOutMin, address[] path, address to, uint deadline)
-
-	// Function selector for swapExactETHForTokens: 0x7ff36ab5
-	selector := []byte{0x7f, 0xf3, 0x6a, 0xb5}
-
-	// Encode parameters
-	data := make([]byte, 4)
-	copy(data, selector)
-
-	// For simplicity, we'll use a basic encoding
-	// In production, you should use the ABI encoder
-
-	// Pack amountOutMin (32 bytes)
-	amountOutMinBytes := make([]byte, 32)
-	amountOutMin.FillBytes(amountOutMinBytes)
-	data = append(data, amountOutMinBytes...)
-
-	// Pack path offset (32 bytes) - offset to path array
-	pathOffset := make([]byte, 32)
-	pathOffset[31] = 0x80 // Offset to path (128 bytes from start)
-	data = append(data, pathOffset...)
-
-	// Pack to address (32 bytes)
-	toBytes := make([]byte, 32)
-	copy(toBytes[12:], from.Bytes())
-	data = append(data, toBytes...)
-
-	// Pack deadline (32 bytes)
-	deadlineBytes := make([]byte, 32)
-	deadline.FillBytes(deadlineBytes)
-	data = append(data, deadlineBytes...)
-
-	// Pack path array
-	// Array length (2)
-	pathLengthBytes := make([]byte, 32)
-	pathLengthBytes[31] = 2
-	data = append(data, pathLengthBytes...)
-
-	// WETH address (Base)
-	wethBytes := make([]byte, 32)
+	// WETH on Base
 	weth := common.HexToAddress("0x4200000000000000000000000000000000000006")
-	copy(wethBytes[12:], weth.Bytes())
-	data = append(data, wethBytes...)
 
-	// Token address
-	tokenBytes := make([]byte, 32)
-	copy(tokenBytes[12:], token.Bytes())
-	data = append(data, tokenBytes...)
+	data, err := PackBuyWithETH(mode, token, weth, amountOut, from, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s swap: %v", mode, err)
+	}
 
-	return types.NewTransaction(
-		nonce,
-		routerAddress,
-		swapAmount,
-		250000, // Gas limit for swap
-		gasPrice,
-		data,
-	), nil
+	return b.txs.NewTx(eth.TxParams{
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       250000, // Gas limit for swap
+		To:        &routerAddress,
+		Value:     swapAmount,
+		Data:      data,
+	}), nil
 }
 
 // createBribeTransaction creates a simple ETH transfer transaction for the bribe
 func (b *BribeHelper) createBribeTransaction(
 	creator common.Address,
 	bribeAmount *big.Int,
-	from common.Address,
 	nonce uint64,
-	gasPrice *big.Int,
+	gasTipCap *big.Int,
+	gasFeeCap *big.Int,
 ) (*types.Transaction, error) {
-	return types.NewTransaction(
-		nonce,
-		creator,
-		bribeAmount,
-		21000, // Standard ETH transfer gas limit
-		gasPrice,
-		nil, // No data for simple transfer
-	), nil
+	return b.txs.NewTx(eth.TxParams{
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       21000, // Standard ETH transfer gas limit
+		To:        &creator,
+		Value:     bribeAmount,
+	}), nil
 }
 
-// EstimateSwapWithBribeGas estimates gas for both swap and bribe transactions
+// EstimateSwapWithBribeGas estimates gas for both swap and bribe
+// transactions, the swap going through the function mode selects as in
+// CreateSwapWithBribeTxs
 func (b *BribeHelper) EstimateSwapWithBribeGas(
 	ctx context.Context,
 	routerAddress common.Address,
 	token common.Address,
+	mode SwapMode,
 	swapAmount *big.Int,
-	amountOutMin *big.Int,
+	amountOut *big.Int,
 	deadline *big.Int,
 	from common.Address,
 ) (uint64, error) {
@@ -202,12 +232,14 @@ func (b *BribeHelper) EstimateSwapWithBribeGas(
 	swapTx, err := b.createSwapTransaction(
 		routerAddress,
 		token,
+		b.swapMode(token, mode),
 		swapAmount,
-		amountOutMin,
+		amountOut,
 		deadline,
 		from,
 		0,             // Dummy nonce
-		big.NewInt(1), // Dummy gas price
+		big.NewInt(0), // Dummy tip
+		big.NewInt(1), // Dummy fee cap
 	)
 	if err != nil {
 		return 0, err
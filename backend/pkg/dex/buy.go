@@ -0,0 +1,74 @@
+package dex
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SwapMode selects the router function that buys a token with ETH
+type SwapMode string
+
+const (
+	// SwapExactIn spends exactly the ETH sent, for at least amountOutMin
+	// tokens (swapExactETHForTokens). It reverts on tokens that take a fee on
+	// transfer, as the router checks what the pair sent rather than what
+	// arrived.
+	SwapExactIn SwapMode = "exact_in"
+
+	// SwapExactInFee is SwapExactIn checking the tokens that arrived instead
+	// (swapExactETHForTokensSupportingFeeOnTransferTokens), which works for
+	// plain and fee-on-transfer tokens alike
+	SwapExactInFee SwapMode = "exact_in_fee"
+
+	// SwapExactOut buys exactly amountOut tokens, spending at most the ETH
+	// sent and refunding the rest (swapETHForExactTokens)
+	SwapExactOut SwapMode = "exact_out"
+)
+
+// DefaultSwapMode is the swap mode used when none is selected
+const DefaultSwapMode = SwapExactInFee
+
+// ParseSwapMode parses a swap mode; "" is DefaultSwapMode
+func ParseSwapMode(s string) (SwapMode, error) {
+	switch mode := SwapMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return DefaultSwapMode, nil
+	case SwapExactIn, SwapExactInFee, SwapExactOut:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid swap mode %q (use %s, %s or %s)", s, SwapExactIn, SwapExactInFee, SwapExactOut)
+	}
+}
+
+// method returns the router function of the mode
+func (m SwapMode) method() string {
+	switch m {
+	case SwapExactIn:
+		return "swapExactETHForTokens"
+	case SwapExactOut:
+		return "swapETHForExactTokens"
+	default:
+		return "swapExactETHForTokensSupportingFeeOnTransferTokens"
+	}
+}
+
+// PackBuyWithETH returns the router call data buying token with ETH for `to`
+// through the function mode selects. amountOut is the minimum output of the
+// exact-in modes and the exact output of SwapExactOut.
+func PackBuyWithETH(mode SwapMode, token, weth common.Address, amountOut *big.Int, to common.Address, deadline *big.Int) ([]byte, error) {
+	parsed, err := abi.JSON(strings.NewReader(UniswapV2RouterBuyABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.Pack(mode.method(),
+		amountOut,
+		[]common.Address{weth, token},
+		to,
+		deadline,
+	)
+}
@@ -71,6 +71,16 @@ func (r *UniswapV2RouterContract) SwapExactETHForTokens(
 	)
 }
 
+// WETH gets the wrapped ETH token the router pairs ETH with
+func (r *UniswapV2RouterContract) WETH(opts *bind.CallOpts) (common.Address, error) {
+	var result []interface{}
+	err := r.contract.Call(opts, &result, "WETH")
+	if err != nil {
+		return common.Address{}, err
+	}
+	return result[0].(common.Address), nil
+}
+
 // UniswapV2FactoryContract represents the Uniswap V2 Factory contract
 type UniswapV2FactoryContract struct {
 	*UniswapV2Factory
@@ -0,0 +1,107 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// transferTopic is the topic of the ERC20 Transfer event
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// withdrawalTopic is the topic of the WETH Withdrawal event, emitted when WETH
+// is unwrapped to ETH
+var withdrawalTopic = crypto.Keccak256Hash([]byte("Withdrawal(address,uint256)"))
+
+// CheckERC20 verifies that token is a contract answering the ERC20 views
+// snipes rely on, decimals() and balanceOf(). It catches addresses extracted
+// from the wrong calldata word and launches of non-standard tokens.
+func CheckERC20(ctx context.Context, client *ethclient.Client, token common.Address) error {
+	code, err := client.CodeAt(ctx, token, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get code: %v", err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no contract code at %s", token.Hex())
+	}
+
+	tokenContract, err := NewERC20PermitContract(client, token)
+	if err != nil {
+		return err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	if _, err := tokenContract.Decimals(opts); err != nil {
+		return fmt.Errorf("decimals() failed: %v", err)
+	}
+	if _, err := tokenContract.BalanceOf(opts, token); err != nil {
+		return fmt.Errorf("balanceOf() failed: %v", err)
+	}
+	return nil
+}
+
+// TokenDecimals returns the decimals of token, which scale every token amount
+// the way 18 scales wei. Tokens like USDC use 6.
+func TokenDecimals(ctx context.Context, client *ethclient.Client, token common.Address) (uint8, error) {
+	tokenContract, err := NewERC20PermitContract(client, token)
+	if err != nil {
+		return 0, err
+	}
+
+	decimals, err := tokenContract.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get decimals: %v", err)
+	}
+	return decimals, nil
+}
+
+// ReceivedTokens sums the Transfer events of token to account in a receipt,
+// i.e. what a transaction paid out to account net of any transfer fee
+func ReceivedTokens(receipt *types.Receipt, token, account common.Address) *big.Int {
+	received := new(big.Int)
+	for _, entry := range receipt.Logs {
+		if entry.Address != token || len(entry.Topics) != 3 || entry.Topics[0] != transferTopic {
+			continue
+		}
+		if common.BytesToAddress(entry.Topics[2].Bytes()) != account {
+			continue
+		}
+		received.Add(received, new(big.Int).SetBytes(entry.Data))
+	}
+	return received
+}
+
+// SentTokens sums the Transfer events of token from account in a receipt,
+// i.e. what a transaction took out of account, transfer fees included
+func SentTokens(receipt *types.Receipt, token, account common.Address) *big.Int {
+	sent := new(big.Int)
+	for _, entry := range receipt.Logs {
+		if entry.Address != token || len(entry.Topics) != 3 || entry.Topics[0] != transferTopic {
+			continue
+		}
+		if common.BytesToAddress(entry.Topics[1].Bytes()) != account {
+			continue
+		}
+		sent.Add(sent, new(big.Int).SetBytes(entry.Data))
+	}
+	return sent
+}
+
+// UnwrappedETH sums the Withdrawal events of weth in a receipt. A router sell
+// for ETH unwraps exactly what it pays out.
+func UnwrappedETH(receipt *types.Receipt, weth common.Address) *big.Int {
+	unwrapped := new(big.Int)
+	for _, entry := range receipt.Logs {
+		if entry.Address != weth || len(entry.Topics) != 2 || entry.Topics[0] != withdrawalTopic {
+			continue
+		}
+		unwrapped.Add(unwrapped, new(big.Int).SetBytes(entry.Data))
+	}
+	return unwrapped
+}
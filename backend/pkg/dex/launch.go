@@ -0,0 +1,118 @@
+package dex
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// UniswapV2LaunchABI holds the router and factory functions that launch a
+// token: creating its pair and adding its first ETH liquidity
+const UniswapV2LaunchABI = `[
+	{
+		"inputs": [
+			{"internalType": "address", "name": "tokenA", "type": "address"},
+			{"internalType": "address", "name": "tokenB", "type": "address"}
+		],
+		"name": "createPair",
+		"outputs": [{"internalType": "address", "name": "pair", "type": "address"}],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "token", "type": "address"},
+			{"internalType": "uint256", "name": "amountTokenDesired", "type": "uint256"},
+			{"internalType": "uint256", "name": "amountTokenMin", "type": "uint256"},
+			{"internalType": "uint256", "name": "amountETHMin", "type": "uint256"},
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "deadline", "type": "uint256"}
+		],
+		"name": "addLiquidityETH",
+		"outputs": [
+			{"internalType": "uint256", "name": "amountToken", "type": "uint256"},
+			{"internalType": "uint256", "name": "amountETH", "type": "uint256"},
+			{"internalType": "uint256", "name": "liquidity", "type": "uint256"}
+		],
+		"stateMutability": "payable",
+		"type": "function"
+	}
+]`
+
+// launchABI is UniswapV2LaunchABI parsed once, as launch calldata is decoded
+// on the proxy's hot path
+var launchABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(UniswapV2LaunchABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid UniswapV2LaunchABI: %v", err))
+	}
+	return parsed
+}()
+
+// AddLiquidityETHArgs are the decoded arguments of an addLiquidityETH call
+type AddLiquidityETHArgs struct {
+	Token              common.Address
+	AmountTokenDesired *big.Int
+	AmountTokenMin     *big.Int
+	AmountETHMin       *big.Int
+	To                 common.Address
+	Deadline           *big.Int
+}
+
+// DecodeAddLiquidityETH decodes addLiquidityETH calldata
+func DecodeAddLiquidityETH(data []byte) (*AddLiquidityETHArgs, error) {
+	args, err := decodeLaunchCall("addLiquidityETH", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddLiquidityETHArgs{
+		Token:              args[0].(common.Address),
+		AmountTokenDesired: args[1].(*big.Int),
+		AmountTokenMin:     args[2].(*big.Int),
+		AmountETHMin:       args[3].(*big.Int),
+		To:                 args[4].(common.Address),
+		Deadline:           args[5].(*big.Int),
+	}, nil
+}
+
+// DecodeCreatePair decodes createPair calldata
+func DecodeCreatePair(data []byte) (tokenA, tokenB common.Address, err error) {
+	args, err := decodeLaunchCall("createPair", data)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	return args[0].(common.Address), args[1].(common.Address), nil
+}
+
+// decodeLaunchCall checks that data is a call to method and decodes its
+// arguments. The arguments must be canonically encoded, which is what the
+// contract's own decoder enforces: an address word with non-zero upper bytes
+// would be read as a different token than the one the call targets. Bytes
+// after the arguments are ignored, as they are on chain.
+func decodeLaunchCall(name string, data []byte) ([]interface{}, error) {
+	method := launchABI.Methods[name]
+
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil, fmt.Errorf("not a %s call", name)
+	}
+
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("malformed %s calldata: %v", name, err)
+	}
+
+	encoded, err := method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, fmt.Errorf("malformed %s calldata: %v", name, err)
+	}
+	if !bytes.Equal(encoded, data[4:4+len(encoded)]) {
+		return nil, fmt.Errorf("malformed %s calldata: arguments are not canonically encoded", name)
+	}
+
+	return args, nil
+}
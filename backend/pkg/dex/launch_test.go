@@ -0,0 +1,116 @@
+package dex
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Words of the launch calldata below, as a router or factory receives it
+const (
+	tokenWord    = "000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	wethWord     = "0000000000000000000000004200000000000000000000000000000000000006"
+	amountWord   = "00000000000000000000000000000000000000000000d3c21bcecceda1000000" // 1,000,000 tokens
+	minWord      = "00000000000000000000000000000000000000000000d3c21bcecceda1000000"
+	ethMinWord   = "0000000000000000000000000000000000000000000000000de0b6b3a7640000" // 1 ETH
+	toWord       = "000000000000000000000000f39fd6e51aad88f6f4ce6ab8827279cfffb92266"
+	deadlineWord = "0000000000000000000000000000000000000000000000000000000066f3a1c0"
+)
+
+// addLiquidityETHCall is addLiquidityETH calldata captured from a launch
+var addLiquidityETHCall = "f305d719" + tokenWord + amountWord + minWord + ethMinWord + toWord + deadlineWord
+
+// createPairCall is createPair calldata for the token and WETH
+var createPairCall = "c9c65396" + tokenWord + wethWord
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeAddLiquidityETH(t *testing.T) {
+	args, err := DecodeAddLiquidityETH(decodeHex(t, addLiquidityETHCall))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+	if args.Token != common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48") {
+		t.Errorf("token %s", args.Token.Hex())
+	}
+	if args.AmountTokenDesired.Cmp(tokens) != 0 || args.AmountTokenMin.Cmp(tokens) != 0 {
+		t.Errorf("token amounts %s, %s, want %s", args.AmountTokenDesired, args.AmountTokenMin, tokens)
+	}
+	if args.AmountETHMin.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("ETH min %s, want 1e18", args.AmountETHMin)
+	}
+	if args.To != common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266") {
+		t.Errorf("to %s", args.To.Hex())
+	}
+	if args.Deadline.Int64() != 0x66f3a1c0 {
+		t.Errorf("deadline %s", args.Deadline)
+	}
+}
+
+func TestDecodeCreatePair(t *testing.T) {
+	tokenA, tokenB, err := DecodeCreatePair(decodeHex(t, createPairCall))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokenA != common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48") {
+		t.Errorf("tokenA %s", tokenA.Hex())
+	}
+	if tokenB != common.HexToAddress("0x4200000000000000000000000000000000000006") {
+		t.Errorf("tokenB %s", tokenB.Hex())
+	}
+}
+
+func TestDecodeLaunchCallMalformed(t *testing.T) {
+	// dirtyToken is the token word with a non-zero byte above its address
+	dirtyToken := "01" + tokenWord[2:]
+
+	tests := []struct {
+		name    string
+		method  string
+		data    string
+		wantErr string // Empty when the calldata should decode
+	}{
+		{"empty", "addLiquidityETH", "", "not a addLiquidityETH call"},
+		{"selector only prefix", "addLiquidityETH", "f305d7", "not a addLiquidityETH call"},
+		{"other method", "addLiquidityETH", createPairCall, "not a addLiquidityETH call"},
+		{"createPair as addLiquidityETH", "createPair", addLiquidityETHCall, "not a createPair call"},
+		{"no arguments", "addLiquidityETH", "f305d719", "malformed addLiquidityETH calldata"},
+		{"truncated", "addLiquidityETH", addLiquidityETHCall[:len(addLiquidityETHCall)-2], "malformed addLiquidityETH calldata"},
+		{"missing word", "createPair", "c9c65396" + tokenWord, "malformed createPair calldata"},
+		{"dirty address", "addLiquidityETH", strings.Replace(addLiquidityETHCall, tokenWord, dirtyToken, 1), "not canonically encoded"},
+		{"dirty pair address", "createPair", "c9c65396" + tokenWord + "ff" + wethWord[2:], "not canonically encoded"},
+		{"trailing bytes", "addLiquidityETH", addLiquidityETHCall + "deadbeef", ""},
+		{"trailing word", "createPair", createPairCall + amountWord, ""},
+	}
+
+	for _, tt := range tests {
+		var err error
+		data := decodeHex(t, tt.data)
+		if tt.method == "createPair" {
+			_, _, err = DecodeCreatePair(data)
+		} else {
+			_, err = DecodeAddLiquidityETH(data)
+		}
+
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && err == nil:
+			t.Errorf("%s: decoded, want error containing %q", tt.name, tt.wantErr)
+		case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+			t.Errorf("%s: error %q, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package dex
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// permitTypedData is the EIP-712 typed data of an EIP-2612 permit for an
+// OpenZeppelin ERC20Permit token, as a wallet would be asked to sign it
+func permitTypedData(token, owner, spender common.Address, value, nonce, deadline *big.Int) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Snipe Token",
+			Version:           "1",
+			ChainId:           math.NewHexOrDecimal256(8453),
+			VerifyingContract: token.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner":    owner.Hex(),
+			"spender":  spender.Hex(),
+			"value":    (*hexutil.Big)(value).String(),
+			"nonce":    (*hexutil.Big)(nonce).String(),
+			"deadline": (*hexutil.Big)(deadline).String(),
+		},
+	}
+}
+
+// TestSignPermit checks signPermit against go-ethereum's own EIP-712 encoder:
+// the digest it signs must be the one a wallet signs for the same permit,
+// and the signature must recover to the owner as the token's ecrecover does
+func TestSignPermit(t *testing.T) {
+	// The key 0x...01, whose address is well known
+	key, err := crypto.ToECDSA(common.LeftPadBytes([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := common.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	if got := crypto.PubkeyToAddress(key.PublicKey); got != owner {
+		t.Fatalf("key address %s, want %s", got.Hex(), owner.Hex())
+	}
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	spender := common.HexToAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24")
+
+	tests := []struct {
+		name     string
+		value    *big.Int
+		nonce    *big.Int
+		deadline *big.Int
+	}{
+		{"exact amount", big.NewInt(1000), big.NewInt(0), big.NewInt(1700000000)},
+		{"max approval", math.MaxBig256, big.NewInt(3), big.NewInt(1700000000)},
+		{"zero", big.NewInt(0), big.NewInt(1), math.MaxBig256},
+	}
+
+	for _, tt := range tests {
+		typedData := permitTypedData(token, owner, spender, tt.value, tt.nonce, tt.deadline)
+		want, _, err := apitypes.TypedDataAndHash(typedData)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		digest := permitDigest([32]byte(domainSeparator), owner, spender, tt.value, tt.nonce, tt.deadline)
+		if !bytes.Equal(digest, want) {
+			t.Errorf("%s: digest %x, want %x", tt.name, digest, want)
+		}
+
+		permit, err := signPermit([32]byte(domainSeparator), tt.nonce, key, token, spender, tt.value, tt.deadline)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if permit.V != 27 && permit.V != 28 {
+			t.Errorf("%s: v %d, want 27 or 28", tt.name, permit.V)
+		}
+		signature := append(append(permit.R[:], permit.S[:]...), permit.V-27)
+		pub, err := crypto.SigToPub(want, signature)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if recovered := crypto.PubkeyToAddress(*pub); recovered != owner {
+			t.Errorf("%s: signature recovers to %s, want the owner %s", tt.name, recovered.Hex(), owner.Hex())
+		}
+		if permit.Owner != owner || permit.Spender != spender || permit.Value.Cmp(tt.value) != 0 || permit.Deadline.Cmp(tt.deadline) != 0 {
+			t.Errorf("%s: permit %+v doesn't carry what was signed", tt.name, permit)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// PoolWETHReserve returns the WETH reserve of the token/WETH pair, or zero if
+// the pair doesn't exist yet
+func PoolWETHReserve(ctx context.Context, client *ethclient.Client, factory, token common.Address) (*big.Int, error) {
+	_, wethReserve, err := PoolReserves(ctx, client, factory, token)
+	return wethReserve, err
+}
+
+// PoolReserves returns the token and WETH reserves of the token/WETH pair, or
+// zeros if the pair doesn't exist yet
+func PoolReserves(ctx context.Context, client *ethclient.Client, factory, token common.Address) (tokenReserve, wethReserve *big.Int, err error) {
+	factoryContract, err := NewUniswapV2FactoryContract(client, factory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	weth := factoryContract.GetWETH()
+
+	pair, err := factoryContract.GetPair(opts, weth, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pair == (common.Address{}) {
+		return big.NewInt(0), big.NewInt(0), nil
+	}
+
+	pairContract, err := NewUniswapV2PairContract(client, pair)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token0, err := PairToken0(opts, pairContract, token, weth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reserve0, reserve1, err := pairContract.GetReserves(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokenReserve, wethReserve = OrientReserves(token0, weth, reserve0, reserve1)
+	return tokenReserve, wethReserve, nil
+}
+
+// PairToken0 reads a token/WETH pair's token0() and token1(), checks that the
+// pair holds exactly token and weth, and returns token0
+func PairToken0(opts *bind.CallOpts, pair *UniswapV2PairContract, token, weth common.Address) (common.Address, error) {
+	token0, err := pair.GetToken0(opts)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get token0: %v", err)
+	}
+	token1, err := pair.GetToken1(opts)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get token1: %v", err)
+	}
+
+	if (token0 != token || token1 != weth) && (token0 != weth || token1 != token) {
+		return common.Address{}, fmt.Errorf("pair holds %s and %s, not %s and WETH", token0.Hex(), token1.Hex(), token.Hex())
+	}
+	return token0, nil
+}
+
+// OrientReserves returns a token/WETH pair's reserve0 and reserve1 as the
+// token and WETH reserves. A pair orders its tokens by address, so WETH is
+// token0 whenever the token's address sorts above it; taking reserve0 as the
+// token's reserve then inverts the price, and every amountOutMin built on it.
+func OrientReserves(token0, weth common.Address, reserve0, reserve1 *big.Int) (tokenReserve, wethReserve *big.Int) {
+	if token0 == weth {
+		return reserve1, reserve0
+	}
+	return reserve0, reserve1
+}
+
+// GetAmountOut is the Uniswap V2 output for amountIn against the given
+// reserves, after the 0.3% fee
+func GetAmountOut(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+	if amountIn.Sign() <= 0 || reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(997))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(1000))
+	denominator.Add(denominator, amountInWithFee)
+	return numerator.Div(numerator, denominator)
+}
+
+// LiquidityAdded returns the token and WETH amounts an addLiquidityETH of
+// amountTokenDesired and ethValue adds to a pool holding the given reserves.
+// An empty pool takes both in full; otherwise, like UniswapV2Router02, the
+// pool's price is kept and only one of the two is added in full.
+func LiquidityAdded(tokenReserve, wethReserve, amountTokenDesired, ethValue *big.Int) (tokenAdded, wethAdded *big.Int) {
+	if tokenReserve.Sign() == 0 && wethReserve.Sign() == 0 {
+		return new(big.Int).Set(amountTokenDesired), new(big.Int).Set(ethValue)
+	}
+
+	ethOptimal := new(big.Int).Mul(amountTokenDesired, wethReserve)
+	ethOptimal.Div(ethOptimal, tokenReserve)
+	if ethOptimal.Cmp(ethValue) <= 0 {
+		return new(big.Int).Set(amountTokenDesired), ethOptimal
+	}
+
+	tokenOptimal := new(big.Int).Mul(ethValue, tokenReserve)
+	tokenOptimal.Div(tokenOptimal, wethReserve)
+	return tokenOptimal, new(big.Int).Set(ethValue)
+}
+
+// BuyQuote is the outcome of buying a token with ETH against a pool's reserves
+type BuyQuote struct {
+	TokensOut *big.Int
+	// PriceImpact is how far, in percent, the effective price is above the
+	// spot price, the 0.3% fee included
+	PriceImpact float64
+}
+
+// QuoteBuy quotes a buy of amountIn wei of token against its pool's reserves.
+// An empty pool quotes zero tokens at 100% impact.
+func QuoteBuy(amountIn, tokenReserve, wethReserve *big.Int) BuyQuote {
+	out := GetAmountOut(amountIn, wethReserve, tokenReserve)
+	if out.Sign() == 0 {
+		return BuyQuote{TokensOut: out, PriceImpact: 100}
+	}
+
+	// Tokens out relative to what amountIn buys at the spot price
+	received := new(big.Float).SetInt(new(big.Int).Mul(out, wethReserve))
+	atSpot := new(big.Float).SetInt(new(big.Int).Mul(amountIn, tokenReserve))
+	ratio, _ := new(big.Float).Quo(received, atSpot).Float64()
+	return BuyQuote{TokensOut: out, PriceImpact: (1 - ratio) * 100}
+}
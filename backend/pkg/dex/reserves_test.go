@@ -0,0 +1,157 @@
+package dex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	testFactory = common.HexToAddress("0x8909Dc15e40173Ff4699343b6eB8132c65e18eC6")
+	testPair    = common.HexToAddress("0x00000000000000000000000000000000000000fa")
+	baseWETH    = common.HexToAddress("0x4200000000000000000000000000000000000006")
+	lowToken    = common.HexToAddress("0x1000000000000000000000000000000000000001") // Sorts below WETH
+	highToken   = common.HexToAddress("0xf000000000000000000000000000000000000001") // Sorts above WETH
+)
+
+// stubPair is a node serving a factory and a single pair holding token0 and
+// token1 with the given reserves
+func stubPair(t *testing.T, token0, token1 common.Address, reserve0, reserve1 *big.Int) *ethclient.Client {
+	t.Helper()
+	factoryABI, err := abi.JSON(strings.NewReader(UniswapV2FactoryABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairABI, err := abi.JSON(strings.NewReader(UniswapV2PairABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		var call struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+			Data  hexutil.Bytes  `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "eth_call" || json.Unmarshal(req.Params[0], &call) != nil {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		input := call.Input
+		if len(input) == 0 {
+			input = call.Data
+		}
+
+		var out []byte
+		switch {
+		case call.To == testFactory && len(input) >= 4:
+			out, err = factoryABI.Methods["getPair"].Outputs.Pack(testPair)
+		case call.To == testPair && len(input) >= 4:
+			method, methodErr := pairABI.MethodById(input[:4])
+			if methodErr != nil {
+				err = methodErr
+				break
+			}
+			switch method.Name {
+			case "token0":
+				out, err = method.Outputs.Pack(token0)
+			case "token1":
+				out, err = method.Outputs.Pack(token1)
+			case "getReserves":
+				out, err = method.Outputs.Pack(reserve0, reserve1, uint32(1))
+			default:
+				err = fmt.Errorf("unexpected method %s", method.Name)
+			}
+		default:
+			err = fmt.Errorf("unexpected call to %s", call.To.Hex())
+		}
+		if err != nil {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":%q}}`, req.ID, err.Error())
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"%s"}`, req.ID, hexutil.Encode(out))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestPoolReservesOrderings(t *testing.T) {
+	tokens := new(big.Int).Mul(big.NewInt(1_000_000), big.NewInt(1e18))
+	weth := new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18))
+
+	tests := []struct {
+		name               string
+		token              common.Address
+		token0, token1     common.Address
+		reserve0, reserve1 *big.Int
+	}{
+		{"token is token0", lowToken, lowToken, baseWETH, tokens, weth},
+		{"WETH is token0", highToken, baseWETH, highToken, weth, tokens},
+	}
+
+	// 1 ETH into 10 ETH against 1,000,000 tokens buys about 90,661 tokens
+	wantOut := GetAmountOut(big.NewInt(1e18), weth, tokens)
+
+	for _, tt := range tests {
+		client := stubPair(t, tt.token0, tt.token1, tt.reserve0, tt.reserve1)
+		tokenReserve, wethReserve, err := PoolReserves(context.Background(), client, testFactory, tt.token)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if tokenReserve.Cmp(tokens) != 0 || wethReserve.Cmp(weth) != 0 {
+			t.Errorf("%s: reserves %s tokens and %s WETH, want %s and %s", tt.name, tokenReserve, wethReserve, tokens, weth)
+		}
+		if out := QuoteBuy(big.NewInt(1e18), tokenReserve, wethReserve).TokensOut; out.Cmp(wantOut) != 0 {
+			t.Errorf("%s: 1 ETH buys %s tokens, want %s", tt.name, out, wantOut)
+		}
+	}
+}
+
+func TestPoolReservesWrongPair(t *testing.T) {
+	other := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	client := stubPair(t, lowToken, other, big.NewInt(1), big.NewInt(1))
+	if _, _, err := PoolReserves(context.Background(), client, testFactory, lowToken); err == nil {
+		t.Error("reserves of a pair without WETH returned no error")
+	}
+}
+
+func TestOrientReserves(t *testing.T) {
+	tokens, weth := big.NewInt(1_000_000), big.NewInt(10)
+
+	tests := []struct {
+		name               string
+		token0             common.Address
+		reserve0, reserve1 *big.Int
+	}{
+		{"token is token0", lowToken, tokens, weth},
+		{"WETH is token0", baseWETH, weth, tokens},
+	}
+
+	for _, tt := range tests {
+		tokenReserve, wethReserve := OrientReserves(tt.token0, baseWETH, tt.reserve0, tt.reserve1)
+		if tokenReserve != tokens || wethReserve != weth {
+			t.Errorf("%s: reserves %s tokens and %s WETH, want %s and %s", tt.name, tokenReserve, wethReserve, tokens, weth)
+		}
+	}
+}
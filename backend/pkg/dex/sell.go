@@ -0,0 +1,26 @@
+package dex
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PackSellForETH returns the router call data selling amountIn of token for
+// ETH sent to `to`
+func PackSellForETH(token, weth common.Address, amountIn, amountOutMin *big.Int, to common.Address, deadline *big.Int) ([]byte, error) {
+	parsed, err := abi.JSON(strings.NewReader(UniswapV2RouterSellABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.Pack("swapExactTokensForETHSupportingFeeOnTransferTokens",
+		amountIn,
+		amountOutMin,
+		[]common.Address{token, weth},
+		to,
+		deadline,
+	)
+}
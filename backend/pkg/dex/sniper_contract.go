@@ -3,6 +3,8 @@ package dex
 import (
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
 	"math/big"
 	"strings"
 
@@ -14,12 +16,18 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// DefaultSnipeGasLimit is the gas limit used for snipeWithBribe transactions
+// unless SetGasLimit overrides it
+const DefaultSnipeGasLimit = 300000
+
 // SniperContract represents the custom sniper contract
 type SniperContract struct {
 	client   *ethclient.Client
 	contract *bind.BoundContract
+	abi      abi.ABI
 	address  common.Address
 	chainID  *big.Int
+	gasLimit uint64
 }
 
 // SnipeData represents the parameters for a snipe
@@ -89,13 +97,64 @@ const SniperContractABI = `[
 	}
 ]`
 
-// NewSniperContract creates a new sniper contract instance
+// snipeWithBribeInputs are the argument types the bot packs for snipeWithBribe
+var snipeWithBribeInputs = []string{"address", "address", "uint256", "uint256", "uint256"}
+
+// ParseSniperABI parses a sniper contract ABI, using the embedded
+// SniperContractABI when abiJSON is empty. It fails if the ABI lacks a
+// snipeWithBribe(address,address,uint256,uint256,uint256) function, since the
+// bot couldn't encode snipes against it.
+func ParseSniperABI(abiJSON string) (abi.ABI, error) {
+	if abiJSON == "" {
+		abiJSON = SniperContractABI
+	}
+
+	// Accept a compiler artifact (e.g. Foundry's out/Sniper.sol/Sniper.json) as well as a bare ABI
+	if strings.HasPrefix(strings.TrimSpace(abiJSON), "{") {
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal([]byte(abiJSON), &artifact); err != nil || artifact.ABI == nil {
+			return abi.ABI{}, fmt.Errorf("invalid sniper contract ABI: expected a JSON array or an artifact with an \"abi\" field")
+		}
+		abiJSON = string(artifact.ABI)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("invalid sniper contract ABI: %v", err)
+	}
+
+	method, ok := parsed.Methods["snipeWithBribe"]
+	if !ok {
+		return abi.ABI{}, fmt.Errorf("sniper contract ABI has no snipeWithBribe function")
+	}
+	if len(method.Inputs) != len(snipeWithBribeInputs) {
+		return abi.ABI{}, fmt.Errorf("sniper contract ABI has %s, expected snipeWithBribe(%s)",
+			method.Sig, strings.Join(snipeWithBribeInputs, ","))
+	}
+	for i, input := range method.Inputs {
+		if input.Type.String() != snipeWithBribeInputs[i] {
+			return abi.ABI{}, fmt.Errorf("sniper contract ABI has %s, expected snipeWithBribe(%s)",
+				method.Sig, strings.Join(snipeWithBribeInputs, ","))
+		}
+	}
+
+	return parsed, nil
+}
+
+// NewSniperContract creates a new sniper contract instance using the embedded ABI
 func NewSniperContract(client *ethclient.Client, address common.Address) (*SniperContract, error) {
-	parsed, err := abi.JSON(strings.NewReader(SniperContractABI))
+	parsed, err := ParseSniperABI("")
 	if err != nil {
 		return nil, err
 	}
 
+	return NewSniperContractWithABI(client, address, parsed)
+}
+
+// NewSniperContractWithABI creates a sniper contract instance with an ABI from ParseSniperABI
+func NewSniperContractWithABI(client *ethclient.Client, address common.Address, parsed abi.ABI) (*SniperContract, error) {
 	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		return nil, err
@@ -105,11 +164,28 @@ func NewSniperContract(client *ethclient.Client, address common.Address) (*Snipe
 	return &SniperContract{
 		client:   client,
 		contract: contract,
+		abi:      parsed,
 		address:  address,
 		chainID:  chainID,
+		gasLimit: DefaultSnipeGasLimit,
 	}, nil
 }
 
+// SetGasLimit sets the gas limit of snipe transactions
+func (s *SniperContract) SetGasLimit(gasLimit uint64) {
+	s.gasLimit = gasLimit
+}
+
+// Address returns the address of the sniper contract
+func (s *SniperContract) Address() common.Address {
+	return s.address
+}
+
+// GasLimit returns the gas limit of snipe transactions
+func (s *SniperContract) GasLimit() uint64 {
+	return s.gasLimit
+}
+
 // SnipeWithBribe executes a single snipe with bribe
 func (s *SniperContract) SnipeWithBribe(
 	ctx context.Context,
@@ -131,8 +207,8 @@ func (s *SniperContract) SnipeWithBribe(
 	totalValue := new(big.Int).Add(swapAmount, bribeAmount)
 	auth.Value = totalValue
 
-	// Estimate gas
-	auth.GasLimit = 300000 // Conservative estimate
+	// Conservative fixed limit instead of an estimate
+	auth.GasLimit = s.gasLimit
 
 	// Get current gas price
 	gasPrice, err := s.client.SuggestGasPrice(ctx)
@@ -164,14 +240,8 @@ func (s *SniperContract) CreateSnipeTransaction(
 	gasPrice *big.Int,
 	nonce uint64,
 ) (*types.Transaction, error) {
-	// Parse the ABI
-	parsed, err := abi.JSON(strings.NewReader(SniperContractABI))
-	if err != nil {
-		return nil, err
-	}
-
 	// Pack the function call data
-	data, err := parsed.Pack("snipeWithBribe",
+	data, err := s.abi.Pack("snipeWithBribe",
 		token,
 		creator,
 		amountOutMin,
@@ -190,12 +260,63 @@ func (s *SniperContract) CreateSnipeTransaction(
 		nonce,
 		s.address,
 		totalValue,
-		300000, // Gas limit
+		s.gasLimit,
 		gasPrice,
 		data,
 	), nil
 }
 
+// SnipeExecuted is the SnipeExecuted event of a successful snipe
+type SnipeExecuted struct {
+	Sniper         common.Address
+	Token          common.Address
+	Creator        common.Address
+	SwapAmount     *big.Int // wei swapped for tokens
+	BribeAmount    *big.Int // wei paid to the creator
+	TokensReceived *big.Int // Swap output in the token's smallest unit, before any transfer fee
+}
+
+// snipeExecutedABI is SniperContractABI parsed once for its SnipeExecuted
+// event. The event is the contract's own, so it is decoded the same way
+// whatever SNIPER_ABI describes the functions with.
+var snipeExecutedABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(SniperContractABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid SniperContractABI: %v", err))
+	}
+	return parsed
+}()
+
+// ParseSnipeExecuted finds the SnipeExecuted event the sniper contract at
+// sniper emitted in receipt and decodes it. It fails if there is none, as
+// with a reverted snipe.
+func ParseSnipeExecuted(receipt *types.Receipt, sniper common.Address) (*SnipeExecuted, error) {
+	event := snipeExecutedABI.Events["SnipeExecuted"]
+	for _, log := range receipt.Logs {
+		if log.Address != sniper || len(log.Topics) != 4 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		var amounts struct {
+			SwapAmount     *big.Int
+			BribeAmount    *big.Int
+			TokensReceived *big.Int
+		}
+		if err := snipeExecutedABI.UnpackIntoInterface(&amounts, "SnipeExecuted", log.Data); err != nil {
+			return nil, fmt.Errorf("failed to decode SnipeExecuted: %v", err)
+		}
+		return &SnipeExecuted{
+			Sniper:         common.BytesToAddress(log.Topics[1].Bytes()),
+			Token:          common.BytesToAddress(log.Topics[2].Bytes()),
+			Creator:        common.BytesToAddress(log.Topics[3].Bytes()),
+			SwapAmount:     amounts.SwapAmount,
+			BribeAmount:    amounts.BribeAmount,
+			TokensReceived: amounts.TokensReceived,
+		}, nil
+	}
+	return nil, fmt.Errorf("no SnipeExecuted event from %s in %s", sniper.Hex(), receipt.TxHash.Hex())
+}
+
 // GetCreatorFromLPAddTx extracts the token creator from an LP_ADD transaction
 func (s *SniperContract) GetCreatorFromLPAddTx(tx *types.Transaction) (common.Address, error) {
 	// For LP_ADD transactions, the creator is typically the tx.origin or from address
@@ -221,14 +342,8 @@ func (s *SniperContract) EstimateGasForSnipe(
 	amountOutMin *big.Int,
 	deadline *big.Int,
 ) (uint64, error) {
-	// Parse the ABI
-	parsed, err := abi.JSON(strings.NewReader(SniperContractABI))
-	if err != nil {
-		return 0, err
-	}
-
 	// Pack the function call data
-	data, err := parsed.Pack("snipeWithBribe",
+	data, err := s.abi.Pack("snipeWithBribe",
 		token,
 		creator,
 		amountOutMin,
@@ -0,0 +1,148 @@
+package dex
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Parties of the snipe receipt below
+var (
+	receiptSniper  = common.HexToAddress("0x5fbdb2315678afecb367f032d93f642f64180aa3")
+	receiptToken   = common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	receiptPair    = common.HexToAddress("0x00000000000000000000000000000000000000fa")
+	receiptWallet  = common.HexToAddress("0x70997970c51812dc3a010c7d01b50e0d17dc79c8")
+	receiptCreator = common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+)
+
+// snipeReceipt is a snipeWithBribe receipt as eth_getTransactionReceipt
+// returns it: the pair's Transfer of the bought tokens to the contract, the
+// contract's Transfer of them to the wallet, then its SnipeExecuted of 0.05
+// ETH swapped and 0.001 ETH bribed for 45,231.5 tokens
+var snipeReceipt = `{
+	"type": "0x2",
+	"status": "0x1",
+	"cumulativeGasUsed": "0x4c2e1",
+	"gasUsed": "0x2b9a1",
+	"effectiveGasPrice": "0x3b9aca00",
+	"logsBloom": "0x` + zeroBloom + `",
+	"transactionHash": "0x6e3c5b0d0f5f7b4a8c1e2d3f4a5b6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7",
+	"transactionIndex": "0x1",
+	"blockHash": "0x1f0e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0",
+	"blockNumber": "0x12a05f2",
+	"from": "0x70997970c51812dc3a010c7d01b50e0d17dc79c8",
+	"to": "0x5fbdb2315678afecb367f032d93f642f64180aa3",
+	"contractAddress": null,
+	"logs": [
+		{
+			"address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			"topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x00000000000000000000000000000000000000000000000000000000000000fa",
+				"0x0000000000000000000000005fbdb2315678afecb367f032d93f642f64180aa3"
+			],
+			"data": "0x0000000000000000000000000000000000000000000009940128c33e010e0000",
+			"transactionHash": "0x6e3c5b0d0f5f7b4a8c1e2d3f4a5b6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7",
+			"logIndex": "0x3"
+		},
+		{
+			"address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			"topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x0000000000000000000000005fbdb2315678afecb367f032d93f642f64180aa3",
+				"0x00000000000000000000000070997970c51812dc3a010c7d01b50e0d17dc79c8"
+			],
+			"data": "0x0000000000000000000000000000000000000000000009940128c33e010e0000",
+			"transactionHash": "0x6e3c5b0d0f5f7b4a8c1e2d3f4a5b6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7",
+			"logIndex": "0x4"
+		},
+		{
+			"address": "0x5fbdb2315678afecb367f032d93f642f64180aa3",
+			"topics": [
+				"0x9509a9bc266259bbedcaf73a71b3475873822289f331305e0dceef1f48b592c2",
+				"0x00000000000000000000000070997970c51812dc3a010c7d01b50e0d17dc79c8",
+				"0x000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				"0x000000000000000000000000f39fd6e51aad88f6f4ce6ab8827279cfffb92266"
+			],
+			"data": "0x00000000000000000000000000000000000000000000000000b1a2bc2ec5000000000000000000000000000000000000000000000000000000038d7ea4c680000000000000000000000000000000000000000000000009940128c33e010e0000",
+			"transactionHash": "0x6e3c5b0d0f5f7b4a8c1e2d3f4a5b6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7",
+			"logIndex": "0x5"
+		}
+	]
+}`
+
+var zeroBloom = strings.Repeat("00", types.BloomByteLength)
+
+func parseReceipt(t *testing.T, s string) *types.Receipt {
+	t.Helper()
+	var receipt types.Receipt
+	if err := json.Unmarshal([]byte(s), &receipt); err != nil {
+		t.Fatal(err)
+	}
+	return &receipt
+}
+
+func TestParseSnipeExecuted(t *testing.T) {
+	receipt := parseReceipt(t, snipeReceipt)
+
+	event, err := ParseSnipeExecuted(receipt, receiptSniper)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokensReceived, _ := new(big.Int).SetString("45231500000000000000000", 10)
+	if event.Sniper != receiptWallet || event.Token != receiptToken || event.Creator != receiptCreator {
+		t.Errorf("sniper %s, token %s, creator %s", event.Sniper.Hex(), event.Token.Hex(), event.Creator.Hex())
+	}
+	if event.SwapAmount.Cmp(big.NewInt(5e16)) != 0 {
+		t.Errorf("swap amount %s, want 0.05 ETH", event.SwapAmount)
+	}
+	if event.BribeAmount.Cmp(big.NewInt(1e15)) != 0 {
+		t.Errorf("bribe amount %s, want 0.001 ETH", event.BribeAmount)
+	}
+	if event.TokensReceived.Cmp(tokensReceived) != 0 {
+		t.Errorf("tokens received %s, want %s", event.TokensReceived, tokensReceived)
+	}
+}
+
+func TestParseSnipeExecutedMissing(t *testing.T) {
+	tests := []struct {
+		name    string
+		receipt func(*types.Receipt)
+		sniper  common.Address
+	}{
+		{
+			name:    "reverted snipe",
+			receipt: func(r *types.Receipt) { r.Status, r.Logs = types.ReceiptStatusFailed, nil },
+			sniper:  receiptSniper,
+		},
+		{
+			// Another deployment's event, or a contract imitating it
+			name:    "other contract",
+			receipt: func(*types.Receipt) {},
+			sniper:  receiptPair,
+		},
+		{
+			name:    "Transfer logs only",
+			receipt: func(r *types.Receipt) { r.Logs = r.Logs[:2] },
+			sniper:  receiptSniper,
+		},
+		{
+			name:    "truncated data",
+			receipt: func(r *types.Receipt) { r.Logs[2].Data = r.Logs[2].Data[:64] },
+			sniper:  receiptSniper,
+		},
+	}
+
+	for _, tt := range tests {
+		receipt := parseReceipt(t, snipeReceipt)
+		tt.receipt(receipt)
+		if event, err := ParseSnipeExecuted(receipt, tt.sniper); err == nil {
+			t.Errorf("%s: decoded %+v, want an error", tt.name, event)
+		}
+	}
+}
@@ -3,15 +3,30 @@ package eth
 import (
 	"context"
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// erc20AllowanceABI covers the ERC20 allowance view
+const erc20AllowanceABI = `[{
+	"inputs": [
+		{"internalType": "address", "name": "owner", "type": "address"},
+		{"internalType": "address", "name": "spender", "type": "address"}
+	],
+	"name": "allowance",
+	"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+	"stateMutability": "view",
+	"type": "function"
+}]`
+
 // Client wraps the Ethereum client with additional functionality
 type Client struct {
 	*ethclient.Client
@@ -62,6 +77,28 @@ func (c *Client) GetTokenBalance(ctx context.Context, tokenAddress, holderAddres
 	return big.NewInt(0), nil
 }
 
+// GetAllowance gets how much of token owner has approved spender to transfer
+func (c *Client) GetAllowance(ctx context.Context, token, owner, spender common.Address) (*big.Int, error) {
+	erc20, err := abi.JSON(strings.NewReader(erc20AllowanceABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %v", err)
+	}
+	data, err := erc20.Pack("allowance", owner, spender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack allowance: %v", err)
+	}
+
+	result, err := c.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("allowance call failed: %v", err)
+	}
+	out, err := erc20.Unpack("allowance", result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack allowance: %v", err)
+	}
+	return abi.ConvertType(out[0], new(big.Int)).(*big.Int), nil
+}
+
 // SendTransaction sends a transaction to the network
 func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
 	return c.Client.SendTransaction(ctx, tx)
@@ -0,0 +1,156 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// GasPriceSource estimates the fee per gas a transaction pays before its
+// priority fee: the base fee on EIP-1559 chains
+type GasPriceSource interface {
+	GasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// NodeGasPrice uses the latest block's base fee, or the node's eth_gasPrice
+// suggestion on chains without one
+type NodeGasPrice struct {
+	client *ethclient.Client
+}
+
+// NewNodeGasPrice creates a gas price source backed by the node
+func NewNodeGasPrice(client *ethclient.Client) *NodeGasPrice {
+	return &NodeGasPrice{client: client}
+}
+
+// GasPrice implements GasPriceSource
+func (n *NodeGasPrice) GasPrice(ctx context.Context) (*big.Int, error) {
+	header, err := n.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %v", err)
+	}
+	if header.BaseFee != nil {
+		return new(big.Int).Set(header.BaseFee), nil
+	}
+
+	gasPrice, err := n.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %v", err)
+	}
+	return gasPrice, nil
+}
+
+// BaseFeeMultipleGasPrice prices at a fixed multiple of the latest base fee,
+// leaving headroom for the base fee to rise before the transaction lands
+type BaseFeeMultipleGasPrice struct {
+	client     *ethclient.Client
+	multiplier *big.Float
+}
+
+// NewBaseFeeMultipleGasPrice creates a gas price source pricing at multiplier
+// times the latest base fee
+func NewBaseFeeMultipleGasPrice(client *ethclient.Client, multiplier float64) *BaseFeeMultipleGasPrice {
+	return &BaseFeeMultipleGasPrice{client: client, multiplier: big.NewFloat(multiplier)}
+}
+
+// GasPrice implements GasPriceSource
+func (b *BaseFeeMultipleGasPrice) GasPrice(ctx context.Context) (*big.Int, error) {
+	header, err := b.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %v", err)
+	}
+	if header.BaseFee == nil {
+		return nil, fmt.Errorf("latest block has no base fee")
+	}
+
+	gasPrice, _ := new(big.Float).Mul(new(big.Float).SetInt(header.BaseFee), b.multiplier).Int(nil)
+	return gasPrice, nil
+}
+
+// OracleGasPrice fetches the gas price from an external oracle, caching each
+// answer for ttl. The oracle must answer a GET with a JSON object whose
+// "gasPrice" field is in wei, as a number or a decimal or 0x-prefixed
+// string. While the oracle fails, prices come from fallback.
+type OracleGasPrice struct {
+	url      string
+	ttl      time.Duration
+	client   *http.Client
+	fallback GasPriceSource
+
+	mu        sync.Mutex
+	price     *big.Int
+	fetchedAt time.Time
+}
+
+// NewOracleGasPrice creates a gas price source backed by the oracle at url
+func NewOracleGasPrice(url string, ttl time.Duration, fallback GasPriceSource) *OracleGasPrice {
+	return &OracleGasPrice{
+		url:      url,
+		ttl:      ttl,
+		client:   &http.Client{Timeout: 2 * time.Second},
+		fallback: fallback,
+	}
+}
+
+// GasPrice implements GasPriceSource
+func (o *OracleGasPrice) GasPrice(ctx context.Context) (*big.Int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.price != nil && time.Since(o.fetchedAt) < o.ttl {
+		return new(big.Int).Set(o.price), nil
+	}
+
+	price, err := o.fetch(ctx)
+	if err != nil {
+		log.Printf("⚠️ Gas oracle failed, using the node's gas price: %v", err)
+		return o.fallback.GasPrice(ctx)
+	}
+
+	o.price, o.fetchedAt = price, time.Now()
+	return new(big.Int).Set(price), nil
+}
+
+// fetch asks the oracle for the current gas price
+func (o *OracleGasPrice) fetch(ctx context.Context) (*big.Int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oracle returned %s", resp.Status)
+	}
+
+	var answer struct {
+		GasPrice json.RawMessage `json:"gasPrice"`
+	}
+	if err := json.Unmarshal(body, &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
+	}
+
+	price, ok := new(big.Int).SetString(strings.Trim(string(answer.GasPrice), `"`), 0)
+	if !ok || price.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid gasPrice %s", answer.GasPrice)
+	}
+	return price, nil
+}
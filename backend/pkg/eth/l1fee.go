@@ -0,0 +1,52 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// GasPriceOracleAddress is the OP Stack predeploy that prices the L1 data
+// fee every transaction on Base pays on top of its L2 gas
+var GasPriceOracleAddress = common.HexToAddress("0x420000000000000000000000000000000000000F")
+
+// getL1FeeUpperBoundSelector is GasPriceOracle.getL1FeeUpperBound(uint256)
+var getL1FeeUpperBoundSelector = crypto.Keccak256([]byte("getL1FeeUpperBound(uint256)"))[:4]
+
+// L1FeeUpperBound returns the most L1 data fee a transaction of txSize
+// unsigned bytes can pay at the current L1 prices
+func L1FeeUpperBound(ctx context.Context, client *ethclient.Client, txSize uint64) (*big.Int, error) {
+	data := append([]byte{}, getL1FeeUpperBoundSelector...)
+	data = append(data, common.LeftPadBytes(new(big.Int).SetUint64(txSize).Bytes(), 32)...)
+
+	out, err := client.CallContract(ctx, ethereum.CallMsg{To: &GasPriceOracleAddress, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getL1FeeUpperBound: %v", err)
+	}
+	if len(out) != 32 {
+		return nil, fmt.Errorf("unexpected getL1FeeUpperBound result of %d bytes", len(out))
+	}
+	return new(big.Int).SetBytes(out), nil
+}
+
+// ReceiptL1Fee returns the L1 data fee a mined transaction paid, from the
+// l1Fee field OP Stack nodes add to receipts, or nil if the node doesn't
+// report one
+func ReceiptL1Fee(ctx context.Context, client *ethclient.Client, txHash common.Hash) (*big.Int, error) {
+	var receipt struct {
+		L1Fee *hexutil.Big `json:"l1Fee"`
+	}
+	if err := client.Client().CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %v", err)
+	}
+	if receipt.L1Fee == nil {
+		return nil, nil
+	}
+	return receipt.L1Fee.ToInt(), nil
+}
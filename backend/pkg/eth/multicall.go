@@ -0,0 +1,147 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Multicall3Address is Multicall3, deployed at the same address on Base and
+// most other chains
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicall3ABI covers aggregate3 and getEthBalance
+const multicall3ABI = `[
+	{
+		"inputs": [{"components": [
+			{"internalType": "address", "name": "target", "type": "address"},
+			{"internalType": "bool", "name": "allowFailure", "type": "bool"},
+			{"internalType": "bytes", "name": "callData", "type": "bytes"}
+		], "internalType": "struct Multicall3.Call3[]", "name": "calls", "type": "tuple[]"}],
+		"name": "aggregate3",
+		"outputs": [{"components": [
+			{"internalType": "bool", "name": "success", "type": "bool"},
+			{"internalType": "bytes", "name": "returnData", "type": "bytes"}
+		], "internalType": "struct Multicall3.Result[]", "name": "returnData", "type": "tuple[]"}],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "address", "name": "addr", "type": "address"}],
+		"name": "getEthBalance",
+		"outputs": [{"internalType": "uint256", "name": "balance", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// maxBatchSize caps the wallets read in one multicall or JSON-RPC batch, to
+// stay within node request limits
+const maxBatchSize = 500
+
+// multicall3Call is the Call3 struct of aggregate3
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicall3Result is the Result struct of aggregate3
+type multicall3Result struct {
+	Success    bool   `json:"success"`
+	ReturnData []byte `json:"returnData"`
+}
+
+// BatchBalances returns the latest balance of each address, in order, reading
+// up to maxBatchSize of them per Multicall3 call instead of one call each
+func (c *Client) BatchBalances(ctx context.Context, addrs []common.Address) ([]*big.Int, error) {
+	multicall3, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Multicall3 ABI: %v", err)
+	}
+
+	balances := make([]*big.Int, 0, len(addrs))
+	for start := 0; start < len(addrs); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(addrs))
+
+		calls := make([]multicall3Call, 0, end-start)
+		for _, addr := range addrs[start:end] {
+			data, err := multicall3.Pack("getEthBalance", addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to pack getEthBalance: %v", err)
+			}
+			calls = append(calls, multicall3Call{Target: Multicall3Address, CallData: data})
+		}
+
+		data, err := multicall3.Pack("aggregate3", calls)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack aggregate3: %v", err)
+		}
+		out, err := c.CallContract(ctx, ethereum.CallMsg{To: &Multicall3Address, Data: data}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("multicall failed: %v", err)
+		}
+
+		unpacked, err := multicall3.Unpack("aggregate3", out)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack aggregate3: %v", err)
+		}
+		results := *abi.ConvertType(unpacked[0], new([]multicall3Result)).(*[]multicall3Result)
+		if len(results) != len(calls) {
+			return nil, fmt.Errorf("multicall returned %d results for %d calls", len(results), len(calls))
+		}
+
+		for _, result := range results {
+			balances = append(balances, new(big.Int).SetBytes(result.ReturnData))
+		}
+	}
+
+	return balances, nil
+}
+
+// BatchPendingNonces returns the pending nonce of each address, in order.
+// Nonces can't be read from inside the EVM, so they are fetched with one
+// JSON-RPC batch request per maxBatchSize addresses instead.
+func (c *Client) BatchPendingNonces(ctx context.Context, addrs []common.Address) ([]uint64, error) {
+	return batchNonces(ctx, c.Client, addrs, "pending")
+}
+
+// batchNonces returns the nonce of each address at block ("pending" or
+// "latest"), in order, with one JSON-RPC batch request per maxBatchSize
+// addresses
+func batchNonces(ctx context.Context, client *ethclient.Client, addrs []common.Address, block string) ([]uint64, error) {
+	nonces := make([]uint64, 0, len(addrs))
+	for start := 0; start < len(addrs); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(addrs))
+
+		results := make([]hexutil.Uint64, end-start)
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for i, addr := range addrs[start:end] {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionCount",
+				Args:   []interface{}{addr, block},
+				Result: &results[i],
+			})
+		}
+
+		if err := client.Client().BatchCallContext(ctx, batch); err != nil {
+			return nil, fmt.Errorf("nonce batch failed: %v", err)
+		}
+		for i, elem := range batch {
+			if elem.Error != nil {
+				return nil, fmt.Errorf("failed to get nonce of %s: %v", addrs[start+i].Hex(), elem.Error)
+			}
+			nonces = append(nonces, uint64(results[i]))
+		}
+	}
+
+	return nonces, nil
+}
@@ -0,0 +1,95 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// NonceMode selects how a NonceReader picks a wallet's next nonce
+type NonceMode int
+
+const (
+	// NonceModePending trusts the node's pending nonce
+	NonceModePending NonceMode = iota
+
+	// NonceModeReconcile reads both the pending and the latest nonce and
+	// falls back to the latest when they disagree suspiciously
+	NonceModeReconcile
+
+	// NonceModeLatest uses the nonce of the latest block, replacing whatever
+	// the wallet has pending
+	NonceModeLatest
+)
+
+// NonceReader reads the next nonce of wallets. Providers behind load
+// balancers don't always agree on the pending nonce: one may lag behind the
+// chain, another may still count transactions that will never be mined. Either
+// leaves the next transaction stuck behind a gap or colliding with another.
+type NonceReader struct {
+	client *ethclient.Client
+	mode   NonceMode
+	maxGap uint64 // Most transactions the pending nonce may be ahead of the latest
+}
+
+// NewNonceReader creates a nonce reader
+func NewNonceReader(client *ethclient.Client, mode NonceMode, maxGap uint64) *NonceReader {
+	return &NonceReader{client: client, mode: mode, maxGap: maxGap}
+}
+
+// Nonce returns the next nonce of wallet
+func (r *NonceReader) Nonce(ctx context.Context, wallet common.Address) (uint64, error) {
+	nonces, err := r.Nonces(ctx, []common.Address{wallet})
+	if err != nil {
+		return 0, err
+	}
+	return nonces[0], nil
+}
+
+// Nonces returns the next nonce of each wallet, in order
+func (r *NonceReader) Nonces(ctx context.Context, wallets []common.Address) ([]uint64, error) {
+	switch r.mode {
+	case NonceModePending:
+		return batchNonces(ctx, r.client, wallets, "pending")
+	case NonceModeLatest:
+		return batchNonces(ctx, r.client, wallets, "latest")
+	}
+
+	pending, err := batchNonces(ctx, r.client, wallets, "pending")
+	if err != nil {
+		return nil, err
+	}
+	latest, err := batchNonces(ctx, r.client, wallets, "latest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to cross-check pending nonces: %v", err)
+	}
+
+	nonces := make([]uint64, len(wallets))
+	for i, wallet := range wallets {
+		nonce, problem := ReconcileNonce(pending[i], latest[i], r.maxGap)
+		if problem != "" {
+			log.Printf("⚠️ Nonce of %s: pending %d, latest %d, %s; using %d", wallet.Hex(), pending[i], latest[i], problem, nonce)
+		}
+		nonces[i] = nonce
+	}
+	return nonces, nil
+}
+
+// ReconcileNonce picks the next nonce from a wallet's pending and latest
+// nonce. A pending nonce behind the latest is stale, and one more than maxGap
+// ahead counts transactions that are unlikely to be mined; both fall back to
+// the latest nonce. It returns the nonce and what was wrong, or "" when the
+// pending nonce is plausible.
+func ReconcileNonce(pending, latest, maxGap uint64) (uint64, string) {
+	switch {
+	case pending < latest:
+		return latest, "pending is stale"
+	case pending-latest > maxGap:
+		return latest, fmt.Sprintf("pending is more than %d ahead", maxGap)
+	default:
+		return pending, ""
+	}
+}
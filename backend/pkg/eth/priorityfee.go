@@ -0,0 +1,98 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// PriorityFeePercentile samples the priority fees of the last blocks blocks:
+// the percentile-th tip of each block (gas-weighted, as eth_feeHistory
+// computes it), then the median of those across the blocks that had
+// transactions, so one busy or empty block doesn't set the result
+func PriorityFeePercentile(ctx context.Context, client *ethclient.Client, blocks uint64, percentile float64) (*big.Int, error) {
+	history, err := client.FeeHistory(ctx, blocks, nil, []float64{percentile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee history: %v", err)
+	}
+
+	var tips []*big.Int
+	for i, rewards := range history.Reward {
+		if len(rewards) == 0 || i >= len(history.GasUsedRatio) || history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		tips = append(tips, rewards[0])
+	}
+	if len(tips) == 0 {
+		return nil, fmt.Errorf("no transactions in the last %d blocks", blocks)
+	}
+
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	return new(big.Int).Set(tips[len(tips)/2]), nil
+}
+
+// PriorityFeeTracker prices the priority fee after recent blocks: the
+// sampled percentile (see PriorityFeePercentile), at least floor and at most
+// ceiling. Samples are reused for ttl. With a percentile of 0, or while
+// sampling fails, the tip is the floor.
+type PriorityFeeTracker struct {
+	client     *ethclient.Client
+	floor      *big.Int
+	ceiling    *big.Int
+	blocks     uint64
+	percentile float64
+	ttl        time.Duration
+
+	mu        sync.Mutex
+	tip       *big.Int
+	fetchedAt time.Time
+}
+
+// NewPriorityFeeTracker creates a priority fee tracker
+func NewPriorityFeeTracker(client *ethclient.Client, floor, ceiling *big.Int, blocks uint64, percentile float64, ttl time.Duration) *PriorityFeeTracker {
+	return &PriorityFeeTracker{
+		client:     client,
+		floor:      floor,
+		ceiling:    ceiling,
+		blocks:     blocks,
+		percentile: percentile,
+		ttl:        ttl,
+	}
+}
+
+// PriorityFee returns the priority fee to pay now
+func (t *PriorityFeeTracker) PriorityFee(ctx context.Context) *big.Int {
+	if t.percentile <= 0 {
+		return new(big.Int).Set(t.floor)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tip != nil && time.Since(t.fetchedAt) < t.ttl {
+		return new(big.Int).Set(t.tip)
+	}
+
+	sampled, err := PriorityFeePercentile(ctx, t.client, t.blocks, t.percentile)
+	if err != nil {
+		log.Printf("⚠️ Failed to sample recent priority fees, using the %s wei floor: %v", t.floor, err)
+		return new(big.Int).Set(t.floor)
+	}
+
+	tip := sampled
+	if tip.Cmp(t.floor) < 0 {
+		tip = t.floor
+	}
+	if tip.Cmp(t.ceiling) > 0 {
+		tip = t.ceiling
+	}
+
+	t.tip, t.fetchedAt = new(big.Int).Set(tip), time.Now()
+	return new(big.Int).Set(tip)
+}
@@ -0,0 +1,90 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SimulationResult is the outcome of one transaction of a simulation
+type SimulationResult struct {
+	Success      bool
+	GasUsed      uint64
+	RevertReason string // Why it failed, when it did
+}
+
+// simulateCall is a call of an eth_simulateV1 block
+type simulateCall struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Gas   hexutil.Uint64  `json:"gas"`
+	Value *hexutil.Big    `json:"value"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+// simulateCallResult is the result of a call of an eth_simulateV1 block
+type simulateCallResult struct {
+	Status     hexutil.Uint64 `json:"status"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Error      *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SimulateTransactions runs signed transactions one after another in a single
+// simulated block on top of the latest one, each seeing the state the ones
+// before it leave, and returns their outcomes in order. It uses
+// eth_simulateV1 without validation, so nonces and fees aren't checked but
+// the sender, gas limit, value and calldata are exactly those signed. A
+// transaction that fails doesn't stop the ones after it.
+func (c *Client) SimulateTransactions(ctx context.Context, txs []*types.Transaction) ([]SimulationResult, error) {
+	calls := make([]simulateCall, 0, len(txs))
+	for _, tx := range txs {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover sender of %s: %v", tx.Hash().Hex(), err)
+		}
+		calls = append(calls, simulateCall{
+			From:  from,
+			To:    tx.To(),
+			Gas:   hexutil.Uint64(tx.Gas()),
+			Value: (*hexutil.Big)(tx.Value()),
+			Input: tx.Data(),
+		})
+	}
+
+	params := map[string]interface{}{
+		"blockStateCalls": []map[string]interface{}{{"calls": calls}},
+		"validation":      false,
+	}
+	var blocks []struct {
+		Calls []simulateCallResult `json:"calls"`
+	}
+	if err := c.Client.Client().CallContext(ctx, &blocks, "eth_simulateV1", params, "latest"); err != nil {
+		return nil, fmt.Errorf("eth_simulateV1 failed: %v", err)
+	}
+	if len(blocks) != 1 || len(blocks[0].Calls) != len(txs) {
+		return nil, fmt.Errorf("eth_simulateV1 returned an unexpected result for %d transactions", len(txs))
+	}
+
+	results := make([]SimulationResult, 0, len(txs))
+	for _, call := range blocks[0].Calls {
+		result := SimulationResult{Success: call.Status == 1, GasUsed: uint64(call.GasUsed)}
+		if !result.Success {
+			if reason, err := abi.UnpackRevert(call.ReturnData); err == nil {
+				result.RevertReason = reason
+			} else if call.Error != nil {
+				result.RevertReason = call.Error.Message
+			} else {
+				result.RevertReason = "execution failed"
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,131 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// TxType is the kind of transaction the bot signs
+type TxType int
+
+const (
+	// TxTypeDynamicFee is an EIP-1559 transaction with a tip and a fee cap
+	TxTypeDynamicFee TxType = iota
+
+	// TxTypeLegacy is a pre-London transaction with a single gas price, for
+	// chains without a base fee
+	TxTypeLegacy
+)
+
+func (t TxType) String() string {
+	if t == TxTypeLegacy {
+		return "legacy"
+	}
+	return "EIP-1559"
+}
+
+// TxParams are the fields of a transaction that don't depend on its type
+type TxParams struct {
+	Nonce     uint64
+	To        *common.Address
+	Value     *big.Int
+	Gas       uint64
+	GasTipCap *big.Int // Dropped by legacy transactions
+	GasFeeCap *big.Int // The gas price of legacy transactions
+	Data      []byte
+}
+
+// TxBuilder builds and signs transactions of one type for one chain
+type TxBuilder struct {
+	txType  TxType
+	chainID *big.Int
+	signer  types.Signer
+}
+
+// NewTxBuilder creates a transaction builder
+func NewTxBuilder(txType TxType, chainID *big.Int) *TxBuilder {
+	signer := types.NewLondonSigner(chainID)
+	if txType == TxTypeLegacy {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	return &TxBuilder{txType: txType, chainID: chainID, signer: signer}
+}
+
+// Type returns the type of the transactions the builder creates
+func (b *TxBuilder) Type() TxType {
+	return b.txType
+}
+
+// ChainID returns the chain the builder signs for
+func (b *TxBuilder) ChainID() *big.Int {
+	return b.chainID
+}
+
+// Signer returns the signer of the builder's transactions
+func (b *TxBuilder) Signer() types.Signer {
+	return b.signer
+}
+
+// FeeCap returns the most a transaction paying tip on top of baseFee should
+// offer per gas. An EIP-1559 transaction only pays the base fee of its block,
+// so its cap leaves room for the base fee to double; a legacy transaction
+// pays its gas price in full, so it offers exactly baseFee plus tip.
+func (b *TxBuilder) FeeCap(baseFee, tip *big.Int) *big.Int {
+	if b.txType == TxTypeLegacy {
+		return new(big.Int).Add(baseFee, tip)
+	}
+	return new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+}
+
+// NewTx builds an unsigned transaction from params
+func (b *TxBuilder) NewTx(params TxParams) *types.Transaction {
+	if b.txType == TxTypeLegacy {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    params.Nonce,
+			GasPrice: params.GasFeeCap,
+			Gas:      params.Gas,
+			To:       params.To,
+			Value:    params.Value,
+			Data:     params.Data,
+		})
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   b.chainID,
+		Nonce:     params.Nonce,
+		GasTipCap: params.GasTipCap,
+		GasFeeCap: params.GasFeeCap,
+		Gas:       params.Gas,
+		To:        params.To,
+		Value:     params.Value,
+		Data:      params.Data,
+	})
+}
+
+// Sign builds a transaction from params and signs it with privateKey
+func (b *TxBuilder) Sign(privateKey *ecdsa.PrivateKey, params TxParams) (*types.Transaction, error) {
+	return types.SignTx(b.NewTx(params), b.signer, privateKey)
+}
+
+// CheckTxType checks that the node's chain accepts transactions of txType.
+// EIP-1559 transactions need a base fee in the latest block; legacy ones are
+// accepted everywhere.
+func CheckTxType(ctx context.Context, client *ethclient.Client, txType TxType) error {
+	if txType == TxTypeLegacy {
+		return nil
+	}
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %v", err)
+	}
+	if header.BaseFee == nil {
+		return fmt.Errorf("the chain has no base fee, so it doesn't accept EIP-1559 transactions")
+	}
+	return nil
+}
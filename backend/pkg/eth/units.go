@@ -0,0 +1,105 @@
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	oneGwei      = big.NewInt(1e9)
+	gweiFloorWei = big.NewInt(1e14) // 0.0001 ETH; smaller amounts are shown in gwei
+)
+
+// ParseEther parses a decimal ETH amount (e.g. "0.05") into wei without
+// float rounding. Digits beyond 18 decimals are truncated.
+func ParseEther(amount string) (*big.Int, error) {
+	return ParseUnits(amount, 18)
+}
+
+// ParseUnits parses a decimal amount into an integer with the given number of decimals
+func ParseUnits(amount string, decimals int) (*big.Int, error) {
+	// big.Rat also accepts fractions like "1/3", which aren't amounts
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok || strings.Contains(amount, "/") {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+
+	return new(big.Int).Quo(value.Num(), value.Denom()), nil
+}
+
+// FormatEther formats a wei amount for display. Amounts of at least 0.0001 ETH
+// are shown in ETH, smaller ones in gwei and sub-gwei ones in wei, so a tiny
+// bribe never renders as "0.0000".
+func FormatEther(wei *big.Int) string {
+	if wei == nil || wei.Sign() == 0 {
+		return "0 ETH"
+	}
+
+	abs := new(big.Int).Abs(wei)
+	switch {
+	case abs.Cmp(oneGwei) < 0:
+		return wei.String() + " wei"
+	case abs.Cmp(gweiFloorWei) < 0:
+		return FormatUnits(wei, 9, 4) + " gwei"
+	default:
+		return FormatUnits(wei, 18, 6) + " ETH"
+	}
+}
+
+// FormatTokens formats a token amount with the token's decimals for display,
+// with up to 4 fractional digits. Amounts too small to show that way keep all
+// their digits, so a dust balance never renders as "0".
+func FormatTokens(amount *big.Int, decimals uint8) string {
+	formatted := FormatUnits(amount, int(decimals), 4)
+	if formatted == "0" && amount != nil && amount.Sign() != 0 {
+		return FormatUnits(amount, int(decimals), int(decimals))
+	}
+	return formatted
+}
+
+// TokenPrice returns the ETH paid per whole token when wei bought tokens (in
+// units of decimals), or 0 when no tokens were bought
+func TokenPrice(wei, tokens *big.Int, decimals uint8) float64 {
+	if tokens == nil || tokens.Sign() == 0 {
+		return 0
+	}
+	perUnit := new(big.Float).Quo(new(big.Float).SetInt(wei), new(big.Float).SetInt(tokens))
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	price, _ := perUnit.Mul(perUnit, scale).Quo(perUnit, big.NewFloat(1e18)).Float64()
+	return price
+}
+
+// FormatUnits formats an integer amount with the given number of decimals,
+// keeping at most precision fractional digits (truncated) and trimming
+// trailing zeros
+func FormatUnits(value *big.Int, decimals int, precision int) string {
+	if value == nil {
+		return "0"
+	}
+
+	sign := ""
+	if value.Sign() < 0 {
+		sign = "-"
+	}
+
+	digits := new(big.Int).Abs(value).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	whole := digits[:len(digits)-decimals]
+	fraction := digits[len(digits)-decimals:]
+	if len(fraction) > precision {
+		fraction = fraction[:precision]
+	}
+	fraction = strings.TrimRight(fraction, "0")
+
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
@@ -0,0 +1,134 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormatEther(t *testing.T) {
+	tests := []struct {
+		wei  string
+		want string
+	}{
+		{"0", "0 ETH"},
+		{"1", "1 wei"},
+		{"999999999", "999999999 wei"},
+		{"1000000000", "1 gwei"},
+		{"1500000000", "1.5 gwei"},
+		{"123456789012", "123.4567 gwei"},
+		{"99999999999999", "99999.9999 gwei"},
+		{"100000000000000", "0.0001 ETH"},
+		{"10000000000000000", "0.01 ETH"},
+		{"1234567890000000000", "1.234567 ETH"},
+		{"1000000000000000000", "1 ETH"},
+		{"100000000000000000000", "100 ETH"},
+		{"-1000000000", "-1 gwei"},
+	}
+
+	for _, tt := range tests {
+		wei, _ := new(big.Int).SetString(tt.wei, 10)
+		if got := FormatEther(wei); got != tt.want {
+			t.Errorf("FormatEther(%s) = %q, want %q", tt.wei, got, tt.want)
+		}
+	}
+	if got := FormatEther(nil); got != "0 ETH" {
+		t.Errorf("FormatEther(nil) = %q", got)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		decimals uint8
+		want     string
+	}{
+		{1_500_000, 6, "1.5"},
+		{123_456_789, 6, "123.4567"},
+		{1, 6, "0.000001"},
+		{0, 18, "0"},
+		{42, 0, "42"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTokens(big.NewInt(tt.amount), tt.decimals); got != tt.want {
+			t.Errorf("FormatTokens(%d, %d) = %q, want %q", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseEther(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+		ok     bool
+	}{
+		{"0.05", "50000000000000000", true},
+		{"100", "100000000000000000000", true},
+		{" 1.000000000000000001 ", "1000000000000000001", true},
+		{"0.0000000000000000019", "1", true},
+		{"1/3", "", false},
+		{"abc", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEther(tt.amount)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseEther(%q) error = %v", tt.amount, err)
+			continue
+		}
+		if tt.ok && got.String() != tt.want {
+			t.Errorf("ParseEther(%q) = %s, want %s", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestParseUnitsSixDecimals(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   int64
+	}{
+		{"1", 1_000_000},
+		{"1.5", 1_500_000},
+		{"0.000001", 1},
+		{"0.0000019", 1}, // Truncated past the token's decimals
+		{"1000000", 1_000_000_000_000},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseUnits(tt.amount, 6)
+		if err != nil {
+			t.Errorf("ParseUnits(%q, 6): %v", tt.amount, err)
+			continue
+		}
+		if got.Int64() != tt.want {
+			t.Errorf("ParseUnits(%q, 6) = %s, want %d", tt.amount, got, tt.want)
+		}
+		if back := FormatUnits(got, 6, 6); tt.amount != "0.0000019" && back != tt.amount {
+			t.Errorf("FormatUnits(%s, 6, 6) = %q, want %q", got, back, tt.amount)
+		}
+	}
+}
+
+func TestTokenPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		wei      *big.Int
+		tokens   *big.Int
+		decimals uint8
+		want     float64
+	}{
+		// 1 ETH for 2,000 USDC-like tokens is 0.0005 ETH each
+		{"six decimals", big.NewInt(1e18), big.NewInt(2_000_000_000), 6, 0.0005},
+		// The same units at 18 decimals are a dust amount of tokens
+		{"eighteen decimals", big.NewInt(1e18), big.NewInt(2_000_000_000), 18, 5e8},
+		{"eight decimals", big.NewInt(5e16), big.NewInt(1e8), 8, 0.05},
+		{"no tokens", big.NewInt(1e18), big.NewInt(0), 6, 0},
+	}
+
+	for _, tt := range tests {
+		got := TokenPrice(tt.wei, tt.tokens, tt.decimals)
+		if diff := got - tt.want; diff > tt.want*1e-12 || diff < -tt.want*1e-12 {
+			t.Errorf("%s: TokenPrice = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
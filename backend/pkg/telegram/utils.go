@@ -6,8 +6,15 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// KeyboardButton is an inline keyboard button and the callback data it sends
+type KeyboardButton struct {
+	Text string
+	Data string
+}
+
 // ValidateAddress validates an Ethereum address
 func ValidateAddress(address string) (common.Address, error) {
 	if !common.IsHexAddress(address) {
@@ -80,9 +87,15 @@ func EscapeMarkdown(text string) string {
 	return replacer.Replace(text)
 }
 
-// CreateKeyboard creates an inline keyboard markup
-func CreateKeyboard(buttons [][]string) string {
-	// This is a simplified version - in a real implementation,
-	// you'd use the Telegram Bot API's InlineKeyboardMarkup
-	return ""
+// CreateKeyboard creates an inline keyboard markup, one slice per row
+func CreateKeyboard(buttons [][]KeyboardButton) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+	for _, row := range buttons {
+		keyboardRow := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			keyboardRow = append(keyboardRow, tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data))
+		}
+		rows = append(rows, keyboardRow)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
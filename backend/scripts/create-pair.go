@@ -14,10 +14,13 @@ import (
 	"strings"
 	"time"
 
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/bundle"
+
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/joho/godotenv"
@@ -34,6 +37,7 @@ type TxParams struct {
 	Client         *ethclient.Client
 	ProxyClient    *ethclient.Client
 	Nonce          *uint64
+	Txs            *eth.TxBuilder // Signs the transaction type TX_TYPE selects for the chain
 }
 
 // Factory ABI for creating pairs
@@ -138,11 +142,15 @@ func createPair(params *TxParams, factoryAddress string, tokenA, tokenB common.A
 		return fmt.Errorf("failed to pack createPair data: %v", err)
 	}
 
-	// Create pair transaction
-	createPairTx := types.NewTransaction(*params.Nonce, factoryAddr, big.NewInt(0), 3000000, params.GasPrice, createPairData)
-
-	// Sign create pair transaction
-	signedCreatePairTx, err := types.SignTx(createPairTx, types.NewEIP155Signer(params.NetworkID), params.PrivateKey)
+	// Create and sign pair transaction
+	signedCreatePairTx, err := params.Txs.Sign(params.PrivateKey, eth.TxParams{
+		Nonce:     *params.Nonce,
+		GasTipCap: params.MaxPriorityFee,
+		GasFeeCap: params.MaxFeePerGas,
+		Gas:       3000000,
+		To:        &factoryAddr,
+		Data:      createPairData,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to sign create pair transaction: %v", err)
 	}
@@ -185,11 +193,15 @@ func approve(params *TxParams, tokenAddress, routerAddress common.Address, token
 		return fmt.Errorf("failed to pack approve data: %v", err)
 	}
 
-	// Create approve transaction
-	approveTx := types.NewTransaction(*params.Nonce, tokenAddress, big.NewInt(0), 100000, params.GasPrice, approveData)
-
-	// Sign approve transaction
-	signedApproveTx, err := types.SignTx(approveTx, types.NewEIP155Signer(params.NetworkID), params.PrivateKey)
+	// Create and sign approve transaction
+	signedApproveTx, err := params.Txs.Sign(params.PrivateKey, eth.TxParams{
+		Nonce:     *params.Nonce,
+		GasTipCap: params.MaxPriorityFee,
+		GasFeeCap: params.MaxFeePerGas,
+		Gas:       100000,
+		To:        &tokenAddress,
+		Data:      approveData,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to sign approve transaction: %v", err)
 	}
@@ -237,9 +249,8 @@ func addLiquidity(params *TxParams, routerAddress, tokenAddress common.Address,
 		return fmt.Errorf("failed to pack liquidity data: %v", err)
 	}
 
-	// Create EIP-1559 liquidity transaction
-	liquidityTx := types.NewTx(&types.DynamicFeeTx{
-		ChainID:   params.NetworkID,
+	// Create and sign liquidity transaction
+	signedLiquidityTx, err := params.Txs.Sign(params.PrivateKey, eth.TxParams{
 		Nonce:     *params.Nonce,
 		GasTipCap: params.MaxPriorityFee,
 		GasFeeCap: params.MaxFeePerGas,
@@ -248,9 +259,6 @@ func addLiquidity(params *TxParams, routerAddress, tokenAddress common.Address,
 		Value:     ethAmount,
 		Data:      liquidityData,
 	})
-
-	// Sign liquidity transaction
-	signedLiquidityTx, err := types.SignTx(liquidityTx, types.NewLondonSigner(params.NetworkID), params.PrivateKey)
 	if err != nil {
 		return fmt.Errorf("failed to sign liquidity transaction: %v", err)
 	}
@@ -260,7 +268,7 @@ func addLiquidity(params *TxParams, routerAddress, tokenAddress common.Address,
 	fmt.Printf("   - From: %s\n", params.FromAddress.Hex())
 	fmt.Printf("   - To: %s\n", routerAddress.Hex())
 	fmt.Printf("   - Value: %s ETH\n", new(big.Float).Quo(new(big.Float).SetInt(ethAmount), big.NewFloat(1e18)).Text('f', 4))
-	fmt.Printf("   - Gas Limit: %d\n", liquidityTx.Gas())
+	fmt.Printf("   - Gas Limit: %d\n", signedLiquidityTx.Gas())
 	fmt.Printf("   - Gas Price: %s Gwei\n", new(big.Float).Quo(new(big.Float).SetInt(params.GasPrice), big.NewFloat(1e9)).Text('f', 2))
 
 	// Send liquidity transaction through proxy
@@ -337,6 +345,13 @@ func main() {
 	}
 	fmt.Printf("🌐 Network ID: %s\n", networkID.String())
 
+	// Transaction type for this chain, from TX_TYPES or TX_TYPE
+	txs, err := bundle.NewTxBuilder(context.Background(), client, config.Load())
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Printf("🧾 Transaction type: %s\n", txs.Type())
+
 	// Get nonce
 	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
 	if err != nil {
@@ -385,6 +400,7 @@ func main() {
 		Client:         client,
 		ProxyClient:    proxyClient,
 		Nonce:          &nonce,
+		Txs:            txs,
 	}
 
 	//// Step 1: Create pair (if needed)
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// isAdmin checks the request's bearer token against the admin key. Operator
+// endpoints require it; the service API key alone isn't enough.
+func (s *Service) isAdmin(r *http.Request) bool {
+	return r.Header.Get("Authorization") == "Bearer "+s.adminKey
+}
+
+// requireAdmin wraps an operator endpoint so it only runs for admin requests.
+// The service API key gets 403, anything else 401.
+func (s *Service) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAdmin(r) {
+			log.Printf("🚨 Unauthorized admin request to %s from %s", r.URL.Path, r.RemoteAddr)
+			if s.isAuthorized(r) {
+				writeError(w, http.StatusForbidden, "Admin credential required")
+			} else {
+				writeError(w, http.StatusUnauthorized, "Unauthorized")
+			}
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleConfig returns the effective configuration with secrets left out (see
+// config.Sanitized), plus the chain ID once the node is connected
+func (s *Service) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	response := s.config.Sanitized()
+	if s.isReady() {
+		response["chain_id"] = s.ethClient.GetChainID().String()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
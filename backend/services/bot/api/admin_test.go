@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sniper-bot/pkg/config"
+)
+
+// adminEndpoints are the operator endpoints, which only the admin key opens
+var adminEndpoints = []struct {
+	method string
+	path   string
+}{
+	{http.MethodPost, "/api/trigger"},
+	{http.MethodPost, "/api/admin/export-wallets"},
+	{http.MethodGet, "/api/pause"},
+	{http.MethodPost, "/api/pause"},
+	{http.MethodGet, "/api/admin/bundles"},
+	{http.MethodPost, "/api/snipes/bulk"},
+	{http.MethodGet, "/api/admin/config"},
+	{http.MethodGet, "/api/debug/ordering"},
+}
+
+func TestAdminEndpointsRejectNonAdmins(t *testing.T) {
+	s := &Service{apiKey: "api-key", adminKey: "admin-key", config: &config.Config{}, ready: make(chan struct{})}
+	mux := s.routes()
+
+	credentials := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"no credential", "", http.StatusUnauthorized},
+		{"unknown key", "Bearer guess", http.StatusUnauthorized},
+		{"admin key without Bearer", "admin-key", http.StatusUnauthorized},
+		{"service API key", "Bearer api-key", http.StatusForbidden},
+	}
+
+	for _, endpoint := range adminEndpoints {
+		for _, credential := range credentials {
+			req := httptest.NewRequest(endpoint.method, endpoint.path, nil)
+			if credential.header != "" {
+				req.Header.Set("Authorization", credential.header)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != credential.status {
+				t.Errorf("%s %s with %s: status %d, want %d", endpoint.method, endpoint.path, credential.name, rec.Code, credential.status)
+			}
+		}
+	}
+
+	// The admin key gets through to the handler
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/config", nil)
+	req.Header.Set("Authorization", "Bearer admin-key")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /api/admin/config with the admin key: status %d, want 200", rec.Code)
+	}
+}
+
+func TestServiceEndpointsRequireAuth(t *testing.T) {
+	s := &Service{apiKey: "api-key", adminKey: "admin-key", config: &config.Config{}, ready: make(chan struct{})}
+	mux := s.routes()
+
+	endpoints := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/api/lp-add"},
+		{http.MethodGet, "/api/costs"},
+		{http.MethodGet, "/api/bribes"},
+		{http.MethodGet, "/metrics"},
+	}
+
+	for _, endpoint := range endpoints {
+		for _, header := range []string{"", "Bearer guess", "api-key"} {
+			req := httptest.NewRequest(endpoint.method, endpoint.path, nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with %q: status %d, want 401", endpoint.method, endpoint.path, header, rec.Code)
+			}
+		}
+	}
+}
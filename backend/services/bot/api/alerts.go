@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"log"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/services/bot/db"
+)
+
+// Notifier sends a user a direct message. The Telegram bot implements it;
+// the API service only needs it for alerts, so it runs without one.
+type Notifier interface {
+	NotifyUser(userID, text string) error
+}
+
+// SetNotifier sets where launch alerts are sent. Call it before Start.
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// sendLaunchAlerts tells each user with a pending snipe on token that its
+// LP_ADD was detected and their snipes are going into a bundle. It returns
+// right away; the messages go out in the background so they never hold up
+// the bundle, and before it resolves.
+func (s *Service) sendLaunchAlerts(token string, snipes []*db.Snipe) {
+	if s.notifier == nil || !s.config.Enabled(config.FeatureLaunchAlerts) {
+		return
+	}
+
+	var users []string
+	counts := make(map[string]int)
+	for _, snipe := range snipes {
+		if counts[snipe.UserID] == 0 {
+			users = append(users, snipe.UserID)
+		}
+		counts[snipe.UserID]++
+	}
+
+	go func() {
+		for _, userID := range users {
+			settings, err := s.db.GetUserSettings(userID)
+			if err != nil {
+				log.Printf("⚠️ Failed to load settings of user %s, not sending launch alert: %v", userID, err)
+				continue
+			}
+			if settings.LaunchAlerts.Valid && !settings.LaunchAlerts.Bool {
+				continue
+			}
+
+			text := fmt.Sprintf("🔔 <b>Liquidity detected</b> for <code>%s</code>\n\nBuilding the bundle with your %d pending snipe(s) now.", token, counts[userID])
+			if err := s.notifier.NotifyUser(userID, text); err != nil {
+				log.Printf("⚠️ Failed to send launch alert for token %s to user %s: %v", token, userID, err)
+			}
+		}
+	}()
+}
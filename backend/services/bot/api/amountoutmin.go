@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// launchPool is the pair a bundle's snipes buy from, as it will be once the
+// LP_ADD lands and then moved along by each snipe ahead in the bundle. Live
+// reserves are no use here: the snipes land in the same block as the launch,
+// so until then the pair is empty.
+type launchPool struct {
+	tokenReserve *big.Int
+	wethReserve  *big.Int
+}
+
+// expectedLaunchPool returns the pair as it will be right after the LP_ADD in
+// notification: its current reserves (zero for a new pair) plus what the
+// addLiquidityETH call adds, read from its calldata and ETH value
+func (s *Service) expectedLaunchPool(ctx context.Context, notification LPAddNotification) (*launchPool, error) {
+	if notification.Trigger == string(config.TriggerCreatePair) {
+		return nil, fmt.Errorf("createPair adds no liquidity")
+	}
+
+	launchTx, err := decodeRawTx(notification.TxCallData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse LP_ADD transaction: %v", err)
+	}
+	args, err := dex.DecodeAddLiquidityETH(launchTx.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	tokenReserve, wethReserve, err := dex.PoolReserves(ctx, s.ethClient.Client,
+		common.HexToAddress(s.config.UniswapV2Factory), common.HexToAddress(notification.TokenAddress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pool reserves: %v", err)
+	}
+
+	tokenAdded, wethAdded := dex.LiquidityAdded(tokenReserve, wethReserve, args.AmountTokenDesired, launchTx.Value())
+	return &launchPool{
+		tokenReserve: tokenReserve.Add(tokenReserve, tokenAdded),
+		wethReserve:  wethReserve.Add(wethReserve, wethAdded),
+	}, nil
+}
+
+// amountOut returns the tokens a swap of amountIn ETH receives at the pool's
+// current reserves
+func (p *launchPool) amountOut(amountIn *big.Int) *big.Int {
+	return dex.GetAmountOut(amountIn, p.wethReserve, p.tokenReserve)
+}
+
+// swap applies a swap of amountIn ETH for amountOut tokens to the reserves, so
+// the snipe after it is quoted against the price it leaves behind
+func (p *launchPool) swap(amountIn, amountOut *big.Int) {
+	p.wethReserve.Add(p.wethReserve, amountIn)
+	p.tokenReserve.Sub(p.tokenReserve, amountOut)
+}
+
+// bundleSlippage returns the max slippage of a bundle snipe in percent: its
+// own, else BUNDLE_SLIPPAGE. 0 means none, i.e. any output is accepted.
+func (s *Service) bundleSlippage(slippage float64) float64 {
+	if slippage > 0 {
+		return slippage
+	}
+	return s.config.BundleSlippage
+}
+
+// minAmountOut is expected less slippage percent, and at least 1 wei
+func minAmountOut(expected *big.Int, slippage float64) *big.Int {
+	keep := big.NewInt(int64((100 - slippage) * 100))
+	amountOutMin := new(big.Int).Mul(expected, keep)
+	amountOutMin.Div(amountOutMin, big.NewInt(10000))
+	if amountOutMin.Sign() <= 0 {
+		return big.NewInt(1)
+	}
+	return amountOutMin
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"sniper-bot/services/bot/db"
+)
+
+// archiveBundle stores the exact bytes of a submitted bundle in bundle_txs
+// for post-mortems, and prunes archived bundles past BUNDLE_ARCHIVE_RETENTION
+func (s *Service) archiveBundle(bundleID string, txs []db.BundleTx) {
+	if err := s.db.ArchiveBundle(txs); err != nil {
+		log.Printf("⚠️ [%s] Failed to archive bundle: %v", bundleID, err)
+		return
+	}
+
+	if s.config.BundleArchiveRetention <= 0 {
+		return
+	}
+	pruned, err := s.db.PruneBundleTxs(time.Now().Add(-s.config.BundleArchiveRetention))
+	if err != nil {
+		log.Printf("⚠️ Failed to prune archived bundles: %v", err)
+	} else if pruned > 0 {
+		log.Printf("🧹 Pruned %d archived bundle transactions older than %s", pruned, s.config.BundleArchiveRetention)
+	}
+}
+
+// handleBundleArchive returns the archived raw transactions of the bundle
+// given by ?id=, in submission order, ready to be replayed
+func (s *Service) handleBundleArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	bundleID := r.URL.Query().Get("id")
+	if bundleID == "" {
+		writeError(w, http.StatusBadRequest, "Missing bundle id")
+		return
+	}
+
+	txs, err := s.db.GetBundleTxs(bundleID)
+	if err != nil {
+		log.Printf("❌ Failed to load archived bundle %s: %v", bundleID, err)
+		writeError(w, http.StatusInternalServerError, "Failed to load bundle")
+		return
+	}
+	if len(txs) == 0 {
+		writeError(w, http.StatusNotFound, "Bundle not archived")
+		return
+	}
+
+	type archivedTx struct {
+		Position int    `json:"position"`
+		TxHash   string `json:"txHash"`
+		RawTx    string `json:"rawTx"`
+		Accepted bool   `json:"accepted"`
+	}
+	response := struct {
+		BundleID     string       `json:"bundleId"`
+		TokenAddress string       `json:"tokenAddress"`
+		SubmittedAt  string       `json:"submittedAt"`
+		Transactions []archivedTx `json:"transactions"`
+	}{BundleID: bundleID, TokenAddress: txs[0].TokenAddress, SubmittedAt: txs[0].SubmittedAt}
+	for _, tx := range txs {
+		response.Transactions = append(response.Transactions, archivedTx{tx.Position, tx.TxHash, tx.RawTx, tx.Accepted})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
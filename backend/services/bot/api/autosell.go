@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// approveGasLimit covers an ERC20 approve of the router
+	approveGasLimit = 60000
+
+	// permitGasLimit covers an EIP-2612 permit of the router, which recovers
+	// the signer on top of the approve
+	permitGasLimit = 100000
+
+	// sellGasLimit covers a router sell, including fee-on-transfer tokens
+	sellGasLimit = 350000
+
+	// sellDeadline is how long a sell transaction stays valid
+	sellDeadline = 5 * time.Minute
+)
+
+// runAutoSell checks the take-profit and stop-loss of landed snipes each
+// AutoSellInterval until stop is closed
+func (s *Service) runAutoSell(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.AutoSellInterval)
+	defer ticker.Stop()
+
+	log.Printf("🎯 Checking take-profit and stop-loss targets every %s", s.config.AutoSellInterval)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.checkAutoSells()
+		}
+	}
+}
+
+// checkAutoSells compares the monitored value of every landed snipe with a
+// target against it and sells the positions that reached one
+func (s *Service) checkAutoSells() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.AutoSellInterval)
+	defer cancel()
+
+	snipes, err := s.db.GetAutoSellSnipes()
+	if err != nil {
+		log.Printf("⚠️ Failed to load auto-sell snipes: %v", err)
+		return
+	}
+
+	for _, snipe := range snipes {
+		if err := s.autoSell(ctx, snipe); err != nil {
+			log.Printf("⚠️ Auto-sell of snipe %d failed: %v", snipe.ID, err)
+		}
+	}
+}
+
+// autoSell sells the wallet's position in a snipe's token if its value has
+// reached the snipe's take-profit or fallen to its stop-loss. Every snipe in
+// the position is marked sold on success.
+func (s *Service) autoSell(ctx context.Context, snipe *db.Snipe) error {
+	token := common.HexToAddress(snipe.TokenAddress)
+	wallet := common.HexToAddress(snipe.Wallet)
+
+	// Not priced yet, or already sold earlier in this pass
+	position, ok := s.monitor.Position(wallet, token)
+	if !ok || position.Balance.Sign() == 0 || position.SwapWei.Sign() == 0 {
+		return nil
+	}
+
+	reason := sellReason(snipe, position.Multiple())
+	if reason == "" {
+		return nil
+	}
+	log.Printf("🎯 Snipe %d %s: position worth %s for a %s swap, selling",
+		snipe.ID, reason, eth.FormatEther(position.ValueWei), eth.FormatEther(position.SwapWei))
+
+	tokenContract, err := dex.NewERC20PermitContract(s.ethClient.Client, token)
+	if err != nil {
+		return fmt.Errorf("failed to bind token: %v", err)
+	}
+
+	// Slippage applies to the sell as it did to the buy; without one any
+	// output is accepted so a stop-loss always goes through
+	amountOutMin := big.NewInt(1)
+	if snipe.Slippage.Valid {
+		keep := big.NewInt(int64((100 - snipe.Slippage.Float64) * 100))
+		amountOutMin = new(big.Int).Mul(position.ValueWei, keep)
+		amountOutMin.Div(amountOutMin, big.NewInt(10000))
+	}
+
+	txHash, err := s.sendSell(ctx, snipe, tokenContract, position.Balance, amountOutMin)
+	if err != nil {
+		return err
+	}
+	s.monitor.Drop(wallet, token)
+
+	if err := s.db.SetPositionSellTx(position.UserID, snipe.TokenAddress, sql.NullString{String: txHash.Hex(), Valid: true}); err != nil {
+		log.Printf("⚠️ Sell %s sent but not recorded on the position of user %s in %s: %v", txHash.Hex(), position.UserID, snipe.TokenAddress, err)
+	}
+
+	for _, id := range position.SnipeIDs {
+		sold, err := s.db.MarkSnipeSold(id, txHash.Hex())
+		if err != nil {
+			log.Printf("⚠️ Sell %s sent but not recorded for snipe %d: %v", txHash.Hex(), id, err)
+			continue
+		}
+		if sold {
+			s.notifyStatus(StatusEvent{
+				SnipeID: id,
+				UserID:  position.UserID,
+				Token:   position.Token.Hex(),
+				Wallet:  position.Wallet.Hex(),
+				Status:  db.SnipeStatusSold,
+				TxHash:  txHash.Hex(),
+			})
+		}
+	}
+
+	log.Printf("💰 Sold snipes %v in %s", position.SnipeIDs, txHash.Hex())
+	return nil
+}
+
+// sellReason returns why a position worth ratio times its swap amount should
+// be sold, or "" if neither its take-profit nor its stop-loss has been reached
+func sellReason(snipe *db.Snipe, ratio float64) string {
+	if snipe.TakeProfitX.Valid && ratio >= snipe.TakeProfitX.Float64 {
+		return fmt.Sprintf("reached its %gx take-profit", snipe.TakeProfitX.Float64)
+	}
+	if snipe.StopLossPct.Valid && ratio <= 1-snipe.StopLossPct.Float64/100 {
+		return fmt.Sprintf("hit its %g%% stop-loss", snipe.StopLossPct.Float64)
+	}
+	return ""
+}
+
+// sendSell sells amount of the snipe's token for ETH through the router,
+// approving the router first, as SELL_APPROVAL says, only if its allowance is
+// too low. Tokens supporting EIP-2612 are approved with a signed permit;
+// otherwise a nonzero allowance is reset to zero before the new approve unless
+// SELL_APPROVAL_RESET is off. The transactions are sent with consecutive
+// nonces so the sell lands right after the approval.
+func (s *Service) sendSell(ctx context.Context, snipe *db.Snipe, tokenContract *dex.ERC20PermitContract, amount, amountOutMin *big.Int) (common.Hash, error) {
+	userWallet, err := s.walletManager.GetWallet(snipe.UserID)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get wallet: %v", err)
+	}
+	if userWallet.Address != common.HexToAddress(snipe.Wallet) {
+		return common.Hash{}, fmt.Errorf("wallet %s no longer belongs to user %s", snipe.Wallet, snipe.UserID)
+	}
+
+	router := common.HexToAddress(s.config.UniswapV2Router)
+
+	baseFee, err := s.bundleManager.GasPrice(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	txs := s.bundleManager.Txs()
+	tip := s.bundleManager.PriorityFee(ctx)
+	feeCap := txs.FeeCap(baseFee, tip)
+
+	s.walletTxs.Lock()
+	defer s.walletTxs.Unlock()
+
+	chainNonce, err := s.bundleManager.Nonce(ctx, userWallet.Address)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	// Each transaction reserves its nonce, which bundle builds for other
+	// tokens then skip
+	send := func(to common.Address, gas uint64, data []byte) (*types.Transaction, error) {
+		lease := s.nonces.reserve(userWallet.Address, chainNonce, 1)
+		tx, err := txs.Sign(userWallet.PrivateKey, eth.TxParams{
+			Nonce:     lease.start,
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			To:        &to,
+			Data:      data,
+		})
+		if err != nil {
+			s.nonces.release(lease)
+			return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		}
+		if err := s.ethClient.SendTransaction(ctx, tx); err != nil {
+			s.nonces.release(lease)
+			return nil, fmt.Errorf("failed to send transaction: %v", err)
+		}
+		return tx, nil
+	}
+
+	token := common.HexToAddress(snipe.TokenAddress)
+	deadline := big.NewInt(time.Now().Add(sellDeadline).Unix())
+	approve := func(value *big.Int) error {
+		approveData, err := tokenContract.PackApprove(router, value)
+		if err != nil {
+			return fmt.Errorf("failed to pack approve: %v", err)
+		}
+		approveTx, err := send(token, approveGasLimit, approveData)
+		if err != nil {
+			return fmt.Errorf("approve failed: %v", err)
+		}
+		log.Printf("✍️ Approved router for %s of snipe %d in %s", value, snipe.ID, approveTx.Hash().Hex())
+		return nil
+	}
+
+	allowance, err := s.ethClient.GetAllowance(ctx, token, userWallet.Address, router)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get router allowance: %v", err)
+	}
+	if allowance.Cmp(amount) < 0 {
+		value := amount
+		if s.config.SellApproval == config.SellApprovalMax {
+			value = math.MaxBig256
+		}
+		approval, err := dex.BuildApproval(ctx, s.ethClient.Client, token, userWallet.PrivateKey, router, value, deadline)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to build approval: %v", err)
+		}
+
+		if approval.Permit != nil {
+			// A permit sets the allowance outright, so it needs no reset
+			permitData, err := approval.Permit.PackPermit()
+			if err != nil {
+				return common.Hash{}, fmt.Errorf("failed to pack permit: %v", err)
+			}
+			permitTx, err := send(token, permitGasLimit, permitData)
+			if err != nil {
+				return common.Hash{}, fmt.Errorf("permit failed: %v", err)
+			}
+			log.Printf("✍️ Permitted router for %s of snipe %d in %s", value, snipe.ID, permitTx.Hash().Hex())
+		} else {
+			// USDT-style tokens revert an approve that changes one nonzero
+			// allowance to another
+			if allowance.Sign() > 0 && s.config.SellApprovalReset {
+				if err := approve(new(big.Int)); err != nil {
+					return common.Hash{}, err
+				}
+			}
+			if err := approve(value); err != nil {
+				return common.Hash{}, err
+			}
+		}
+	}
+
+	sellData, err := dex.PackSellForETH(common.HexToAddress(snipe.TokenAddress), common.HexToAddress(config.WETHAddress),
+		amount, amountOutMin, userWallet.Address, deadline)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to pack sell: %v", err)
+	}
+	sellTx, err := send(router, sellGasLimit, sellData)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("sell failed: %v", err)
+	}
+
+	return sellTx.Hash(), nil
+}
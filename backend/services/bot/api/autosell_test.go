@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql/driver"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/services/bot/db"
+	"sniper-bot/services/bot/wallet"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// mockTokenCode returns the creation code of an ERC20 stand-in with a single
+// allowance kept in slot 0: approve(spender, value) stores value, and
+// allowance(owner, spender) returns it whoever asks. Any other call, such as
+// the router's transferFrom, does nothing.
+func mockTokenCode() []byte {
+	return common.FromHex(strings.Join([]string{
+		"6037", "80", "600b", "6000", "39", "6000", "f3", // Copy the 0x37 bytes of runtime code below and return them
+		"600035", "60e01c", // 0x00 selector: PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+		"80", "63dd62ed3e", "14", "601a", "57", // 0x06 DUP1 PUSH4 allowance EQ PUSH1 0x1a JUMPI
+		"63095ea7b3", "14", "6026", "57", // 0x10 PUSH4 approve EQ PUSH1 0x26 JUMPI
+		"00",                     // 0x19 STOP
+		"5b", "600054", "600052", // 0x1a JUMPDEST SLOAD slot 0, MSTORE at 0
+		"60206000f3",             // RETURN 32 bytes
+		"5b", "602435", "600055", // 0x26 JUMPDEST value at 0x24, SSTORE slot 0
+		"6001600052", "60206000f3", // RETURN true
+	}, ""))
+}
+
+// mockPermitTokenCode returns the creation code of mockTokenCode's stand-in
+// extended with EIP-2612: DOMAIN_SEPARATOR() returns a constant, nonces(owner)
+// returns 0 and permit(owner, spender, value, ...) stores value as the
+// allowance without checking the signature.
+func mockPermitTokenCode() []byte {
+	return common.FromHex(strings.Join([]string{
+		"608d", "80", "600b", "6000", "39", "6000", "f3", // Copy the 0x8d bytes of runtime code below and return them
+		"600035", "60e01c", // 0x00 selector: PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+		"80", "63dd62ed3e", "14", "6038", "57", // 0x06 DUP1 PUSH4 allowance EQ PUSH1 0x38 JUMPI
+		"80", "63095ea7b3", "14", "6044", "57", // 0x10 DUP1 PUSH4 approve EQ PUSH1 0x44 JUMPI
+		"80", "633644e515", "14", "6055", "57", // 0x1a DUP1 PUSH4 DOMAIN_SEPARATOR EQ PUSH1 0x55 JUMPI
+		"80", "637ecebe00", "14", "607f", "57", // 0x24 DUP1 PUSH4 nonces EQ PUSH1 0x7f JUMPI
+		"63d505accf", "14", "6085", "57", // 0x2e PUSH4 permit EQ PUSH1 0x85 JUMPI
+		"00",                     // 0x37 STOP
+		"5b", "600054", "600052", // 0x38 JUMPDEST SLOAD slot 0, MSTORE at 0
+		"60206000f3",             // RETURN 32 bytes
+		"5b", "602435", "600055", // 0x44 JUMPDEST value at 0x24, SSTORE slot 0
+		"6001600052", "60206000f3", // RETURN true
+		"5b", "7f" + strings.Repeat("11", 32), "600052", // 0x55 JUMPDEST PUSH32 domain separator, MSTORE at 0
+		"60206000f3",       // RETURN 32 bytes
+		"5b", "60206000f3", // 0x7f JUMPDEST RETURN 32 zero bytes
+		"5b", "604435", "600055", "00", // 0x85 JUMPDEST value at 0x44, SSTORE slot 0, STOP
+	}, ""))
+}
+
+// newWalletManager returns a wallet manager whose only wallet is key's, for
+// userID
+func newWalletManager(t *testing.T, userID string, key *ecdsa.PrivateKey) *wallet.Manager {
+	database, _ := newFakeDB(t,
+		[]string{"id", "telegram_user_id", "wallet_address", "private_key", "derivation_index", "created_at"},
+		[]driver.Value{
+			int64(1), userID, crypto.PubkeyToAddress(key.PublicKey).Hex(),
+			hex.EncodeToString(crypto.FromECDSA(key)), nil, "2024-03-09 14:00:00",
+		})
+
+	manager, err := wallet.NewManager(database, &config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manager
+}
+
+// TestSendSellApproves sells 1000 units of a token against a simulated chain,
+// with the router's allowance already at initial, and checks which approves,
+// or the permit of a token supporting EIP-2612, precede the sell
+func TestSendSellApproves(t *testing.T) {
+	amount := big.NewInt(1000)
+
+	tests := []struct {
+		name     string
+		approval config.SellApproval
+		reset    bool
+		permit   bool // The token supports EIP-2612
+		initial  *big.Int
+		approves []*big.Int // Values approved, or permitted, before the sell, in order
+	}{
+		{"no allowance", config.SellApprovalExact, true, false, big.NewInt(0), []*big.Int{amount}},
+		{"allowance short, reset to zero first", config.SellApprovalExact, true, false, big.NewInt(5), []*big.Int{big.NewInt(0), amount}},
+		{"allowance short, reset off", config.SellApprovalExact, false, false, big.NewInt(5), []*big.Int{amount}},
+		{"allowance sufficient", config.SellApprovalExact, true, false, big.NewInt(2000), nil},
+		{"allowance exactly the amount", config.SellApprovalExact, true, false, amount, nil},
+		{"max approval", config.SellApprovalMax, true, false, big.NewInt(5), []*big.Int{big.NewInt(0), math.MaxBig256}},
+		{"permit, no reset", config.SellApprovalExact, true, true, big.NewInt(5), []*big.Int{amount}},
+		{"permit, max approval", config.SellApprovalMax, true, true, big.NewInt(0), []*big.Int{math.MaxBig256}},
+		{"permit, allowance sufficient", config.SellApprovalExact, true, true, big.NewInt(2000), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, _ := crypto.GenerateKey()
+			deployer, _ := crypto.GenerateKey()
+			owner := crypto.PubkeyToAddress(key.PublicKey)
+			bot := newSimBot(t, key, deployer)
+			s := bot.service
+			s.config.SellApproval = tt.approval
+			s.config.SellApprovalReset = tt.reset
+			s.walletManager = newWalletManager(t, "42", key)
+
+			code := mockTokenCode()
+			if tt.permit {
+				code = mockPermitTokenCode()
+			}
+			deployment := bot.chain.send(t, deployer, nil, big.NewInt(0), code, big.NewInt(1e9))
+			bot.chain.backend.Commit()
+			token := bot.chain.receipt(t, deployment.Hash()).ContractAddress
+
+			tokenContract, err := dex.NewERC20PermitContract(s.ethClient.Client, token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.initial.Sign() > 0 {
+				approveData, err := tokenContract.PackApprove(bot.router, tt.initial)
+				if err != nil {
+					t.Fatal(err)
+				}
+				bot.chain.send(t, key, &token, big.NewInt(0), approveData, big.NewInt(1e9))
+				bot.chain.backend.Commit()
+			}
+
+			snipe := &db.Snipe{ID: 7, UserID: "42", TokenAddress: token.Hex(), Wallet: owner.Hex()}
+			sellHash, err := s.sendSell(context.Background(), snipe, tokenContract, amount, big.NewInt(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			bot.chain.backend.Commit()
+
+			block, err := bot.chain.client.BlockByNumber(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer := types.LatestSignerForChainID(bot.chain.chainID)
+			var approves []*big.Int
+			var sent []*types.Transaction
+			for _, tx := range block.Transactions() {
+				if from, _ := types.Sender(signer, tx); from != owner {
+					continue
+				}
+				sent = append(sent, tx)
+				if receipt := bot.chain.receipt(t, tx.Hash()); receipt.Status != types.ReceiptStatusSuccessful {
+					t.Errorf("transaction %s to %s reverted", tx.Hash().Hex(), tx.To().Hex())
+				}
+				if *tx.To() != token {
+					continue
+				}
+				data := tx.Data()
+				switch {
+				case tt.permit:
+					if len(data) != 228 || hex.EncodeToString(data[:4]) != "d505accf" ||
+						common.BytesToAddress(data[4:36]) != owner || common.BytesToAddress(data[36:68]) != bot.router {
+						t.Errorf("transaction to the token %x, want permit(owner, router, ...)", data)
+					} else {
+						approves = append(approves, new(big.Int).SetBytes(data[68:100]))
+					}
+				case len(data) != 68 || hex.EncodeToString(data[:4]) != "095ea7b3" || common.BytesToAddress(data[4:36]) != bot.router:
+					t.Errorf("transaction to the token %x, want approve(router, ...)", data)
+				default:
+					approves = append(approves, new(big.Int).SetBytes(data[36:]))
+				}
+			}
+
+			if !reflect.DeepEqual(bigStrings(approves), bigStrings(tt.approves)) {
+				t.Errorf("approved %v, want %v", approves, tt.approves)
+			}
+			if len(sent) != len(tt.approves)+1 || sent[len(sent)-1].Hash() != sellHash || *sent[len(sent)-1].To() != bot.router {
+				t.Errorf("sent %d transactions, want the approves then the sell %s to the router", len(sent), sellHash.Hex())
+			}
+
+			allowance, err := s.ethClient.GetAllowance(context.Background(), token, owner, bot.router)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.initial
+			if len(tt.approves) > 0 {
+				want = tt.approves[len(tt.approves)-1]
+			}
+			if allowance.Cmp(want) != 0 {
+				t.Errorf("router allowance %s after the sell, want %s", allowance, want)
+			}
+		})
+	}
+}
+
+func bigStrings(values []*big.Int) []string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		strs = append(strs, value.String())
+	}
+	return strs
+}
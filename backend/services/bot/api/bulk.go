@@ -0,0 +1,237 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/services/bot/db"
+	"sniper-bot/services/bot/validation"
+)
+
+// maxBulkSnipeBody bounds the size of a bulk import request
+const maxBulkSnipeBody = 4 << 20
+
+// BulkSnipeRow is one snipe of a bulk import: the user it is placed for and
+// the same fields /snipe takes
+type BulkSnipeRow struct {
+	UserID string `json:"userId"`
+	validation.SnipeRequest
+}
+
+// BulkSnipeResult is the outcome of one row of a bulk import, numbered from 1
+type BulkSnipeResult struct {
+	Row     int               `json:"row"`
+	SnipeID int64             `json:"snipeId,omitempty"`
+	Errors  validation.Errors `json:"errors,omitempty"`
+}
+
+// bulkSnipeColumns maps the CSV header names, the JSON names of
+// BulkSnipeRow, to the fields they fill
+var bulkSnipeColumns = map[string]func(*BulkSnipeRow) *string{
+	"userId":       func(r *BulkSnipeRow) *string { return &r.UserID },
+	"tokenAddress": func(r *BulkSnipeRow) *string { return &r.TokenAddress },
+	"amount":       func(r *BulkSnipeRow) *string { return &r.Amount },
+	"bribeAmount":  func(r *BulkSnipeRow) *string { return &r.BribeAmount },
+	"slippage":     func(r *BulkSnipeRow) *string { return &r.Slippage },
+	"takeProfitX":  func(r *BulkSnipeRow) *string { return &r.TakeProfit },
+	"stopLossPct":  func(r *BulkSnipeRow) *string { return &r.StopLoss },
+	"recipient":    func(r *BulkSnipeRow) *string { return &r.Recipient },
+}
+
+// handleBulkSnipes serves POST /api/snipes/bulk: it imports a JSON array of
+// BulkSnipeRow, or a CSV with a header row of the same names when sent as
+// text/csv. Every row is validated as /snipe would; the valid ones are
+// created together in one transaction, and the response reports each row's
+// snipe ID or errors.
+func (s *Service) handleBulkSnipes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxBulkSnipeBody)
+	var rows []BulkSnipeRow
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		rows, err = readBulkSnipeCSV(body)
+	} else if err = json.NewDecoder(body).Decode(&rows); err != nil {
+		err = fmt.Errorf("invalid JSON: %v", err)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		writeError(w, http.StatusBadRequest, "No snipes to import")
+		return
+	}
+	if len(rows) > s.config.BulkSnipeMaxRows {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Too many snipes: %d, at most %d per request", len(rows), s.config.BulkSnipeMaxRows))
+		return
+	}
+
+	results := make([]BulkSnipeResult, len(rows))
+	var snipes []*db.Snipe
+	var created []int // Indexes of the rows in snipes
+	queued := make(map[string]bool)
+	for i, row := range rows {
+		results[i].Row = i + 1
+		snipe, errs := s.bulkSnipe(row, queued)
+		if errs != nil {
+			results[i].Errors = errs
+			continue
+		}
+		snipes = append(snipes, snipe)
+		created = append(created, i)
+	}
+
+	if len(snipes) > 0 {
+		if err := s.db.CreateSnipes(snipes); err != nil {
+			log.Printf("❌ Failed to import %d snipes: %v", len(snipes), err)
+			writeError(w, http.StatusInternalServerError, "Failed to create snipes")
+			return
+		}
+		for n, i := range created {
+			results[i].SnipeID = snipes[n].ID
+		}
+	}
+
+	log.Printf("📥 Imported %d of %d snipes from %s", len(snipes), len(rows), r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"created": len(snipes),
+		"failed":  len(rows) - len(snipes),
+		"results": results,
+	})
+}
+
+// bulkSnipe validates one row of a bulk import and returns the snipe it
+// creates. Like /snipe, it fills in the user's default tip and slippage and
+// refuses blocked tokens; balances aren't checked, since a snipe the wallet
+// can't pay for is skipped at launch anyway. Rather than merging, a row is
+// refused when DUPLICATE_SNIPE_POLICY isn't allow and the user already has a
+// pending snipe for the token, here or earlier in the import (queued).
+func (s *Service) bulkSnipe(row BulkSnipeRow, queued map[string]bool) (*db.Snipe, validation.Errors) {
+	if row.UserID == "" {
+		return nil, validation.Errors{{Field: validation.FieldUserID, Reason: "is required"}}
+	}
+	userWallet, err := s.walletManager.GetWallet(row.UserID)
+	if err != nil {
+		return nil, validation.Errors{{Field: validation.FieldUserID, Reason: "has no wallet"}}
+	}
+
+	req := row.SnipeRequest
+	settings, err := s.db.GetUserSettings(row.UserID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load settings of user %s: %v", row.UserID, err)
+		return nil, validation.Errors{{Field: validation.FieldUserID, Reason: "settings couldn't be loaded"}}
+	}
+	if req.BribeAmount == "" && settings.Tip.Valid {
+		req.BribeAmount = settings.Tip.String
+	}
+	if req.Slippage == "" && settings.Slippage.Valid {
+		req.Slippage = strconv.FormatFloat(settings.Slippage.Float64, 'f', -1, 64)
+	}
+
+	validated, errs := validation.ValidateSnipe(req, nil)
+	if errs != nil {
+		return nil, errs
+	}
+	tokenAddress := validated.TokenAddress.Hex()
+	if name, blocked := s.config.BlockedSnipeTarget(tokenAddress); blocked {
+		return nil, validation.Errors{{Field: validation.FieldTokenAddress, Reason: fmt.Sprintf("%s can't be sniped", name)}}
+	}
+	if validated.Recipient != nil && *validated.Recipient == userWallet.Address {
+		return nil, validation.Errors{{Field: validation.FieldRecipient, Reason: "is already the user's sniper wallet"}}
+	}
+
+	if s.config.DuplicateSnipePolicy != config.DuplicateSnipeAllow {
+		key := row.UserID + "/" + tokenAddress
+		existing, err := s.db.GetPendingSnipeForToken(row.UserID, tokenAddress)
+		if err != nil {
+			log.Printf("⚠️ Failed to look up pending snipes of user %s: %v", row.UserID, err)
+			return nil, validation.Errors{{Field: validation.FieldTokenAddress, Reason: "pending snipes couldn't be checked"}}
+		}
+		if existing != nil || queued[key] {
+			return nil, validation.Errors{{Field: validation.FieldTokenAddress, Reason: "the user already has a pending snipe for this token"}}
+		}
+		queued[key] = true
+	}
+
+	snipe := &db.Snipe{
+		UserID:       row.UserID,
+		TokenAddress: tokenAddress,
+		Amount:       req.Amount,
+		AmountMode:   db.AmountModeETH,
+		BribeAmount:  req.BribeAmount,
+		Wallet:       userWallet.Address.Hex(),
+		Status:       db.SnipeStatusPending,
+	}
+	if validated.PoolPercent != "" {
+		snipe.Amount = validated.PoolPercent
+		snipe.AmountMode = db.AmountModePoolPercent
+	}
+	if req.Slippage != "" {
+		snipe.Slippage = sql.NullFloat64{Float64: validated.Slippage, Valid: true}
+	}
+	if req.TakeProfit != "" {
+		snipe.TakeProfitX = sql.NullFloat64{Float64: validated.TakeProfitX, Valid: true}
+	}
+	if req.StopLoss != "" {
+		snipe.StopLossPct = sql.NullFloat64{Float64: validated.StopLossPct, Valid: true}
+	}
+	if validated.Recipient != nil {
+		snipe.Recipient = sql.NullString{String: validated.Recipient.Hex(), Valid: true}
+	}
+	return snipe, nil
+}
+
+// readBulkSnipeCSV reads the rows of a CSV import. Its header row names the
+// columns, in any order; columns left out stay empty.
+func readBulkSnipeCSV(r io.Reader) ([]BulkSnipeRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	fields := make([]func(*BulkSnipeRow) *string, len(header))
+	for i, name := range header {
+		field, ok := bulkSnipeColumns[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		fields[i] = field
+	}
+
+	var rows []BulkSnipeRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+
+		var row BulkSnipeRow
+		for i, value := range record {
+			*fields[i](&row) = strings.TrimSpace(value)
+		}
+		rows = append(rows, row)
+	}
+}
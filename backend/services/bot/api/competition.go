@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"math/big"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/bundle"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// tipBump is how far the tips of a bundle's snipes are raised to stay ahead
+// of competing snipes
+type tipBump struct {
+	perGas   *big.Int            // Added to each bumped snipe's tip and fee cap
+	cost     *big.Int            // What perGas costs a snipe at its gas limit
+	maxBumps map[string]*big.Int // Each user's max_bump setting, absent when not set
+}
+
+// allows reports whether the owner of bid lets it pay the bump
+func (b *tipBump) allows(bid *bundle.SnipeBid) bool {
+	maxBump, ok := b.maxBumps[bid.UserID]
+	return ok && maxBump.Cmp(b.cost) >= 0
+}
+
+// competitorBump looks for competing snipes on the launch's token among the
+// pending transactions and, if there are any, returns the bump that puts the
+// bundle's tips over the highest of theirs. It returns nil when the
+// competitor_bump feature is off, nothing competes or baseTip already wins.
+func (s *Service) competitorBump(ctx context.Context, bids []*bundle.SnipeBid, notification LPAddNotification, baseTip *big.Int) *tipBump {
+	if !s.config.Enabled(config.FeatureCompetitorBump) || len(bids) == 0 {
+		return nil
+	}
+
+	// Our own snipes and the launch's own transactions don't compete
+	ours := map[common.Address]bool{
+		common.HexToAddress(notification.CreatorAddress): true,
+		common.HexToAddress(notification.SenderAddress):  true,
+	}
+	for _, bid := range bids {
+		ours[bid.Wallet] = true
+	}
+	var launchHash common.Hash
+	if launchTx, err := decodeRawTx(notification.TxCallData); err == nil {
+		launchHash = launchTx.Hash()
+	}
+
+	competitors, baseFee, err := s.competingSnipes(ctx, common.HexToAddress(notification.TokenAddress), launchHash, ours)
+	if err != nil {
+		log.Printf("⚠️ Failed to check for competing snipes on token %s, not bumping: %v", notification.TokenAddress, err)
+		return nil
+	}
+	if len(competitors) == 0 {
+		return nil
+	}
+
+	topTip := new(big.Int)
+	for _, tx := range competitors {
+		if tip := tx.EffectiveGasTipValue(baseFee); tip.Cmp(topTip) > 0 {
+			topTip = tip
+		}
+	}
+	target := bumpTarget(topTip, len(competitors), s.config.CompetitorBumpStep)
+	perGas := new(big.Int).Sub(target, baseTip)
+	if perGas.Sign() <= 0 {
+		log.Printf("🥊 %d competing snipes on token %s, top tip %s per gas is already beaten", len(competitors), notification.TokenAddress, eth.FormatEther(topTip))
+		return nil
+	}
+
+	bump := &tipBump{
+		perGas:   perGas,
+		cost:     new(big.Int).Mul(perGas, new(big.Int).SetUint64(s.config.Gas.SnipeGasLimit)),
+		maxBumps: make(map[string]*big.Int),
+	}
+	for _, bid := range bids {
+		if _, seen := bump.maxBumps[bid.UserID]; seen {
+			continue
+		}
+		settings, err := s.db.GetUserSettings(bid.UserID)
+		if err != nil {
+			log.Printf("⚠️ Failed to load settings of user %s, not bumping their snipes: %v", bid.UserID, err)
+			continue
+		}
+		if !settings.MaxBump.Valid {
+			continue
+		}
+		if maxBump, err := eth.ParseEther(settings.MaxBump.String); err == nil {
+			bump.maxBumps[bid.UserID] = maxBump
+		}
+	}
+
+	log.Printf("🥊 %d competing snipes on token %s, top tip %s per gas; bumping tips by %s per gas (%s per snipe)",
+		len(competitors), notification.TokenAddress, eth.FormatEther(topTip), eth.FormatEther(perGas), eth.FormatEther(bump.cost))
+	return bump
+}
+
+// competingSnipes returns the transactions of the pending block that look
+// like another bot buying token, along with the block's base fee: any call
+// whose calldata names the token, such as a router swap with it in the path
+// or a call to another sniper contract. Calls to the token itself, the launch
+// and transactions sent by ours are left out.
+func (s *Service) competingSnipes(ctx context.Context, token common.Address, launchHash common.Hash, ours map[common.Address]bool) ([]*types.Transaction, *big.Int, error) {
+	block, err := s.ethClient.Client.BlockByNumber(ctx, big.NewInt(int64(rpc.PendingBlockNumber)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer := types.LatestSignerForChainID(s.ethClient.GetChainID())
+	var competitors []*types.Transaction
+	for _, tx := range block.Transactions() {
+		if tx.Hash() == launchHash || !namesToken(tx, token) {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil || ours[from] {
+			continue
+		}
+		competitors = append(competitors, tx)
+	}
+	return competitors, block.BaseFee(), nil
+}
+
+// namesToken reports whether tx calls a contract other than token with token
+// as an argument
+func namesToken(tx *types.Transaction, token common.Address) bool {
+	if tx.To() == nil || *tx.To() == token {
+		return false
+	}
+	return bytes.Contains(tx.Data(), common.LeftPadBytes(token.Bytes(), 32))
+}
+
+// bumpTarget returns the tip that outbids topTip by step percent of it for
+// each competing transaction, and by at least 1 wei
+func bumpTarget(topTip *big.Int, competitors, step int) *big.Int {
+	margin := new(big.Int).Mul(topTip, big.NewInt(int64(step*competitors)))
+	margin.Div(margin, big.NewInt(100))
+	if margin.Sign() == 0 {
+		margin.SetInt64(1)
+	}
+	return margin.Add(margin, topTip)
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// runConfirmer polls the receipts of submitted snipes each ConfirmInterval
+// until stop is closed
+func (s *Service) runConfirmer(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.ConfirmInterval)
+	defer ticker.Stop()
+
+	log.Printf("🧾 Checking snipe receipts every %s", s.config.ConfirmInterval)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.confirmSnipes()
+		}
+	}
+}
+
+// confirmSnipes records the outcome and gas cost of every submitted snipe that
+// has been mined since the last check and the positions they open, closes the
+// positions whose sells have been mined, then forwards the tokens of landed
+// snipes with a recipient
+func (s *Service) confirmSnipes() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ConfirmInterval)
+	defer cancel()
+
+	snipes, err := s.db.GetUnconfirmedSnipes()
+	if err != nil {
+		log.Printf("⚠️ Failed to load submitted snipes: %v", err)
+		return
+	}
+
+	for _, snipe := range snipes {
+		receipt, err := s.ethClient.TransactionReceipt(ctx, common.HexToHash(snipe.TxHash.String))
+		if err != nil {
+			// Not mined yet
+			continue
+		}
+
+		status := db.SnipeStatusLanded
+		revertReason := ""
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			status = db.SnipeStatusReverted
+			revertReason = s.revertReason(ctx, receipt.TxHash, receipt.BlockNumber)
+		}
+
+		// On Base every transaction also pays an L1 data fee, which the
+		// standard receipt fields leave out
+		var l1Fee *big.Int
+		if s.config.Enabled(config.FeatureL1Fee) {
+			if l1Fee, err = eth.ReceiptL1Fee(ctx, s.ethClient.Client, receipt.TxHash); err != nil {
+				log.Printf("⚠️ Failed to get L1 fee of snipe %d: %v", snipe.ID, err)
+			}
+		}
+
+		var tokensReceived *big.Int
+		var entryPrice float64
+		if status == db.SnipeStatusLanded {
+			tokensReceived, entryPrice = s.snipeEntry(ctx, snipe, receipt)
+		}
+
+		err = s.db.SetSnipeReceipt(snipe.ID, db.SnipeReceipt{
+			Status:            status,
+			BlockNumber:       receipt.BlockNumber.Uint64(),
+			GasUsed:           receipt.GasUsed,
+			EffectiveGasPrice: receipt.EffectiveGasPrice,
+			RevertReason:      revertReason,
+			L1Fee:             l1Fee,
+			TokensReceived:    tokensReceived,
+			EntryPrice:        entryPrice,
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to record receipt for snipe %d: %v", snipe.ID, err)
+			continue
+		}
+		event := snipeStatusEvent(snipe, status, snipe.TxHash.String)
+		if tokensReceived != nil {
+			event.TokensReceived = tokensReceived.String()
+		}
+		event.EntryPrice = entryPrice
+		s.notifyStatus(event)
+		if status == db.SnipeStatusLanded {
+			s.funnel.add(snipe.TokenAddress, funnelLanded, 1)
+		} else {
+			s.funnel.add(snipe.TokenAddress, funnelReverted, 1)
+		}
+		if status == db.SnipeStatusLanded && !snipe.Recipient.Valid {
+			s.openPosition(ctx, snipe, receipt, l1Fee)
+		}
+
+		log.Printf("🧾 Snipe %d %s in block %d (gas used: %d)", snipe.ID, status, receipt.BlockNumber.Uint64(), receipt.GasUsed)
+		if entryPrice > 0 {
+			log.Printf("🏷️ Snipe %d bought %s token units at %.6g ETH per token", snipe.ID, tokensReceived, entryPrice)
+		}
+	}
+
+	s.confirmSells(ctx)
+	s.forwardSnipes(ctx)
+}
+
+// snipeEntry reads what a landed snipe bought from its SnipeExecuted event:
+// the tokens received, in the token's smallest unit, and the effective price
+// paid, the swapped ETH per whole token. The bribe and gas are left out of
+// the price. Either is nil or 0 when it can't be read.
+//
+// The event reports the swap's output as the contract saw it. Positions
+// still count the Transfer logs to the wallet instead, which are net of any
+// fee-on-transfer tax, so auto-sells never try to sell more than the wallet
+// holds.
+func (s *Service) snipeEntry(ctx context.Context, snipe *db.Snipe, receipt *types.Receipt) (*big.Int, float64) {
+	execution, err := dex.ParseSnipeExecuted(receipt, s.bundleManager.GetSniperContract().Address())
+	if err != nil {
+		log.Printf("⚠️ Failed to read what snipe %d bought: %v", snipe.ID, err)
+		return nil, 0
+	}
+
+	decimals, err := dex.TokenDecimals(ctx, s.ethClient.Client, common.HexToAddress(snipe.TokenAddress))
+	if err != nil {
+		log.Printf("⚠️ Failed to read decimals of token %s for the entry price of snipe %d: %v", snipe.TokenAddress, snipe.ID, err)
+		return execution.TokensReceived, 0
+	}
+	return execution.TokensReceived, eth.TokenPrice(execution.SwapAmount, execution.TokensReceived, decimals)
+}
+
+// maxRevertReason is the size of the revert_reason column
+const maxRevertReason = 255
+
+// revertReason replays a reverted transaction on the state of the block it
+// was mined in and returns the error, or "" if the replay succeeds or can't
+// be run. Later transactions in the block have changed that state, so this
+// is a best-effort diagnosis.
+func (s *Service) revertReason(ctx context.Context, txHash common.Hash, blockNumber *big.Int) string {
+	tx, _, err := s.ethClient.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return ""
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return ""
+	}
+
+	_, err = s.ethClient.CallContract(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}, blockNumber)
+	if err == nil || ctx.Err() != nil {
+		return ""
+	}
+
+	reason := err.Error()
+	if len(reason) > maxRevertReason {
+		reason = reason[:maxRevertReason]
+	}
+	return reason
+}
+
+// handleCostReport serves GET /api/costs?user=<telegram id> or ?token=0x...
+func (s *Service) handleCostReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var report *db.CostReport
+	var err error
+
+	user := r.URL.Query().Get("user")
+	token := r.URL.Query().Get("token")
+	switch {
+	case user != "":
+		report, err = s.db.GetUserCosts(user)
+	case common.IsHexAddress(token):
+		report, err = s.db.GetTokenCosts(common.HexToAddress(token).Hex())
+	default:
+		writeError(w, http.StatusBadRequest, "Either user or a valid token address is required")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to build cost report: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to build cost report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleBribeReport serves GET /api/bribes?token=0x..., comparing the bribes
+// of recently landed and reverted snipes for a token, or for all tokens when
+// token is omitted
+func (s *Service) handleBribeReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token != "" {
+		if !common.IsHexAddress(token) {
+			writeError(w, http.StatusBadRequest, "Invalid token address")
+			return
+		}
+		token = common.HexToAddress(token).Hex()
+	}
+
+	report, err := s.db.GetBribeReport(token, time.Now().Add(-s.config.BribeReportWindow))
+	if err != nil {
+		log.Printf("❌ Failed to build bribe report: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to build bribe report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/bundle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// connect creates the eth client and bundle manager, both of which need the
+// RPC node to be reachable
+func connect(cfg *config.Config) (*eth.Client, *bundle.Manager, error) {
+	ethClient, err := eth.NewClient(cfg.BaseRPCURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create eth client: %v", err)
+	}
+
+	// Initialize bundle manager with sniper contract address
+	bundleManager, err := bundle.NewManager(ethClient.Client, common.HexToAddress(cfg.SniperContract), cfg)
+	if err != nil {
+		ethClient.Close()
+		return nil, nil, fmt.Errorf("failed to create bundle manager: %v", err)
+	}
+
+	return ethClient, bundleManager, nil
+}
+
+// setConnected stores the node connection and marks the service ready
+func (s *Service) setConnected(ethClient *eth.Client, bundleManager *bundle.Manager) {
+	s.ethClient = ethClient
+	s.bundleManager = bundleManager
+	close(s.ready)
+}
+
+// isReady reports whether the node connection is up. Until it is, ethClient
+// and bundleManager are nil.
+func (s *Service) isReady() bool {
+	select {
+	case <-s.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// requireReady rejects a request with 503 while the service is degraded
+func (s *Service) requireReady(w http.ResponseWriter) bool {
+	if s.isReady() {
+		return true
+	}
+	writeError(w, http.StatusServiceUnavailable, "Service degraded: sniper contract not connected")
+	return false
+}
+
+// runConnect retries connecting to the node each ConnectRetryInterval until it
+// succeeds or stop is closed, then starts the background workers
+func (s *Service) runConnect(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.ConnectRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ethClient, bundleManager, err := connect(s.config)
+			if err != nil {
+				log.Printf("⚠️ Still degraded, retrying in %s: %v", s.config.ConnectRetryInterval, err)
+				continue
+			}
+
+			s.setConnected(ethClient, bundleManager)
+			log.Printf("✅ Connected to the sniper contract, leaving degraded mode")
+			s.startWorkers()
+			return
+		}
+	}
+}
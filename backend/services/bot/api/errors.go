@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes of the API error envelope, one per HTTP status used
+const (
+	ErrorCodeBadRequest         = "bad_request"
+	ErrorCodeUnauthorized       = "unauthorized"
+	ErrorCodeForbidden          = "forbidden"
+	ErrorCodeMethodNotAllowed   = "method_not_allowed"
+	ErrorCodeInternal           = "internal_error"
+	ErrorCodeServiceUnavailable = "service_unavailable"
+)
+
+// errorCodes maps HTTP statuses to envelope error codes
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          ErrorCodeBadRequest,
+	http.StatusUnauthorized:        ErrorCodeUnauthorized,
+	http.StatusForbidden:           ErrorCodeForbidden,
+	http.StatusMethodNotAllowed:    ErrorCodeMethodNotAllowed,
+	http.StatusInternalServerError: ErrorCodeInternal,
+	http.StatusServiceUnavailable:  ErrorCodeServiceUnavailable,
+}
+
+// ErrorResponse is the body of every API error:
+// {"error": {"code": "bad_request", "message": "Invalid JSON"}}
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an API error. Code is stable for clients to match
+// on; Message is for humans.
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError replies to the request with status and an error envelope
+func writeError(w http.ResponseWriter, status int, message string) {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = ErrorCodeInternal
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: ErrorDetail{Code: code, Message: message}})
+}
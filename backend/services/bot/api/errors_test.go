@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sniper-bot/pkg/config"
+)
+
+// decodeErrorResponse checks that rec holds an error envelope with status and
+// code, and returns its message
+func decodeErrorResponse(t *testing.T, name string, rec *httptest.ResponseRecorder, status int, code string) string {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Errorf("%s: status %d, want %d", name, rec.Code, status)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("%s: Content-Type %q, want application/json", name, contentType)
+	}
+
+	var envelope map[string]map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Errorf("%s: body %q is not a JSON envelope: %v", name, rec.Body.String(), err)
+		return ""
+	}
+	if len(envelope) != 1 || len(envelope["error"]) != 2 {
+		t.Errorf("%s: body %q, want only error.code and error.message", name, rec.Body.String())
+	}
+	if got := envelope["error"]["code"]; got != code {
+		t.Errorf("%s: code %q, want %q", name, got, code)
+	}
+	return envelope["error"]["message"]
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusBadRequest, ErrorCodeBadRequest},
+		{http.StatusUnauthorized, ErrorCodeUnauthorized},
+		{http.StatusForbidden, ErrorCodeForbidden},
+		{http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed},
+		{http.StatusInternalServerError, ErrorCodeInternal},
+		{http.StatusServiceUnavailable, ErrorCodeServiceUnavailable},
+		{http.StatusTeapot, ErrorCodeInternal}, // No code of its own
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeError(rec, tt.status, "went wrong")
+
+		name := http.StatusText(tt.status)
+		if message := decodeErrorResponse(t, name, rec, tt.status, tt.code); message != "went wrong" {
+			t.Errorf("%s: message %q", name, message)
+		}
+		if rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("%s: missing X-Content-Type-Options: nosniff", name)
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	ready := make(chan struct{})
+	close(ready)
+	s := &Service{apiKey: "api-key", adminKey: "admin-key", config: &config.Config{}, ready: ready}
+	degraded := &Service{apiKey: "api-key", config: &config.Config{}, ready: make(chan struct{})}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		auth    string
+		body    string
+		status  int
+		code    string
+		message string
+	}{
+		{"lp-add wrong method", s.handleLPAddNotification, http.MethodGet, "api-key", "", http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "Method not allowed"},
+		{"lp-add no token", s.requireAuth(s.handleLPAddNotification), http.MethodPost, "", "{}", http.StatusUnauthorized, ErrorCodeUnauthorized, "Unauthorized"},
+		{"lp-add wrong token", s.requireAuth(s.handleLPAddNotification), http.MethodPost, "admin", "{}", http.StatusUnauthorized, ErrorCodeUnauthorized, "Unauthorized"},
+		{"lp-add bad JSON", s.handleLPAddNotification, http.MethodPost, "api-key", "{not json", http.StatusBadRequest, ErrorCodeBadRequest, "Invalid JSON"},
+		{"lp-add degraded", degraded.handleLPAddNotification, http.MethodPost, "api-key", "{}", http.StatusServiceUnavailable, ErrorCodeServiceUnavailable, "Service degraded: sniper contract not connected"},
+		{"trigger bad JSON", s.handleTrigger, http.MethodPost, "", "[", http.StatusBadRequest, ErrorCodeBadRequest, "Invalid JSON"},
+		{"trigger bad address", s.handleTrigger, http.MethodPost, "", `{"token":"0x12","creator":"0x12"}`, http.StatusBadRequest, ErrorCodeBadRequest, "Invalid token or creator address"},
+		{"admin endpoint no token", s.requireAdmin(s.handleConfig), http.MethodGet, "", "", http.StatusUnauthorized, ErrorCodeUnauthorized, "Unauthorized"},
+		{"admin endpoint API key", s.requireAdmin(s.handleConfig), http.MethodGet, "api-key", "", http.StatusForbidden, ErrorCodeForbidden, "Admin credential required"},
+		{"export short passphrase", s.handleExportWallets, http.MethodPost, "", `{"passphrase":"short"}`, http.StatusBadRequest, ErrorCodeBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		tt.handler(rec, req)
+
+		message := decodeErrorResponse(t, tt.name, rec, tt.status, tt.code)
+		if tt.message != "" && message != tt.message {
+			t.Errorf("%s: message %q, want %q", tt.name, message, tt.message)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"sniper-bot/pkg/dex"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// transferGasLimit covers an ERC20 transfer, including fee-on-transfer tokens
+const transferGasLimit = 150000
+
+// forwardSnipes sends the tokens of every landed snipe with a recipient on to
+// that recipient. The Sniper contract always pays out to the wallet that
+// called it, so delivery elsewhere takes a second transaction.
+func (s *Service) forwardSnipes(ctx context.Context) {
+	snipes, err := s.db.GetUnforwardedSnipes()
+	if err != nil {
+		log.Printf("⚠️ Failed to load snipes to forward: %v", err)
+		return
+	}
+
+	for _, snipe := range snipes {
+		if err := s.forwardSnipe(ctx, snipe); err != nil {
+			log.Printf("⚠️ Forwarding snipe %d to %s failed: %v", snipe.ID, snipe.Recipient.String, err)
+		}
+	}
+}
+
+// forwardSnipe transfers the tokens a landed snipe bought to its recipient.
+// The amount comes from the snipe's receipt rather than the wallet balance,
+// so tokens the wallet held before, or bought in other snipes, stay put.
+func (s *Service) forwardSnipe(ctx context.Context, snipe *db.Snipe) error {
+	token := common.HexToAddress(snipe.TokenAddress)
+	wallet := common.HexToAddress(snipe.Wallet)
+	recipient := common.HexToAddress(snipe.Recipient.String)
+
+	receipt, err := s.ethClient.TransactionReceipt(ctx, common.HexToHash(snipe.TxHash.String))
+	if err != nil {
+		return fmt.Errorf("failed to get snipe receipt: %v", err)
+	}
+	amount := dex.ReceivedTokens(receipt, token, wallet)
+	if amount.Sign() == 0 {
+		return fmt.Errorf("snipe %s paid no tokens to %s", snipe.TxHash.String, wallet.Hex())
+	}
+
+	userWallet, err := s.walletManager.GetWallet(snipe.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet: %v", err)
+	}
+	if userWallet.Address != wallet {
+		return fmt.Errorf("wallet %s no longer belongs to user %s", snipe.Wallet, snipe.UserID)
+	}
+
+	tokenContract, err := dex.NewERC20PermitContract(s.ethClient.Client, token)
+	if err != nil {
+		return fmt.Errorf("failed to bind token: %v", err)
+	}
+	data, err := tokenContract.PackTransfer(recipient, amount)
+	if err != nil {
+		return fmt.Errorf("failed to pack transfer: %v", err)
+	}
+
+	baseFee, err := s.bundleManager.GasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	txs := s.bundleManager.Txs()
+	tip := s.bundleManager.PriorityFee(ctx)
+	feeCap := txs.FeeCap(baseFee, tip)
+
+	// Auto-sells send from the same wallets; one transaction at a time keeps
+	// their pending nonces from colliding
+	s.walletTxs.Lock()
+	defer s.walletTxs.Unlock()
+
+	chainNonce, err := s.bundleManager.Nonce(ctx, wallet)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %v", err)
+	}
+	lease := s.nonces.reserve(wallet, chainNonce, 1)
+
+	tx, err := txs.Sign(userWallet.PrivateKey, eth.TxParams{
+		Nonce:     lease.start,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       transferGasLimit,
+		To:        &token,
+		Data:      data,
+	})
+	if err != nil {
+		s.nonces.release(lease)
+		return fmt.Errorf("failed to sign transfer: %v", err)
+	}
+
+	// Recorded before sending so a crash can't forward the tokens twice
+	if err := s.db.SetSnipeForwardTx(snipe.ID, sql.NullString{String: tx.Hash().Hex(), Valid: true}); err != nil {
+		s.nonces.release(lease)
+		return fmt.Errorf("failed to record transfer: %v", err)
+	}
+	if err := s.ethClient.SendTransaction(ctx, tx); err != nil {
+		s.nonces.release(lease)
+		if clearErr := s.db.SetSnipeForwardTx(snipe.ID, sql.NullString{}); clearErr != nil {
+			log.Printf("⚠️ Failed to clear unsent transfer of snipe %d: %v", snipe.ID, clearErr)
+		}
+		return fmt.Errorf("failed to send transfer: %v", err)
+	}
+
+	// The wallet's pre-warmed nonce is now stale; a later bundle refetches it
+	s.walletCache.take(wallet)
+
+	log.Printf("📬 Forwarded %s tokens of snipe %d to %s in %s", amount, snipe.ID, recipient.Hex(), tx.Hash().Hex())
+	return nil
+}
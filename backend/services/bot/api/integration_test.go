@@ -0,0 +1,400 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/services/bot/bundle"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+// sniperBroadcast is the Foundry broadcast of the Sniper deployment, the only
+// compiled Sniper bytecode in the repository
+const sniperBroadcast = "../../../../contracts/broadcast/Sniper.s.sol/8453/run-latest.json"
+
+// mockWETH is the address the mock router reports as WETH
+var mockWETH = common.HexToAddress(config.WETHAddress)
+
+// mockRouterCode returns the runtime code of a Uniswap V2 router stand-in.
+// WETH() returns mockWETH; swapExactETHForTokens(...) returns the amounts
+// [msg.value, msg.value * 1000]; any other call, such as addLiquidityETH,
+// keeps its ETH and does nothing.
+func mockRouterCode() []byte {
+	return common.FromHex(strings.Join([]string{
+		"600035", "60e01c", // 0x00 selector: PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+		"80", "63ad5c4648", "14", "601b", "57", // 0x06 DUP1 PUSH4 WETH() EQ PUSH1 0x1b JUMPI
+		"80", "637ff36ab5", "14", "6039", "57", // 0x10 DUP1 PUSH4 swapExactETHForTokens EQ PUSH1 0x39 JUMPI
+		"00",                                              // 0x1a STOP
+		"5b", "73" + hex.EncodeToString(mockWETH.Bytes()), // 0x1b JUMPDEST PUSH20 WETH
+		"600052", "60206000f3", // MSTORE at 0, RETURN 32 bytes
+		"5b", "6020600052", "6002602052", // 0x39 JUMPDEST offset 0x20 at 0, length 2 at 0x20
+		"34604052",              // amounts[0] = CALLVALUE at 0x40
+		"346103e8026060" + "52", // amounts[1] = CALLVALUE * 1000 at 0x60
+		"60806000f3",            // RETURN 128 bytes
+	}, ""))
+}
+
+// freePort returns a TCP port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// sniperCreationCode returns the Sniper's creation code with router as its
+// constructor argument
+func sniperCreationCode(t *testing.T, router common.Address) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.FromSlash(sniperBroadcast))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var broadcast struct {
+		Transactions []struct {
+			ContractName string `json:"contractName"`
+			Transaction  struct {
+				Input string `json:"input"`
+			} `json:"transaction"`
+		} `json:"transactions"`
+	}
+	if err := json.Unmarshal(data, &broadcast); err != nil {
+		t.Fatal(err)
+	}
+	if len(broadcast.Transactions) == 0 || broadcast.Transactions[0].ContractName != "Sniper" {
+		t.Fatalf("%s doesn't deploy Sniper", sniperBroadcast)
+	}
+
+	// The deployment's constructor argument is its router, the last word
+	code := common.FromHex(broadcast.Transactions[0].Transaction.Input)
+	return append(code[:len(code)-32], common.LeftPadBytes(router.Bytes(), 32)...)
+}
+
+// simChain is a simulated chain served over HTTP, as the services dial it
+type simChain struct {
+	backend *simulated.Backend
+	url     string
+	client  *ethclient.Client
+	chainID *big.Int
+}
+
+func newSimChain(t *testing.T, alloc types.GenesisAlloc) *simChain {
+	t.Helper()
+	port := freePort(t)
+	backend := simulated.NewBackend(alloc, func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+		nodeConf.HTTPHost = "127.0.0.1"
+		nodeConf.HTTPPort = port
+		nodeConf.HTTPModules = []string{"eth", "net", "web3"}
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", port)
+	client, err := ethclient.Dial(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &simChain{backend: backend, url: url, client: client, chainID: chainID}
+}
+
+// send signs and sends a dynamic fee transaction of key's
+func (c *simChain) send(t *testing.T, key *ecdsa.PrivateKey, to *common.Address, value *big.Int, data []byte, tip *big.Int) *types.Transaction {
+	t.Helper()
+	ctx := context.Background()
+	nonce, err := c.client.PendingNonceAt(ctx, crypto.PubkeyToAddress(key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(c.chainID), &types.DynamicFeeTx{
+		ChainID:   c.chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(tip, big.NewInt(10e9)),
+		Gas:       3_000_000,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.client.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// receipt returns the receipt of a mined transaction
+func (c *simChain) receipt(t *testing.T, hash common.Hash) *types.Receipt {
+	t.Helper()
+	receipt, err := c.client.TransactionReceipt(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("receipt of %s: %v", hash.Hex(), err)
+	}
+	return receipt
+}
+
+// simBot is the bot service running against a simulated chain with the
+// Sniper contract deployed on a mock router
+type simBot struct {
+	chain   *simChain
+	service *Service
+	router  common.Address
+	sniper  common.Address
+}
+
+// newSimBot starts a simulated chain that funds accounts with 10 ETH each,
+// deploys the Sniper and connects a bot service to it
+func newSimBot(t *testing.T, accounts ...*ecdsa.PrivateKey) *simBot {
+	t.Helper()
+	deployer, _ := crypto.GenerateKey()
+	router := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	alloc := types.GenesisAlloc{router: {Code: mockRouterCode(), Balance: big.NewInt(0)}}
+	for _, key := range append(accounts, deployer) {
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.Account{Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(10))}
+	}
+	chain := newSimChain(t, alloc)
+
+	deployment := chain.send(t, deployer, nil, big.NewInt(0), sniperCreationCode(t, router), big.NewInt(1e9))
+	chain.backend.Commit()
+	deployed := chain.receipt(t, deployment.Hash())
+	if deployed.Status != types.ReceiptStatusSuccessful {
+		t.Fatal("Sniper deployment reverted")
+	}
+
+	cfg := config.Load()
+	cfg.BaseRPCURL = chain.url
+	cfg.BaseSequencerRPCURL = chain.url
+	cfg.SubmitEndpoints = nil
+	cfg.SniperContract = deployed.ContractAddress.Hex()
+	cfg.UniswapV2Router = router.Hex()
+	cfg.Features = map[config.Feature]bool{}
+	ethClient, bundleManager, err := connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database, _ := newFakeDB(t, nil)
+	s := &Service{
+		config:      cfg,
+		db:          database,
+		walletCache: newWalletCache(time.Minute),
+		nonces:      newNonceReservations(),
+		ready:       make(chan struct{}),
+		submitter:   NewHTTPSubmitter(http.DefaultClient),
+	}
+	s.setConnected(ethClient, bundleManager)
+
+	return &simBot{chain: chain, service: s, router: router, sniper: deployed.ContractAddress}
+}
+
+// launch signs creator's addLiquidityETH of 1 ETH for token, the transaction
+// the proxy intercepts, and returns it with its notification. It pays the
+// highest tip, so it is mined ahead of its snipes.
+func (b *simBot) launch(t *testing.T, creator *ecdsa.PrivateKey, token common.Address) (*types.Transaction, LPAddNotification) {
+	t.Helper()
+	ether := big.NewInt(1e18)
+	creatorAddr := crypto.PubkeyToAddress(creator.PublicKey)
+
+	launchABI, err := abi.JSON(strings.NewReader(dex.UniswapV2LaunchABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addLiquidity, err := launchABI.Pack("addLiquidityETH", token, ether, ether, ether, creatorAddr, big.NewInt(time.Now().Add(time.Hour).Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	launch, err := types.SignNewTx(creator, types.LatestSignerForChainID(b.chain.chainID), &types.DynamicFeeTx{
+		ChainID:   b.chain.chainID,
+		GasTipCap: big.NewInt(50e9),
+		GasFeeCap: big.NewInt(100e9),
+		Gas:       200_000,
+		To:        &b.router,
+		Value:     ether,
+		Data:      addLiquidity,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawLaunch, err := launch.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return launch, LPAddNotification{
+		TokenAddress:   token.Hex(),
+		CreatorAddress: creatorAddr.Hex(),
+		TxCallData:     "0x" + hex.EncodeToString(rawLaunch),
+		TxHash:         launch.Hash().Hex(),
+	}
+}
+
+// simBid is a snipe of 0.01 ETH for token from key's wallet
+func simBid(id int64, token common.Address, key *ecdsa.PrivateKey, bribe int64) *bundle.SnipeBid {
+	return &bundle.SnipeBid{
+		SnipeID:      id,
+		TokenAddress: token,
+		SwapAmount:   big.NewInt(1e16),
+		BribeAmount:  big.NewInt(bribe),
+		Wallet:       crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey:   hex.EncodeToString(crypto.FromECDSA(key)),
+		CreatedAt:    time.Now(),
+	}
+}
+
+// TestBundleLandsInBribeOrder runs the LP_ADD → bundle build → submit flow
+// against a simulated chain with the Sniper contract and a mock router: the
+// launch and the snipes are submitted as the bot does, then mined together.
+func TestBundleLandsInBribeOrder(t *testing.T) {
+	ether := big.NewInt(1e18)
+	creator, _ := crypto.GenerateKey()
+	creatorAddr := crypto.PubkeyToAddress(creator.PublicKey)
+
+	// Three snipers, placed in an order unrelated to their bribes
+	bribes := []int64{2e15, 5e15, 1e15}
+	var snipers []*ecdsa.PrivateKey
+	for range bribes {
+		key, _ := crypto.GenerateKey()
+		snipers = append(snipers, key)
+	}
+	bot := newSimBot(t, append(snipers, creator)...)
+	chain, s := bot.chain, bot.service
+
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	launch, notification := bot.launch(t, creator, token)
+
+	var bids []*bundle.SnipeBid
+	for i, key := range snipers {
+		bids = append(bids, simBid(int64(i+1), token, key, bribes[i]))
+	}
+	bundle.SortBids(bids)
+
+	ctx := context.Background()
+	transactions, included, err := s.createBundleTransactions(ctx, bids, notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(included) != len(bids) {
+		t.Fatalf("%d of %d snipes built", len(included), len(bids))
+	}
+	creatorBefore, err := chain.client.BalanceAt(ctx, creatorAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, accepted := s.submitBundle(ctx, notification.TokenAddress, notification.TxCallData, transactions)
+	for i, ok := range accepted {
+		if !ok {
+			t.Errorf("transaction %d of the bundle was rejected", i)
+		}
+	}
+	chain.backend.Commit()
+
+	// The launch comes first, then the snipes by bribe, highest first
+	launchReceipt := chain.receipt(t, launch.Hash())
+	if launchReceipt.Status != types.ReceiptStatusSuccessful || launchReceipt.TransactionIndex != 0 {
+		t.Errorf("launch status %d at index %d, want success at 0", launchReceipt.Status, launchReceipt.TransactionIndex)
+	}
+	totalBribes := new(big.Int)
+	for i, tx := range transactions {
+		receipt := chain.receipt(t, tx.Hash())
+		if receipt.BlockNumber.Cmp(launchReceipt.BlockNumber) != 0 {
+			t.Errorf("snipe %d mined in block %s, the launch in %s", included[i].SnipeID, receipt.BlockNumber, launchReceipt.BlockNumber)
+		}
+		if receipt.TransactionIndex != uint(i+1) {
+			t.Errorf("snipe %d (bribe %s) at index %d, want %d", included[i].SnipeID, included[i].BribeAmount, receipt.TransactionIndex, i+1)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Errorf("snipe %d reverted", included[i].SnipeID)
+			continue
+		}
+
+		executed, err := dex.ParseSnipeExecuted(receipt, bot.sniper)
+		if err != nil {
+			t.Errorf("snipe %d: %v", included[i].SnipeID, err)
+			continue
+		}
+		if executed.BribeAmount.Cmp(included[i].BribeAmount) != 0 || executed.Creator != creatorAddr {
+			t.Errorf("snipe %d executed %+v", included[i].SnipeID, executed)
+		}
+		totalBribes.Add(totalBribes, executed.BribeAmount)
+	}
+
+	creatorAfter, err := chain.client.BalanceAt(ctx, creatorAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The creator paid for the launch out of the same balance
+	launchCost := new(big.Int).Mul(new(big.Int).SetUint64(launchReceipt.GasUsed), launchReceipt.EffectiveGasPrice)
+	launchCost.Add(launchCost, ether)
+	if got := new(big.Int).Sub(creatorAfter, creatorBefore); got.Cmp(new(big.Int).Sub(totalBribes, launchCost)) != 0 {
+		t.Errorf("creator balance changed by %s, want bribes %s less the launch's %s", got, totalBribes, launchCost)
+	}
+}
+
+// TestSnipeGasEstimates estimates the snipes of a bundle against a simulated
+// chain, where the mock router makes them cheaper than SNIPE_GAS_LIMIT, and
+// checks that a snipe is signed with its estimate and still lands
+func TestSnipeGasEstimates(t *testing.T) {
+	creator, _ := crypto.GenerateKey()
+	sniper, _ := crypto.GenerateKey()
+	broke, _ := crypto.GenerateKey() // Unfunded, so its estimate fails
+	bot := newSimBot(t, sniper, creator)
+	s := bot.service
+
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	_, notification := bot.launch(t, creator, token)
+	bids := []*bundle.SnipeBid{simBid(1, token, sniper, 2e15), simBid(2, token, broke, 1e15)}
+
+	ctx := context.Background()
+	ceiling := s.config.Gas.SnipeGasLimit
+	s.bundleManager.EstimateSnipeGas(ctx, token, common.HexToAddress(notification.CreatorAddress), bids)
+	if gasLimit := bids[0].GasLimit; gasLimit == 0 || gasLimit >= ceiling {
+		t.Fatalf("snipe gas limit %d, want an estimate under the %d limit", gasLimit, ceiling)
+	}
+	if gasLimit := bids[1].GasLimit; gasLimit != ceiling {
+		t.Errorf("gas limit %d for a snipe that can't be estimated, want the %d limit", gasLimit, ceiling)
+	}
+
+	transactions, included, err := s.createBundleTransactions(ctx, bids[:1], notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(included) != 1 || transactions[0].Gas() != bids[0].GasLimit {
+		t.Fatalf("snipe signed with %d gas, want its %d estimate", transactions[0].Gas(), bids[0].GasLimit)
+	}
+
+	// The headroom over the estimate is enough for the snipe to land behind the launch
+	s.submitBundle(ctx, notification.TokenAddress, notification.TxCallData, transactions)
+	bot.chain.backend.Commit()
+	if receipt := bot.chain.receipt(t, transactions[0].Hash()); receipt.Status != types.ReceiptStatusSuccessful {
+		t.Errorf("snipe signed with its estimate reverted, using %d of %d gas", receipt.GasUsed, transactions[0].Gas())
+	}
+}
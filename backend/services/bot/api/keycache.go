@@ -0,0 +1,69 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"sniper-bot/services/bot/bundle"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// keyCache holds the private keys decoded while signing one bundle, so a
+// wallet with several snipes in it is decoded once. clear zeroes them once
+// the bundle is built; the decoding buffers are zeroed right away.
+type keyCache struct {
+	keys map[common.Address]*ecdsa.PrivateKey
+}
+
+func newKeyCache() *keyCache {
+	return &keyCache{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+}
+
+// get returns the decoded private key of bid's wallet
+func (c *keyCache) get(bid *bundle.SnipeBid) (*ecdsa.PrivateKey, error) {
+	if key, ok := c.keys[bid.Wallet]; ok {
+		return key, nil
+	}
+
+	privateKeyHex := strings.TrimPrefix(bid.PrivateKey, "0x")
+	if privateKeyHex == "" {
+		return nil, fmt.Errorf("private key not found for wallet %s", bid.Wallet.Hex())
+	}
+
+	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+	defer zeroBytes(privateKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key for %s: %v", bid.Wallet.Hex(), err)
+	}
+
+	// ToECDSA copies the scalar, so the buffer can be zeroed after
+	key, err := crypto.ToECDSA(privateKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key for %s: %v", bid.Wallet.Hex(), err)
+	}
+
+	c.keys[bid.Wallet] = key
+	return key, nil
+}
+
+// clear zeroes and forgets every cached key
+func (c *keyCache) clear() {
+	for wallet, key := range c.keys {
+		words := key.D.Bits()
+		for i := range words {
+			words[i] = 0
+		}
+		key.D.SetInt64(0)
+		delete(c.keys, wallet)
+	}
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// launchVerdict is what to do with an LP_ADD notification
+type launchVerdict int
+
+const (
+	launchNew       launchVerdict = iota // Not seen before: build its bundle
+	launchDuplicate                      // Its transaction was already notified: ignore it
+	launchStale                          // A later LP_ADD for the token was already notified: submit it alone
+)
+
+// launchLog remembers the LP_ADD transactions the bot service was notified
+// of for window, so the at-least-once delivery of the RPC proxy's outbox
+// can't build a bundle twice. Notifications are told apart by the launch
+// transaction's hash; a new hash for the same token is a new launch, unless
+// its sequence is below that of one already taken for the token, which makes
+// it a notification that arrived out of order. Each notification's sequence
+// comes from the proxy, and 0 (an older proxy) skips the ordering check. The
+// log is in memory, so a restart forgets it.
+type launchLog struct {
+	mu     sync.Mutex
+	window time.Duration
+	hashes map[common.Hash]time.Time      // When each transaction was notified
+	latest map[common.Address]launchEntry // Highest sequence taken per token
+}
+
+// launchEntry is the highest sequence taken for a token and when
+type launchEntry struct {
+	sequence uint64
+	at       time.Time
+}
+
+func newLaunchLog(window time.Duration) *launchLog {
+	return &launchLog{
+		window: window,
+		hashes: make(map[common.Hash]time.Time),
+		latest: make(map[common.Address]launchEntry),
+	}
+}
+
+// admit records a notification of the launch transaction hash for token and
+// returns what to do with it. Everything is new with a zero window or hash.
+func (l *launchLog) admit(token common.Address, hash common.Hash, sequence uint64) launchVerdict {
+	if l.window <= 0 || hash == (common.Hash{}) {
+		return launchNew
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+
+	if _, ok := l.hashes[hash]; ok {
+		return launchDuplicate
+	}
+	l.hashes[hash] = now
+
+	if sequence == 0 {
+		return launchNew
+	}
+	if latest, ok := l.latest[token]; ok && sequence < latest.sequence {
+		return launchStale
+	}
+	l.latest[token] = launchEntry{sequence: sequence, at: now}
+	return launchNew
+}
+
+// prune forgets what was notified more than window ago
+func (l *launchLog) prune(now time.Time) {
+	cutoff := now.Add(-l.window)
+	for hash, at := range l.hashes {
+		if at.Before(cutoff) {
+			delete(l.hashes, hash)
+		}
+	}
+	for token, entry := range l.latest {
+		if entry.at.Before(cutoff) {
+			delete(l.latest, token)
+		}
+	}
+}
+
+// launchHash returns the hash of a notification's launch transaction: the
+// one the proxy tagged it with, or else that of its raw transaction. It is
+// zero when neither is available.
+func launchHash(notification LPAddNotification) common.Hash {
+	if notification.TxHash != "" {
+		return common.HexToHash(notification.TxHash)
+	}
+	if launchTx, err := decodeRawTx(notification.TxCallData); err == nil {
+		return launchTx.Hash()
+	}
+	return common.Hash{}
+}
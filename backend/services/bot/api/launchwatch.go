@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/bundle"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// cancelGasLimit is the gas of the zero-value self-transfer that replaces a
+// snipe or commission transaction
+const cancelGasLimit = 21000
+
+// watchLaunch cancels a bundle whose LP_ADD doesn't land within
+// LaunchLandTimeout, e.g. because the creator dropped or replaced it. Its
+// snipes would only revert against a missing pool, so every transaction of
+// the bundle that isn't mined yet is replaced by a self-transfer with the
+// same nonce and higher fees. After another LaunchLandTimeout, snipes whose
+// own transaction still hasn't been mined are marked cancelled; any that
+// landed anyway are left to the confirmer.
+//
+// An LP_ADD that was mined but reverted takes the snipes mined with it down
+// too. Under LaunchRevertRequeue the bundle is cancelled the same way, and
+// each snipe that didn't land is placed again as a new pending snipe to catch
+// the creator's next try.
+//
+// txs are the bundle's transactions after the LP_ADD, the snipes of bids
+// first and then the commission transfers.
+func (s *Service) watchLaunch(bundleID, launchRawTx string, txs []*types.Transaction, bids []*bundle.SnipeBid) {
+	launchTx, err := decodeRawTx(launchRawTx)
+	if err != nil {
+		return
+	}
+
+	select {
+	case <-s.stop:
+		return
+	case <-time.After(s.config.LaunchLandTimeout):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.LaunchLandTimeout)
+	defer cancel()
+
+	requeue := false
+	if receipt, err := s.ethClient.TransactionReceipt(ctx, launchTx.Hash()); err == nil {
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			return
+		}
+		if s.config.LaunchRevertPolicy != config.LaunchRevertRequeue {
+			log.Printf("💥 [%s] LP_ADD %s reverted, leaving its snipes as they are", bundleID, launchTx.Hash().Hex())
+			return
+		}
+
+		log.Printf("💥 [%s] LP_ADD %s reverted, cancelling the bundle's unmined transactions and requeueing its snipes",
+			bundleID, launchTx.Hash().Hex())
+		requeue = true
+
+		requeued := 0
+		for i, bid := range bids {
+			if reverted, mined := s.snipeReverted(ctx, txs[i]); mined && reverted && s.requeueLaunchSnipe(bundleID, bid) {
+				requeued++
+			}
+		}
+		if requeued > 0 {
+			log.Printf("🔁 [%s] Requeued %d snipes mined with the reverted LP_ADD", bundleID, requeued)
+		}
+	} else {
+		log.Printf("🪤 [%s] LP_ADD %s didn't land within %s, cancelling the bundle's %d transactions",
+			bundleID, launchTx.Hash().Hex(), s.config.LaunchLandTimeout, len(txs))
+	}
+
+	keys := make(map[common.Address]string, len(bids))
+	for _, bid := range bids {
+		keys[bid.Wallet] = bid.PrivateKey
+	}
+
+	// Snipe index -> hash of the transaction replacing it
+	cancelled := make(map[int]string)
+	for i, tx := range txs {
+		if _, err := s.ethClient.TransactionReceipt(ctx, tx.Hash()); err == nil {
+			continue
+		}
+
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			continue
+		}
+		cancelTx, err := s.sendCancel(ctx, tx, keys[from])
+		if err != nil {
+			log.Printf("⚠️ [%s] Failed to cancel transaction %s of wallet %s: %v", bundleID, tx.Hash().Hex(), from.Hex(), err)
+			continue
+		}
+		log.Printf("🪤 [%s] Replacing transaction %s of wallet %s with %s", bundleID, tx.Hash().Hex(), from.Hex(), cancelTx.Hash().Hex())
+		if i < len(bids) {
+			cancelled[i] = cancelTx.Hash().Hex()
+		}
+	}
+	if len(cancelled) == 0 {
+		return
+	}
+
+	select {
+	case <-s.stop:
+		return
+	case <-time.After(s.config.LaunchLandTimeout):
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), s.config.LaunchLandTimeout)
+	defer cancel()
+
+	count, requeued := 0, 0
+	for i, cancelTxHash := range cancelled {
+		bid := bids[i]
+		if reverted, mined := s.snipeReverted(ctx, txs[i]); mined {
+			log.Printf("⚠️ [%s] Snipe %d was mined before its cancellation", bundleID, bid.SnipeID)
+			if requeue && reverted && s.requeueLaunchSnipe(bundleID, bid) {
+				requeued++
+			}
+			continue
+		}
+
+		updated, err := s.db.UpdateSnipeStatusAtomic(bid.SnipeID, db.SnipeStatusSubmitted, db.SnipeStatusCancelled)
+		if err != nil {
+			log.Printf("⚠️ [%s] Failed to mark snipe %d cancelled: %v", bundleID, bid.SnipeID, err)
+			continue
+		}
+		if updated {
+			count++
+			s.notifyStatus(bidStatusEvent(bid, bundleID, db.SnipeStatusCancelled, cancelTxHash))
+			if requeue && s.requeueLaunchSnipe(bundleID, bid) {
+				requeued++
+			}
+		}
+	}
+	log.Printf("🪤 [%s] Cancelled %d snipes", bundleID, count)
+	if requeued > 0 {
+		log.Printf("🔁 [%s] Requeued %d snipes after the LP_ADD reverted", bundleID, requeued)
+	}
+}
+
+// snipeReverted reports whether a snipe transaction has been mined, and if so
+// whether it reverted
+func (s *Service) snipeReverted(ctx context.Context, tx *types.Transaction) (reverted, mined bool) {
+	receipt, err := s.ethClient.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return false, false
+	}
+	return receipt.Status != types.ReceiptStatusSuccessful, true
+}
+
+// requeueLaunchSnipe places a snipe of a bundle whose LP_ADD reverted again as
+// a new pending snipe, and reports the new snipe to the webhook. It returns
+// false if the snipe couldn't be requeued.
+func (s *Service) requeueLaunchSnipe(bundleID string, bid *bundle.SnipeBid) bool {
+	id, err := s.db.RequeueLaunchSnipe(bid.SnipeID)
+	if err != nil {
+		log.Printf("⚠️ [%s] Failed to requeue snipe %d: %v", bundleID, bid.SnipeID, err)
+		return false
+	}
+	if id == 0 {
+		return false
+	}
+
+	event := bidStatusEvent(bid, bundleID, db.SnipeStatusPending, "")
+	event.SnipeID = id
+	event.RequeuedFrom = bid.SnipeID
+	s.notifyStatus(event)
+	return true
+}
+
+// sendCancel replaces tx with a zero-value transfer from its sender to
+// itself, at the same nonce and with fees bumped enough for nodes to accept
+// the replacement. It is sent to every submission endpoint and succeeds once
+// any of them accepts it.
+func (s *Service) sendCancel(ctx context.Context, tx *types.Transaction, privateKeyHex string) (*types.Transaction, error) {
+	if privateKeyHex == "" {
+		return nil, fmt.Errorf("private key not found")
+	}
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	tip := bumpFee(tx.GasTipCap())
+	feeCap := bumpFee(tx.GasFeeCap())
+	if feeCap.Cmp(tip) < 0 {
+		feeCap.Set(tip)
+	}
+
+	cancelTx, err := s.bundleManager.Txs().Sign(privateKey, eth.TxParams{
+		Nonce:     tx.Nonce(),
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       cancelGasLimit,
+		To:        &from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign cancellation: %v", err)
+	}
+
+	rawTx, err := cancelTx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cancellation: %v", err)
+	}
+
+	submitURLs, err := s.config.SubmitURLs()
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, submitURL := range submitURLs {
+		if _, err := s.submitter.SendRawTransaction(ctx, submitURL, "0x"+hex.EncodeToString(rawTx)); err != nil {
+			lastErr = err
+			continue
+		}
+		return cancelTx, nil
+	}
+	return nil, lastErr
+}
+
+// bumpFee raises a fee by 12.5% plus 1 wei, over the 10% bump nodes require
+// to replace a pending transaction
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(1125))
+	bumped.Div(bumped, big.NewInt(1000))
+	return bumped.Add(bumped, big.NewInt(1))
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// recordLPEvent stores the pool baseline of an addLiquidityETH launch: the
+// token amount and ETH it adds, parsed from its calldata, and the pair's
+// reserves before it. Entry prices and price impact of the snipes behind it
+// are computed against these. The row is written once the ID of the bundle
+// the launch went out in arrives on bundleIDs ("" if it wasn't submitted).
+//
+// It runs alongside the bundle build. The launch is only submitted once the
+// bundle is built and lands a block later, so the reserves read here are
+// still the ones from before it.
+func (s *Service) recordLPEvent(notification LPAddNotification, bundleIDs <-chan string) {
+	if notification.Trigger == string(config.TriggerCreatePair) {
+		return
+	}
+
+	launchTx, err := decodeRawTx(notification.TxCallData)
+	if err != nil {
+		return
+	}
+	args, err := dex.DecodeAddLiquidityETH(launchTx.Data())
+	if err != nil {
+		log.Printf("⚠️ Not recording LP_ADD baseline for token %s: %v", notification.TokenAddress, err)
+		return
+	}
+
+	event := &db.LPEvent{
+		TokenAddress:       notification.TokenAddress,
+		TxHash:             launchTx.Hash().Hex(),
+		Creator:            notification.CreatorAddress,
+		AmountTokenDesired: args.AmountTokenDesired.String(),
+		ETHValue:           launchTx.Value().String(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.BlockTime)
+	defer cancel()
+
+	token := common.HexToAddress(notification.TokenAddress)
+	amountTokens := args.AmountTokenDesired.String() + " units"
+	decimals, err := dex.TokenDecimals(ctx, s.ethClient.Client, token)
+	if err != nil {
+		log.Printf("⚠️ Failed to read decimals of token %s: %v", notification.TokenAddress, err)
+	} else {
+		event.TokenDecimals = sql.NullInt32{Int32: int32(decimals), Valid: true}
+		amountTokens = eth.FormatTokens(args.AmountTokenDesired, decimals)
+	}
+
+	tokenReserve, wethReserve, err := dex.PoolReserves(ctx, s.ethClient.Client,
+		common.HexToAddress(s.config.UniswapV2Factory), token)
+	if err != nil {
+		log.Printf("⚠️ Failed to read reserves before the LP_ADD of token %s: %v", notification.TokenAddress, err)
+	} else {
+		event.TokenReserveBefore = sql.NullString{String: tokenReserve.String(), Valid: true}
+		event.WETHReserveBefore = sql.NullString{String: wethReserve.String(), Valid: true}
+	}
+
+	if bundleID := <-bundleIDs; bundleID != "" {
+		event.BundleID = sql.NullString{String: bundleID, Valid: true}
+	}
+
+	if err := s.db.CreateLPEvent(event); err != nil {
+		log.Printf("⚠️ Failed to record LP_ADD baseline for token %s: %v", notification.TokenAddress, err)
+		return
+	}
+	log.Printf("📸 LP_ADD baseline for token %s: adds %s tokens and %s", notification.TokenAddress,
+		amountTokens, eth.FormatEther(launchTx.Value()))
+}
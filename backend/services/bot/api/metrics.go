@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// funnelStage is a step snipes pass on their way from pending to landed
+type funnelStage int
+
+// Snipe funnel stages, in the order snipes pass them
+const (
+	funnelMatched  funnelStage = iota // Pending for the token when its LP_ADD arrived
+	funnelEligible                    // Turned into a bid: token not blocked, amounts valid, wallet found
+	funnelSelected                    // Fit the bundle's gas cap and MAX_BUNDLE_SNIPES
+	funnelFunded                      // Signed into the bundle, the wallet covering value and gas
+	funnelAccepted                    // Accepted by a submission endpoint
+	funnelLanded                      // Mined and succeeded
+	funnelReverted                    // Mined but reverted
+	funnelStages
+)
+
+var funnelStageNames = [funnelStages]string{"matched", "eligible", "selected", "funded", "accepted", "landed", "reverted"}
+
+// funnelOtherToken labels the counts of tokens past the per-token limit
+const funnelOtherToken = "other"
+
+// snipeFunnel counts the snipes reaching each funnel stage, in total and per
+// token. Only the first maxTokens tokens get their own counts, so a stream of
+// launches can't grow the metrics without bound; later ones are counted
+// together as funnelOtherToken. With maxTokens 0 there are only totals.
+type snipeFunnel struct {
+	mu        sync.Mutex
+	maxTokens int
+	total     [funnelStages]uint64
+	tokens    map[string]*[funnelStages]uint64
+}
+
+func newSnipeFunnel(maxTokens int) *snipeFunnel {
+	return &snipeFunnel{maxTokens: maxTokens, tokens: make(map[string]*[funnelStages]uint64)}
+}
+
+// add counts n snipes of token reaching stage
+func (f *snipeFunnel) add(token string, stage funnelStage, n int) {
+	if n <= 0 {
+		return
+	}
+
+	label := common.HexToAddress(token).Hex()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.total[stage] += uint64(n)
+	if f.maxTokens == 0 {
+		return
+	}
+	counts, ok := f.tokens[label]
+	if !ok {
+		if len(f.tokens) >= f.maxTokens {
+			label = funnelOtherToken
+		}
+		if counts, ok = f.tokens[label]; !ok {
+			counts = new([funnelStages]uint64)
+			f.tokens[label] = counts
+		}
+	}
+	counts[stage] += uint64(n)
+}
+
+// writeMetrics writes the counts in the Prometheus text format
+func (f *snipeFunnel) writeMetrics(w io.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP sniper_snipe_funnel_total Snipes reaching each stage from LP_ADD to landed.")
+	fmt.Fprintln(w, "# TYPE sniper_snipe_funnel_total counter")
+	for stage, name := range funnelStageNames {
+		fmt.Fprintf(w, "sniper_snipe_funnel_total{stage=%q} %d\n", name, f.total[stage])
+	}
+
+	tokens := make([]string, 0, len(f.tokens))
+	for token := range f.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	fmt.Fprintln(w, "# HELP sniper_snipe_funnel_token_total Snipes reaching each stage from LP_ADD to landed, by token.")
+	fmt.Fprintln(w, "# TYPE sniper_snipe_funnel_token_total counter")
+	for _, token := range tokens {
+		for stage, name := range funnelStageNames {
+			fmt.Fprintf(w, "sniper_snipe_funnel_token_total{stage=%q,token=%q} %d\n", name, token, f.tokens[token][stage])
+		}
+	}
+}
+
+// handleMetrics serves GET /metrics with the snipe funnel in the Prometheus
+// text format. It is routed behind requireAuth, so scrapers need AUTH_KEY.
+func (s *Service) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	s.funnel.writeMetrics(w)
+}
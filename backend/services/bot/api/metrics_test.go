@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sniper-bot/pkg/config"
+)
+
+func TestMetricsRequireAuth(t *testing.T) {
+	s := &Service{apiKey: "api-key", adminKey: "admin-key", config: &config.Config{}, ready: make(chan struct{}), funnel: newSnipeFunnel(10)}
+	s.funnel.add("0x00000000000000000000000000000000000000aa", funnelMatched, 3)
+	mux := s.routes()
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"no credential", "", http.StatusUnauthorized},
+		{"unknown key", "Bearer guess", http.StatusUnauthorized},
+		{"API key without Bearer", "api-key", http.StatusUnauthorized},
+		{"API key", "Bearer api-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		counted := strings.Contains(rec.Body.String(), `sniper_snipe_funnel_total{stage="matched"} 3`)
+		if counted != (tt.status == http.StatusOK) {
+			t.Errorf("%s: body %q", tt.name, rec.Body.String())
+		}
+	}
+}
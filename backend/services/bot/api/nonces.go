@@ -0,0 +1,73 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// nonceReservationTTL is how long a wallet's reserved nonces outrank the
+// chain's. Bundles target the block after their launch, so by then the node
+// counts the transactions that landed, and the rest were dropped.
+const nonceReservationTTL = time.Minute
+
+// nonceReservations hands out wallet nonces to bundle builds and to the
+// transactions sent outside bundles. The chain's nonce doesn't count
+// transactions that are signed but not yet submitted, so two builds for
+// different tokens that share a wallet would otherwise both use it.
+type nonceReservations struct {
+	mu      sync.Mutex
+	wallets map[common.Address]*nonceReservation
+}
+
+// nonceReservation is the next nonce no transaction of a wallet holds yet
+type nonceReservation struct {
+	next       uint64
+	reservedAt time.Time
+}
+
+// nonceLease is a run of consecutive nonces reserved for a wallet
+type nonceLease struct {
+	wallet common.Address
+	start  uint64
+	count  uint64
+}
+
+func newNonceReservations() *nonceReservations {
+	return &nonceReservations{wallets: make(map[common.Address]*nonceReservation)}
+}
+
+// reserve reserves count consecutive nonces of wallet, starting at
+// chainNonce, or at the end of the wallet's last reservation if that is later
+// and younger than nonceReservationTTL
+func (r *nonceReservations) reserve(wallet common.Address, chainNonce, count uint64) nonceLease {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for address, reservation := range r.wallets {
+		if now.Sub(reservation.reservedAt) > nonceReservationTTL {
+			delete(r.wallets, address)
+		}
+	}
+
+	start := chainNonce
+	if reservation, ok := r.wallets[wallet]; ok && reservation.next > start {
+		start = reservation.next
+	}
+	r.wallets[wallet] = &nonceReservation{next: start + count, reservedAt: now}
+	return nonceLease{wallet: wallet, start: start, count: count}
+}
+
+// release gives back the nonces of a lease that went unused, if they are
+// still the wallet's last. Nonces handed out after them keep their place; the
+// gap closes when the reservation expires.
+func (r *nonceReservations) release(lease nonceLease) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reservation, ok := r.wallets[lease.wallet]; ok && reservation.next == lease.start+lease.count {
+		reservation.next = lease.start
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"sniper-bot/services/bot/bundle"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestNonceReservationsConcurrent(t *testing.T) {
+	r := newNonceReservations()
+	wallets := []common.Address{
+		common.HexToAddress("0x00000000000000000000000000000000000000a1"),
+		common.HexToAddress("0x00000000000000000000000000000000000000a2"),
+	}
+
+	// Builds for many tokens reserve at once, each seeing the same chain
+	// nonce, with and without a commission transfer
+	const builds = 200
+	var mu sync.Mutex
+	leases := make(map[common.Address][]nonceLease)
+	var wg sync.WaitGroup
+	for i := 0; i < builds; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			wallet := wallets[i%len(wallets)]
+			lease := r.reserve(wallet, 5, uint64(1+i%2))
+			mu.Lock()
+			leases[wallet] = append(leases[wallet], lease)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	// Each wallet's leases tile its nonces from the chain's, with no overlap
+	for _, wallet := range wallets {
+		got := leases[wallet]
+		sort.Slice(got, func(i, j int) bool { return got[i].start < got[j].start })
+		next := uint64(5)
+		for _, lease := range got {
+			if lease.start != next {
+				t.Fatalf("wallet %s: lease at %d, want %d (overlap or gap)", wallet.Hex(), lease.start, next)
+			}
+			next += lease.count
+		}
+		if len(got) != builds/len(wallets) {
+			t.Errorf("wallet %s: %d leases, want %d", wallet.Hex(), len(got), builds/len(wallets))
+		}
+	}
+}
+
+func TestNonceReservationsRelease(t *testing.T) {
+	r := newNonceReservations()
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+
+	first := r.reserve(wallet, 3, 2)  // 3, 4
+	second := r.reserve(wallet, 3, 1) // 5
+	if first.start != 3 || second.start != 5 {
+		t.Fatalf("leases at %d and %d, want 3 and 5", first.start, second.start)
+	}
+
+	// Releasing a lease with nonces after it leaves a gap rather than
+	// handing out 3 and 4 again alongside 5
+	r.release(first)
+	if lease := r.reserve(wallet, 3, 1); lease.start != 6 {
+		t.Errorf("after releasing an earlier lease, next at %d, want 6", lease.start)
+	}
+
+	// The last lease unwinds
+	last := r.reserve(wallet, 3, 2) // 7, 8
+	r.release(last)
+	if lease := r.reserve(wallet, 3, 1); lease.start != 7 {
+		t.Errorf("after releasing the last lease, next at %d, want 7", lease.start)
+	}
+
+	// A chain nonce past the reservations wins
+	if lease := r.reserve(wallet, 20, 1); lease.start != 20 {
+		t.Errorf("with the chain at 20, lease at %d", lease.start)
+	}
+
+	// Expired reservations yield to the chain again
+	r.wallets[wallet].reservedAt = time.Now().Add(-2 * nonceReservationTTL)
+	if lease := r.reserve(wallet, 4, 1); lease.start != 4 {
+		t.Errorf("after expiry, lease at %d, want the chain's 4", lease.start)
+	}
+}
+
+// TestConcurrentBundlesShareWallet builds bundles for two tokens launching
+// at once, both sniped from the same wallet, and checks that its snipes get
+// distinct nonces and both land
+func TestConcurrentBundlesShareWallet(t *testing.T) {
+	shared, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	creatorA, _ := crypto.GenerateKey()
+	creatorB, _ := crypto.GenerateKey()
+	bot := newSimBot(t, shared, other, creatorA, creatorB)
+	s := bot.service
+
+	tokenA := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tokenB := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	launchA, notificationA := bot.launch(t, creatorA, tokenA)
+	launchB, notificationB := bot.launch(t, creatorB, tokenB)
+
+	builds := []struct {
+		notification LPAddNotification
+		bids         []*bundle.SnipeBid
+		transactions []*types.Transaction
+		err          error
+	}{
+		{notification: notificationA, bids: []*bundle.SnipeBid{simBid(1, tokenA, shared, 2e15), simBid(2, tokenA, other, 1e15)}},
+		{notification: notificationB, bids: []*bundle.SnipeBid{simBid(3, tokenB, shared, 3e15)}},
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := range builds {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			builds[i].transactions, _, builds[i].err = s.createBundleTransactions(ctx, builds[i].bids, builds[i].notification)
+		}(i)
+	}
+	wg.Wait()
+
+	sharedWallet := crypto.PubkeyToAddress(shared.PublicKey)
+	signer := types.LatestSignerForChainID(bot.chain.chainID)
+	var sharedNonces []uint64
+	for _, build := range builds {
+		if build.err != nil {
+			t.Fatalf("bundle for token %s: %v", build.notification.TokenAddress, build.err)
+		}
+		for _, tx := range build.transactions {
+			if from, _ := types.Sender(signer, tx); from == sharedWallet {
+				sharedNonces = append(sharedNonces, tx.Nonce())
+			}
+		}
+	}
+	sort.Slice(sharedNonces, func(i, j int) bool { return sharedNonces[i] < sharedNonces[j] })
+	if len(sharedNonces) != 2 || sharedNonces[0] != 0 || sharedNonces[1] != 1 {
+		t.Fatalf("shared wallet's snipes have nonces %v, want [0 1]", sharedNonces)
+	}
+
+	for _, build := range builds {
+		s.submitBundle(ctx, build.notification.TokenAddress, build.notification.TxCallData, build.transactions)
+	}
+	bot.chain.backend.Commit()
+
+	for _, launch := range []*types.Transaction{launchA, launchB} {
+		if receipt := bot.chain.receipt(t, launch.Hash()); receipt.Status != types.ReceiptStatusSuccessful {
+			t.Errorf("launch %s reverted", launch.Hash().Hex())
+		}
+	}
+	for _, build := range builds {
+		for i, tx := range build.transactions {
+			if receipt := bot.chain.receipt(t, tx.Hash()); receipt.Status != types.ReceiptStatusSuccessful {
+				t.Errorf("snipe %d reverted", build.bids[i].SnipeID)
+			}
+		}
+	}
+}
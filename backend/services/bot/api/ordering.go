@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// OrderingEntry describes where one snipe of a bundle landed on-chain
+type OrderingEntry struct {
+	SnipeID          int64  `json:"snipeId"`
+	TxHash           string `json:"txHash"`
+	BribeAmount      string `json:"bribeAmount"`
+	IntendedPosition int64  `json:"intendedPosition"`
+	Landed           bool   `json:"landed"`
+	BlockNumber      uint64 `json:"blockNumber,omitempty"`
+	TxIndex          uint   `json:"txIndex,omitempty"`
+}
+
+// OrderingReport compares the on-chain ordering of each of a token's bundles
+// with its intended bribe ordering
+type OrderingReport struct {
+	TokenAddress string            `json:"tokenAddress"`
+	Bundles      []*BundleOrdering `json:"bundles"` // Oldest first
+}
+
+// BundleOrdering compares one bundle's on-chain ordering with its intended
+// bribe ordering. A token's requeued snipes go out in later bundles, and
+// positions are only comparable within a bundle.
+type BundleOrdering struct {
+	BundleID   string           `json:"bundleId"`
+	Entries    []*OrderingEntry `json:"entries"`
+	Landed     int              `json:"landed"`
+	Inversions int              `json:"inversions"` // Landed pairs whose on-chain order contradicts the bribe order
+	Honored    bool             `json:"honored"`    // True when every landed snipe kept its relative position
+}
+
+// handleOrderingReport serves GET /api/debug/ordering?token=0x...
+func (s *Service) handleOrderingReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !s.requireReady(w) {
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if !common.IsHexAddress(token) {
+		writeError(w, http.StatusBadRequest, "Invalid token address")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	report, err := s.buildOrderingReport(ctx, token)
+	if err != nil {
+		log.Printf("❌ Failed to build ordering report for %s: %v", token, err)
+		writeError(w, http.StatusInternalServerError, "Failed to build ordering report")
+		return
+	}
+
+	for _, b := range report.Bundles {
+		log.Printf("📐 Ordering report for %s, bundle %s: %d/%d landed, %d inversions, honored=%t",
+			token, b.BundleID, b.Landed, len(b.Entries), b.Inversions, b.Honored)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// buildOrderingReport fetches the receipt of every submitted snipe for a token
+// and, per bundle, counts how often the sequencer placed a lower bribe ahead of
+// a higher one
+func (s *Service) buildOrderingReport(ctx context.Context, token string) (*OrderingReport, error) {
+	snipes, err := s.db.GetSubmittedSnipesByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderingReport(token, snipes, func(hash common.Hash) (*types.Receipt, error) {
+		return s.ethClient.TransactionReceipt(ctx, hash)
+	}), nil
+}
+
+// orderingReport builds the report of snipes, sorted by bundle and then
+// intended position, with receipt looking up their transactions
+func orderingReport(token string, snipes []*db.Snipe, receipt func(common.Hash) (*types.Receipt, error)) *OrderingReport {
+	report := &OrderingReport{TokenAddress: token}
+	firstSnipe := make(map[*BundleOrdering]int64) // Lowest snipe ID of each bundle, to sort them by age
+	var b *BundleOrdering
+
+	for _, snipe := range snipes {
+		if b == nil || b.BundleID != snipe.BundleID.String {
+			b = &BundleOrdering{BundleID: snipe.BundleID.String}
+			report.Bundles = append(report.Bundles, b)
+			firstSnipe[b] = snipe.ID
+		}
+		firstSnipe[b] = min(firstSnipe[b], snipe.ID)
+
+		entry := &OrderingEntry{
+			SnipeID:          snipe.ID,
+			TxHash:           snipe.TxHash.String,
+			BribeAmount:      snipe.BribeAmount,
+			IntendedPosition: snipe.BundlePosition.Int64,
+		}
+		b.Entries = append(b.Entries, entry)
+
+		r, err := receipt(common.HexToHash(snipe.TxHash.String))
+		if err != nil {
+			// Not mined (or dropped); it can't contribute to the on-chain ordering
+			continue
+		}
+
+		entry.Landed = true
+		entry.BlockNumber = r.BlockNumber.Uint64()
+		entry.TxIndex = r.TransactionIndex
+	}
+
+	for _, b := range report.Bundles {
+		var landed []*OrderingEntry
+		for _, entry := range b.Entries {
+			if entry.Landed {
+				landed = append(landed, entry)
+			}
+		}
+
+		// Entries are sorted by intended position, so any later entry that
+		// landed earlier on-chain is an inversion of the fee ladder
+		for i := 0; i < len(landed); i++ {
+			for j := i + 1; j < len(landed); j++ {
+				if landsBefore(landed[j], landed[i]) {
+					b.Inversions++
+				}
+			}
+		}
+
+		b.Landed = len(landed)
+		b.Honored = b.Inversions == 0
+	}
+
+	sort.Slice(report.Bundles, func(i, j int) bool {
+		return firstSnipe[report.Bundles[i]] < firstSnipe[report.Bundles[j]]
+	})
+	return report
+}
+
+// landsBefore reports whether a was included on-chain before b
+func landsBefore(a, b *OrderingEntry) bool {
+	if a.BlockNumber != b.BlockNumber {
+		return a.BlockNumber < b.BlockNumber
+	}
+	return a.TxIndex < b.TxIndex
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// bundleSummary is the part of a BundleOrdering the tests compare
+type bundleSummary struct {
+	bundleID   string
+	snipes     []int64
+	landed     int
+	inversions int
+}
+
+func TestOrderingReport(t *testing.T) {
+	// A snipe of bundle with its intended position, landing at index of block
+	// 100, or not landing with index -1
+	type landing struct {
+		id       int64
+		bundle   string
+		position int64
+		index    int
+	}
+
+	tests := []struct {
+		name     string
+		landings []landing // Sorted by bundle and then position, as GetSubmittedSnipesByToken returns them
+		want     []bundleSummary
+	}{
+		{
+			name:     "one bundle in order",
+			landings: []landing{{1, "0xaa", 0, 1}, {2, "0xaa", 1, 2}, {3, "0xaa", 2, 3}},
+			want:     []bundleSummary{{"0xaa", []int64{1, 2, 3}, 3, 0}},
+		},
+		{
+			name:     "one bundle inverted",
+			landings: []landing{{1, "0xaa", 0, 3}, {2, "0xaa", 1, 1}, {3, "0xaa", 2, -1}},
+			want:     []bundleSummary{{"0xaa", []int64{1, 2, 3}, 2, 1}},
+		},
+		{
+			// The requeued snipes 4 and 5 reuse positions 0 and 1 in a later bundle,
+			// landing ahead of the first bundle's position 1
+			name: "requeued into a later bundle",
+			landings: []landing{
+				{4, "0x11", 0, 1}, {5, "0x11", 1, 2},
+				{1, "0xff", 0, 0}, {2, "0xff", 1, 5}, {3, "0xff", 2, -1},
+			},
+			want: []bundleSummary{
+				{"0xff", []int64{1, 2, 3}, 2, 0},
+				{"0x11", []int64{4, 5}, 2, 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var snipes []*db.Snipe
+		receipts := make(map[common.Hash]*types.Receipt)
+		for _, l := range tt.landings {
+			hash := common.BigToHash(big.NewInt(l.id))
+			snipes = append(snipes, &db.Snipe{
+				ID:             l.id,
+				TxHash:         sql.NullString{String: hash.Hex(), Valid: true},
+				BundlePosition: sql.NullInt64{Int64: l.position, Valid: true},
+				BundleID:       sql.NullString{String: l.bundle, Valid: true},
+			})
+			if l.index >= 0 {
+				receipts[hash] = &types.Receipt{BlockNumber: big.NewInt(100), TransactionIndex: uint(l.index)}
+			}
+		}
+
+		report := orderingReport("0xtoken", snipes, func(hash common.Hash) (*types.Receipt, error) {
+			if receipt, ok := receipts[hash]; ok {
+				return receipt, nil
+			}
+			return nil, errors.New("not found")
+		})
+
+		var got []bundleSummary
+		for _, b := range report.Bundles {
+			summary := bundleSummary{bundleID: b.BundleID, landed: b.Landed, inversions: b.Inversions}
+			for _, entry := range b.Entries {
+				summary.snipes = append(summary.snipes, entry.SnipeID)
+			}
+			if b.Honored != (b.Inversions == 0) {
+				t.Errorf("%s: bundle %s honored=%t with %d inversions", tt.name, b.BundleID, b.Honored, b.Inversions)
+			}
+			got = append(got, summary)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: bundles %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
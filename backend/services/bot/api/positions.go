@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math/big"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// openPosition adds a landed snipe to its user's position in the token. The
+// ETH spent is everything the snipe cost the wallet: the value sent to the
+// sniper contract, which covers the swap and its bribe, plus gas and the L1
+// fee. Snipes with a recipient are left out, as their tokens leave the wallet.
+func (s *Service) openPosition(ctx context.Context, snipe *db.Snipe, receipt *types.Receipt, l1Fee *big.Int) {
+	tx, _, err := s.ethClient.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		log.Printf("⚠️ Failed to get snipe %d for its position: %v", snipe.ID, err)
+		return
+	}
+
+	spent := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	spent.Add(spent, tx.Value())
+	if l1Fee != nil {
+		spent.Add(spent, l1Fee)
+	}
+
+	entry := &db.PositionEntry{
+		UserID:       snipe.UserID,
+		Wallet:       snipe.Wallet,
+		TokenAddress: snipe.TokenAddress,
+		Tokens:       dex.ReceivedTokens(receipt, common.HexToAddress(snipe.TokenAddress), common.HexToAddress(snipe.Wallet)),
+		ETHSpent:     spent,
+		Block:        receipt.BlockNumber.Uint64(),
+	}
+	if err := s.db.OpenPosition(entry); err != nil {
+		log.Printf("⚠️ Failed to record position of snipe %d: %v", snipe.ID, err)
+	}
+}
+
+// confirmSells closes the positions whose sells have been mined. A reverted
+// sell leaves its position open and clears the sell so another can be sent.
+func (s *Service) confirmSells(ctx context.Context) {
+	positions, err := s.db.GetSellingPositions()
+	if err != nil {
+		log.Printf("⚠️ Failed to load positions being sold: %v", err)
+		return
+	}
+
+	for _, position := range positions {
+		receipt, err := s.ethClient.TransactionReceipt(ctx, common.HexToHash(position.SellTxHash.String))
+		if err != nil {
+			// Not mined yet
+			continue
+		}
+
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			if err := s.db.SetPositionSellTx(position.UserID, position.TokenAddress, sql.NullString{}); err != nil {
+				log.Printf("⚠️ Failed to clear reverted sell of position %d: %v", position.ID, err)
+				continue
+			}
+			log.Printf("⚠️ Sell %s of position %d reverted", position.SellTxHash.String, position.ID)
+			continue
+		}
+
+		exit := db.PositionExit{
+			Tokens:      dex.SentTokens(receipt, common.HexToAddress(position.TokenAddress), common.HexToAddress(position.Wallet)),
+			ETHReceived: dex.UnwrappedETH(receipt, common.HexToAddress(config.WETHAddress)),
+			Block:       receipt.BlockNumber.Uint64(),
+		}
+		if err := s.db.ClosePosition(position.ID, exit); err != nil {
+			log.Printf("⚠️ Failed to close position %d: %v", position.ID, err)
+			continue
+		}
+		log.Printf("📒 Closed position %d in %s: sold %s tokens for %s wei", position.ID, position.TokenAddress, exit.Tokens, exit.ETHReceived)
+	}
+}
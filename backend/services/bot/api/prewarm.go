@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// walletState is the pre-fetched on-chain state of a sniper wallet
+type walletState struct {
+	Nonce     uint64
+	Balance   *big.Int // nil when only the nonce was fetched
+	FetchedAt time.Time
+}
+
+// walletCache holds pre-warmed wallet states so bundle construction doesn't
+// wait on RPC round-trips. An entry is removed when a bundle uses it, since the
+// nonce is spent by the submitted snipe.
+type walletCache struct {
+	mu     sync.Mutex
+	states map[common.Address]*walletState
+	maxAge time.Duration
+}
+
+func newWalletCache(maxAge time.Duration) *walletCache {
+	return &walletCache{
+		states: make(map[common.Address]*walletState),
+		maxAge: maxAge,
+	}
+}
+
+// set stores the state of a wallet
+func (c *walletCache) set(wallet common.Address, state *walletState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[wallet] = state
+}
+
+// take removes and returns the cached state of a wallet, or nil if it is
+// missing or older than maxAge
+func (c *walletCache) take(wallet common.Address) *walletState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.states[wallet]
+	if !ok {
+		return nil
+	}
+	delete(c.states, wallet)
+
+	if time.Since(state.FetchedAt) > c.maxAge {
+		return nil
+	}
+	return state
+}
+
+// missing returns the wallets without a cached state younger than maxAge
+func (c *walletCache) missing(wallets []common.Address) []common.Address {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var missing []common.Address
+	for _, wallet := range wallets {
+		if state, ok := c.states[wallet]; !ok || time.Since(state.FetchedAt) > c.maxAge {
+			missing = append(missing, wallet)
+		}
+	}
+	return missing
+}
+
+// retain drops every cached wallet that isn't in wallets
+func (c *walletCache) retain(wallets map[common.Address]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for wallet := range c.states {
+		if !wallets[wallet] {
+			delete(c.states, wallet)
+		}
+	}
+}
+
+// runPrewarm refreshes the cached nonce and balance of every wallet with a
+// pending snipe each PrewarmInterval until stop is closed
+func (s *Service) runPrewarm(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.PrewarmInterval)
+	defer ticker.Stop()
+
+	log.Printf("🔥 Pre-warming sniper wallet state every %s", s.config.PrewarmInterval)
+
+	for {
+		s.prewarmWallets()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prewarmWallets fetches the nonce and balance of every wallet with a pending snipe
+func (s *Service) prewarmWallets() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.PrewarmInterval)
+	defer cancel()
+
+	wallets, err := s.db.GetPendingSnipeWallets()
+	if err != nil {
+		log.Printf("⚠️ Failed to load pending snipe wallets for pre-warming: %v", err)
+		return
+	}
+
+	active := make(map[common.Address]bool, len(wallets))
+	addrs := make([]common.Address, 0, len(wallets))
+	for _, walletHex := range wallets {
+		wallet := common.HexToAddress(walletHex)
+		active[wallet] = true
+		addrs = append(addrs, wallet)
+	}
+
+	if err := s.fetchWalletStates(ctx, addrs); err != nil {
+		log.Printf("⚠️ Failed to pre-warm %d wallets: %v", len(addrs), err)
+	}
+
+	s.walletCache.retain(active)
+}
+
+// fetchWalletStates caches the nonce and balance of wallets, read in
+// one JSON-RPC batch and one multicall rather than two calls per wallet. If
+// only the balances fail, the nonces are still cached.
+func (s *Service) fetchWalletStates(ctx context.Context, wallets []common.Address) error {
+	if len(wallets) == 0 {
+		return nil
+	}
+
+	nonces, err := s.bundleManager.Nonces(ctx, wallets)
+	if err != nil {
+		return err
+	}
+	balances, balanceErr := s.ethClient.BatchBalances(ctx, wallets)
+
+	now := time.Now()
+	for i, wallet := range wallets {
+		state := &walletState{Nonce: nonces[i], FetchedAt: now}
+		if balanceErr == nil {
+			state.Balance = balances[i]
+		}
+		s.walletCache.set(wallet, state)
+	}
+	return balanceErr
+}
+
+// walletStateFor returns the pre-warmed state of a wallet, falling back to
+// fetching the nonce when the cache has no fresh entry
+func (s *Service) walletStateFor(ctx context.Context, wallet common.Address) (*walletState, error) {
+	if state := s.walletCache.take(wallet); state != nil {
+		return state, nil
+	}
+
+	nonce, err := s.bundleManager.Nonce(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+	return &walletState{Nonce: nonce, FetchedAt: time.Now()}, nil
+}
+
+// coversTransaction reports whether a known balance pays for value plus the
+// worst-case gas cost; an unknown balance is assumed to be sufficient
+func (state *walletState) coversTransaction(value *big.Int, gas uint64, maxFeePerGas *big.Int) bool {
+	if state.Balance == nil {
+		return true
+	}
+
+	required := new(big.Int).Mul(new(big.Int).SetUint64(gas), maxFeePerGas)
+	required.Add(required, value)
+	return state.Balance.Cmp(required) >= 0
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/wallet"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SendFromWallet signs and sends a call to a contract from a user's wallet,
+// for the bot's /send. Its nonce is reserved under walletTxs as an auto-sell's
+// is, so it can't take one a bundle build holds for the wallet. The gas limit
+// is estimated, which also refuses calls that would revert, and the wallet
+// must hold the ETH for it at the worst-case fee.
+func (s *Service) SendFromWallet(ctx context.Context, userWallet *wallet.Wallet, to common.Address, data []byte) (common.Hash, error) {
+	if !s.isReady() {
+		return common.Hash{}, fmt.Errorf("not connected to the node yet, try again shortly")
+	}
+
+	gas, err := s.ethClient.EstimateGas(ctx, ethereum.CallMsg{From: userWallet.Address, To: &to, Data: data})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("the transaction would fail: %v", err)
+	}
+	gas += gas / 5
+
+	baseFee, err := s.bundleManager.GasPrice(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get gas price: %v", err)
+	}
+	txs := s.bundleManager.Txs()
+	tip := s.bundleManager.PriorityFee(ctx)
+	feeCap := txs.FeeCap(baseFee, tip)
+
+	balance, err := s.ethClient.GetBalance(ctx, userWallet.Address)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get balance: %v", err)
+	}
+	if gasCost := new(big.Int).Mul(new(big.Int).SetUint64(gas), feeCap); balance.Cmp(gasCost) < 0 {
+		return common.Hash{}, fmt.Errorf("not enough ETH for gas: need up to %s, have %s", eth.FormatEther(gasCost), eth.FormatEther(balance))
+	}
+
+	s.walletTxs.Lock()
+	defer s.walletTxs.Unlock()
+
+	chainNonce, err := s.bundleManager.Nonce(ctx, userWallet.Address)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get nonce: %v", err)
+	}
+	lease := s.nonces.reserve(userWallet.Address, chainNonce, 1)
+
+	tx, err := txs.Sign(userWallet.PrivateKey, eth.TxParams{
+		Nonce:     lease.start,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &to,
+		Data:      data,
+	})
+	if err != nil {
+		s.nonces.release(lease)
+		return common.Hash{}, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if err := s.ethClient.SendTransaction(ctx, tx); err != nil {
+		s.nonces.release(lease)
+		return common.Hash{}, fmt.Errorf("failed to send transaction: %v", err)
+	}
+
+	// The wallet's pre-warmed nonce is now stale; a later bundle refetches it
+	s.walletCache.take(userWallet.Address)
+
+	return tx.Hash(), nil
+}
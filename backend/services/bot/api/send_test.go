@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"sniper-bot/pkg/dex"
+	"sniper-bot/services/bot/wallet"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestSendFromWalletReservesNonces sends /send transfers from a wallet while
+// bundle builds reserve its nonces, one of them from before the sends, and
+// checks that every transaction got a nonce of its own with none skipped
+func TestSendFromWalletReservesNonces(t *testing.T) {
+	const sends, builds = 4, 4
+	ctx := context.Background()
+
+	key, _ := crypto.GenerateKey()
+	deployer, _ := crypto.GenerateKey()
+	bot := newSimBot(t, key, deployer)
+	s := bot.service
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	userWallet := &wallet.Wallet{Address: owner, PrivateKey: key, UserID: "42"}
+
+	deployment := bot.chain.send(t, deployer, nil, big.NewInt(0), mockTokenCode(), big.NewInt(1e9))
+	bot.chain.backend.Commit()
+	token := bot.chain.receipt(t, deployment.Hash()).ContractAddress
+	tokenContract, err := dex.NewERC20PermitContract(s.ethClient.Client, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := tokenContract.PackTransfer(crypto.PubkeyToAddress(deployer.PublicKey), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	holders := make(map[uint64]string)
+	hold := func(nonce uint64, holder string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if other, ok := holders[nonce]; ok {
+			t.Errorf("nonce %d taken by both %s and %s", nonce, other, holder)
+		}
+		holders[nonce] = holder
+	}
+
+	// A bundle being built holds the wallet's next nonces, for a snipe and
+	// its commission transfer, before any /send
+	held := s.nonces.reserve(owner, 0, 2)
+	hold(held.start, "the first bundle")
+	hold(held.start+1, "the first bundle")
+
+	var wg sync.WaitGroup
+	for i := 0; i < sends; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hash, err := s.SendFromWallet(ctx, userWallet, token, data)
+			if err != nil {
+				t.Errorf("send %d: %v", i, err)
+				return
+			}
+			tx, _, err := bot.chain.client.TransactionByHash(ctx, hash)
+			if err != nil {
+				t.Errorf("send %d: %v", i, err)
+				return
+			}
+			hold(tx.Nonce(), fmt.Sprintf("send %d", i))
+		}(i)
+	}
+	for i := 0; i < builds; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			state, err := s.walletStateFor(ctx, owner)
+			if err != nil {
+				t.Errorf("bundle %d: %v", i, err)
+				return
+			}
+			lease := s.nonces.reserve(owner, state.Nonce, 2)
+			hold(lease.start, fmt.Sprintf("bundle %d", i))
+			hold(lease.start+1, fmt.Sprintf("bundle %d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if len(holders) != 2+sends+2*builds {
+		t.Errorf("%d nonces taken, want %d", len(holders), 2+sends+2*builds)
+	}
+	for nonce := uint64(0); nonce < uint64(len(holders)); nonce++ {
+		if _, ok := holders[nonce]; !ok {
+			t.Errorf("nonce %d skipped", nonce)
+		}
+	}
+}
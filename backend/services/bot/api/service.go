@@ -1,23 +1,22 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"math/big"
 	"net/http"
 	"os"
 	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
 	"sniper-bot/services/bot/bundle"
 	"sniper-bot/services/bot/db"
+	"sniper-bot/services/bot/positions"
 	"sniper-bot/services/bot/wallet"
-	"sort"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"sniper-bot/pkg/eth"
@@ -34,15 +33,43 @@ type Service struct {
 	db            *db.DB
 	httpServer    *http.Server
 	apiKey        string
+	adminKey      string // Bearer token of operator endpoints
 	bundleManager *bundle.Manager
 	config        *config.Config
+	bundleSlots   chan struct{}      // Semaphore bounding concurrent bundle builds
+	tokenLocks    *tokenLocks        // One bundle build per token at a time
+	launches      *launchLog         // LP_ADD transactions already notified, against redeliveries
+	walletCache   *walletCache       // Pre-warmed nonces and balances of pending-snipe wallets
+	walletTxs     sync.Mutex         // Serializes the auto-sells and transfers sent outside bundles
+	nonces        *nonceReservations // Wallet nonces held by transactions not yet on chain
+	monitor       *positions.Monitor
+	stop          chan struct{} // Closed on Stop to end the background workers
+	ready         chan struct{} // Closed once ethClient and bundleManager are set
+	submitter     Submitter     // Sends bundle transactions to the submission endpoints
+	funnel        *snipeFunnel  // Snipes reaching each stage from LP_ADD to landed, for /metrics
+	notifier      Notifier      // DMs launch alerts to users; nil without the bot
+
+	// Status events waiting for the webhook; nil without one
+	webhookEvents chan StatusEvent
 }
 
 // LPAddNotification represents the payload for LP_ADD notifications
 type LPAddNotification struct {
-	TokenAddress   string `json:"tokenAddress"`
-	CreatorAddress string `json:"creatorAddress"`
-	TxCallData     string `json:"txCallData"`
+	Trigger          string `json:"trigger,omitempty"` // "create_pair" or "add_liquidity" (default)
+	TokenAddress     string `json:"tokenAddress"`
+	CreatorAddress   string `json:"creatorAddress"`             // Bribe recipient
+	SenderAddress    string `json:"senderAddress,omitempty"`    // Signer of the LP_ADD transaction
+	RecipientAddress string `json:"recipientAddress,omitempty"` // LP token recipient (addLiquidityETH `to`)
+	TxCallData       string `json:"txCallData"`
+	TxHash           string `json:"txHash,omitempty"`   // Hash of the launch transaction
+	Sequence         uint64 `json:"sequence,omitempty"` // Increases with each notification the proxy sends
+}
+
+// TriggerRequest represents the payload for manually triggering a bundle
+type TriggerRequest struct {
+	Token      string `json:"token"`
+	Creator    string `json:"creator"`
+	TxCallData string `json:"txCallData"`
 }
 
 // BundleSubmissionRequest represents the request to submit a bundle to Base sequencer
@@ -58,7 +85,7 @@ type BundleSubmissionRequest struct {
 }
 
 // NewService creates a new API service
-func NewService(walletManager *wallet.Manager, database *db.DB) (*Service, error) {
+func NewService(walletManager *wallet.Manager, database *db.DB, monitor *positions.Monitor) (*Service, error) {
 	cfg := config.Load()
 
 	// Get API key for authentication
@@ -67,54 +94,137 @@ func NewService(walletManager *wallet.Manager, database *db.DB) (*Service, error
 		panic("AUTH_KEY environment variable is required")
 	}
 
-	// Use BASE_SEQUENCER_URL for RPC connection
-	rpcUrl := cfg.BaseRPCURL
-
-	ethClient, err := eth.NewClient(rpcUrl)
+	// A bad ABI is a configuration error, so it still stops startup
+	abiJSON, err := cfg.SniperABIJSON()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create eth client: %v", err)
+		return nil, err
 	}
-
-	// Initialize bundle manager with sniper contract address
-	sniperContractAddr := common.HexToAddress(cfg.SniperContract)
-
-	bundleManager, err := bundle.NewManager(ethClient.Client, sniperContractAddr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create bundle manager: %v", err)
+	if _, err := dex.ParseSniperABI(abiJSON); err != nil {
+		return nil, err
 	}
 
-	return &Service{
+	s := &Service{
 		walletManager: walletManager,
-		ethClient:     ethClient,
 		db:            database,
 		apiKey:        apiKey,
-		bundleManager: bundleManager,
+		adminKey:      cfg.AdminAuthKey,
 		config:        cfg,
-	}, nil
+		bundleSlots:   make(chan struct{}, cfg.MaxConcurrentBundles),
+		tokenLocks:    newTokenLocks(),
+		launches:      newLaunchLog(cfg.LPAddDedupWindow),
+		walletCache:   newWalletCache(2 * cfg.PrewarmInterval),
+		nonces:        newNonceReservations(),
+		monitor:       monitor,
+		stop:          make(chan struct{}),
+		ready:         make(chan struct{}),
+		submitter:     NewHTTPSubmitter(http.DefaultClient),
+		funnel:        newSnipeFunnel(cfg.MetricsMaxTokens),
+	}
+	if s.adminKey == "" {
+		log.Printf("⚠️ ADMIN_AUTH_KEY is not set, operator endpoints accept AUTH_KEY")
+		s.adminKey = apiKey
+	}
+	if cfg.StatusWebhookURL != "" {
+		s.webhookEvents = make(chan StatusEvent, webhookQueueSize)
+	}
+
+	// An unreachable node is retried in the background once started
+	ethClient, bundleManager, err := connect(cfg)
+	if err != nil {
+		log.Printf("⚠️ Starting degraded, sniper contract not connected: %v", err)
+		return s, nil
+	}
+	s.setConnected(ethClient, bundleManager)
+
+	return s, nil
 }
 
-// Start starts the API service
-func (s *Service) Start() error {
+// startWorkers launches the background workers that need the node
+func (s *Service) startWorkers() {
+	// Keep pending-snipe wallet state warm so LP_ADD handling skips those RPC calls
+	if s.config.PrewarmInterval > 0 {
+		go s.runPrewarm(s.stop)
+	}
+
+	// Record the outcome and gas cost of submitted snipes once they are mined
+	if s.config.ConfirmInterval > 0 {
+		go s.runConfirmer(s.stop)
+	}
+
+	// Sell landed snipes that reach their take-profit or stop-loss
+	if s.config.Enabled(config.FeatureAutoSell) && s.config.AutoSellInterval > 0 {
+		if !s.config.Enabled(config.FeaturePriceMonitor) || s.config.PricePollInterval <= 0 {
+			log.Printf("⚠️ The price monitor is off, positions are never priced so take-profit and stop-loss won't trigger")
+		}
+		go s.runAutoSell(s.stop)
+	}
+}
+
+// routes registers the API's endpoints
+func (s *Service) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Add the LP_ADD notification endpoint
-	mux.HandleFunc("/api/lp-add", s.handleLPAddNotification)
+	mux.HandleFunc("/api/lp-add", s.requireAuth(s.handleLPAddNotification))
+
+	// Manually trigger a bundle as if an LP_ADD notification arrived
+	mux.HandleFunc("/api/trigger", s.requireAdmin(s.handleTrigger))
+
+	// Debug endpoint comparing on-chain snipe ordering with the intended bribe ordering
+	mux.HandleFunc("/api/debug/ordering", s.requireAdmin(s.handleOrderingReport))
+
+	// Gas and bribe cost of mined snipes per user or token
+	mux.HandleFunc("/api/costs", s.requireAuth(s.handleCostReport))
+
+	// Bribes of recently landed versus reverted snipes
+	mux.HandleFunc("/api/bribes", s.requireAuth(s.handleBribeReport))
+
+	// Encrypted backup of every wallet
+	mux.HandleFunc("/api/admin/export-wallets", s.requireAdmin(s.handleExportWallets))
+
+	// Pause or resume sniping at runtime
+	mux.HandleFunc("/api/pause", s.requireAdmin(s.handlePause))
+
+	// Raw transactions of a submitted bundle, for forensic replay
+	mux.HandleFunc("/api/admin/bundles", s.requireAdmin(s.handleBundleArchive))
+
+	// Import many snipes at once from JSON or CSV
+	mux.HandleFunc("/api/snipes/bulk", s.requireAdmin(s.handleBulkSnipes))
+
+	// Effective configuration, secrets left out
+	mux.HandleFunc("/api/admin/config", s.requireAdmin(s.handleConfig))
+
+	// Snipe funnel counts for Prometheus
+	mux.HandleFunc("/metrics", s.requireAuth(s.handleMetrics))
 
 	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	mux.HandleFunc("/health", s.handleHealth)
 
+	return mux
+}
+
+// Start starts the API service
+func (s *Service) Start() error {
 	// Get port from environment or use default
 	port := os.Getenv("API_HTTP_PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	// Status events don't need the node, so they are delivered while degraded too
+	if s.webhookEvents != nil {
+		go s.runWebhook(s.stop)
+	}
+
+	if s.isReady() {
+		s.startWorkers()
+	} else {
+		go s.runConnect(s.stop)
+	}
+
 	s.httpServer = &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: s.routes(),
 	}
 
 	log.Printf("🌐 Starting API HTTP server on port %s", port)
@@ -129,16 +239,14 @@ func (s *Service) Start() error {
 func (s *Service) handleLPAddNotification(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Check authentication
-	authHeader := r.Header.Get("Authorization")
-	expectedAuth := "Bearer " + s.apiKey
-	if authHeader != expectedAuth {
-		log.Printf("🚨 Unauthorized LP_ADD request from %s", r.RemoteAddr)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	// Without the contract no bundle can be built; the RPC proxy then
+	// forwards the LP_ADD itself
+	if !s.requireReady(w) {
+		log.Printf("⚠️ Rejecting LP_ADD notification while degraded")
 		return
 	}
 
@@ -146,24 +254,46 @@ func (s *Service) handleLPAddNotification(w http.ResponseWriter, r *http.Request
 	var notification LPAddNotification
 	if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
 		log.Printf("❌ Failed to parse LP_ADD notification: %v", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	// Log the received data
 	log.Printf("📨 LP_ADD Notification received:")
+	if notification.Trigger == string(config.TriggerCreatePair) {
+		log.Printf("   🧪 Trigger: createPair (speculative)")
+	}
 	log.Printf("   🎯 Token Address: %s", notification.TokenAddress)
 	log.Printf("   👤 Creator Address: %s", notification.CreatorAddress)
+	if common.HexToAddress(notification.CreatorAddress) == (common.Address{}) {
+		log.Printf("   ⚠️ No creator: bribes will be refunded to the snipers")
+	}
+	if notification.SenderAddress != "" || notification.RecipientAddress != "" {
+		log.Printf("   ✍️ Sender: %s, LP Recipient: %s", notification.SenderAddress, notification.RecipientAddress)
+	}
 	log.Printf("   📝 TX Call Data: %s", notification.TxCallData)
 	log.Printf("   🌐 From: %s", r.RemoteAddr)
 
-	// Process the LP_ADD notification and create bundle
-	go s.processLPAddAndCreateBundle(notification)
+	// The outbox delivers at least once, so the same launch can come again
+	message := "LP_ADD notification received and processing started"
+	hash := launchHash(notification)
+	switch s.launches.admit(common.HexToAddress(notification.TokenAddress), hash, notification.Sequence) {
+	case launchDuplicate:
+		log.Printf("🔁 LP_ADD %s for token %s was already notified, ignoring", hash.Hex(), notification.TokenAddress)
+		message = "Duplicate LP_ADD notification ignored"
+	case launchStale:
+		log.Printf("🔀 LP_ADD %s for token %s (sequence %d) arrived after a later one, submitting only this launch tx",
+			hash.Hex(), notification.TokenAddress, notification.Sequence)
+		go s.submitLaunchOnly(context.Background(), notification)
+		message = "Out-of-order LP_ADD notification submitted without snipes"
+	default:
+		go s.scheduleBundle(notification)
+	}
 
 	// Respond with success immediately
 	response := map[string]interface{}{
 		"status":  "success",
-		"message": "LP_ADD notification received and processing started",
+		"message": message,
 		"data": map[string]string{
 			"tokenAddress":   notification.TokenAddress,
 			"creatorAddress": notification.CreatorAddress,
@@ -175,77 +305,530 @@ func (s *Service) handleLPAddNotification(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleTrigger runs the LP_ADD bundle flow on demand for a token
+func (s *Service) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !s.requireReady(w) {
+		return
+	}
+
+	var req TriggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if !common.IsHexAddress(req.Token) || !common.IsHexAddress(req.Creator) {
+		writeError(w, http.StatusBadRequest, "Invalid token or creator address")
+		return
+	}
+
+	if _, err := hex.DecodeString(strings.TrimPrefix(req.TxCallData, "0x")); err != nil || req.TxCallData == "" {
+		writeError(w, http.StatusBadRequest, "Invalid txCallData")
+		return
+	}
+
+	notification := LPAddNotification{
+		TokenAddress:   req.Token,
+		CreatorAddress: req.Creator,
+		TxCallData:     req.TxCallData,
+	}
+
+	log.Printf("🛠️ Manual bundle trigger from %s for token %s (creator %s)", r.RemoteAddr, req.Token, req.Creator)
+
+	go s.scheduleBundle(notification)
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Manual bundle trigger accepted and processing started",
+		"data": map[string]string{
+			"tokenAddress":   notification.TokenAddress,
+			"creatorAddress": notification.CreatorAddress,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// PauseRequest represents the payload for pausing or resuming sniping
+type PauseRequest struct {
+	Paused bool `json:"paused"`
+}
+
+// handlePause serves GET /api/pause (current state) and POST /api/pause
+// {"paused": true|false}
+func (s *Service) handlePause(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req PauseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		if err := s.db.SetPaused(req.Paused); err != nil {
+			log.Printf("❌ Failed to set paused=%t: %v", req.Paused, err)
+			writeError(w, http.StatusInternalServerError, "Failed to update pause mode")
+			return
+		}
+		log.Printf("⏸️ Sniping paused=%t via API from %s", req.Paused, r.RemoteAddr)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	paused, err := s.db.IsPaused()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read pause mode")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"paused": paused})
+}
+
+// ExportWalletsRequest represents the payload for a wallet export
+type ExportWalletsRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+// handleExportWallets streams every wallet as a JSON array of V3 keystores
+// encrypted under the passphrase in the request
+func (s *Service) handleExportWallets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req ExportWalletsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if len(req.Passphrase) < wallet.MinExportPassphraseLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Passphrase must be at least %d characters", wallet.MinExportPassphraseLength))
+		return
+	}
+
+	log.Printf("🔐 Wallet export requested via API from %s", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=wallets.json")
+
+	// Headers are already sent once streaming starts, so a failure can only be logged
+	count, err := s.walletManager.ExportKeystores(w, req.Passphrase)
+	if err != nil {
+		log.Printf("❌ Wallet export to %s failed after %d wallets: %v", r.RemoteAddr, count, err)
+		return
+	}
+
+	log.Printf("🔐 Exported %d wallets via API to %s", count, r.RemoteAddr)
+}
+
+// handleHealth reports liveness, whether sniping is paused and the features
+// that are on
+func (s *Service) handleHealth(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{"status": "ok", "contract": s.isReady(), "features": s.config.Features.Active()}
+	if !s.isReady() {
+		response["status"] = "degraded"
+	}
+
+	if paused, err := s.db.IsPaused(); err != nil {
+		response["status"] = "degraded"
+		response["error"] = "failed to read pause mode"
+	} else {
+		response["paused"] = paused
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// isAuthorized checks the request's bearer token against the API key
+func (s *Service) isAuthorized(r *http.Request) bool {
+	return r.Header.Get("Authorization") == "Bearer "+s.apiKey
+}
+
+// requireAuth wraps an endpoint so it only runs for requests carrying the API
+// key
+func (s *Service) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAuthorized(r) {
+			log.Printf("🚨 Unauthorized request to %s from %s", r.URL.Path, r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// scheduleBundle runs processLPAddAndCreateBundle once the token's lock and a
+// bundle slot are free. Builds beyond MaxConcurrentBundles wait up to
+// BundleQueueTimeout and are then dropped, leaving their snipes pending; a
+// bundle that late would miss its block. The launch tx the proxy held back is
+// still submitted on its own.
+func (s *Service) scheduleBundle(notification LPAddNotification) {
+	// The token's lock is taken first, so a build waiting on it holds no slot
+	wait := s.config.BundleQueueTimeout
+	if s.config.TokenBuildPolicy == config.TokenBuildSkip {
+		wait = 0
+	}
+	unlock, ok := s.tokenLocks.acquire(common.HexToAddress(notification.TokenAddress), wait)
+	if !ok {
+		log.Printf("🔒 A bundle for token %s is already being built, submitting only this launch tx", notification.TokenAddress)
+		s.submitLaunchOnly(context.Background(), notification)
+		return
+	}
+	defer unlock()
+
+	select {
+	case s.bundleSlots <- struct{}{}:
+	default:
+		log.Printf("⚠️ %d bundle builds already running, queueing token %s", cap(s.bundleSlots), notification.TokenAddress)
+
+		timer := time.NewTimer(s.config.BundleQueueTimeout)
+		defer timer.Stop()
+
+		select {
+		case s.bundleSlots <- struct{}{}:
+		case <-timer.C:
+			log.Printf("❌ No bundle slot freed within %s, dropping bundle for token %s and submitting only the launch tx", s.config.BundleQueueTimeout, notification.TokenAddress)
+			s.submitLaunchOnly(context.Background(), notification)
+			return
+		}
+	}
+	defer func() { <-s.bundleSlots }()
+
+	s.processLPAddAndCreateBundle(notification)
+}
+
 // processLPAddAndCreateBundle processes the LP_ADD notification and creates a bundle
 func (s *Service) processLPAddAndCreateBundle(notification LPAddNotification) {
 	ctx := context.Background()
 
 	log.Printf("🔄 Processing LP_ADD for token %s", notification.TokenAddress)
 
+	// Snapshot the pool before the launch lands, for entry price analysis.
+	// The snapshot is stored with the ID of the bundle submitted, if any.
+	var bundleID string
+	bundleIDs := make(chan string, 1)
+	defer func() { bundleIDs <- bundleID }()
+	go s.recordLPEvent(notification, bundleIDs)
+
+	// While paused, snipes stay pending so they can still fire on a later LP_ADD.
+	// Pausing is the operators' kill switch, so an unknown mode counts as paused.
+	paused, err := s.db.IsPaused()
+	if err != nil {
+		log.Printf("❌ Failed to read pause mode, treating sniping as paused: %v", err)
+		paused = true
+	}
+	if paused {
+		log.Printf("⏸️ Sniping is paused, submitting only the launch tx for token %s", notification.TokenAddress)
+		bundleID = s.submitLaunchOnly(ctx, notification)
+		return
+	}
+
+	// Snipes behind a launch that can no longer land would revert with it
+	if left, ok := s.launchTimeLeft(ctx, notification); ok && left < s.config.LaunchDeadlineBuffer {
+		log.Printf("⌛ LP_ADD deadline for token %s is %s from the latest block, under the %s buffer; submitting only the launch tx",
+			notification.TokenAddress, left, s.config.LaunchDeadlineBuffer)
+		bundleID = s.submitLaunchOnly(ctx, notification)
+		return
+	}
+
 	// Get pending snipes for this token
 	snipes, err := s.db.GetSnipesByToken(notification.TokenAddress)
 	if err != nil {
-		log.Printf("❌ Failed to get snipes for token %s: %v", notification.TokenAddress, err)
+		log.Printf("❌ Failed to get snipes for token %s, submitting only the launch tx: %v", notification.TokenAddress, err)
+		bundleID = s.submitLaunchOnly(ctx, notification)
 		return
 	}
 
 	if len(snipes) == 0 {
-		log.Printf("ℹ️ No pending snipes found for token %s", notification.TokenAddress)
+		log.Printf("ℹ️ No pending snipes found for token %s, submitting only the launch tx", notification.TokenAddress)
+		bundleID = s.submitLaunchOnly(ctx, notification)
 		return
 	}
 
 	log.Printf("📊 Found %d pending snipes for token %s", len(snipes), notification.TokenAddress)
+	s.funnel.add(notification.TokenAddress, funnelMatched, len(snipes))
+	s.sendLaunchAlerts(notification.TokenAddress, snipes)
+
+	// A token that isn't a sane ERC20 would only waste every snipe's gas. The
+	// snipes stay pending in case a later LP_ADD names the right token.
+	if err := dex.CheckERC20(ctx, s.ethClient.Client, common.HexToAddress(notification.TokenAddress)); err != nil {
+		log.Printf("🚫 Token %s doesn't look like an ERC20 (%v), submitting only the launch tx", notification.TokenAddress, err)
+		bundleID = s.submitLaunchOnly(ctx, notification)
+		return
+	}
 
 	// Convert database snipes to bundle format
-	bundleBids, err := s.convertSnipesToBundleBids(snipes)
+	bundleBids, err := s.convertSnipesToBundleBids(ctx, snipes, notification)
 	if err != nil {
-		log.Printf("❌ Failed to convert snipes to bundle bids: %v", err)
+		log.Printf("❌ Failed to convert snipes to bundle bids, submitting only the launch tx: %v", err)
+		bundleID = s.submitLaunchOnly(ctx, notification)
 		return
 	}
+	s.funnel.add(notification.TokenAddress, funnelEligible, len(bundleBids))
 
-	// Sort bids by bribe amount (descending) - highest bribes first
-	sort.Slice(bundleBids, func(i, j int) bool {
-		return bundleBids[i].BribeAmount.Cmp(bundleBids[j].BribeAmount) > 0
-	})
+	// Sort bids by bribe amount (descending) - highest bribes first, equal
+	// bribes in the order they were placed
+	bundle.SortBids(bundleBids)
 
 	log.Printf("💰 Sorted %d snipes by bribe amount (highest first)", len(bundleBids))
 	for i, bid := range bundleBids {
-		bribeETH := new(big.Float).Quo(new(big.Float).SetInt(bid.BribeAmount), big.NewFloat(1e18))
-		log.Printf("   %d. Wallet %s: %s ETH bribe", i+1, bid.Wallet.Hex()[:10]+"...", bribeETH.Text('f', 4))
+		log.Printf("   %d. Wallet %s: %s bribe", i+1, bid.Wallet.Hex()[:10]+"...", eth.FormatEther(bid.BribeAmount))
 	}
 
+	// A bundle over the sequencer's gas limit could never be included, and
+	// MAX_BUNDLE_SNIPES may cap it further, so the snipes that don't fit are
+	// cut as BUNDLE_SELECTION picks. Each snipe is signed with its own gas
+	// estimate, at most SNIPE_GAS_LIMIT.
+	var launchGas uint64
+	if launchTx, err := decodeRawTx(notification.TxCallData); err == nil {
+		launchGas = launchTx.Gas()
+	}
+	s.bundleManager.EstimateSnipeGas(ctx, common.HexToAddress(notification.TokenAddress), common.HexToAddress(notification.CreatorAddress), bundleBids)
+	var extraGas uint64
+	if s.config.Commission.Enabled() {
+		extraGas = commissionGasLimit
+	}
+
+	var dropped []*bundle.SnipeBid
+	var totalGas uint64
+	bundleBids, dropped, totalGas = s.bundleManager.SelectBids(launchGas, extraGas, bundleBids)
+	s.funnel.add(notification.TokenAddress, funnelSelected, len(bundleBids))
+	log.Printf("⛽ Bundle gas: %d of max %d", totalGas, s.config.Gas.MaxBundleGas)
+	if len(dropped) > 0 {
+		log.Printf("✂️ Dropping %d snipes that don't fit (%s selection), highest dropped bribe %s",
+			len(dropped), s.config.BundleSelection, eth.FormatEther(dropped[0].BribeAmount))
+	}
 	// Create bundle transactions
-	bundleTxs, err := s.createBundleTransactions(ctx, bundleBids, notification)
+	bundleTxs, bundleBids, err := s.createBundleTransactions(ctx, bundleBids, notification)
 	if err != nil {
-		log.Printf("❌ Failed to create bundle transactions: %v", err)
+		log.Printf("❌ Failed to create bundle transactions, submitting only the launch tx: %v", err)
+		bundleID = s.submitLaunchOnly(ctx, notification)
 		return
 	}
+	s.funnel.add(notification.TokenAddress, funnelFunded, len(bundleBids))
+
+	// With simulate_snipes on, snipes that revert behind the launch are
+	// dropped before anything is submitted. A bundle that can't be simulated
+	// at all isn't trusted: its snipes stay pending and the launch goes alone.
+	var simulated *bundleSimulation
+	if s.config.Enabled(config.FeatureSimulateSnipes) && len(bundleBids) > 0 {
+		simulated, err = s.simulateBundle(ctx, notification, bundleTxs, bundleBids)
+		if err != nil {
+			log.Printf("❌ Failed to simulate the bundle for token %s, submitting only the launch tx: %v", notification.TokenAddress, err)
+			s.releaseNonces(bundleTxs)
+			bundleID = s.submitLaunchOnly(ctx, notification)
+			return
+		}
+		bundleTxs, bundleBids = simulated.transactions, simulated.bids
+	}
 
-	log.Printf("📦 Created bundle with %d transactions (1 LP_ADD + %d snipes)", len(bundleTxs)+1, len(bundleBids))
+	// The snipes are claimed before anything is sent, so that if the outcome
+	// can't be recorded afterwards they don't stay pending to fire again
+	bundleTxs, bundleBids, err = s.claimBundle(notification, bundleTxs, bundleBids)
+	if err != nil {
+		log.Printf("❌ Failed to claim the snipes for token %s, submitting only the launch tx: %v", notification.TokenAddress, err)
+		s.releaseNonces(bundleTxs)
+		bundleID = s.submitLaunchOnly(ctx, notification)
+		return
+	}
 
 	// Submit bundle to Base sequencer
-	s.submitBundle(ctx, notification.TxCallData, bundleTxs)
+	bundleID, accepted := s.submitBundle(ctx, notification.TokenAddress, notification.TxCallData, bundleTxs)
+
+	// Record each snipe's tx hash and intended position for ordering analysis
+	// and mark the bundle's snipes submitted, or submit_failed if every
+	// endpoint rejected them, and the cut ones dropped, all in one
+	// transaction. Snipes skipped while signing stay pending. Commission
+	// transfers follow the snipes and are not recorded.
+	submitted := bundleSubmissions(bundleTxs, bundleBids)
+	acceptedCount := 0
+	for i := range submitted {
+		submitted[i].Accepted = accepted[i]
+		if accepted[i] {
+			acceptedCount++
+		}
+	}
+	s.funnel.add(notification.TokenAddress, funnelAccepted, acceptedCount)
+	droppedIDs := make([]int64, 0, len(dropped))
+	for _, bid := range dropped {
+		droppedIDs = append(droppedIDs, bid.SnipeID)
+	}
+	var simulations []db.SnipeSimulation
+	if simulated != nil {
+		for _, bid := range simulated.failed {
+			droppedIDs = append(droppedIDs, bid.SnipeID)
+		}
+		simulations = simulated.records
+	}
+	if err := s.db.RecordBundle(bundleID, submitted, droppedIDs, simulations); err != nil {
+		log.Printf("❌ Failed to record bundle %s for token %s, its snipes stay %s until their receipts are recorded: %v",
+			bundleID, notification.TokenAddress, db.SnipeStatusSubmitting, err)
+		return
+	}
+	for i, bid := range bundleBids {
+		status := db.SnipeStatusSubmitted
+		if !accepted[i] {
+			status = db.SnipeStatusSubmitFailed
+		}
+		s.notifyStatus(bidStatusEvent(bid, bundleID, status, submitted[i].TxHash))
+	}
+	for _, bid := range dropped {
+		s.notifyStatus(bidStatusEvent(bid, bundleID, db.SnipeStatusDropped, ""))
+	}
+	if simulated != nil {
+		for _, bid := range simulated.failed {
+			event := bidStatusEvent(bid, bundleID, db.SnipeStatusDropped, "")
+			event.SimulationError = simulated.reasons[bid.SnipeID]
+			s.notifyStatus(event)
+		}
+	}
 
-	// Update snipe statuses to 'submitted'
-	for _, snipe := range snipes {
-		if err := s.db.UpdateSnipeStatus(snipe.ID, "submitted"); err != nil {
-			log.Printf("⚠️ Failed to update snipe status for ID %d: %v", snipe.ID, err)
+	// Snipes behind an LP_ADD that never lands would only revert
+	if s.config.LaunchLandTimeout > 0 {
+		go s.watchLaunch(bundleID, notification.TxCallData, bundleTxs, bundleBids)
+	}
+
+	// An endpoint can accept a transaction and still lose it
+	if s.config.SubmitVerifyDelay > 0 && acceptedCount > 0 {
+		go s.verifySubmission(bundleID, bundleTxs, bundleBids, accepted)
+	}
+
+	if acceptedCount < len(bundleBids) {
+		log.Printf("⚠️ Bundle %s for token %s: %d of %d snipes rejected by every endpoint, marked %s",
+			bundleID, notification.TokenAddress, len(bundleBids)-acceptedCount, len(bundleBids), db.SnipeStatusSubmitFailed)
+	}
+	log.Printf("✅ Bundle %s submitted for token %s with %d of %d snipes accepted", bundleID, notification.TokenAddress, acceptedCount, len(bundleBids))
+}
+
+// claimBundle marks the snipes of a signed bundle submitting, under the ID
+// submitBundle will give the bundle. Snipes that are no longer pending, having
+// been cancelled while the bundle was built, are dropped from it first, along
+// with the later snipes of their wallets, which stay pending. It returns the
+// bundle left. On error nothing is claimed, and the transactions returned are
+// those whose nonces are still held.
+func (s *Service) claimBundle(notification LPAddNotification, transactions []*types.Transaction, bids []*bundle.SnipeBid) ([]*types.Transaction, []*bundle.SnipeBid, error) {
+	token := common.HexToAddress(notification.TokenAddress)
+	launchHash := common.HexToHash(rawTxHash(notification.TxCallData))
+	for {
+		unclaimed, err := s.db.ClaimBundle(bundle.ID(token, launchHash, transactions), bundleSubmissions(transactions, bids))
+		if err != nil || len(unclaimed) == 0 {
+			return transactions, bids, err
 		}
+
+		gone := make(map[int64]bool, len(unclaimed))
+		for _, id := range unclaimed {
+			gone[id] = true
+		}
+		kept, keptBids, dropped, err := s.dropSnipes(transactions, bids, func(bid *bundle.SnipeBid) bool { return gone[bid.SnipeID] })
+		if err != nil {
+			return transactions, bids, err
+		}
+		transactions, bids = kept, keptBids
+		log.Printf("🚮 %d snipes for token %s are no longer pending, leaving them and %d later snipes of their wallets out of the bundle",
+			len(unclaimed), notification.TokenAddress, len(dropped)-len(unclaimed))
+	}
+}
+
+// bundleSubmissions returns the snipes of a bundle, one per bid, as recorded
+// with it. Commission transfers follow the snipes and are not recorded.
+func bundleSubmissions(transactions []*types.Transaction, bids []*bundle.SnipeBid) []db.SnipeSubmission {
+	submitted := make([]db.SnipeSubmission, 0, len(bids))
+	for i, bid := range bids {
+		submitted = append(submitted, db.SnipeSubmission{
+			SnipeID:  bid.SnipeID,
+			TxHash:   transactions[i].Hash().Hex(),
+			Position: i,
+			SwapWei:  bid.SwapAmount,
+		})
+	}
+	return submitted
+}
+
+// launchTimeLeft returns how far the addLiquidityETH deadline of the launch
+// is past the latest block, negative once it has passed. ok is false when
+// there is no usable deadline: a createPair launch, calldata that doesn't
+// decode, a deadline too far out to matter or an unreachable node.
+func (s *Service) launchTimeLeft(ctx context.Context, notification LPAddNotification) (left time.Duration, ok bool) {
+	if notification.Trigger == string(config.TriggerCreatePair) {
+		return 0, false
+	}
+
+	launchTx, err := decodeRawTx(notification.TxCallData)
+	if err != nil {
+		return 0, false
+	}
+	args, err := dex.DecodeAddLiquidityETH(launchTx.Data())
+	if err != nil || !args.Deadline.IsInt64() {
+		return 0, false
+	}
+
+	head, err := s.ethClient.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		log.Printf("⚠️ Failed to get latest block, not checking the LP_ADD deadline: %v", err)
+		return 0, false
 	}
 
-	log.Printf("✅ Bundle submitted successfully for token %s with %d snipes", notification.TokenAddress, len(snipes))
+	// Deadlines days out (often the max uint256) could overflow a Duration
+	secondsLeft := args.Deadline.Int64() - int64(head.Time)
+	if secondsLeft > int64(24*time.Hour/time.Second) {
+		return 0, false
+	}
+	return time.Duration(secondsLeft) * time.Second, true
 }
 
 // convertSnipesToBundleBids converts database snipes to bundle bid format
-func (s *Service) convertSnipesToBundleBids(snipes []*db.Snipe) ([]*bundle.SnipeBid, error) {
+func (s *Service) convertSnipesToBundleBids(ctx context.Context, snipes []*db.Snipe, notification LPAddNotification) ([]*bundle.SnipeBid, error) {
 	var bundleBids []*bundle.SnipeBid
 
+	// Pool liquidity is only looked up if a snipe is sized as a percent of it
+	var poolETH *big.Int
+
 	for _, snipe := range snipes {
+		// Snipes created before a token was blocked must not reach the bundle
+		if name, blocked := s.config.BlockedSnipeTarget(snipe.TokenAddress); blocked {
+			log.Printf("⚠️ Snipe %d targets %s, skipping", snipe.ID, name)
+			continue
+		}
+
 		// Parse amounts
-		swapAmount, err := s.parseETHAmount(snipe.Amount)
+		var swapAmount *big.Int
+		var err error
+		if snipe.AmountMode == db.AmountModePoolPercent {
+			// A freshly created pair has no liquidity to take a percentage of
+			if notification.Trigger == string(config.TriggerCreatePair) {
+				log.Printf("⚠️ Snipe %d is sized by pool percent, which can't be resolved on createPair, skipping", snipe.ID)
+				continue
+			}
+			if poolETH == nil {
+				if poolETH, err = s.expectedPoolETH(ctx, notification); err != nil {
+					log.Printf("⚠️ Failed to read pool liquidity for token %s: %v", notification.TokenAddress, err)
+					poolETH = big.NewInt(0)
+				}
+			}
+			swapAmount, err = poolPercentAmount(poolETH, snipe.Amount)
+		} else {
+			swapAmount, err = s.parseETHAmount(snipe.Amount)
+		}
 		if err != nil {
 			log.Printf("⚠️ Failed to parse swap amount for snipe %d: %v", snipe.ID, err)
 			continue
 		}
+		if swapAmount.Sign() <= 0 {
+			log.Printf("⚠️ Snipe %d resolved to a zero swap amount, skipping", snipe.ID)
+			continue
+		}
 
 		bribeAmount, err := s.parseETHAmount(snipe.BribeAmount)
 		if err != nil {
@@ -253,6 +836,12 @@ func (s *Service) convertSnipesToBundleBids(snipes []*db.Snipe) ([]*bundle.Snipe
 			continue
 		}
 
+		createdAt, err := db.ParseTimestamp(snipe.CreatedAt)
+		if err != nil {
+			log.Printf("⚠️ Failed to parse creation time of snipe %d: %v", snipe.ID, err)
+			continue
+		}
+
 		// Get wallet private key
 		wallet, err := s.walletManager.GetWallet(snipe.UserID)
 		if err != nil {
@@ -260,14 +849,19 @@ func (s *Service) convertSnipesToBundleBids(snipes []*db.Snipe) ([]*bundle.Snipe
 			continue
 		}
 
+		keyBytes := crypto.FromECDSA(wallet.PrivateKey)
 		bundleBid := &bundle.SnipeBid{
+			SnipeID:      snipe.ID,
 			UserID:       snipe.UserID,
 			TokenAddress: common.HexToAddress(snipe.TokenAddress),
 			SwapAmount:   swapAmount,
 			BribeAmount:  bribeAmount,
 			Wallet:       wallet.Address,
-			PrivateKey:   hex.EncodeToString(crypto.FromECDSA(wallet.PrivateKey)),
+			PrivateKey:   hex.EncodeToString(keyBytes),
+			CreatedAt:    createdAt,
+			Slippage:     snipe.Slippage.Float64,
 		}
+		zeroBytes(keyBytes)
 
 		bundleBids = append(bundleBids, bundleBid)
 	}
@@ -275,53 +869,140 @@ func (s *Service) convertSnipesToBundleBids(snipes []*db.Snipe) ([]*bundle.Snipe
 	return bundleBids, nil
 }
 
-// parseETHAmount parses ETH amount string to wei
-func (s *Service) parseETHAmount(amountStr string) (*big.Int, error) {
-	amount, err := strconv.ParseFloat(amountStr, 64)
+// expectedPoolETH returns the pool's ETH liquidity once the LP_ADD lands: the
+// pair's current WETH reserve (zero for a new pair) plus the ETH sent with the
+// addLiquidityETH transaction
+func (s *Service) expectedPoolETH(ctx context.Context, notification LPAddNotification) (*big.Int, error) {
+	txData, err := hex.DecodeString(strings.TrimPrefix(notification.TxCallData, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode LP_ADD transaction: %v", err)
+	}
+
+	lpAddTx := new(types.Transaction)
+	if err := lpAddTx.UnmarshalBinary(txData); err != nil {
+		return nil, fmt.Errorf("failed to parse LP_ADD transaction: %v", err)
+	}
+
+	poolETH := new(big.Int).Set(lpAddTx.Value())
+
+	if s.config.UniswapV2Factory != "" {
+		reserve, err := dex.PoolWETHReserve(ctx, s.ethClient.Client,
+			common.HexToAddress(s.config.UniswapV2Factory), common.HexToAddress(notification.TokenAddress))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pool reserves: %v", err)
+		}
+		poolETH.Add(poolETH, reserve)
+	}
+
+	return poolETH, nil
+}
+
+// poolPercentAmount returns percent% of the pool's ETH liquidity in wei
+func poolPercentAmount(poolETH *big.Int, percent string) (*big.Int, error) {
+	// Scale the percentage to an integer with 6 decimals to avoid float math
+	scaled, err := eth.ParseUnits(percent, 6)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to wei
-	weiFloat := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(1e18))
-	wei, _ := weiFloat.Int(nil)
+	amount := new(big.Int).Mul(poolETH, scaled)
+	return amount.Div(amount, big.NewInt(100*1e6)), nil
+}
+
+// parseETHAmount parses ETH amount string to wei
+func (s *Service) parseETHAmount(amountStr string) (*big.Int, error) {
+	return eth.ParseEther(amountStr)
+}
+
+// feeLadderTop returns the max fee of the first of n snipes given the desired
+// fee, the minimum any snipe may pay and the MAX_FEE_PER_GAS cap. Each
+// following snipe pays 1 wei less, so the top is raised when needed to keep
+// the whole ladder at or above minFee: clamping the tail instead would give it
+// identical fees and lose the bribe ordering. The top never goes past the cap,
+// unless minFee alone does; a ladder that doesn't fit under the cap has its
+// tail clamped by feeLadderRung.
+func feeLadderTop(desired, minFee, maxFee *big.Int, n int) *big.Int {
+	if n < 1 {
+		n = 1
+	}
+	top := new(big.Int).Add(minFee, big.NewInt(int64(n-1)))
+	if desired.Cmp(top) > 0 {
+		top.Set(desired)
+	}
+	if top.Cmp(maxFee) > 0 {
+		top.Set(maxFee)
+	}
+	if top.Cmp(minFee) < 0 {
+		top.Set(minFee)
+	}
+	return top
+}
 
-	return wei, nil
+// feeLadderRung returns the max fee of snipe i of a ladder starting at top,
+// 1 wei less per snipe and never below minFee
+func feeLadderRung(top, minFee *big.Int, i int) *big.Int {
+	fee := new(big.Int).Sub(top, big.NewInt(int64(i)))
+	if fee.Cmp(minFee) < 0 {
+		fee.Set(minFee)
+	}
+	return fee
 }
 
-// createBundleTransactions creates the bundle transactions with proper gas pricing
-func (s *Service) createBundleTransactions(ctx context.Context, bids []*bundle.SnipeBid, notification LPAddNotification) ([]*types.Transaction, error) {
+// commissionGasLimit is the gas of a plain ETH transfer to the commission treasury
+const commissionGasLimit = 21000
+
+// createBundleTransactions creates the bundle transactions with proper gas pricing.
+// It also returns the bids that made it into the bundle, in transaction order.
+// When a commission is configured, each snipe's commission transfer is appended
+// after all the snipes, so the first len(included) transactions are the snipes.
+func (s *Service) createBundleTransactions(ctx context.Context, bids []*bundle.SnipeBid, notification LPAddNotification) ([]*types.Transaction, []*bundle.SnipeBid, error) {
 	var transactions []*types.Transaction
+	var commissionTxs []*types.Transaction
+	var included []*bundle.SnipeBid
 
-	// Get base fee for EIP-1559 transactions
+	// The head block sets the swap deadline
 	latestBlock, err := s.ethClient.Client.HeaderByNumber(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest block: %v", err)
+		return nil, nil, fmt.Errorf("failed to get latest block: %v", err)
 	}
 
-	baseFee := latestBlock.BaseFee
-	if baseFee == nil {
-		// Fallback to legacy gas price if base fee not available
-		legacyGasPrice, err := s.ethClient.Client.SuggestGasPrice(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get gas price: %v", err)
-		}
-		baseFee = legacyGasPrice
+	// Base fee (on legacy chains, the gas price before the tip), from the
+	// configured GAS_PRICE_SOURCE
+	baseFee, err := s.bundleManager.GasPrice(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	gas := s.config.Gas
+	commission := s.config.Commission
+	treasury := common.HexToAddress(commission.Treasury)
+
+	// Snipes are signed as TX_TYPE selects for the chain; legacy transactions
+	// pay the ladder's max fee as their gas price
+	txs := s.bundleManager.Txs()
+
 	// Set initial max priority fee per gas (tip to miners/validators)
-	maxPriorityFeePerGas := big.NewInt(2000000) // 2 gwei tip
+	maxPriorityFeePerGas := s.bundleManager.PriorityFee(ctx)
+
+	// With competitor_bump on, competing snipes seen pending raise our tips
+	bump := s.competitorBump(ctx, bids, notification, maxPriorityFeePerGas)
+	bumped := 0
 
 	// Calculate initial max fee per gas = base fee + priority fee + buffer
-	buffer := big.NewInt(1000000) // 1 gwei buffer
 	initialMaxFeePerGas := new(big.Int).Add(baseFee, maxPriorityFeePerGas)
-	initialMaxFeePerGas.Add(initialMaxFeePerGas, buffer)
+	initialMaxFeePerGas.Add(initialMaxFeePerGas, gas.FeeBuffer)
+
+	// Cap max fee at reasonable level for Base network
+	if initialMaxFeePerGas.Cmp(gas.MaxFee) > 0 {
+		initialMaxFeePerGas = new(big.Int).Set(gas.MaxFee)
+	}
 
-	// Cap max fee at reasonable level for Base network (20 gwei)
-	maxGasPriceGwei := big.NewInt(20)
-	maxGasPrice := new(big.Int).Mul(maxGasPriceGwei, big.NewInt(1e9))
-	if initialMaxFeePerGas.Cmp(maxGasPrice) > 0 {
-		initialMaxFeePerGas = maxGasPrice
+	// Every snipe must pay at least base fee + priority fee
+	minMaxFee := new(big.Int).Add(baseFee, maxPriorityFeePerGas)
+	initialMaxFeePerGas = feeLadderTop(initialMaxFeePerGas, minMaxFee, gas.MaxFee, len(bids))
+	if rungs := new(big.Int).Sub(initialMaxFeePerGas, minMaxFee); len(bids) > 1 && rungs.Cmp(big.NewInt(int64(len(bids)-1))) < 0 {
+		log.Printf("⚠️ Base fee + priority fee is within %s wei of the %s wei max fee, the last %d of %d snipes share the lowest fee",
+			rungs, gas.MaxFee, len(bids)-int(rungs.Int64()), len(bids))
 	}
 
 	// Debug gas price information
@@ -329,38 +1010,105 @@ func (s *Service) createBundleTransactions(ctx context.Context, bids []*bundle.S
 	maxFeeGwei := new(big.Float).Quo(new(big.Float).SetInt(initialMaxFeePerGas), big.NewFloat(1e9))
 	priorityFeeGwei := new(big.Float).Quo(new(big.Float).SetInt(maxPriorityFeePerGas), big.NewFloat(1e9))
 
-	fmt.Printf("💰 EIP-1559 Gas Price Debug:\n")
+	fmt.Printf("💰 %s Gas Price Debug:\n", txs.Type())
 	fmt.Printf("   Base Fee: %s wei (%s gwei)\n", baseFee.String(), baseFeeGwei.Text('f', 2))
 	fmt.Printf("   Initial Max Fee: %s wei (%s gwei)\n", initialMaxFeePerGas.String(), maxFeeGwei.Text('f', 2))
 	fmt.Printf("   Priority Fee: %s wei (%s gwei)\n", maxPriorityFeePerGas.String(), priorityFeeGwei.Text('f', 2))
 
+	// All snipes target the block after the LP_ADD, so they share one deadline
+	deadline := s.bundleManager.SwapDeadline(latestBlock)
+
+	// Wallets the pre-warm cache misses are fetched together up front rather
+	// than one by one in the loop, which also lets their balances be checked
+	wallets := make([]common.Address, 0, len(bids))
+	for _, bid := range bids {
+		wallets = append(wallets, bid.Wallet)
+	}
+	if err := s.fetchWalletStates(ctx, s.walletCache.missing(wallets)); err != nil {
+		log.Printf("⚠️ Failed to batch-fetch sniper wallet state, falling back to per-wallet nonces: %v", err)
+	}
+
+	// The nonces of snipes left out, or of a bundle that fails to build, are
+	// given back
+	built := false
+	var leases []nonceLease
+	defer func() {
+		if !built {
+			for _, lease := range leases {
+				s.nonces.release(lease)
+			}
+		}
+	}()
+
+	// Each wallet's key is decoded once per bundle and zeroed after it
+	keys := newKeyCache()
+	defer keys.clear()
+
+	// Snipes with a max slippage get an amountOutMin quoted against the pool
+	// as the LP_ADD leaves it; the rest accept any output
+	var pool *launchPool
+	for _, bid := range bids {
+		if s.bundleSlippage(bid.Slippage) > 0 {
+			if pool, err = s.expectedLaunchPool(ctx, notification); err != nil {
+				log.Printf("⚠️ Can't derive amountOutMin for token %s, accepting any output: %v", notification.TokenAddress, err)
+			}
+			break
+		}
+	}
+
 	// Create snipe transactions with decreasing max fee per gas (sorted by bribe size)
 	for i, bid := range bids {
 		// Calculate max fee per gas: each subsequent tx has maxFeePerGas = previous - 1 wei
 		// This ensures strict ordering based on bribe size for Base sequencer
-		maxFeePerGas := new(big.Int).Sub(initialMaxFeePerGas, big.NewInt(int64(i)))
+		// (the ladder top leaves room for every step above the minimum, unless
+		// the max fee cap is in the way)
+		maxFeePerGas := feeLadderRung(initialMaxFeePerGas, minMaxFee, len(transactions))
 
-		// Ensure minimum fee (at least base fee + priority fee)
-		minMaxFee := new(big.Int).Add(baseFee, maxPriorityFeePerGas)
-		if maxFeePerGas.Cmp(minMaxFee) < 0 {
-			maxFeePerGas = minMaxFee
-		}
+		// In tip mode the bribe is paid as priority fee on top of the ladder
+		// rather than to the creator through the contract
+		snipeGas := s.bundleManager.SnipeGas(bid)
+		contractBribe, tipPerGas := s.bundleManager.BribeSplit(bid.BribeAmount, snipeGas)
+		gasTipCap := new(big.Int).Add(maxPriorityFeePerGas, tipPerGas)
+		maxFeePerGas.Add(maxFeePerGas, tipPerGas)
 
 		// Debug gas price for this transaction
 		maxFeeGwei := new(big.Float).Quo(new(big.Float).SetInt(maxFeePerGas), big.NewFloat(1e9))
-		bribeETH := new(big.Float).Quo(new(big.Float).SetInt(bid.BribeAmount), big.NewFloat(1e18))
-		fmt.Printf("   Tx %d (Bribe: %s ETH) Max Fee: %s gwei\n", i+1, bribeETH.Text('f', 4), maxFeeGwei.Text('f', 2))
+		fmt.Printf("   Tx %d (Bribe: %s) Max Fee: %s gwei\n", i+1, eth.FormatEther(bid.BribeAmount), maxFeeGwei.Text('f', 2))
 
-		// Get nonce for the sniper
-		nonce, err := s.ethClient.Client.PendingNonceAt(ctx, bid.Wallet)
+		// Get nonce (and balance, when pre-warmed) for the sniper
+		state, err := s.walletStateFor(ctx, bid.Wallet)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get nonce for sniper %s: %v", bid.Wallet.Hex(), err)
+			return nil, nil, fmt.Errorf("failed to get nonce for sniper %s: %v", bid.Wallet.Hex(), err)
 		}
 
-		// Extract creator address from notification
+		// Builds for other tokens may hold the wallet's next nonces already;
+		// a commission transfer takes the one after the snipe's
+		commissionAmount := commission.Amount(bid.SwapAmount)
+		nonceCount := uint64(1)
+		if commissionAmount.Sign() > 0 {
+			nonceCount = 2
+		}
+		lease := s.nonces.reserve(bid.Wallet, state.Nonce, nonceCount)
+		leases = append(leases, lease)
+		nonce := lease.start
+
+		// Extract creator address from notification. Without one (the launch
+		// signer couldn't be recovered) the bribe is refunded to the sniper, which
+		// keeps the contract call valid; ordering still follows the fee ladder.
 		creatorAddr := common.HexToAddress(notification.CreatorAddress)
-		deadline := big.NewInt(time.Now().Add(5 * time.Minute).Unix())
+		if creatorAddr == (common.Address{}) {
+			creatorAddr = bid.Wallet
+		}
 		amountOutMin := big.NewInt(1) // Minimum 1 wei of tokens (unlimited slippage)
+		var expectedOut *big.Int
+		if pool != nil {
+			expectedOut = pool.amountOut(bid.SwapAmount)
+			if slippage := s.bundleSlippage(bid.Slippage); slippage > 0 {
+				amountOutMin = minAmountOut(expectedOut, slippage)
+				fmt.Printf("   Tx %d expects %s tokens, amountOutMin %s (%.2f%% slippage)\n",
+					i+1, expectedOut.String(), amountOutMin.String(), slippage)
+			}
+		}
 
 		// Get sniper contract from bundle manager
 		sniperContract := s.bundleManager.GetSniperContract()
@@ -372,160 +1120,213 @@ func (s *Service) createBundleTransactions(ctx context.Context, bids []*bundle.S
 			bid.TokenAddress,
 			creatorAddr,
 			bid.SwapAmount,
-			bid.BribeAmount,
+			contractBribe,
 			amountOutMin,
 			deadline,
 			maxFeePerGas, // Pass maxFeePerGas instead of legacy gasPrice
 			nonce,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create snipe transaction for %s: %v", bid.Wallet.Hex(), err)
+			return nil, nil, fmt.Errorf("failed to create snipe transaction for %s: %v", bid.Wallet.Hex(), err)
 		}
 
-		// Create EIP-1559 transaction (v2)
-		dynamicTx := &types.DynamicFeeTx{
-			ChainID:   big.NewInt(8453), // Base mainnet chain ID
-			Nonce:     nonce,
-			GasTipCap: maxPriorityFeePerGas,
-			GasFeeCap: maxFeePerGas,
-			Gas:       snipeTx.Gas(),
-			To:        snipeTx.To(),
-			Value:     snipeTx.Value(),
-			Data:      snipeTx.Data(),
+		// The commission transfer uses the next nonce and the floor of the ladder
+		requiredValue := new(big.Int).Set(snipeTx.Value())
+		requiredGas := snipeGas
+		if commissionAmount.Sign() > 0 {
+			requiredValue.Add(requiredValue, commissionAmount)
+			requiredGas += commissionGasLimit
 		}
 
-		// Convert to Transaction type
-		eip1559Tx := types.NewTx(dynamicTx)
-
-		// Sign the transaction with the user's private key
-		privateKeyHex := bid.PrivateKey
-		if privateKeyHex == "" {
-			return nil, fmt.Errorf("private key not found for wallet %s", bid.Wallet.Hex())
+		// The bump is applied from the top of the bundle down, for as long as
+		// each snipe's owner allows it and its wallet can pay; stopping at the
+		// first that can't keeps the bundle in bribe order
+		if bump != nil {
+			bumpedFee := new(big.Int).Add(maxFeePerGas, bump.perGas)
+			if bump.allows(bid) && state.coversTransaction(requiredValue, requiredGas, bumpedFee) {
+				gasTipCap.Add(gasTipCap, bump.perGas)
+				maxFeePerGas = bumpedFee
+				bumped++
+			} else {
+				bump = nil
+			}
 		}
 
-		// Remove 0x prefix if present
-		if strings.HasPrefix(privateKeyHex, "0x") {
-			privateKeyHex = privateKeyHex[2:]
+		// A snipe the wallet can't pay for would only revert, so leave it out
+		if !state.coversTransaction(requiredValue, requiredGas, maxFeePerGas) {
+			log.Printf("⚠️ Skipping snipe %d: wallet %s balance %s can't cover %s plus gas",
+				bid.SnipeID, bid.Wallet.Hex(), eth.FormatEther(state.Balance), eth.FormatEther(requiredValue))
+			s.nonces.release(lease)
+			leases = leases[:len(leases)-1]
+			continue
 		}
 
-		privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+		// Sign the transaction with the user's private key
+		privateKey, err := keys.get(bid)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode private key for %s: %v", bid.Wallet.Hex(), err)
+			return nil, nil, err
 		}
 
-		privateKey, err := crypto.ToECDSA(privateKeyBytes)
+		signedTx, err := txs.Sign(privateKey, eth.TxParams{
+			Nonce:     nonce,
+			GasTipCap: gasTipCap,
+			GasFeeCap: maxFeePerGas,
+			Gas:       snipeGas,
+			To:        snipeTx.To(),
+			Value:     snipeTx.Value(),
+			Data:      snipeTx.Data(),
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse private key for %s: %v", bid.Wallet.Hex(), err)
+			return nil, nil, fmt.Errorf("failed to sign %s transaction for %s: %v", txs.Type(), bid.Wallet.Hex(), err)
 		}
 
-		// Get chain ID for signing
-		chainID, err := s.ethClient.Client.ChainID(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get chain ID: %v", err)
+		log.Printf("✅ %s transaction signed for wallet %s (Bribe: %s)",
+			txs.Type(),
+			bid.Wallet.Hex()[:10]+"...",
+			eth.FormatEther(bid.BribeAmount))
+
+		if commissionAmount.Sign() > 0 {
+			commissionTx, err := txs.Sign(privateKey, eth.TxParams{
+				Nonce:     nonce + 1,
+				GasTipCap: maxPriorityFeePerGas,
+				GasFeeCap: minMaxFee,
+				Gas:       commissionGasLimit,
+				To:        &treasury,
+				Value:     commissionAmount,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to sign commission transaction for %s: %v", bid.Wallet.Hex(), err)
+			}
+			commissionTxs = append(commissionTxs, commissionTx)
 		}
 
-		// Sign EIP-1559 transaction with London signer
-		signedTx, err := types.SignTx(eip1559Tx, types.NewLondonSigner(chainID), privateKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to sign EIP-1559 transaction for %s: %v", bid.Wallet.Hex(), err)
-		}
+		transactions = append(transactions, signedTx)
+		included = append(included, bid)
 
-		log.Printf("✅ EIP-1559 transaction signed for wallet %s (Bribe: %s ETH)",
-			bid.Wallet.Hex()[:10]+"...",
-			bribeETH.Text('f', 4))
+		// The snipes behind this one buy at the price it leaves
+		if pool != nil {
+			pool.swap(bid.SwapAmount, expectedOut)
+		}
+	}
 
-		transactions = append(transactions, signedTx)
+	log.Printf("📦 Created %d %s transactions sorted by bribe size (highest to lowest)", len(transactions), txs.Type())
+	if bumped > 0 {
+		log.Printf("🚀 Bumped the tips of the top %d snipes over competing snipes", bumped)
 	}
+	if len(commissionTxs) > 0 {
+		log.Printf("🏦 Appending %d commission transfers to %s", len(commissionTxs), commission.Treasury)
+	}
+	built = true
+	return append(transactions, commissionTxs...), included, nil
+}
 
-	log.Printf("📦 Created %d EIP-1559 transactions sorted by bribe size (highest to lowest)", len(transactions))
-	return transactions, nil
+// submitLaunchOnly submits the notification's launch tx without any snipes.
+// The proxy held the launch tx back for the bundle, so whenever no bundle is
+// built for it, it still has to go out on its own.
+func (s *Service) submitLaunchOnly(ctx context.Context, notification LPAddNotification) string {
+	bundleID, _ := s.submitBundle(ctx, notification.TokenAddress, notification.TxCallData, nil)
+	return bundleID
 }
 
-func (s *Service) submitBundle(ctx context.Context, addLiqRawTx string, transactions []*types.Transaction) {
-	err := s.submitTx(ctx, addLiqRawTx)
+// submitBundle sends the LP_ADD followed by the snipes to every configured
+// submission endpoint in parallel. Each endpoint receives the transactions in
+// bundle order; a transaction counts as submitted once any endpoint accepts it.
+// It returns the bundle's ID (see bundle.ID), which prefixes its logs, and
+// whether each of transactions was accepted.
+func (s *Service) submitBundle(ctx context.Context, tokenAddress, addLiqRawTx string, transactions []*types.Transaction) (string, []bool) {
+	bundleID := bundle.ID(common.HexToAddress(tokenAddress), common.HexToHash(rawTxHash(addLiqRawTx)), transactions)
+	log.Printf("📦 Bundle %s: 1 LP_ADD + %d transactions for token %s", bundleID, len(transactions), tokenAddress)
+
+	// In private-only mode this refuses to fall back to the public RPC
+	txAccepted := make([]bool, len(transactions))
+	submitURLs, err := s.config.SubmitURLs()
 	if err != nil {
-		log.Printf("failed to submit add liq transaction: %v", err)
+		log.Printf("❌ Not submitting bundle %s: %v", bundleID, err)
+		return bundleID, txAccepted
 	}
 
-	for _, tx := range transactions {
+	rawTxs := []string{addLiqRawTx}
+	hashes := []string{rawTxHash(addLiqRawTx)}
+	txIndexes := []int{-1} // Index in rawTxs -> index in transactions
+	for i, tx := range transactions {
 		// Convert transaction to raw hex string
 		rawTx, err := tx.MarshalBinary()
 		if err != nil {
-			log.Printf("failed to submit transaction: %v; hash: %s", err, tx.Hash().Hex())
+			log.Printf("[%s] failed to encode transaction: %v; hash: %s", bundleID, err, tx.Hash().Hex())
 			continue
 		}
-		rawTxHex := "0x" + hex.EncodeToString(rawTx)
-		err = s.submitTx(ctx, rawTxHex)
-		if err != nil {
-			log.Printf("failed to submit transaction: %v; hash: %s", err, tx.Hash().Hex())
-		}
+		rawTxs = append(rawTxs, "0x"+hex.EncodeToString(rawTx))
+		hashes = append(hashes, tx.Hash().Hex())
+		txIndexes = append(txIndexes, i)
 	}
-}
-
-func (s *Service) submitTx(ctx context.Context, rawTxHex string) error {
 
-	// Create eth_sendRawTransaction request
-	type RawTxRequest struct {
-		JSONRPC string   `json:"jsonrpc"`
-		Method  string   `json:"method"`
-		Params  []string `json:"params"`
-		ID      int      `json:"id"`
+	// Index in rawTxs -> first endpoint that accepted it
+	var accepted sync.Map
+
+	var wg sync.WaitGroup
+	for _, submitURL := range submitURLs {
+		wg.Add(1)
+		go func(submitURL string) {
+			defer wg.Done()
+			for i, rawTx := range rawTxs {
+				hash, err := s.submitter.SendRawTransaction(ctx, submitURL, rawTx)
+				if err != nil {
+					log.Printf("[%s] failed to submit transaction %d to %s: %v", bundleID, i, submitURL, err)
+					continue
+				}
+				if _, dup := accepted.LoadOrStore(i, submitURL); !dup {
+					log.Printf("[%s] Transaction submitted successfully via %s; Hash: %s", bundleID, submitURL, hash)
+				}
+			}
+		}(submitURL)
 	}
+	wg.Wait()
 
-	txReq := RawTxRequest{
-		JSONRPC: "2.0",
-		Method:  "eth_sendRawTransaction",
-		Params:  []string{rawTxHex},
-		ID:      1,
+	archived := make([]db.BundleTx, len(rawTxs))
+	for i := range rawTxs {
+		_, ok := accepted.Load(i)
+		if !ok {
+			log.Printf("❌ [%s] Transaction %d of the bundle was rejected by all %d endpoints", bundleID, i, len(submitURLs))
+		}
+		archived[i] = db.BundleTx{BundleID: bundleID, TokenAddress: tokenAddress, Position: i,
+			TxHash: hashes[i], RawTx: rawTxs[i], Accepted: ok}
+		if txIndexes[i] >= 0 {
+			txAccepted[txIndexes[i]] = ok
+		}
 	}
-
-	// Marshal request
-	reqBody, err := json.Marshal(txReq)
-	if err != nil {
-		return fmt.Errorf("failed to marshal transaction request: %v", err)
+	if s.config.Enabled(config.FeatureBundleArchive) {
+		go s.archiveBundle(bundleID, archived)
 	}
 
-	resp, err := http.Post(s.config.BaseSequencerRPCURL, "application/json", bytes.NewBuffer(reqBody))
-
-	if err != nil {
-		return fmt.Errorf("failed to submit transaction: %v", err)
+	// Once the launch is out, new snipes for the token are in cooldown
+	if archived[0].Accepted {
+		go func() {
+			if err := s.db.RecordTokenBundle(common.HexToAddress(tokenAddress).Hex(), bundleID); err != nil {
+				log.Printf("⚠️ [%s] Failed to record launch of token %s: %v", bundleID, tokenAddress, err)
+			}
+		}()
 	}
-	defer resp.Body.Close()
+	return bundleID, txAccepted
+}
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+// decodeRawTx decodes a hex-encoded signed transaction
+func decodeRawTx(rawTxHex string) (*types.Transaction, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(rawTxHex, "0x"))
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("transaction submission failed with status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	// Parse response to get transaction hash
-	type RawTxResponse struct {
-		JSONRPC string      `json:"jsonrpc"`
-		Result  string      `json:"result"`
-		Error   interface{} `json:"error"`
-		ID      int         `json:"id"`
-	}
-
-	var txResp RawTxResponse
-	if err := json.Unmarshal(respBody, &txResp); err != nil {
-		return fmt.Errorf("failed to parse response: %v", err)
+		return nil, err
 	}
 
-	if txResp.Error != nil {
-		return fmt.Errorf("transaction failed: %v", txResp.Error)
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, err
 	}
-
-	log.Printf("Transaction submitted successfully; Hash: %s", txResp.Result)
-
-	return nil
+	return tx, nil
 }
 
 // Stop stops the API service
 func (s *Service) Stop() error {
+	close(s.stop)
+
 	// Gracefully shutdown HTTP server
 	if s.httpServer != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFeeLadder(t *testing.T) {
+	gwei := big.NewInt(1e9)
+	minFee := new(big.Int).Mul(big.NewInt(2), gwei)
+	maxFee := new(big.Int).Mul(big.NewInt(10), gwei)
+
+	tests := []struct {
+		name    string
+		desired *big.Int
+		minFee  *big.Int
+		maxFee  *big.Int
+		n       int
+		top     *big.Int
+	}{
+		{"one snipe", maxFee, minFee, maxFee, 1, maxFee},
+		{"ten snipes", maxFee, minFee, maxFee, 10, maxFee},
+		{"thousand snipes", maxFee, minFee, maxFee, 1000, maxFee},
+		{"desired below the ladder", minFee, minFee, maxFee, 1000, new(big.Int).Add(minFee, big.NewInt(999))},
+		{"ladder wider than the cap", minFee, minFee, big.NewInt(2e9 + 5), 1000, big.NewInt(2e9 + 5)},
+		{"minimum above the cap", minFee, minFee, gwei, 10, minFee},
+	}
+
+	for _, tt := range tests {
+		top := feeLadderTop(tt.desired, tt.minFee, tt.maxFee, tt.n)
+		if top.Cmp(tt.top) != 0 {
+			t.Errorf("%s: top %s, want %s", tt.name, top, tt.top)
+		}
+
+		// Every rung is valid and never over the cap, unless the minimum is
+		fits := new(big.Int).Sub(top, tt.minFee).Int64()
+		limit := tt.maxFee
+		if tt.minFee.Cmp(limit) > 0 {
+			limit = tt.minFee
+		}
+		var previous *big.Int
+		for i := 0; i < tt.n; i++ {
+			fee := feeLadderRung(top, tt.minFee, i)
+			if fee.Cmp(tt.minFee) < 0 {
+				t.Fatalf("%s: snipe %d pays %s, below the minimum %s", tt.name, i, fee, tt.minFee)
+			}
+			if fee.Cmp(limit) > 0 {
+				t.Fatalf("%s: snipe %d pays %s, over the cap %s", tt.name, i, fee, limit)
+			}
+			// Strictly decreasing while the ladder fits, then flat at the minimum
+			if previous != nil {
+				if int64(i) <= fits && fee.Cmp(previous) >= 0 {
+					t.Fatalf("%s: snipe %d pays %s, not below %s", tt.name, i, fee, previous)
+				}
+				if int64(i) > fits && fee.Cmp(tt.minFee) != 0 {
+					t.Fatalf("%s: snipe %d pays %s past the cap's room, want %s", tt.name, i, fee, tt.minFee)
+				}
+			}
+			previous = fee
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"sniper-bot/services/bot/bundle"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// sameWalletFailed is the simulation error of a snipe dropped because one
+// of its wallet's earlier snipes in the bundle failed, leaving its nonce
+// unreachable
+const sameWalletFailed = "an earlier snipe of the same wallet failed simulation"
+
+// bundleSimulation is the outcome of simulating a bundle's snipes
+type bundleSimulation struct {
+	transactions []*types.Transaction // The snipes that passed, then their commission transfers
+	bids         []*bundle.SnipeBid   // The bids of the snipes that passed, in bundle order
+	failed       []*bundle.SnipeBid   // The bids of the snipes dropped, in bundle order
+	reasons      map[int64]string     // Simulation error of each dropped snipe, by snipe ID
+	records      []db.SnipeSimulation // Result of every snipe, to store with the bundle
+}
+
+// walletNonce identifies a transaction by its sender and nonce
+type walletNonce struct {
+	wallet common.Address
+	nonce  uint64
+}
+
+// simulateBundle runs the launch transaction followed by the bundle's signed
+// snipes, in bundle order, through one simulated block, so each snipe sees the
+// pool the launch and the snipes ahead of it leave. transactions are the
+// snipes, one per bid, followed by their commission transfers. Snipes that
+// revert are dropped with their commission transfer and their nonces given
+// back, and so are the later snipes of the same wallet, whose nonces would
+// no longer be reachable. An error means nothing could be simulated.
+func (s *Service) simulateBundle(ctx context.Context, notification LPAddNotification, transactions []*types.Transaction, bids []*bundle.SnipeBid) (*bundleSimulation, error) {
+	launchTx, err := decodeRawTx(notification.TxCallData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode launch transaction: %v", err)
+	}
+
+	snipeTxs := transactions[:len(bids)]
+	results, err := s.ethClient.SimulateTransactions(ctx, append([]*types.Transaction{launchTx}, snipeTxs...))
+	if err != nil {
+		return nil, err
+	}
+	if !results[0].Success {
+		log.Printf("⚠️ Launch tx for token %s fails in simulation (%s), so its snipes will too", notification.TokenAddress, results[0].RevertReason)
+	}
+
+	failed := make(map[int64]string)
+	for i, bid := range bids {
+		if result := results[i+1]; !result.Success {
+			reason := result.RevertReason
+			if len(reason) > maxRevertReason {
+				reason = reason[:maxRevertReason]
+			}
+			failed[bid.SnipeID] = reason
+		}
+	}
+
+	kept, keptBids, droppedBids, err := s.dropSnipes(transactions, bids, func(bid *bundle.SnipeBid) bool {
+		_, ok := failed[bid.SnipeID]
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	simulation := &bundleSimulation{transactions: kept, bids: keptBids, failed: droppedBids, reasons: make(map[int64]string)}
+	for _, bid := range droppedBids {
+		reason, ok := failed[bid.SnipeID]
+		if !ok {
+			reason = sameWalletFailed
+		}
+		log.Printf("🧪 Dropping snipe %d of wallet %s, it fails in simulation: %s", bid.SnipeID, bid.Wallet.Hex(), reason)
+		simulation.reasons[bid.SnipeID] = reason
+	}
+	for _, bid := range bids {
+		if reason, ok := simulation.reasons[bid.SnipeID]; ok {
+			simulation.records = append(simulation.records, db.SnipeSimulation{SnipeID: bid.SnipeID, Status: db.SimulationFailed, Error: reason})
+		} else {
+			simulation.records = append(simulation.records, db.SnipeSimulation{SnipeID: bid.SnipeID, Status: db.SimulationPassed})
+		}
+	}
+
+	if len(simulation.failed) > 0 {
+		log.Printf("🧪 %d of %d snipes for token %s fail in simulation and are dropped", len(simulation.failed), len(bids), notification.TokenAddress)
+	}
+	return simulation, nil
+}
+
+// dropSnipes takes the snipes of the bids drop selects out of a bundle built
+// by createBundleTransactions, whose transactions are the snipes, one per bid,
+// followed by their commission transfers. The later snipes of the same wallet
+// go too, since their nonces would no longer be reachable, and so does every
+// dropped snipe's commission transfer. The nonces of everything taken out
+// are given back. It returns the transactions and bids left, and the bids
+// dropped, in bundle order.
+func (s *Service) dropSnipes(transactions []*types.Transaction, bids []*bundle.SnipeBid, drop func(*bundle.SnipeBid) bool) ([]*types.Transaction, []*bundle.SnipeBid, []*bundle.SnipeBid, error) {
+	snipeTxs := transactions[:len(bids)]
+	var kept, removed []*types.Transaction
+	var keptBids, droppedBids []*bundle.SnipeBid
+	keptNonces := make(map[walletNonce]bool)
+	droppedWallets := make(map[common.Address]bool)
+	for i, bid := range bids {
+		if droppedWallets[bid.Wallet] || drop(bid) {
+			droppedWallets[bid.Wallet] = true
+			removed = append(removed, snipeTxs[i])
+			droppedBids = append(droppedBids, bid)
+			continue
+		}
+		keptNonces[walletNonce{bid.Wallet, snipeTxs[i].Nonce()}] = true
+		kept = append(kept, snipeTxs[i])
+		keptBids = append(keptBids, bid)
+	}
+
+	// A commission transfer takes the nonce after its snipe's
+	signer := types.LatestSignerForChainID(s.ethClient.GetChainID())
+	for _, tx := range transactions[len(bids):] {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to recover sender of commission transfer %s: %v", tx.Hash().Hex(), err)
+		}
+		if keptNonces[walletNonce{from, tx.Nonce() - 1}] {
+			kept = append(kept, tx)
+		} else {
+			removed = append(removed, tx)
+		}
+	}
+
+	s.releaseNonces(removed)
+	return kept, keptBids, droppedBids, nil
+}
+
+// releaseNonces gives back the nonces of signed transactions that won't be
+// submitted. They are released highest first, so each wallet's run of nonces
+// unwinds from its end.
+func (s *Service) releaseNonces(transactions []*types.Transaction) {
+	sorted := append([]*types.Transaction(nil), transactions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Nonce() > sorted[j].Nonce() })
+
+	signer := types.LatestSignerForChainID(s.ethClient.GetChainID())
+	for _, tx := range sorted {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		s.nonces.release(nonceLease{wallet: from, start: tx.Nonce(), count: 1})
+	}
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/services/bot/bundle"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeDriver is a database/sql driver whose connections run against the
+// fakeDB registered under their DSN, so a test can see what a Service wrote
+// and choose what it reads
+type fakeDriver struct{}
+
+// fakeDB is what a test's fakeDriver connections do: every write is sent to
+// queries, and every read returns rows under columns. Without columns, reads
+// fail.
+type fakeDB struct {
+	queries chan string
+	columns []string
+	rows    [][]driver.Value
+}
+
+var (
+	registerFakeDriver sync.Once
+	fakeDBsMu          sync.Mutex
+	fakeDBs            = make(map[string]*fakeDB)
+)
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	return fakeConn{fakeDBs[name]}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (fakeConn) Close() error                                { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                   { return nil, errors.New("transactions not supported") }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.queries <- s.query
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.db.columns == nil {
+		return nil, errors.New("queries not supported")
+	}
+	return &fakeRows{columns: s.db.columns, rows: s.db.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (*fakeRows) Close() error        { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// newFakeDB returns a database whose writes are sent to the returned channel
+// and whose reads return rows under columns, or fail if columns is nil
+func newFakeDB(t *testing.T, columns []string, rows ...[]driver.Value) (*db.DB, chan string) {
+	registerFakeDriver.Do(func() { sql.Register("apitest", fakeDriver{}) })
+
+	queries := make(chan string, 16)
+	fakeDBsMu.Lock()
+	fakeDBs[t.Name()] = &fakeDB{queries: queries, columns: columns, rows: rows}
+	fakeDBsMu.Unlock()
+
+	conn, err := sql.Open("apitest", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return db.NewFromConn(conn, db.DialectFor("")), queries
+}
+
+// signedTx returns a signed transfer of key's at nonce, hex-encoded as well
+func signedTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64) (*types.Transaction, string) {
+	t.Helper()
+	to := common.HexToAddress("0x4200000000000000000000000000000000000006")
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(8453)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(8453),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(2e9),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx, "0x" + hex.EncodeToString(raw)
+}
+
+// submission is a transaction a stub Submitter received
+type submission struct {
+	endpoint string
+	hash     string
+}
+
+func TestSubmitBundle(t *testing.T) {
+	const token = "0x00000000000000000000000000000000000000aa"
+	creator, _ := crypto.GenerateKey()
+	sniper, _ := crypto.GenerateKey()
+
+	launch, launchRaw := signedTx(t, creator, 0)
+	var snipes []*types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx, _ := signedTx(t, sniper, nonce)
+		snipes = append(snipes, tx)
+	}
+	hashes := []string{launch.Hash().Hex(), snipes[0].Hash().Hex(), snipes[1].Hash().Hex(), snipes[2].Hash().Hex()}
+
+	tests := []struct {
+		name      string
+		cfg       config.Config
+		reject    func(endpoint, hash string) bool // Rejected submissions
+		submitted int                              // Submissions expected across endpoints
+		accepted  []bool
+		cooldown  bool // Whether the launch is recorded for the token's cooldown
+	}{
+		{
+			name:      "sequencer accepts all",
+			cfg:       config.Config{BaseSequencerRPCURL: "http://sequencer"},
+			reject:    func(string, string) bool { return false },
+			submitted: 4,
+			accepted:  []bool{true, true, true},
+			cooldown:  true,
+		},
+		{
+			name:      "one endpoint down",
+			cfg:       config.Config{SubmitEndpoints: []string{"http://down", "http://builder"}},
+			reject:    func(endpoint, _ string) bool { return endpoint == "http://down" },
+			submitted: 8,
+			accepted:  []bool{true, true, true},
+			cooldown:  true,
+		},
+		{
+			name: "snipe rejected everywhere",
+			cfg:  config.Config{SubmitEndpoints: []string{"http://a", "http://b"}},
+			reject: func(endpoint, hash string) bool {
+				return hash == hashes[2] || (endpoint == "http://a" && hash == hashes[3])
+			},
+			submitted: 8,
+			accepted:  []bool{true, false, true},
+			cooldown:  true,
+		},
+		{
+			name:      "launch rejected",
+			cfg:       config.Config{BaseSequencerRPCURL: "http://sequencer"},
+			reject:    func(_, hash string) bool { return hash == hashes[0] },
+			submitted: 4,
+			accepted:  []bool{true, true, true},
+			cooldown:  false,
+		},
+		{
+			name: "private only without a private endpoint",
+			cfg: config.Config{
+				BaseRPCURL:          "http://public",
+				BaseSequencerRPCURL: "http://public",
+				Features:            map[config.Feature]bool{config.FeaturePrivateOnly: true},
+			},
+			reject:    func(string, string) bool { return false },
+			submitted: 0,
+			accepted:  []bool{false, false, false},
+			cooldown:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database, queries := newFakeDB(t, nil)
+
+			var mu sync.Mutex
+			received := make(map[string][]string) // Hashes each endpoint received, in order
+			submitter := SubmitterFunc(func(ctx context.Context, endpoint, rawTxHex string) (string, error) {
+				hash := rawTxHash(rawTxHex)
+				mu.Lock()
+				received[endpoint] = append(received[endpoint], hash)
+				mu.Unlock()
+				if tt.reject(endpoint, hash) {
+					return "", fmt.Errorf("rejected by %s", endpoint)
+				}
+				return hash, nil
+			})
+
+			cfg := tt.cfg
+			s := &Service{config: &cfg, submitter: submitter, db: database}
+			bundleID, accepted := s.submitBundle(context.Background(), token, launchRaw, snipes)
+
+			if want := bundle.ID(common.HexToAddress(token), launch.Hash(), snipes); bundleID != want {
+				t.Errorf("bundle ID %s, want %s", bundleID, want)
+			}
+			if !reflect.DeepEqual(accepted, tt.accepted) {
+				t.Errorf("accepted %v, want %v", accepted, tt.accepted)
+			}
+
+			submitted := 0
+			for endpoint, got := range received {
+				submitted += len(got)
+				if !reflect.DeepEqual(got, hashes) {
+					t.Errorf("%s received %v, want the bundle in order %v", endpoint, got, hashes)
+				}
+			}
+			if submitted != tt.submitted {
+				t.Errorf("%d submissions, want %d", submitted, tt.submitted)
+			}
+
+			select {
+			case query := <-queries:
+				if !tt.cooldown {
+					t.Errorf("unexpected write %q", query)
+				} else if !strings.Contains(query, "token_bundles") {
+					t.Errorf("write %q, want the token's launch", query)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.cooldown {
+					t.Error("launch not recorded for the token's cooldown")
+				}
+			}
+		})
+	}
+}
+
+// TestPauseUnreadable has the pause mode fail to read while a snipe is pending,
+// and checks that only the launch tx is submitted
+func TestPauseUnreadable(t *testing.T) {
+	const token = "0x00000000000000000000000000000000000000aa"
+	creator, _ := crypto.GenerateKey()
+	launch, launchRaw := signedTx(t, creator, 0)
+
+	// Every read returns this snipe row, which the pause mode's single column can't scan
+	columns := strings.Split("id, user_id, token_address, amount, amount_mode, bribe_amount, wallet, created_at, status, slippage, tx_hash, bundle_position, gas_used, effective_gas_price, take_profit_x, stop_loss_pct, swap_wei, sell_tx_hash, block_number, revert_reason, bundle_id, recipient, forward_tx_hash, tokens_received, entry_price, simulation_status, simulation_error", ", ")
+	row := make([]driver.Value, len(columns))
+	copy(row, []driver.Value{
+		int64(1), "42", token, "0.1", db.AmountModeETH, "0.001",
+		"0x00000000000000000000000000000000000000bb", "2024-03-09 14:00:00", db.SnipeStatusPending,
+	})
+	database, _ := newFakeDB(t, columns, row)
+
+	var mu sync.Mutex
+	var received []string
+	submitter := SubmitterFunc(func(ctx context.Context, endpoint, rawTxHex string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, rawTxHash(rawTxHex))
+		return rawTxHash(rawTxHex), nil
+	})
+
+	s := &Service{
+		config:    &config.Config{BaseSequencerRPCURL: "http://sequencer"},
+		submitter: submitter,
+		db:        database,
+		funnel:    newSnipeFunnel(0),
+	}
+	s.processLPAddAndCreateBundle(LPAddNotification{TokenAddress: token, TxCallData: launchRaw})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{launch.Hash().Hex()}; !reflect.DeepEqual(received, want) {
+		t.Errorf("submitted %v, want only the launch %v", received, want)
+	}
+	if matched := s.funnel.total[funnelMatched]; matched != 0 {
+		t.Errorf("%d snipes matched, want none while the pause mode is unknown", matched)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Submitter sends signed transactions to a submission endpoint (the
+// sequencer, a builder or the RPC) and returns their hashes. Bundle
+// submission only goes through this interface, so it can be exercised
+// without a live endpoint.
+type Submitter interface {
+	SendRawTransaction(ctx context.Context, endpoint string, rawTxHex string) (string, error)
+}
+
+// SubmitterFunc adapts a function to a Submitter, e.g. to stub endpoints
+// in tests
+type SubmitterFunc func(ctx context.Context, endpoint string, rawTxHex string) (string, error)
+
+// SendRawTransaction calls f
+func (f SubmitterFunc) SendRawTransaction(ctx context.Context, endpoint string, rawTxHex string) (string, error) {
+	return f(ctx, endpoint, rawTxHex)
+}
+
+// HTTPSubmitter submits transactions over JSON-RPC on HTTP
+type HTTPSubmitter struct {
+	client *http.Client
+}
+
+// NewHTTPSubmitter creates a submitter sending its requests with client
+func NewHTTPSubmitter(client *http.Client) *HTTPSubmitter {
+	return &HTTPSubmitter{client: client}
+}
+
+// SendRawTransaction sends a raw transaction to submitURL with
+// eth_sendRawTransaction and returns its hash. A transaction the endpoint
+// already knows counts as submitted.
+func (h *HTTPSubmitter) SendRawTransaction(ctx context.Context, submitURL string, rawTxHex string) (string, error) {
+	// Create eth_sendRawTransaction request
+	type RawTxRequest struct {
+		JSONRPC string   `json:"jsonrpc"`
+		Method  string   `json:"method"`
+		Params  []string `json:"params"`
+		ID      int      `json:"id"`
+	}
+
+	txReq := RawTxRequest{
+		JSONRPC: "2.0",
+		Method:  "eth_sendRawTransaction",
+		Params:  []string{rawTxHex},
+		ID:      1,
+	}
+
+	// Marshal request
+	reqBody, err := json.Marshal(txReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal transaction request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, submitURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to submit transaction: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transaction submission failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	// Parse response to get transaction hash
+	type RawTxResponse struct {
+		JSONRPC string `json:"jsonrpc"`
+		Result  string `json:"result"`
+		Error   *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+		ID int `json:"id"`
+	}
+
+	var txResp RawTxResponse
+	if err := json.Unmarshal(respBody, &txResp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %v", err)
+	}
+
+	if txResp.Error != nil {
+		if isAlreadyKnown(txResp.Error.Message) {
+			return rawTxHash(rawTxHex), nil
+		}
+		return "", fmt.Errorf("transaction failed: %d %s", txResp.Error.Code, txResp.Error.Message)
+	}
+
+	return txResp.Result, nil
+}
+
+// isAlreadyKnown reports whether an RPC error means the node already has the transaction
+func isAlreadyKnown(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "already known") || strings.Contains(message, "known transaction")
+}
+
+// rawTxHash returns the hash of a raw transaction, or "" if it can't be decoded
+func rawTxHash(rawTxHex string) string {
+	tx, err := decodeRawTx(rawTxHex)
+	if err != nil {
+		return ""
+	}
+	return tx.Hash().Hex()
+}
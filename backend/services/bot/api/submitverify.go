@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"sniper-bot/services/bot/bundle"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// verifySubmission waits SubmitVerifyDelay after a bundle's submission, then
+// asks the node for each snipe an endpoint accepted. A snipe the node has
+// neither mined nor seen pending was lost after acceptance, and becomes
+// submit_failed unless its status has moved on meanwhile.
+func (s *Service) verifySubmission(bundleID string, txs []*types.Transaction, bids []*bundle.SnipeBid, accepted []bool) {
+	select {
+	case <-s.stop:
+		return
+	case <-time.After(s.config.SubmitVerifyDelay):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lost := 0
+	for i, bid := range bids {
+		if !accepted[i] {
+			continue
+		}
+		_, _, err := s.ethClient.TransactionByHash(ctx, txs[i].Hash())
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			log.Printf("⚠️ [%s] Failed to verify snipe %d: %v", bundleID, bid.SnipeID, err)
+			continue
+		}
+
+		updated, err := s.db.UpdateSnipeStatusAtomic(bid.SnipeID, db.SnipeStatusSubmitted, db.SnipeStatusSubmitFailed)
+		if err != nil {
+			log.Printf("⚠️ [%s] Failed to mark snipe %d %s: %v", bundleID, bid.SnipeID, db.SnipeStatusSubmitFailed, err)
+			continue
+		}
+		if updated {
+			lost++
+			s.notifyStatus(bidStatusEvent(bid, bundleID, db.SnipeStatusSubmitFailed, txs[i].Hash().Hex()))
+		}
+	}
+	if lost > 0 {
+		log.Printf("🚫 [%s] %d accepted snipes unknown to the node after %s, marked %s", bundleID, lost, s.config.SubmitVerifyDelay, db.SnipeStatusSubmitFailed)
+	}
+}
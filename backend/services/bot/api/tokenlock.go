@@ -0,0 +1,77 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// tokenLocks lets only one bundle build run per token at a time, so two
+// LP_ADD notifications for the same token can't both take its pending snipes
+// and sign them with the same nonces. Each lock is a one-slot channel, which
+// lets a wait time out like one for a bundle slot.
+type tokenLocks struct {
+	mu    sync.Mutex
+	locks map[common.Address]*tokenLock
+}
+
+// tokenLock is the lock of one token; refs counts the builds holding or
+// waiting for it, and it is dropped from the map once none do
+type tokenLock struct {
+	slot chan struct{}
+	refs int
+}
+
+func newTokenLocks() *tokenLocks {
+	return &tokenLocks{locks: make(map[common.Address]*tokenLock)}
+}
+
+// acquire takes the lock of token, waiting up to wait for another build to
+// release it (not at all if wait is 0). It returns the function releasing the
+// lock, or false if it couldn't be taken in time.
+func (l *tokenLocks) acquire(token common.Address, wait time.Duration) (func(), bool) {
+	l.mu.Lock()
+	lock, ok := l.locks[token]
+	if !ok {
+		lock = &tokenLock{slot: make(chan struct{}, 1)}
+		l.locks[token] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	select {
+	case lock.slot <- struct{}{}:
+	default:
+		if wait <= 0 {
+			l.unref(token, lock)
+			return nil, false
+		}
+
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case lock.slot <- struct{}{}:
+		case <-timer.C:
+			l.unref(token, lock)
+			return nil, false
+		}
+	}
+
+	return func() {
+		<-lock.slot
+		l.unref(token, lock)
+	}, true
+}
+
+// unref drops one build's reference to the lock of token
+func (l *tokenLocks) unref(token common.Address, lock *tokenLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, token)
+	}
+}
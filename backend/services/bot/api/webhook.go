@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"sniper-bot/services/bot/bundle"
+	"sniper-bot/services/bot/db"
+)
+
+const (
+	// webhookQueueSize bounds the events waiting for delivery; events beyond
+	// it are dropped rather than blocking bundle handling
+	webhookQueueSize = 1024
+
+	// webhookTimeout bounds a single delivery attempt
+	webhookTimeout = 10 * time.Second
+
+	// webhookBackoff is the wait before the first retry, doubled after each
+	webhookBackoff = time.Second
+
+	// webhookSignatureHeader carries the hex HMAC-SHA256 of the body, keyed
+	// with STATUS_WEBHOOK_SECRET
+	webhookSignatureHeader = "X-Sniper-Signature"
+)
+
+// StatusEvent is the JSON body POSTed to the status webhook when a snipe
+// changes status
+type StatusEvent struct {
+	SnipeID      int64  `json:"snipeId"`
+	UserID       string `json:"userId"`
+	Token        string `json:"token"`
+	Wallet       string `json:"wallet"`
+	Status       string `json:"status"`                 // submitted, submit_failed, dropped, landed, reverted, sold or cancelled; pending when requeued
+	TxHash       string `json:"txHash,omitempty"`       // Snipe tx, or the sell tx once sold
+	BundleID     string `json:"bundleId,omitempty"`     // Bundle the snipe was submitted or dropped from
+	RequeuedFrom int64  `json:"requeuedFrom,omitempty"` // Snipe this one replaces, after its bundle's LP_ADD reverted
+	Timestamp    int64  `json:"timestamp"`              // Unix seconds
+
+	// When landed, what the snipe bought, from its SnipeExecuted event
+	TokensReceived string  `json:"tokensReceived,omitempty"` // In the token's smallest unit
+	EntryPrice     float64 `json:"entryPrice,omitempty"`     // ETH swapped per whole token
+
+	// When dropped for failing simulate_snipes, why it failed
+	SimulationError string `json:"simulationError,omitempty"`
+}
+
+// bidStatusEvent is the status event of a snipe in a bundle
+func bidStatusEvent(bid *bundle.SnipeBid, bundleID, status, txHash string) StatusEvent {
+	return StatusEvent{
+		SnipeID:  bid.SnipeID,
+		UserID:   bid.UserID,
+		Token:    bid.TokenAddress.Hex(),
+		Wallet:   bid.Wallet.Hex(),
+		Status:   status,
+		TxHash:   txHash,
+		BundleID: bundleID,
+	}
+}
+
+// snipeStatusEvent is the status event of a stored snipe
+func snipeStatusEvent(snipe *db.Snipe, status, txHash string) StatusEvent {
+	return StatusEvent{
+		SnipeID:  snipe.ID,
+		UserID:   snipe.UserID,
+		Token:    snipe.TokenAddress,
+		Wallet:   snipe.Wallet,
+		Status:   status,
+		TxHash:   txHash,
+		BundleID: snipe.BundleID.String,
+	}
+}
+
+// notifyStatus queues a status event for the webhook, if one is configured
+func (s *Service) notifyStatus(event StatusEvent) {
+	if s.webhookEvents == nil {
+		return
+	}
+
+	event.Timestamp = time.Now().Unix()
+	select {
+	case s.webhookEvents <- event:
+	default:
+		log.Printf("⚠️ Webhook queue full, dropping %s event for snipe %d", event.Status, event.SnipeID)
+	}
+}
+
+// runWebhook delivers queued status events one at a time, in order, until
+// stop is closed
+func (s *Service) runWebhook(stop <-chan struct{}) {
+	client := &http.Client{Timeout: webhookTimeout}
+
+	log.Printf("🪝 Sending snipe status events to %s", s.config.StatusWebhookURL)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-s.webhookEvents:
+			s.deliverWebhook(client, event, stop)
+		}
+	}
+}
+
+// deliverWebhook POSTs an event, retrying with exponential backoff up to
+// WebhookMaxAttempts times while the receiver fails or answers non-2xx
+func (s *Service) deliverWebhook(client *http.Client, event StatusEvent, stop <-chan struct{}) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("❌ Failed to encode webhook event for snipe %d: %v", event.SnipeID, err)
+		return
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.config.StatusWebhookSecret))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		err := postWebhook(client, s.config.StatusWebhookURL, body, signature)
+		if err == nil {
+			return
+		}
+		if attempt >= s.config.WebhookMaxAttempts {
+			log.Printf("❌ Giving up on %s webhook for snipe %d after %d attempts: %v", event.Status, event.SnipeID, attempt, err)
+			return
+		}
+
+		log.Printf("⚠️ Webhook for snipe %d failed (attempt %d of %d), retrying in %s: %v",
+			event.SnipeID, attempt, s.config.WebhookMaxAttempts, backoff, err)
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook sends one signed delivery
+func postWebhook(client *http.Client, url string, body []byte, signature string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookSignatureHeader, "sha256="+signature)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver returned %s", resp.Status)
+	}
+	return nil
+}
@@ -2,29 +2,70 @@ package bot
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"html"
+	"io"
 	"log"
 	"math/big"
 	"os"
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/services/bot/bundle"
 	"sniper-bot/services/bot/db"
+	"sniper-bot/services/bot/positions"
+	"sniper-bot/services/bot/selftest"
+	"sniper-bot/services/bot/validation"
 	"sniper-bot/services/bot/wallet"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"sniper-bot/pkg/eth"
+	"sniper-bot/pkg/telegram"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// confirmationTTL is how long a /snipe confirmation button stays valid
+const confirmationTTL = 5 * time.Minute
+
+// Callback data prefixes for the snipe confirmation keyboard
+const (
+	callbackConfirmSnipe = "snipe_confirm:"
+	callbackCancelSnipe  = "snipe_cancel:"
+)
+
 // Service represents the Telegram bot service
 type Service struct {
 	bot           *tgbotapi.BotAPI
 	ethClient     *eth.Client
 	walletManager *wallet.Manager
 	db            *db.DB
+	config        *config.Config
+	monitor       *positions.Monitor
+	gasPrices     eth.GasPriceSource
+	priorityFees  *eth.PriorityFeeTracker
+	sender        WalletSender // Sends /send transfers; nil until set
+
+	mu            sync.Mutex
+	confirmations map[string]*pendingConfirmation // map[confirmationID]*pendingConfirmation
+}
+
+// pendingConfirmation is a validated snipe waiting for the user to confirm it
+type pendingConfirmation struct {
+	snipe     *db.Snipe
+	expiresAt time.Time
 }
 
 // NewService creates a new bot service
-func NewService(walletManager *wallet.Manager, database *db.DB, ethClient *eth.Client) (*Service, error) {
+func NewService(walletManager *wallet.Manager, database *db.DB, ethClient *eth.Client, monitor *positions.Monitor) (*Service, error) {
 	token := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if token == "" {
 		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN environment variable is required")
@@ -35,11 +76,18 @@ func NewService(walletManager *wallet.Manager, database *db.DB, ethClient *eth.C
 		return nil, fmt.Errorf("failed to create bot: %v", err)
 	}
 
+	cfg := config.Load()
+
 	return &Service{
 		bot:           bot,
 		walletManager: walletManager,
 		ethClient:     ethClient,
 		db:            database,
+		config:        cfg,
+		monitor:       monitor,
+		gasPrices:     bundle.NewGasPriceSource(ethClient.Client, cfg.Gas),
+		priorityFees:  bundle.NewPriorityFeeTracker(ethClient.Client, cfg.Gas),
+		confirmations: make(map[string]*pendingConfirmation),
 	}, nil
 }
 
@@ -54,6 +102,11 @@ func (s *Service) Start() error {
 	updates := s.bot.GetUpdatesChan(u)
 
 	for update := range updates {
+		if update.CallbackQuery != nil {
+			s.handleCallbackQuery(update.CallbackQuery)
+			continue
+		}
+
 		if update.Message == nil {
 			continue
 		}
@@ -72,8 +125,32 @@ func (s *Service) Start() error {
 			msg.Text = s.handleRegister(update.Message.From.ID)
 		case "balance":
 			msg.Text = s.handleBalance(update.Message.From.ID)
+		case "send":
+			msg.Text = s.handleSend(update.Message.From.ID, update.Message.CommandArguments())
 		case "snipe":
-			msg.Text = s.handleSnipe(update.Message.From.ID, update.Message.CommandArguments())
+			msg.Text, msg.ReplyMarkup = s.handleSnipe(update.Message.From.ID, update.Message.CommandArguments())
+		case "requeue":
+			msg.Text = s.handleRequeue(update.Message.From.ID, update.Message.CommandArguments())
+		case "costs":
+			msg.Text = s.handleCosts(update.Message.From.ID)
+		case "positions":
+			msg.Text = s.handlePositions(update.Message.From.ID)
+		case "settings":
+			msg.Text = s.handleSettings(update.Message.From.ID, update.Message.CommandArguments())
+		case "bribes":
+			msg.Text = s.handleBribes(update.Message.CommandArguments())
+		case "simulate":
+			msg.Text = s.handleSimulate(update.Message.CommandArguments())
+		case "exportwallets":
+			msg.Text = s.handleExportWallets(update.Message)
+		case "pause":
+			msg.Text = s.handleSetPaused(update.Message.From.ID, true)
+		case "resume":
+			msg.Text = s.handleSetPaused(update.Message.From.ID, false)
+		case "config":
+			msg.Text = s.handleConfig(update.Message.From.ID)
+		case "selftest":
+			msg.Text = s.handleSelftest(update.Message.From.ID)
 		default:
 			msg.Text = "Unknown command"
 		}
@@ -93,6 +170,19 @@ func (s *Service) Stop() error {
 	return nil
 }
 
+// NotifyUser sends userID a direct message rendered as HTML
+func (s *Service) NotifyUser(userID, text string) error {
+	chatID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q: %v", userID, err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	_, err = s.bot.Send(msg)
+	return err
+}
+
 func (s *Service) handleRegister(userID int64) string {
 	userIDStr := fmt.Sprintf("%d", userID)
 
@@ -126,52 +216,1091 @@ func (s *Service) handleBalance(userID int64) string {
 		return fmt.Sprintf("Error getting balance: %v", err)
 	}
 
-	// Convert wei to ETH
-	ethBalance := new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(1e18))
-
-	return fmt.Sprintf("Wallet address: %s\nBalance: %s ETH", wallet.Address.Hex(), ethBalance.Text('f', 6))
+	return fmt.Sprintf("Wallet address: %s\nBalance: %s", wallet.Address.Hex(), eth.FormatEther(balance))
 }
 
-func (s *Service) handleSnipe(userID int64, args string) string {
+// sendTimeout bounds the RPC calls of one /send
+const sendTimeout = 15 * time.Second
+
+// handleSend transfers ERC20 tokens from the user's wallet to another address:
+// /send <token_address> <amount | all> <address>. The amount is in whole
+// tokens, scaled by the token's decimals.
+func (s *Service) handleSend(userID int64, args string) string {
 	parts := strings.Fields(args)
 	if len(parts) != 3 {
-		return "Usage: /snipe <token_address> <amount_in_ETH> <bribe_in_ETH>"
+		return "Usage: /send &lt;token_address&gt; &lt;amount | all&gt; &lt;address&gt;\n" +
+			"Transfers tokens from your wallet, e.g. /send 0x... 1000 0x... The amount is in whole tokens."
+	}
+
+	if s.sender == nil {
+		return "❌ Sending is unavailable right now. Please try again later."
+	}
+
+	userIDStr := fmt.Sprintf("%d", userID)
+	userWallet, err := s.walletManager.GetWallet(userIDStr)
+	if err != nil {
+		return "❌ Wallet not found. Please register first using /register"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	req := validation.TransferRequest{TokenAddress: parts[0], Amount: parts[1], Recipient: parts[2]}
+
+	// The amount can only be scaled and checked once the token is known
+	var tokenContract *dex.ERC20PermitContract
+	var decimals uint8
+	balance := new(big.Int)
+	if common.IsHexAddress(req.TokenAddress) {
+		opts := &bind.CallOpts{Context: ctx}
+		tokenContract, err = dex.NewERC20PermitContract(s.ethClient.Client, common.HexToAddress(req.TokenAddress))
+		if err == nil {
+			decimals, err = tokenContract.Decimals(opts)
+		}
+		if err == nil {
+			balance, err = tokenContract.BalanceOf(opts, userWallet.Address)
+		}
+		if err != nil {
+			log.Printf("Failed to read token %s for /send: %v", req.TokenAddress, err)
+			return renderValidationErrors(validation.Errors{{
+				Field:  validation.FieldTokenAddress,
+				Reason: "doesn't answer decimals() and balanceOf() like an ERC20 token",
+			}})
+		}
+	}
+
+	transfer, errs := validation.ValidateTransfer(req, userWallet.Address, decimals, balance)
+	if errs != nil {
+		return renderValidationErrors(errs)
+	}
+
+	data, err := tokenContract.PackTransfer(transfer.Recipient, transfer.Amount)
+	if err != nil {
+		log.Printf("Failed to pack transfer: %v", err)
+		return "❌ Failed to prepare the transfer. Please try again."
+	}
+
+	txHash, err := s.sender.SendFromWallet(ctx, userWallet, transfer.TokenAddress, data)
+	if err != nil {
+		log.Printf("Failed to send tokens for user %s: %v", userIDStr, err)
+		return "❌ " + html.EscapeString(err.Error())
+	}
+
+	log.Printf("📤 User %s sent %s of token %s to %s in %s", userIDStr, transfer.Amount, transfer.TokenAddress.Hex(),
+		transfer.Recipient.Hex(), txHash.Hex())
+	return fmt.Sprintf("📤 <b>Tokens sent</b>\n\n"+
+		"🪙 %s of <code>%s</code>\n"+
+		"📬 To: <code>%s</code>\n"+
+		"🔗 Tx: <code>%s</code>",
+		eth.FormatTokens(transfer.Amount, decimals), transfer.TokenAddress.Hex(), transfer.Recipient.Hex(), txHash.Hex())
+}
+
+// WalletSender sends transactions from users' wallets. The API service
+// implements it, so their nonces stay clear of the bundles it builds.
+type WalletSender interface {
+	SendFromWallet(ctx context.Context, userWallet *wallet.Wallet, to common.Address, data []byte) (common.Hash, error)
+}
+
+// SetWalletSender sets what /send transfers go through. Call it before Start.
+func (s *Service) SetWalletSender(sender WalletSender) {
+	s.sender = sender
+}
+
+// handleCosts reports what the user's mined snipes cost in gas and bribes
+func (s *Service) handleCosts(userID int64) string {
+	report, err := s.db.GetUserCosts(fmt.Sprintf("%d", userID))
+	if err != nil {
+		log.Printf("Failed to get costs for user %d: %v", userID, err)
+		return "❌ Failed to load your snipe costs. Please try again."
+	}
+
+	if report.Snipes == 0 {
+		return "ℹ️ None of your snipes have been mined yet."
+	}
+
+	gasCost, ok := new(big.Int).SetString(report.GasCostWei, 10)
+	if !ok {
+		gasCost = big.NewInt(0)
+	}
+	l1Fees, ok := new(big.Int).SetString(report.L1FeeWei, 10)
+	if !ok {
+		l1Fees = big.NewInt(0)
+	}
+	bribes, err := eth.ParseEther(report.BribeETH)
+	if err != nil {
+		bribes = big.NewInt(0)
+	}
+	total := new(big.Int).Add(bribes, gasCost)
+	total.Add(total, l1Fees)
+
+	return fmt.Sprintf("🧾 <b>Snipe costs</b>\n\n"+
+		"🎯 Mined snipes: %d (%d reverted)\n"+
+		"⛽ Gas: %d used, %s\n"+
+		"📡 L1 data fees: %s\n"+
+		"💸 Bribes: %s\n"+
+		"💰 Total: %s",
+		report.Snipes, report.Reverted, report.GasUsed, eth.FormatEther(gasCost), eth.FormatEther(l1Fees),
+		eth.FormatEther(bribes), eth.FormatEther(total))
+}
+
+// handlePositions summarizes the tokens the user acquired: the amount held,
+// what it cost, its current value as last priced by the position monitor and
+// the unrealized PnL. Tokens sold since are listed with their sell transaction.
+func (s *Service) handlePositions(userID int64) string {
+	userIDStr := fmt.Sprintf("%d", userID)
+	snipes, err := s.db.GetUserHoldings(userIDStr)
+	if err != nil {
+		log.Printf("Failed to get holdings for user %d: %v", userID, err)
+		return "❌ Failed to load your positions. Please try again."
+	}
+	if len(snipes) == 0 {
+		return "ℹ️ You don't hold any sniped tokens yet."
+	}
+
+	var held, sold strings.Builder
+	seen := make(map[string]bool)
+	for _, snipe := range snipes {
+		token := common.HexToAddress(snipe.TokenAddress)
+		wallet := common.HexToAddress(snipe.Wallet)
+		key := snipe.Status + "/" + wallet.Hex() + "/" + token.Hex() + "/" + snipe.Recipient.String
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if snipe.Status == db.SnipeStatusSold {
+			fmt.Fprintf(&sold, "✅ <code>%s</code> sold in <code>%s</code>\n", token.Hex(), snipe.SellTxHash.String)
+			continue
+		}
+
+		if snipe.Recipient.Valid {
+			if snipe.ForwardTxHash.Valid {
+				fmt.Fprintf(&held, "\n🎯 <code>%s</code>\n📬 Delivered to <code>%s</code> in <code>%s</code>\n",
+					token.Hex(), snipe.Recipient.String, snipe.ForwardTxHash.String)
+			} else {
+				fmt.Fprintf(&held, "\n🎯 <code>%s</code>\n⏳ Forwarding to <code>%s</code>\n", token.Hex(), snipe.Recipient.String)
+			}
+			continue
+		}
+
+		position, ok := s.monitor.Position(wallet, token)
+		switch {
+		case !ok:
+			fmt.Fprintf(&held, "\n🎯 <code>%s</code>\n⏳ Not priced yet\n", token.Hex())
+		case position.Balance.Sign() == 0:
+			fmt.Fprintf(&held, "\n🎯 <code>%s</code>\n📤 Fully sold or transferred out (entry %s)\n",
+				token.Hex(), eth.FormatEther(position.EntryWei))
+		default:
+			sign := ""
+			if position.PnL().Sign() > 0 {
+				sign = "+"
+			}
+			fmt.Fprintf(&held, "\n🎯 <code>%s</code>\n"+
+				"🪙 Held: %s · Entry: %s · Now: %s\n"+
+				"📈 PnL: %s%s (%s%.1f%%) · priced %s ago\n",
+				token.Hex(), eth.FormatTokens(position.Balance, position.Decimals),
+				eth.FormatEther(position.EntryWei), eth.FormatEther(position.ValueWei),
+				sign, eth.FormatEther(position.PnL()), sign, position.PnLPercent(),
+				time.Since(position.UpdatedAt).Round(time.Second))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("📊 <b>Your positions</b>\n")
+	if held.Len() == 0 {
+		b.WriteString("\nℹ️ No open positions.\n")
+	}
+	b.WriteString(held.String())
+	if sold.Len() > 0 {
+		b.WriteString("\n<b>Sold</b>\n")
+		b.WriteString(sold.String())
+	}
+	return b.String()
+}
+
+// simulateTimeout bounds the RPC calls of one /simulate
+const simulateTimeout = 10 * time.Second
+
+// handleSimulate estimates what buying a token with an amount of ETH would get
+// at the pool's current reserves, without placing a snipe:
+// /simulate <token_address> <amount>
+func (s *Service) handleSimulate(args string) string {
+	parts := strings.Fields(args)
+	if len(parts) != 2 {
+		return "Usage: /simulate &lt;token_address&gt; &lt;amount&gt;\n" +
+			"Estimates the tokens an ETH amount buys at the pool's current reserves, e.g. /simulate 0x... 0.1"
+	}
+
+	simulation, errs := validation.ValidateSimulation(validation.SimulationRequest{TokenAddress: parts[0], Amount: parts[1]})
+	if errs != nil {
+		return renderValidationErrors(errs)
+	}
+	token := simulation.TokenAddress
+	if name, blocked := s.config.BlockedSnipeTarget(token.Hex()); blocked {
+		return fmt.Sprintf("❌ <code>%s</code> is %s and can't be sniped.", token.Hex(), name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), simulateTimeout)
+	defer cancel()
+
+	tokenReserve, wethReserve, err := dex.PoolReserves(ctx, s.ethClient.Client, common.HexToAddress(s.config.UniswapV2Factory), token)
+	if err != nil {
+		log.Printf("Failed to read the pool of token %s for /simulate: %v", token.Hex(), err)
+		return "❌ Failed to read the token's pool. Please try again."
+	}
+	if tokenReserve.Sign() == 0 || wethReserve.Sign() == 0 {
+		return fmt.Sprintf("ℹ️ <code>%s</code> has no liquidity yet, so there is nothing to simulate against. "+
+			"Try again once it launches, or place a /snipe to buy at launch.", token.Hex())
+	}
+
+	decimals, err := dex.TokenDecimals(ctx, s.ethClient.Client, token)
+	if err != nil {
+		log.Printf("Failed to read decimals of token %s for /simulate: %v", token.Hex(), err)
+		return "❌ Failed to read the token's decimals. Please try again."
+	}
+
+	quote := dex.QuoteBuy(simulation.Amount, tokenReserve, wethReserve)
+	if quote.TokensOut.Sign() == 0 {
+		return fmt.Sprintf("❌ %s is too little to buy any <code>%s</code>.", eth.FormatEther(simulation.Amount), token.Hex())
+	}
+
+	return fmt.Sprintf("🧪 <b>Simulated buy of</b> <code>%s</code>\n\n"+
+		"💰 Spend: %s\n"+
+		"🪙 Receive: ~%s tokens\n"+
+		"💱 Spot price: %s ETH per token\n"+
+		"💸 Effective price: %s ETH per token\n"+
+		"📉 Price impact: %.2f%% (incl. 0.3%% pool fee)\n"+
+		"🏊 Pool: %s / %s tokens\n\n"+
+		"ℹ️ At current reserves. Snipes ahead of yours in the launch bundle, other buyers and transfer taxes will change the outcome.",
+		token.Hex(), eth.FormatEther(simulation.Amount), eth.FormatTokens(quote.TokensOut, decimals),
+		tokenPrice(wethReserve, tokenReserve, decimals), tokenPrice(simulation.Amount, quote.TokensOut, decimals),
+		quote.PriceImpact, eth.FormatEther(wethReserve), eth.FormatTokens(tokenReserve, decimals))
+}
+
+// tokenPrice formats wei paid for tokens (in units of decimals) as ETH per
+// whole token
+func tokenPrice(wei, tokens *big.Int, decimals uint8) string {
+	return fmt.Sprintf("%.6g", eth.TokenPrice(wei, tokens, decimals))
+}
+
+// handleBribes reports the bribes of recently landed versus reverted snipes,
+// for one token or for all tokens, to help users pick a winning bribe
+func (s *Service) handleBribes(args string) string {
+	token := strings.TrimSpace(args)
+	scope := "all tokens"
+	if token != "" {
+		if !common.IsHexAddress(token) {
+			return "Usage: /bribes [token_address]"
+		}
+		token = common.HexToAddress(token).Hex()
+		scope = fmt.Sprintf("<code>%s</code>", token)
 	}
 
-	tokenAddress := parts[0]
-	amount := parts[1]
-	bribeAmount := parts[2]
+	report, err := s.db.GetBribeReport(token, time.Now().Add(-s.config.BribeReportWindow))
+	if err != nil {
+		log.Printf("Failed to get bribe report: %v", err)
+		return "❌ Failed to load the bribe report. Please try again."
+	}
+
+	if report.Landed.Snipes == 0 && report.Reverted.Snipes == 0 {
+		return fmt.Sprintf("ℹ️ No snipes for %s were mined in the last %s.", scope, formatWindow(s.config.BribeReportWindow))
+	}
+
+	return fmt.Sprintf("📊 <b>Bribes for %s</b> (last %s)\n\n"+
+		"✅ Landed: %s\n"+
+		"❌ Reverted: %s",
+		scope, formatWindow(s.config.BribeReportWindow), formatBribeStats(report.Landed), formatBribeStats(report.Reverted))
+}
+
+// formatBribeStats renders bribe stats as "3 snipes, min / avg / max ETH"
+func formatBribeStats(stats db.BribeStats) string {
+	if stats.Snipes == 0 {
+		return "none"
+	}
+
+	format := func(wei string) string {
+		value, ok := new(big.Int).SetString(wei, 10)
+		if !ok {
+			return "?"
+		}
+		return eth.FormatEther(value)
+	}
+
+	return fmt.Sprintf("%d snipes, bribe %s / %s / %s (min / avg / max)",
+		stats.Snipes, format(stats.MinWei), format(stats.AvgWei), format(stats.MaxWei))
+}
+
+// formatWindow renders a report window, in days when it is a whole number of them
+func formatWindow(window time.Duration) string {
+	if window >= 24*time.Hour && window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d days", window/(24*time.Hour))
+	}
+	return window.String()
+}
+
+// handleExportWallets sends every wallet as a keystore file encrypted under the
+// passphrase given with the command (admins only). The command message is
+// deleted so the passphrase doesn't stay in the chat history.
+func (s *Service) handleExportWallets(message *tgbotapi.Message) string {
+	userIDStr := fmt.Sprintf("%d", message.From.ID)
+	if !s.config.IsAdmin(userIDStr) {
+		return "Unknown command"
+	}
+
+	if _, err := s.bot.Request(tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID)); err != nil {
+		log.Printf("Failed to delete /exportwallets message: %v", err)
+	}
+
+	// The keystores and the passphrase would both be readable by everyone in
+	// a group, so the export only goes to the admin's private chat
+	if !message.Chat.IsPrivate() {
+		log.Printf("🚨 Wallet export by admin %s refused in non-private chat %d", userIDStr, message.Chat.ID)
+		return "🔒 /exportwallets only works in a private chat with the bot. Choose a new passphrase, this one was posted to the group."
+	}
+
+	passphrase := strings.TrimSpace(message.CommandArguments())
+	if len(passphrase) < wallet.MinExportPassphraseLength {
+		return fmt.Sprintf("Usage: /exportwallets &lt;passphrase&gt; (at least %d characters)", wallet.MinExportPassphraseLength)
+	}
+
+	log.Printf("🔐 Wallet export requested by admin %s", userIDStr)
+
+	// Stream the export straight into the upload instead of buffering it
+	reader, writer := io.Pipe()
+	go func() {
+		count, err := s.walletManager.ExportKeystores(writer, passphrase)
+		if err != nil {
+			log.Printf("❌ Wallet export by admin %s failed after %d wallets: %v", userIDStr, count, err)
+		} else {
+			log.Printf("🔐 Exported %d wallets for admin %s", count, userIDStr)
+		}
+		writer.CloseWithError(err)
+	}()
+
+	doc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileReader{
+		Name:   fmt.Sprintf("wallets-%s.json", time.Now().UTC().Format("20060102-150405")),
+		Reader: reader,
+	})
+	doc.Caption = "🔐 Wallet backup (V3 keystores, encrypted under your passphrase)"
+	if _, err := s.bot.Send(doc); err != nil {
+		reader.CloseWithError(err)
+		log.Printf("❌ Failed to send wallet export to admin %s: %v", userIDStr, err)
+		return "❌ Wallet export failed. Check the logs."
+	}
+
+	return "✅ Wallet export sent."
+}
+
+// handleSettings shows the user's /snipe defaults, or updates them from
+// slippage=<percent>, tip=<ETH> and max_bump=<ETH> options ("off" clears
+// one) and launch_alerts=on|off
+func (s *Service) handleSettings(userID int64, args string) string {
+	userIDStr := fmt.Sprintf("%d", userID)
+
+	settings, err := s.db.GetUserSettings(userIDStr)
+	if err != nil {
+		log.Printf("Failed to load settings for user %s: %v", userIDStr, err)
+		return "❌ Failed to load your settings. Please try again."
+	}
+
+	options := strings.Fields(args)
+	if len(options) == 0 {
+		return formatSettings(settings)
+	}
+
+	var req validation.SettingsRequest
+	for _, option := range options {
+		key, value, ok := strings.Cut(option, "=")
+		switch {
+		case ok && key == "slippage" && value == "off":
+			req.Slippage = ""
+			settings.Slippage = sql.NullFloat64{}
+		case ok && key == "slippage":
+			req.Slippage = value
+		case ok && key == "tip" && value == "off":
+			req.Tip = ""
+			settings.Tip = sql.NullString{}
+		case ok && key == "tip":
+			req.Tip = value
+		case ok && key == "max_bump" && value == "off":
+			req.MaxBump = ""
+			settings.MaxBump = sql.NullString{}
+		case ok && key == "max_bump":
+			req.MaxBump = value
+		case ok && key == "launch_alerts" && (value == "on" || value == "off"):
+			settings.LaunchAlerts = sql.NullBool{Bool: value == "on", Valid: true}
+		default:
+			return fmt.Sprintf("❌ Unknown option %q. Supported: slippage=&lt;percent&gt;, tip=&lt;ETH&gt;, max_bump=&lt;ETH&gt;, or off to clear one, and launch_alerts=on|off", option)
+		}
+	}
+
+	validated, errs := validation.ValidateSettings(req)
+	if errs != nil {
+		return renderValidationErrors(errs)
+	}
+	if req.Slippage != "" {
+		settings.Slippage = sql.NullFloat64{Float64: validated.Slippage, Valid: true}
+	}
+	if req.Tip != "" {
+		settings.Tip = sql.NullString{String: req.Tip, Valid: true}
+	}
+	if req.MaxBump != "" {
+		settings.MaxBump = sql.NullString{String: req.MaxBump, Valid: true}
+	}
+
+	if err := s.db.SaveUserSettings(settings); err != nil {
+		log.Printf("Failed to save settings for user %s: %v", userIDStr, err)
+		return "❌ Failed to save your settings. Please try again."
+	}
+
+	return "✅ Settings saved.\n\n" + formatSettings(settings)
+}
+
+// formatSettings renders a user's settings for /settings
+func formatSettings(settings *db.UserSettings) string {
+	slippage := "not set"
+	if settings.Slippage.Valid {
+		slippage = fmt.Sprintf("%.2f%%", settings.Slippage.Float64)
+	}
+	tip := "not set, give a bribe in each /snipe"
+	if settings.Tip.Valid {
+		tip = settings.Tip.String + " ETH"
+	}
+	maxBump := "not set, snipes are never bumped"
+	if settings.MaxBump.Valid {
+		maxBump = settings.MaxBump.String + " ETH per snipe"
+	}
+	launchAlerts := "on"
+	if settings.LaunchAlerts.Valid && !settings.LaunchAlerts.Bool {
+		launchAlerts = "off"
+	}
+
+	return fmt.Sprintf("⚙️ <b>Your snipe defaults</b>\n\n"+
+		"📉 Slippage: %s\n"+
+		"💸 Tip (bribe): %s\n"+
+		"🚀 Max bump: %s\n"+
+		"🔔 Launch alerts: %s\n\n"+
+		"Slippage and tip are used when a /snipe leaves them out; max bump is the most extra priority fee a snipe may pay to stay ahead of competing snipes; launch alerts message you as soon as a token you snipe gets liquidity. "+
+		"Change them with /settings slippage=&lt;percent&gt; tip=&lt;ETH&gt; max_bump=&lt;ETH&gt; launch_alerts=on|off, or clear one with e.g. tip=off.",
+		slippage, tip, maxBump, launchAlerts)
+}
+
+// handleSetPaused pauses or resumes sniping for every user (admins only)
+func (s *Service) handleSetPaused(userID int64, paused bool) string {
+	userIDStr := fmt.Sprintf("%d", userID)
+	if !s.config.IsAdmin(userIDStr) {
+		return "Unknown command"
+	}
+
+	if err := s.db.SetPaused(paused); err != nil {
+		log.Printf("Failed to set paused=%t: %v", paused, err)
+		return "❌ Failed to update pause mode. Please try again."
+	}
+
+	log.Printf("⏸️ Sniping paused=%t by admin %s", paused, userIDStr)
+	if paused {
+		return "⏸️ Sniping is paused. LP_ADD events are ignored and snipes stay pending until /resume."
+	}
+	return "▶️ Sniping resumed."
+}
+
+// handleConfig shows the effective configuration with secrets left out
+// (admins only)
+func (s *Service) handleConfig(userID int64) string {
+	if !s.config.IsAdmin(fmt.Sprintf("%d", userID)) {
+		return "Unknown command"
+	}
+
+	settings := s.config.Sanitized()
+	settings["chain_id"] = s.ethClient.GetChainID().String()
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("⚙️ <b>Effective configuration</b>\n\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: <code>%s</code>\n", key, html.EscapeString(fmt.Sprintf("%v", settings[key])))
+	}
+	return b.String()
+}
+
+// handleSelftest runs the same checks as "bot selftest" against the live
+// configuration
+func (s *Service) handleSelftest(userID int64) string {
+	if !s.config.IsAdmin(fmt.Sprintf("%d", userID)) {
+		return "Unknown command"
+	}
+
+	results := selftest.Run(context.Background(), s.config)
+
+	var b strings.Builder
+	if selftest.Passed(results) {
+		b.WriteString("🩺 <b>Self-test passed</b>\n\n")
+	} else {
+		b.WriteString("🩺 <b>Self-test failed</b>\n\n")
+	}
+	for _, result := range results {
+		status := "✅"
+		if !result.OK {
+			status = "❌"
+		}
+		fmt.Fprintf(&b, "%s %s (%s): %s\n", status, html.EscapeString(result.Name),
+			result.Latency.Round(time.Millisecond), html.EscapeString(result.Detail))
+	}
+	return b.String()
+}
+
+func (s *Service) handleSnipe(userID int64, args string) (string, interface{}) {
+	parts := strings.Fields(args)
+	if len(parts) > 0 && parts[0] == "status" {
+		return s.handleSnipeStatus(userID, parts[1:]), nil
+	}
+
+	if paused, err := s.db.IsPaused(); err != nil {
+		log.Printf("Failed to read pause mode: %v", err)
+	} else if paused {
+		return "⏸️ Sniping is temporarily paused for maintenance. Please try again later.", nil
+	}
+
+	if len(parts) < 2 {
+		return "Usage: /snipe <token_address> <amount_in_ETH | pool_percent%> [bribe_in_ETH] [slippage=<percent>] [tp=<multiple>] [sl=<percent>] [to=<address>]\n" +
+			"The bribe and slippage default to your /settings. Use /snipe status <snipe_id> to look one up.", nil
+	}
+
+	req := validation.SnipeRequest{
+		TokenAddress: parts[0],
+		Amount:       parts[1],
+	}
+
+	// The bribe may be left out in favour of the user's default tip
+	options := parts[2:]
+	if len(options) > 0 && !strings.Contains(options[0], "=") {
+		req.BribeAmount = options[0]
+		options = options[1:]
+	}
+
+	// Optional key=value arguments
+	for _, option := range options {
+		key, value, ok := strings.Cut(option, "=")
+		switch {
+		case ok && key == "slippage":
+			req.Slippage = value
+		case ok && key == "tp":
+			req.TakeProfit = value
+		case ok && key == "sl":
+			req.StopLoss = value
+		case ok && key == "to":
+			req.Recipient = value
+		default:
+			return fmt.Sprintf("❌ Unknown option %q. Supported: slippage=<percent>, tp=<multiple>, sl=<percent>, to=<address>", option), nil
+		}
+	}
 
 	userIDStr := fmt.Sprintf("%d", userID)
 
 	// Check if user has a wallet
 	userWallet, err := s.walletManager.GetWallet(userIDStr)
 	if err != nil {
-		return "❌ Wallet not found. Please register first using /register"
+		return "❌ Wallet not found. Please register first using /register", nil
 	}
 
-	// Validate token address format
-	if len(tokenAddress) != 42 || tokenAddress[:2] != "0x" {
-		return "❌ Invalid token address format. Must be a valid Ethereum address (0x...)"
+	// Fill in what the user left out from their /settings
+	settings, err := s.db.GetUserSettings(userIDStr)
+	if err != nil {
+		log.Printf("Failed to load settings for user %s: %v", userIDStr, err)
+		return "❌ Failed to load your settings. Please try again.", nil
+	}
+	bribeSuffix, slippageSuffix := "", ""
+	if req.BribeAmount == "" {
+		if !settings.Tip.Valid {
+			return "❌ Missing bribe. Add it after the amount, or set a default with /settings tip=&lt;ETH&gt;", nil
+		}
+		req.BribeAmount = settings.Tip.String
+		bribeSuffix = " (your default)"
+	}
+	if req.Slippage == "" && settings.Slippage.Valid {
+		req.Slippage = strconv.FormatFloat(settings.Slippage.Float64, 'f', -1, 64)
+		slippageSuffix = " (your default)"
 	}
 
-	// Validate amount and bribe amount are positive numbers
-	if !isValidAmount(amount) {
-		return "❌ Invalid amount. Must be a positive number (e.g., 0.1, 1.5)"
+	// The balance check is skipped if the node can't be reached
+	balance, err := s.ethClient.GetBalance(context.Background(), userWallet.Address)
+	if err != nil {
+		log.Printf("Failed to get balance for %s: %v", userWallet.Address.Hex(), err)
+		balance = nil
 	}
 
-	if !isValidAmount(bribeAmount) {
-		return "❌ Invalid bribe amount. Must be a positive number (e.g., 0.01, 0.1)"
+	validated, errs := validation.ValidateSnipe(req, balance)
+	if errs != nil {
+		return renderValidationErrors(errs), nil
+	}
+	if name, blocked := s.config.BlockedSnipeTarget(validated.TokenAddress.Hex()); blocked {
+		return renderValidationErrors(validation.Errors{{
+			Field:  validation.FieldTokenAddress,
+			Reason: fmt.Sprintf("%s can't be sniped", name),
+		}}), nil
+	}
+	if validated.Recipient != nil && *validated.Recipient == userWallet.Address {
+		return renderValidationErrors(validation.Errors{{
+			Field:  validation.FieldRecipient,
+			Reason: "is already your sniper wallet, leave to= out",
+		}}), nil
+	}
+
+	// A bribe below the snipe's own gas cost makes a losing snipe
+	gasLine := ""
+	if s.config.BribeGasCheck != config.BribeGasCheckOff {
+		gasCost, err := s.snipeGasCost(context.Background())
+		if err != nil {
+			log.Printf("Failed to estimate snipe gas cost: %v", err)
+		} else if validated.BribeAmount.Cmp(gasCost) < 0 {
+			if s.config.BribeGasCheck == config.BribeGasCheckBlock {
+				return renderValidationErrors(validation.Errors{{
+					Field:  validation.FieldBribeAmount,
+					Reason: fmt.Sprintf("must cover the snipe's gas cost of about %s at current fees", eth.FormatEther(gasCost)),
+				}}), nil
+			}
+			gasLine = fmt.Sprintf("⚠️ The bribe is below the snipe's gas cost of about %s at current fees\n", eth.FormatEther(gasCost))
+		}
+	}
+
+	// A token that just launched won't get the same LP_ADD again
+	cooldownLine := ""
+	if s.config.SnipeCooldown > 0 {
+		launch, err := s.db.GetTokenBundleSince(validated.TokenAddress.Hex(), time.Now().Add(-s.config.SnipeCooldown))
+		if err != nil {
+			log.Printf("Failed to look up the launch of token %s: %v", validated.TokenAddress.Hex(), err)
+		} else if launch != nil {
+			if s.config.SnipeCooldownPolicy == config.SnipeCooldownBlock {
+				return renderValidationErrors(validation.Errors{{
+					Field:  validation.FieldTokenAddress,
+					Reason: fmt.Sprintf("this token launched at %s, try again once %s have passed", launch.SubmittedAt, s.config.SnipeCooldown),
+				}}), nil
+			}
+			cooldownLine = fmt.Sprintf("⚠️ This token already launched at %s. The snipe only fires if liquidity is added again\n", launch.SubmittedAt)
+		}
+	}
+
+	tokenAddress := validated.TokenAddress.Hex()
+	amount := req.Amount
+	bribeAmount := req.BribeAmount
+
+	amountMode := db.AmountModeETH
+	amountLine := fmt.Sprintf("💰 Amount: %s ETH\n", amount)
+	if validated.PoolPercent != "" {
+		amountMode = db.AmountModePoolPercent
+		amount = validated.PoolPercent
+		amountLine = fmt.Sprintf("💰 Amount: %s%% of pool ETH liquidity (resolved at launch)\n", amount)
+	}
+
+	slippageLine := ""
+	if req.Slippage != "" {
+		slippageLine = fmt.Sprintf("📉 Max slippage: %.2f%%%s\n", validated.Slippage, slippageSuffix)
+	}
+	if req.TakeProfit != "" {
+		slippageLine += fmt.Sprintf("🎯 Take-profit: sell at %gx\n", validated.TakeProfitX)
+	}
+	if req.StopLoss != "" {
+		slippageLine += fmt.Sprintf("🛑 Stop-loss: sell after a %g%% loss\n", validated.StopLossPct)
+	}
+	if validated.Recipient != nil {
+		slippageLine += fmt.Sprintf("📬 Deliver to: <code>%s</code> (an extra transfer after the snipe lands)\n", validated.Recipient.Hex())
 	}
 
-	// Create snipe record in database
 	snipe := &db.Snipe{
 		UserID:       userIDStr,
 		TokenAddress: tokenAddress,
 		Amount:       amount,
+		AmountMode:   amountMode,
 		BribeAmount:  bribeAmount,
 		Wallet:       userWallet.Address.Hex(),
-		Status:       "pending",
+		Status:       db.SnipeStatusPending,
+	}
+	if req.Slippage != "" {
+		snipe.Slippage = sql.NullFloat64{Float64: validated.Slippage, Valid: true}
+	}
+	if req.TakeProfit != "" {
+		snipe.TakeProfitX = sql.NullFloat64{Float64: validated.TakeProfitX, Valid: true}
+	}
+	if req.StopLoss != "" {
+		snipe.StopLossPct = sql.NullFloat64{Float64: validated.StopLossPct, Valid: true}
+	}
+	if validated.Recipient != nil {
+		snipe.Recipient = sql.NullString{String: validated.Recipient.Hex(), Valid: true}
+	}
+
+	// The operator's commission is paid on top of the amount and bribe
+	commissionLine := ""
+	if s.config.Commission.Enabled() {
+		if balance != nil && !coversSnipe(balance, snipe, s.config.Commission) {
+			return fmt.Sprintf("❌ Invalid balance: insufficient funds for %s, %s ETH bribe and the %s commission, have %s",
+				formatSnipeAmount(snipe), bribeAmount, s.config.Commission.Describe(), eth.FormatEther(balance)), nil
+		}
+		commissionLine = fmt.Sprintf("🏦 Commission: %s (charged even if the snipe fails)\n", s.config.Commission.Describe())
+	}
+
+	// A second pending snipe for the same token would only outbid the first
+	mergeLine := ""
+	existing, err := s.findDuplicateSnipe(snipe)
+	if err != nil {
+		log.Printf("Failed to look up pending snipes for user %s: %v", userIDStr, err)
+		return "❌ Failed to prepare snipe request. Please try again.", nil
+	}
+	if existing != nil {
+		if s.config.DuplicateSnipePolicy == config.DuplicateSnipeReject {
+			return fmt.Sprintf("❌ You already have a pending snipe (#%d) for this token.", existing.ID), nil
+		}
+
+		merged, err := mergeSnipe(existing, snipe)
+		if err != nil {
+			return "❌ " + err.Error(), nil
+		}
+
+		if balance != nil && !coversSnipe(balance, merged, s.config.Commission) {
+			return fmt.Sprintf("❌ Invalid balance: insufficient funds for the merged snipe (%s + %s ETH bribe), have %s",
+				formatSnipeAmount(merged), merged.BribeAmount, eth.FormatEther(balance)), nil
+		}
+
+		mergeLine = fmt.Sprintf("🔀 Merges into pending snipe #%d → %s, %s ETH bribe\n",
+			existing.ID, formatSnipeAmount(merged), merged.BribeAmount)
+	}
+
+	// Hold the snipe until the user confirms it
+	confirmationID, err := s.addConfirmation(snipe)
+	if err != nil {
+		log.Printf("Failed to create snipe confirmation: %v", err)
+		return "❌ Failed to prepare snipe request. Please try again.", nil
+	}
+
+	keyboard := telegram.CreateKeyboard([][]telegram.KeyboardButton{{
+		{Text: "✅ Confirm", Data: callbackConfirmSnipe + confirmationID},
+		{Text: "❌ Cancel", Data: callbackCancelSnipe + confirmationID},
+	}})
+
+	return fmt.Sprintf("🔎 <b>Please confirm your snipe:</b>\n\n"+
+		"🎯 Token: <code>%s</code>\n"+
+		"%s"+
+		"💸 Bribe: %s ETH%s\n"+
+		"%s"+
+		"%s"+
+		"%s"+
+		"%s"+
+		"👛 Wallet: <code>%s</code>\n"+
+		"%s\n"+
+		"⏳ This confirmation expires in %d minutes.",
+		tokenAddress, amountLine, bribeAmount, bribeSuffix, slippageLine, gasLine, cooldownLine, commissionLine, userWallet.Address.Hex(), mergeLine, int(confirmationTTL.Minutes())), keyboard
+}
+
+// snipeStatusEmoji marks each snipe status in /snipe status
+var snipeStatusEmoji = map[string]string{
+	db.SnipeStatusPending:      "⏳",
+	db.SnipeStatusSubmitting:   "📨",
+	db.SnipeStatusSubmitted:    "📤",
+	db.SnipeStatusSubmitFailed: "🚫",
+	db.SnipeStatusLanded:       "✅",
+	db.SnipeStatusReverted:     "❌",
+	db.SnipeStatusDropped:      "✂️",
+	db.SnipeStatusSold:         "💰",
+	db.SnipeStatusCancelled:    "🪤",
+}
+
+// handleSnipeStatus shows everything recorded about one of the user's snipes:
+// its parameters, status, transaction, block and confirmations, gas cost and
+// revert reason
+func (s *Service) handleSnipeStatus(userID int64, args []string) string {
+	if len(args) != 1 {
+		return "Usage: /snipe status &lt;snipe_id&gt;"
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil || id <= 0 {
+		return "❌ Invalid snipe ID. Use the number shown when the snipe was placed, e.g. /snipe status 42"
+	}
+
+	userIDStr := fmt.Sprintf("%d", userID)
+	snipe, err := s.db.GetSnipeByID(id, userIDStr)
+	if err != nil {
+		log.Printf("Failed to load snipe %d for user %s: %v", id, userIDStr, err)
+		return "❌ Failed to load the snipe. Please try again."
+	}
+	// Other users' snipes are reported as missing too
+	if snipe == nil {
+		return fmt.Sprintf("❌ Snipe #%d not found.", id)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 <b>Snipe #%d</b>\n\n", snipe.ID)
+	fmt.Fprintf(&b, "%s Status: <b>%s</b>\n", snipeStatusEmoji[snipe.Status], snipe.Status)
+	fmt.Fprintf(&b, "🎯 Token: <code>%s</code>\n", snipe.TokenAddress)
+	fmt.Fprintf(&b, "💰 Amount: %s\n", formatSnipeAmount(snipe))
+	fmt.Fprintf(&b, "💸 Bribe: %s ETH\n", snipe.BribeAmount)
+	if snipe.Slippage.Valid {
+		fmt.Fprintf(&b, "📉 Max slippage: %.2f%%\n", snipe.Slippage.Float64)
+	}
+	if snipe.TakeProfitX.Valid {
+		fmt.Fprintf(&b, "🎯 Take-profit: sell at %gx\n", snipe.TakeProfitX.Float64)
+	}
+	if snipe.StopLossPct.Valid {
+		fmt.Fprintf(&b, "🛑 Stop-loss: sell after a %g%% loss\n", snipe.StopLossPct.Float64)
+	}
+	if snipe.Recipient.Valid {
+		fmt.Fprintf(&b, "📬 Deliver to: <code>%s</code>\n", snipe.Recipient.String)
+	}
+	fmt.Fprintf(&b, "👛 Wallet: <code>%s</code>\n", snipe.Wallet)
+	fmt.Fprintf(&b, "🕐 Placed: %s\n", snipe.CreatedAt)
+
+	if snipe.TxHash.Valid {
+		fmt.Fprintf(&b, "\n🔗 Tx: <code>%s</code>\n", snipe.TxHash.String)
+	}
+	if snipe.BundlePosition.Valid {
+		fmt.Fprintf(&b, "📦 Bundle position: %d\n", snipe.BundlePosition.Int64+1)
+	}
+	if snipe.BundleID.Valid {
+		fmt.Fprintf(&b, "🆔 Bundle: <code>%s</code>\n", snipe.BundleID.String)
+	}
+	if snipe.BlockNumber.Valid {
+		block := uint64(snipe.BlockNumber.Int64)
+		if head, err := s.ethClient.Client.BlockNumber(context.Background()); err == nil && head >= block {
+			fmt.Fprintf(&b, "🧱 Block: %d (%d confirmations)\n", block, head-block+1)
+		} else {
+			fmt.Fprintf(&b, "🧱 Block: %d\n", block)
+		}
+	}
+	if snipe.EntryPrice.Valid {
+		fmt.Fprintf(&b, "🏷️ Entry price: %.6g ETH per token\n", snipe.EntryPrice.Float64)
+	}
+	if snipe.GasUsed.Valid {
+		gasLine := fmt.Sprintf("⛽ Gas used: %d", snipe.GasUsed.Int64)
+		if price, ok := new(big.Int).SetString(snipe.EffectiveGasPrice.String, 10); ok {
+			cost := new(big.Int).Mul(big.NewInt(snipe.GasUsed.Int64), price)
+			gasLine += fmt.Sprintf(" (%s)", eth.FormatEther(cost))
+		}
+		b.WriteString(gasLine + "\n")
+	}
+	if snipe.RevertReason.Valid {
+		fmt.Fprintf(&b, "⚠️ Revert reason: <code>%s</code>\n", html.EscapeString(snipe.RevertReason.String))
+	} else if snipe.Status == db.SnipeStatusReverted {
+		b.WriteString("⚠️ Revert reason: unknown, the revert didn't reproduce\n")
+	}
+	if snipe.SimulationStatus.String == db.SimulationFailed {
+		fmt.Fprintf(&b, "🧪 Failed simulation: <code>%s</code>\n", html.EscapeString(snipe.SimulationError.String))
+	} else if snipe.SimulationStatus.Valid {
+		b.WriteString("🧪 Passed simulation\n")
+	}
+	if snipe.SellTxHash.Valid {
+		fmt.Fprintf(&b, "💰 Sold in <code>%s</code>\n", snipe.SellTxHash.String)
+	}
+	if snipe.ForwardTxHash.Valid {
+		fmt.Fprintf(&b, "📬 Forwarded in <code>%s</code>\n", snipe.ForwardTxHash.String)
+	}
+
+	return b.String()
+}
+
+// handleRequeue places a reverted, dropped or cancelled snipe again as a new
+// pending snipe with the same parameters, optionally with a new bribe and
+// slippage: /requeue <snipe_id> [bribe_in_ETH] [slippage=<percent>]
+func (s *Service) handleRequeue(userID int64, args string) string {
+	usage := "Usage: /requeue &lt;snipe_id&gt; [bribe_in_ETH] [slippage=&lt;percent&gt;]\n" +
+		"Places a reverted, dropped or cancelled snipe again with the same amount and targets."
+
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		return usage
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "#"), 10, 64)
+	if err != nil || id <= 0 {
+		return "❌ Invalid snipe ID. Use the number shown when the snipe was placed, e.g. /requeue 42"
+	}
+
+	if paused, err := s.db.IsPaused(); err != nil {
+		log.Printf("Failed to read pause mode: %v", err)
+	} else if paused {
+		return "⏸️ Sniping is temporarily paused for maintenance. Please try again later."
+	}
+
+	userIDStr := fmt.Sprintf("%d", userID)
+	original, err := s.db.GetSnipeByID(id, userIDStr)
+	if err != nil {
+		log.Printf("Failed to load snipe %d for user %s: %v", id, userIDStr, err)
+		return "❌ Failed to load the snipe. Please try again."
+	}
+	if original == nil {
+		return fmt.Sprintf("❌ Snipe #%d not found.", id)
+	}
+	switch original.Status {
+	case db.SnipeStatusReverted, db.SnipeStatusDropped, db.SnipeStatusCancelled, db.SnipeStatusSubmitFailed:
+	default:
+		return fmt.Sprintf("❌ Snipe #%d is %s. Only reverted, dropped, cancelled or submit_failed snipes can be requeued.", id, original.Status)
+	}
+
+	// The original's parameters, with the bribe and slippage open to change
+	req := validation.SnipeRequest{
+		TokenAddress: original.TokenAddress,
+		Amount:       original.Amount,
+		BribeAmount:  original.BribeAmount,
+	}
+	if original.AmountMode == db.AmountModePoolPercent {
+		req.Amount += "%"
+	}
+	if original.Slippage.Valid {
+		req.Slippage = strconv.FormatFloat(original.Slippage.Float64, 'f', -1, 64)
+	}
+
+	options := parts[1:]
+	if len(options) > 0 && !strings.Contains(options[0], "=") {
+		req.BribeAmount = options[0]
+		options = options[1:]
+	}
+	for _, option := range options {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok || key != "slippage" {
+			return fmt.Sprintf("❌ Unknown option %q. Supported: slippage=&lt;percent&gt;", option)
+		}
+		req.Slippage = value
+	}
+
+	// The wallet must still cover the snipe, as for a new /snipe
+	userWallet, err := s.walletManager.GetWallet(userIDStr)
+	if err != nil {
+		return "❌ Wallet not found. Please register first using /register"
+	}
+	balance, err := s.ethClient.GetBalance(context.Background(), userWallet.Address)
+	if err != nil {
+		log.Printf("Failed to get balance for %s: %v", userWallet.Address.Hex(), err)
+		balance = nil
+	}
+
+	validated, errs := validation.ValidateSnipe(req, balance)
+	if errs != nil {
+		return renderValidationErrors(errs)
+	}
+	if name, blocked := s.config.BlockedSnipeTarget(original.TokenAddress); blocked {
+		return fmt.Sprintf("❌ %s can't be sniped.", name)
+	}
+
+	snipe := *original
+	snipe.BribeAmount = req.BribeAmount
+	snipe.Slippage = sql.NullFloat64{}
+	if req.Slippage != "" {
+		snipe.Slippage = sql.NullFloat64{Float64: validated.Slippage, Valid: true}
+	}
+	if balance != nil && !coversSnipe(balance, &snipe, s.config.Commission) {
+		return fmt.Sprintf("❌ Invalid balance: insufficient funds for %s, %s ETH bribe and the %s commission, have %s",
+			formatSnipeAmount(&snipe), snipe.BribeAmount, s.config.Commission.Describe(), eth.FormatEther(balance))
+	}
+
+	// A requeued snipe isn't merged; the pending one can be changed with /snipe
+	existing, err := s.findDuplicateSnipe(&snipe)
+	if err != nil {
+		log.Printf("Failed to look up pending snipes for user %s: %v", userIDStr, err)
+		return "❌ Failed to requeue the snipe. Please try again."
+	}
+	if existing != nil {
+		return fmt.Sprintf("❌ You already have a pending snipe (#%d) for this token.", existing.ID)
+	}
+
+	newID, err := s.db.RequeueSnipe(&snipe)
+	if err != nil {
+		log.Printf("Failed to requeue snipe %d for user %s: %v", id, userIDStr, err)
+		return "❌ Failed to requeue the snipe. Please try again."
+	}
+	if newID == 0 {
+		return fmt.Sprintf("❌ Snipe #%d can no longer be requeued.", id)
+	}
+
+	slippage := "none"
+	if snipe.Slippage.Valid {
+		slippage = fmt.Sprintf("%.2f%%", snipe.Slippage.Float64)
+	}
+	return fmt.Sprintf("🔁 Snipe #%d requeued as #%d\n\n"+
+		"🎯 Token: <code>%s</code>\n"+
+		"💰 Amount: %s\n"+
+		"💸 Bribe: %s ETH\n"+
+		"📉 Max slippage: %s\n\n"+
+		"⏳ It fires on the token's next LP_ADD. Follow it with /snipe status %d.",
+		id, newID, snipe.TokenAddress, formatSnipeAmount(&snipe), snipe.BribeAmount, slippage, newID)
+}
+
+// snipeGasCost estimates what a snipe pays in gas at current fees: the full
+// snipe gas limit at the current gas price plus the priority fee, and the L1
+// data fee when the l1_fee feature is on
+func (s *Service) snipeGasCost(ctx context.Context) (*big.Int, error) {
+	gasPrice, err := s.gasPrices.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	perGas := new(big.Int).Add(gasPrice, s.priorityFees.PriorityFee(ctx))
+	cost := perGas.Mul(perGas, new(big.Int).SetUint64(s.config.Gas.SnipeGasLimit))
+
+	// A chain without the GasPriceOracle predeploy just has no L1 fee
+	if s.config.Enabled(config.FeatureL1Fee) {
+		l1Fee, err := eth.L1FeeUpperBound(ctx, s.ethClient.Client, snipeTxSize)
+		if err != nil {
+			log.Printf("⚠️ Failed to price the L1 data fee, leaving it out: %v", err)
+		} else {
+			cost.Add(cost, l1Fee)
+		}
+	}
+	return cost, nil
+}
+
+// snipeTxSize is a generous size in bytes of a snipeWithBribe transaction,
+// for pricing its L1 data fee
+const snipeTxSize = 400
+
+// handleCallbackQuery handles inline keyboard button presses
+func (s *Service) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+	var text string
+
+	switch {
+	case strings.HasPrefix(query.Data, callbackConfirmSnipe):
+		text = s.confirmSnipe(query.From.ID, strings.TrimPrefix(query.Data, callbackConfirmSnipe))
+	case strings.HasPrefix(query.Data, callbackCancelSnipe):
+		text = s.cancelSnipe(query.From.ID, strings.TrimPrefix(query.Data, callbackCancelSnipe))
+	default:
+		text = "Unknown action"
+	}
+
+	// Acknowledge the button press so the client stops its loading indicator
+	if _, err := s.bot.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("Error answering callback query: %v", err)
+	}
+
+	if query.Message == nil {
+		return
+	}
+
+	// Replace the confirmation prompt (and its buttons) with the outcome
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.ParseMode = "HTML"
+	if _, err := s.bot.Send(edit); err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+}
+
+// confirmSnipe inserts a snipe the user confirmed
+func (s *Service) confirmSnipe(userID int64, confirmationID string) string {
+	snipe := s.takeConfirmation(userID, confirmationID)
+	if snipe == nil {
+		return "⌛ This confirmation has expired. Please send /snipe again."
+	}
+
+	// Re-check for a duplicate: another snipe may have been confirmed since the prompt
+	existing, err := s.findDuplicateSnipe(snipe)
+	if err != nil {
+		log.Printf("Failed to look up pending snipes for user %s: %v", snipe.UserID, err)
+		return "❌ Failed to submit snipe request. Please try again."
+	}
+	if existing != nil {
+		if s.config.DuplicateSnipePolicy == config.DuplicateSnipeReject {
+			return fmt.Sprintf("❌ You already have a pending snipe (#%d) for this token.", existing.ID)
+		}
+		return s.mergeIntoPending(existing, snipe)
 	}
 
 	if err := s.db.CreateSnipe(snipe); err != nil {
@@ -182,27 +1311,190 @@ func (s *Service) handleSnipe(userID int64, args string) string {
 	return fmt.Sprintf("✅ Snipe request submitted successfully!\n\n"+
 		"📋 <b>Details:</b>\n"+
 		"🎯 Token: <code>%s</code>\n"+
-		"💰 Amount: %s ETH\n"+
+		"💰 Amount: %s\n"+
 		"💸 Bribe: %s ETH\n"+
 		"👛 Wallet: <code>%s</code>\n"+
 		"🆔 Request ID: %d\n\n"+
-		"⏳ Your request is now pending. You'll be included in the next bundle when liquidity is added for this token.",
-		tokenAddress, amount, bribeAmount, userWallet.Address.Hex(), snipe.ID)
+		"⏳ Your request is now pending. You'll be included in the next bundle when liquidity is added for this token. Follow it with /snipe status %d.",
+		snipe.TokenAddress, formatSnipeAmount(snipe), snipe.BribeAmount, snipe.Wallet, snipe.ID, snipe.ID)
 }
 
-// isValidAmount checks if a string represents a valid positive number
-func isValidAmount(amount string) bool {
-	if amount == "" {
-		return false
+// cancelSnipe discards a snipe awaiting confirmation
+func (s *Service) cancelSnipe(userID int64, confirmationID string) string {
+	if s.takeConfirmation(userID, confirmationID) == nil {
+		return "⌛ This confirmation has already expired."
 	}
+	return "🚫 Snipe request cancelled."
+}
+
+// addConfirmation stores a snipe awaiting confirmation and returns its ID
+func (s *Service) addConfirmation(snipe *db.Snipe) (string, error) {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	confirmationID := hex.EncodeToString(idBytes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired confirmations so abandoned prompts don't accumulate
+	now := time.Now()
+	for id, pending := range s.confirmations {
+		if now.After(pending.expiresAt) {
+			delete(s.confirmations, id)
+		}
+	}
+
+	s.confirmations[confirmationID] = &pendingConfirmation{
+		snipe:     snipe,
+		expiresAt: now.Add(confirmationTTL),
+	}
+
+	return confirmationID, nil
+}
 
-	// Try to parse as float
-	var f float64
-	n, err := fmt.Sscanf(amount, "%f", &f)
-	if err != nil || n != 1 {
+// takeConfirmation removes and returns the user's pending snipe, or nil if it
+// doesn't exist, has expired, or belongs to another user
+func (s *Service) takeConfirmation(userID int64, confirmationID string) *db.Snipe {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, ok := s.confirmations[confirmationID]
+	if !ok || pending.snipe.UserID != fmt.Sprintf("%d", userID) {
+		return nil
+	}
+
+	delete(s.confirmations, confirmationID)
+	if time.Now().After(pending.expiresAt) {
+		return nil
+	}
+
+	return pending.snipe
+}
+
+// mergeIntoPending folds a confirmed snipe into the user's pending snipe for the same token
+func (s *Service) mergeIntoPending(existing, snipe *db.Snipe) string {
+	merged, err := mergeSnipe(existing, snipe)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+
+	updated, err := s.db.UpdatePendingSnipe(merged)
+	if err != nil {
+		log.Printf("Failed to merge snipe into #%d: %v", existing.ID, err)
+		return "❌ Failed to submit snipe request. Please try again."
+	}
+	if !updated {
+		return fmt.Sprintf("⚠️ Your pending snipe #%d was just submitted, so nothing was merged. Please send /snipe again.", existing.ID)
+	}
+
+	return fmt.Sprintf("🔀 Merged into your pending snipe #%d!\n\n"+
+		"💰 Amount: %s\n"+
+		"💸 Bribe: %s ETH\n\n"+
+		"⏳ Your request is still pending. It will be included in the next bundle when liquidity is added for this token.",
+		merged.ID, formatSnipeAmount(merged), merged.BribeAmount)
+}
+
+// findDuplicateSnipe returns the user's pending snipe for the same token, or
+// nil if there is none or duplicates are allowed
+func (s *Service) findDuplicateSnipe(snipe *db.Snipe) (*db.Snipe, error) {
+	if s.config.DuplicateSnipePolicy == config.DuplicateSnipeAllow {
+		return nil, nil
+	}
+	return s.db.GetPendingSnipeForToken(snipe.UserID, snipe.TokenAddress)
+}
+
+// mergeSnipe combines a pending snipe with a new one for the same user and
+// token: the amounts are summed, the higher bribe is kept, and a newly given
+// slippage, take-profit or stop-loss replaces the old one
+func mergeSnipe(existing, incoming *db.Snipe) (*db.Snipe, error) {
+	if existing.AmountMode != incoming.AmountMode {
+		return nil, fmt.Errorf("your pending snipe #%d for this token is sized as %s and can't be merged with %s",
+			existing.ID, formatSnipeAmount(existing), formatSnipeAmount(incoming))
+	}
+	if existing.Recipient != incoming.Recipient {
+		return nil, fmt.Errorf("your pending snipe #%d for this token delivers to a different address and can't be merged", existing.ID)
+	}
+
+	decimals := 18
+	if existing.AmountMode == db.AmountModePoolPercent {
+		decimals = 6
+	}
+
+	existingAmount, err := eth.ParseUnits(existing.Amount, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount on pending snipe #%d", existing.ID)
+	}
+	incomingAmount, err := eth.ParseUnits(incoming.Amount, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount")
+	}
+	total := new(big.Int).Add(existingAmount, incomingAmount)
+
+	if existing.AmountMode == db.AmountModePoolPercent && total.Cmp(big.NewInt(validation.MaxPoolPercent*1e6)) > 0 {
+		return nil, fmt.Errorf("merged with pending snipe #%d the pool share would exceed %d%%", existing.ID, validation.MaxPoolPercent)
+	}
+
+	existingBribe, err := eth.ParseEther(existing.BribeAmount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bribe on pending snipe #%d", existing.ID)
+	}
+	incomingBribe, err := eth.ParseEther(incoming.BribeAmount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bribe amount")
+	}
+
+	merged := *existing
+	merged.Amount = eth.FormatUnits(total, decimals, decimals)
+	if incomingBribe.Cmp(existingBribe) > 0 {
+		merged.BribeAmount = incoming.BribeAmount
+	}
+	if incoming.Slippage.Valid {
+		merged.Slippage = incoming.Slippage
+	}
+	if incoming.TakeProfitX.Valid {
+		merged.TakeProfitX = incoming.TakeProfitX
+	}
+	if incoming.StopLossPct.Valid {
+		merged.StopLossPct = incoming.StopLossPct
+	}
+	return &merged, nil
+}
+
+// coversSnipe reports whether balance pays for a snipe's bribe, amount and
+// commission (pool-percent amounts aren't known until launch and are not
+// counted)
+func coversSnipe(balance *big.Int, snipe *db.Snipe, commission config.CommissionConfig) bool {
+	required, err := eth.ParseEther(snipe.BribeAmount)
+	if err != nil {
 		return false
 	}
+	amount := big.NewInt(0)
+	if snipe.AmountMode != db.AmountModePoolPercent {
+		amount, err = eth.ParseEther(snipe.Amount)
+		if err != nil {
+			return false
+		}
+		required.Add(required, amount)
+	}
+	required.Add(required, commission.Amount(amount))
+	return balance.Cmp(required) >= 0
+}
+
+// formatSnipeAmount renders a snipe's amount according to its amount mode
+func formatSnipeAmount(snipe *db.Snipe) string {
+	if snipe.AmountMode == db.AmountModePoolPercent {
+		return snipe.Amount + "% of pool"
+	}
+	return snipe.Amount + " ETH"
+}
 
-	// Must be positive
-	return f > 0
+// renderValidationErrors renders validation errors as a bot reply
+func renderValidationErrors(errs validation.Errors) string {
+	lines := make([]string, 0, len(errs))
+	for _, fieldErr := range errs {
+		lines = append(lines, "❌ "+fieldErr.Message())
+	}
+	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"sniper-bot/pkg/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestAdminCommandsRejectNonAdmins(t *testing.T) {
+	// Non-admins are turned away before the database, node or bot is touched
+	s := &Service{config: &config.Config{AdminUserIDs: []string{"1001"}}}
+
+	commands := []struct {
+		name string
+		run  func(userID int64) string
+	}{
+		{"/pause", func(userID int64) string { return s.handleSetPaused(userID, true) }},
+		{"/resume", func(userID int64) string { return s.handleSetPaused(userID, false) }},
+		{"/config", s.handleConfig},
+		{"/selftest", s.handleSelftest},
+		{"/exportwallets", func(userID int64) string {
+			return s.handleExportWallets(&tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Text: "/exportwallets passphrase"})
+		}},
+	}
+
+	for _, command := range commands {
+		for _, userID := range []int64{1002, 100, 0} {
+			if got := command.run(userID); got != "Unknown command" {
+				t.Errorf("%s from user %d: %q, want Unknown command", command.name, userID, got)
+			}
+		}
+	}
+}
+
+// stubTelegram is a Bot API server that accepts every request and records
+// the methods called
+type stubTelegram struct {
+	*httptest.Server
+	mu      sync.Mutex
+	methods []string
+}
+
+func newStubTelegram(t *testing.T) (*stubTelegram, *tgbotapi.BotAPI) {
+	t.Helper()
+	stub := &stubTelegram{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		stub.mu.Lock()
+		stub.methods = append(stub.methods, method)
+		stub.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if method == "getMe" {
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"sniper","username":"sniper_bot"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":true}`)
+	}))
+	t.Cleanup(stub.Close)
+
+	api, err := tgbotapi.NewBotAPIWithClient("token", stub.URL+"/bot%s/%s", stub.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stub, api
+}
+
+func (s *stubTelegram) called(method string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExportWalletsRefusedOutsidePrivateChats(t *testing.T) {
+	for _, chatType := range []string{"group", "supergroup", "channel"} {
+		telegram, api := newStubTelegram(t)
+		s := &Service{bot: api, config: &config.Config{AdminUserIDs: []string{"1001"}}}
+
+		reply := s.handleExportWallets(&tgbotapi.Message{
+			MessageID: 5,
+			From:      &tgbotapi.User{ID: 1001},
+			Chat:      &tgbotapi.Chat{ID: -200, Type: chatType},
+			Text:      "/exportwallets a long enough passphrase",
+		})
+
+		if telegram.called("sendDocument") {
+			t.Errorf("%s chat: wallet export sent", chatType)
+		}
+		if !telegram.called("deleteMessage") {
+			t.Errorf("%s chat: command message with the passphrase not deleted", chatType)
+		}
+		if !strings.Contains(reply, "private chat") {
+			t.Errorf("%s chat: reply %q, want a refusal", chatType, reply)
+		}
+	}
+}
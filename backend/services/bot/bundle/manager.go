@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sniper-bot/pkg/config"
 	"sniper-bot/pkg/dex"
+	"sniper-bot/pkg/eth"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
@@ -17,29 +21,159 @@ import (
 type Manager struct {
 	client         *ethclient.Client
 	sniperContract *dex.SniperContract
+	config         *config.Config
+	gasPrices      eth.GasPriceSource
+	priorityFees   *eth.PriorityFeeTracker
+	nonces         *eth.NonceReader
+	txs            *eth.TxBuilder
 }
 
 // NewManager creates a new bundle manager
-func NewManager(client *ethclient.Client, sniperContractAddr common.Address) (*Manager, error) {
-	sniperContract, err := dex.NewSniperContract(client, sniperContractAddr)
+func NewManager(client *ethclient.Client, sniperContractAddr common.Address, cfg *config.Config) (*Manager, error) {
+	abiJSON, err := cfg.SniperABIJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validated here so a bad ABI stops startup instead of failing the first bundle
+	sniperABI, err := dex.ParseSniperABI(abiJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	sniperContract, err := dex.NewSniperContractWithABI(client, sniperContractAddr, sniperABI)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sniper contract: %v", err)
 	}
+	sniperContract.SetGasLimit(cfg.Gas.SnipeGasLimit)
+
+	txs, err := NewTxBuilder(context.Background(), client, cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Manager{
 		client:         client,
 		sniperContract: sniperContract,
+		config:         cfg,
+		gasPrices:      NewGasPriceSource(client, cfg.Gas),
+		priorityFees:   NewPriorityFeeTracker(client, cfg.Gas),
+		nonces:         NewNonceReader(client, cfg),
+		txs:            txs,
 	}, nil
 }
 
+// NewGasPriceSource creates the gas price source selected by GAS_PRICE_SOURCE
+func NewGasPriceSource(client *ethclient.Client, gas config.GasConfig) eth.GasPriceSource {
+	node := eth.NewNodeGasPrice(client)
+
+	switch gas.PriceSource {
+	case config.GasSourceBaseFee:
+		return eth.NewBaseFeeMultipleGasPrice(client, gas.BaseFeeMultiplier)
+	case config.GasSourceOracle:
+		return eth.NewOracleGasPrice(gas.OracleURL, gas.OracleTTL, node)
+	default:
+		return node
+	}
+}
+
+// NewPriorityFeeTracker creates the priority fee tracker configured by the
+// GAS_PRIORITY_FEE_* settings
+func NewPriorityFeeTracker(client *ethclient.Client, gas config.GasConfig) *eth.PriorityFeeTracker {
+	return eth.NewPriorityFeeTracker(client, gas.PriorityFee, gas.MaxPriorityFee,
+		gas.PriorityFeeBlocks, gas.PriorityFeePercentile, gas.PriorityFeeTTL)
+}
+
+// NewNonceReader creates the nonce reader selected by NONCE_STRATEGY
+func NewNonceReader(client *ethclient.Client, cfg *config.Config) *eth.NonceReader {
+	switch cfg.NonceStrategy {
+	case config.NonceStrategyPending:
+		return eth.NewNonceReader(client, eth.NonceModePending, cfg.NonceMaxGap)
+	case config.NonceStrategyLatest:
+		return eth.NewNonceReader(client, eth.NonceModeLatest, cfg.NonceMaxGap)
+	default:
+		return eth.NewNonceReader(client, eth.NonceModeReconcile, cfg.NonceMaxGap)
+	}
+}
+
+// NewTxBuilder creates the transaction builder for the node's chain, of the
+// type TX_TYPES or TX_TYPE selects for it. It fails if the chain doesn't
+// support that type, so a misconfigured chain stops startup rather than every
+// transaction.
+func NewTxBuilder(ctx context.Context, client *ethclient.Client, cfg *config.Config) (*eth.TxBuilder, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+
+	txType := eth.TxTypeDynamicFee
+	if cfg.TxTypeFor(chainID) == config.TxTypeLegacy {
+		txType = eth.TxTypeLegacy
+	}
+	if err := eth.CheckTxType(ctx, client, txType); err != nil {
+		return nil, fmt.Errorf("chain %s can't use %s transactions (see TX_TYPE): %v", chainID, txType, err)
+	}
+	return eth.NewTxBuilder(txType, chainID), nil
+}
+
+// Txs returns the builder of the transactions the bot signs
+func (m *Manager) Txs() *eth.TxBuilder {
+	return m.txs
+}
+
+// Nonce returns the next nonce of wallet, read as NONCE_STRATEGY selects
+func (m *Manager) Nonce(ctx context.Context, wallet common.Address) (uint64, error) {
+	return m.nonces.Nonce(ctx, wallet)
+}
+
+// Nonces returns the next nonce of each wallet, in order, read in batches
+func (m *Manager) Nonces(ctx context.Context, wallets []common.Address) ([]uint64, error) {
+	return m.nonces.Nonces(ctx, wallets)
+}
+
+// PriorityFee returns the priority fee transactions pay now, GAS_PRIORITY_FEE_WEI
+// or more after recent blocks (see eth.PriorityFeeTracker)
+func (m *Manager) PriorityFee(ctx context.Context) *big.Int {
+	return m.priorityFees.PriorityFee(ctx)
+}
+
+// GasPrice returns the fee per gas transactions pay before their priority
+// fee, from the configured gas price source
+func (m *Manager) GasPrice(ctx context.Context) (*big.Int, error) {
+	gasPrice, err := m.gasPrices.GasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s gas price: %v", m.config.Gas.PriceSource, err)
+	}
+	return gasPrice, nil
+}
+
 // SnipeBid represents a sniper's bid for a token
 type SnipeBid struct {
+	SnipeID      int64
 	UserID       string
 	TokenAddress common.Address
 	SwapAmount   *big.Int
 	BribeAmount  *big.Int
 	Wallet       common.Address
-	PrivateKey   string // Base64 encoded private key
+	PrivateKey   string    // Base64 encoded private key
+	CreatedAt    time.Time // When the snipe was placed
+	Slippage     float64   // Max slippage in percent; 0 when the snipe has none
+	GasLimit     uint64    // Set by EstimateSnipeGas; 0 for SnipeGasLimit
+}
+
+// SortBids orders bids by bribe, highest first. Equal bribes are served
+// first come, first served: the earliest snipe wins, then the lowest snipe
+// ID, matching the ORDER BY of db.GetSnipesByToken.
+func SortBids(bids []*SnipeBid) {
+	sort.SliceStable(bids, func(i, j int) bool {
+		if c := bids[i].BribeAmount.Cmp(bids[j].BribeAmount); c != 0 {
+			return c > 0
+		}
+		if !bids[i].CreatedAt.Equal(bids[j].CreatedAt) {
+			return bids[i].CreatedAt.Before(bids[j].CreatedAt)
+		}
+		return bids[i].SnipeID < bids[j].SnipeID
+	})
 }
 
 // CreateBundleTransactions creates transaction bundle from an LP_ADD transaction and snipe bids
@@ -48,10 +182,8 @@ func (m *Manager) CreateBundleTransactions(
 	lpAddTx *types.Transaction,
 	bids []*SnipeBid,
 ) ([]*types.Transaction, error) {
-	// Sort bids by bribe amount (descending)
-	sort.Slice(bids, func(i, j int) bool {
-		return bids[i].BribeAmount.Cmp(bids[j].BribeAmount) > 0
-	})
+	// Sort bids by bribe amount (descending), ties first come, first served
+	SortBids(bids)
 
 	// Extract token creator from LP_ADD transaction
 	creator, err := m.sniperContract.GetCreatorFromLPAddTx(lpAddTx)
@@ -68,12 +200,18 @@ func (m *Manager) CreateBundleTransactions(
 	// Get base gas price from LP_ADD transaction
 	baseGasPrice := lpAddTx.GasPrice()
 	if baseGasPrice == nil {
-		baseGasPrice, err = m.client.SuggestGasPrice(ctx)
+		baseGasPrice, err = m.GasPrice(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get gas price: %v", err)
+			return nil, err
 		}
 	}
 
+	head, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %v", err)
+	}
+	deadline := m.SwapDeadline(head)
+
 	var transactions []*types.Transaction
 
 	// Add the original LP_ADD transaction first
@@ -81,14 +219,12 @@ func (m *Manager) CreateBundleTransactions(
 
 	// Create snipe transactions for each bid
 	for i, bid := range bids {
-		// Calculate deadline (5 minutes from now)
-		deadline := big.NewInt(time.Now().Add(5 * time.Minute).Unix())
 
 		// Calculate minimum amount out (can be improved with price calculation)
 		amountOutMin := big.NewInt(1) // Minimum 1 wei of tokens
 
 		// Get nonce for the sniper
-		nonce, err := m.client.PendingNonceAt(ctx, bid.Wallet)
+		nonce, err := m.Nonce(ctx, bid.Wallet)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get nonce for sniper %s: %v", bid.Wallet.Hex(), err)
 		}
@@ -96,10 +232,14 @@ func (m *Manager) CreateBundleTransactions(
 		// Calculate gas price (decreasing for proper ordering)
 		// Each subsequent transaction should have slightly lower gas price
 		gasPrice := new(big.Int).Sub(baseGasPrice, big.NewInt(int64(i+1)))
-		if gasPrice.Cmp(big.NewInt(1000000000)) < 0 { // Minimum 1 gwei
-			gasPrice = big.NewInt(1000000000)
+		if gasPrice.Cmp(m.config.Gas.MinGasPrice) < 0 {
+			gasPrice = new(big.Int).Set(m.config.Gas.MinGasPrice)
 		}
 
+		// In tip mode the bribe raises the gas price instead of going to the contract
+		contractBribe, tipPerGas := m.BribeSplit(bid.BribeAmount, m.config.Gas.SnipeGasLimit)
+		gasPrice.Add(gasPrice, tipPerGas)
+
 		// Create snipe transaction
 		snipeTx, err := m.sniperContract.CreateSnipeTransaction(
 			ctx,
@@ -107,7 +247,7 @@ func (m *Manager) CreateBundleTransactions(
 			token,
 			creator,
 			bid.SwapAmount,
-			bid.BribeAmount,
+			contractBribe,
 			amountOutMin,
 			deadline,
 			gasPrice,
@@ -136,6 +276,39 @@ func (m *Manager) SubmitBundle(ctx context.Context, transactions []*types.Transa
 	return nil
 }
 
+// SwapDeadline returns the on-chain swap deadline for a bundle targeting the
+// block after head: the head timestamp plus SwapDeadlineBlocks block times and
+// SwapDeadlineBuffer. A bundle that misses this window is useless anyway, and a
+// tight deadline keeps a stale signed snipe from landing later. The deadline
+// is never more than MaxSwapDeadline past the current time.
+func (m *Manager) SwapDeadline(head *types.Header) *big.Int {
+	window := m.config.SwapDeadlineWindow()
+	headTime := time.Unix(int64(head.Time), 0)
+
+	// Never go below wall-clock time plus the window, in case the head is stale
+	now := time.Now()
+	deadline := headTime.Add(window)
+	if earliest := now.Add(window); earliest.After(deadline) {
+		deadline = earliest
+	}
+	// Never past the cap either, in case the head is timestamped ahead of our clock
+	if latest := now.Add(m.config.MaxSwapDeadline); deadline.After(latest) {
+		deadline = latest
+	}
+
+	return big.NewInt(deadline.Unix())
+}
+
+// BribeSplit divides a bid's bribe according to the configured bribe mode. It
+// returns the bribe to pass to snipeWithBribe and the extra priority fee per gas
+// for a transaction with the given gas limit; one of the two is always zero.
+func (m *Manager) BribeSplit(bribe *big.Int, gasLimit uint64) (contractBribe, tipPerGas *big.Int) {
+	if m.config.BribeMode != config.BribeModeTip || gasLimit == 0 {
+		return bribe, big.NewInt(0)
+	}
+	return big.NewInt(0), new(big.Int).Div(bribe, new(big.Int).SetUint64(gasLimit))
+}
+
 // GetBundleGasPrice calculates the gas price for a bundle transaction
 func (m *Manager) GetBundleGasPrice(baseGasPrice *big.Int, position int) *big.Int {
 	// Each subsequent transaction in the bundle should have a slightly lower gas price
@@ -143,10 +316,9 @@ func (m *Manager) GetBundleGasPrice(baseGasPrice *big.Int, position int) *big.In
 	decrease := big.NewInt(int64(position))
 	result := new(big.Int).Sub(baseGasPrice, decrease)
 
-	// Ensure minimum gas price of 1 gwei
-	minGasPrice := big.NewInt(1000000000)
-	if result.Cmp(minGasPrice) < 0 {
-		return minGasPrice
+	// Ensure the minimum gas price
+	if result.Cmp(m.config.Gas.MinGasPrice) < 0 {
+		return new(big.Int).Set(m.config.Gas.MinGasPrice)
 	}
 
 	return result
@@ -167,7 +339,8 @@ func (m *Manager) extractTokenFromLPAdd(tx *types.Transaction) (common.Address,
 	return token, nil
 }
 
-// EstimateBundleGas estimates the total gas required for a bundle
+// EstimateBundleGas estimates the total gas required for a bundle: the
+// launch's gas limit plus the gas limit EstimateSnipeGas gives each bid
 func (m *Manager) EstimateBundleGas(
 	ctx context.Context,
 	lpAddTx *types.Transaction,
@@ -186,33 +359,148 @@ func (m *Manager) EstimateBundleGas(
 		return 0, err
 	}
 
-	// Estimate gas for each snipe
+	m.EstimateSnipeGas(ctx, token, creator, bids)
 	for _, bid := range bids {
-		deadline := big.NewInt(time.Now().Add(5 * time.Minute).Unix())
-		amountOutMin := big.NewInt(1)
+		totalGas += bid.GasLimit
+	}
 
-		gas, err := m.sniperContract.EstimateGasForSnipe(
-			ctx,
-			bid.Wallet,
-			token,
-			creator,
-			bid.SwapAmount,
-			bid.BribeAmount,
-			amountOutMin,
-			deadline,
-		)
-		if err != nil {
-			// Use a conservative estimate if estimation fails
-			gas = 300000
+	return totalGas, nil
+}
+
+// snipeGasHeadroom is the percent a snipe's gas estimate is raised by for its
+// gas limit, since it is estimated before the launch changes the pool
+const snipeGasHeadroom = 20
+
+// EstimateSnipeGas sets the GasLimit of each bid to its gas estimate plus
+// snipeGasHeadroom percent, with SnipeGasLimit as the ceiling. A bid whose
+// estimate fails, as it does while the token has no pool yet, gets
+// SnipeGasLimit. The estimates run in parallel, for at most a block time.
+func (m *Manager) EstimateSnipeGas(ctx context.Context, token, creator common.Address, bids []*SnipeBid) {
+	ceiling := m.config.Gas.SnipeGasLimit
+	for _, bid := range bids {
+		bid.GasLimit = ceiling
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.BlockTime)
+	defer cancel()
+
+	head, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return
+	}
+	deadline := m.SwapDeadline(head)
+
+	var wg sync.WaitGroup
+	for _, bid := range bids {
+		wg.Add(1)
+		go func(bid *SnipeBid) {
+			defer wg.Done()
+
+			contractBribe, _ := m.BribeSplit(bid.BribeAmount, ceiling)
+			estimate, err := m.sniperContract.EstimateGasForSnipe(
+				ctx,
+				bid.Wallet,
+				token,
+				creator,
+				bid.SwapAmount,
+				contractBribe,
+				big.NewInt(1),
+				deadline,
+			)
+			if err != nil {
+				return
+			}
+			if gasLimit := estimate * (100 + snipeGasHeadroom) / 100; gasLimit < ceiling {
+				bid.GasLimit = gasLimit
+			}
+		}(bid)
+	}
+	wg.Wait()
+}
+
+// SnipeGas returns the gas limit bid is signed with: the one EstimateSnipeGas
+// gave it, or SnipeGasLimit
+func (m *Manager) SnipeGas(bid *SnipeBid) uint64 {
+	if bid.GasLimit > 0 {
+		return bid.GasLimit
+	}
+	return m.config.Gas.SnipeGasLimit
+}
+
+// SelectBids picks the bids that go in the bundle. The bundle holds as many
+// snipes as fit in MaxBundleGas after the launch transaction, each taking its
+// SnipeGas plus extraGas, and at most MaxBundleSnipes when that is set. bids
+// must be sorted by bribe, highest first. Under BundleSelectionBribe the
+// highest bribes that fit go in; under BundleSelectionMixed the earliest-placed
+// snipes first get BundleEarlyShare percent of the gas, and of the
+// MaxBundleSnipes slots. Both returned slices keep the bribe order. Returns the
+// bundle's total gas.
+func (m *Manager) SelectBids(launchGas, extraGas uint64, bids []*SnipeBid) (kept, dropped []*SnipeBid, totalGas uint64) {
+	var budget uint64
+	if m.config.Gas.MaxBundleGas > launchGas {
+		budget = m.config.Gas.MaxBundleGas - launchGas
+	}
+	slots := len(bids)
+	if m.config.MaxBundleSnipes > 0 {
+		slots = min(slots, m.config.MaxBundleSnipes)
+	}
+
+	selected := make(map[*SnipeBid]bool, len(bids))
+	var used uint64
+	fill := func(candidates []*SnipeBid, gasCap uint64, count int) {
+		for _, bid := range candidates {
+			if len(selected) >= count {
+				return
+			}
+			gas := m.SnipeGas(bid) + extraGas
+			if selected[bid] || used+gas > gasCap {
+				continue
+			}
+			selected[bid] = true
+			used += gas
 		}
+	}
 
-		totalGas += gas
+	if m.config.BundleSelection == config.BundleSelectionMixed {
+		byAge := append([]*SnipeBid(nil), bids...)
+		sort.SliceStable(byAge, func(i, j int) bool {
+			if !byAge[i].CreatedAt.Equal(byAge[j].CreatedAt) {
+				return byAge[i].CreatedAt.Before(byAge[j].CreatedAt)
+			}
+			return byAge[i].SnipeID < byAge[j].SnipeID
+		})
+		earlySlots := slots
+		if m.config.MaxBundleSnipes > 0 {
+			earlySlots = slots * m.config.BundleEarlyShare / 100
+		}
+		fill(byAge, budget*uint64(m.config.BundleEarlyShare)/100, earlySlots)
 	}
+	fill(bids, budget, slots)
 
-	return totalGas, nil
+	for _, bid := range bids {
+		if selected[bid] {
+			kept = append(kept, bid)
+		} else {
+			dropped = append(dropped, bid)
+		}
+	}
+	return kept, dropped, launchGas + used
 }
 
 // GetSniperContract returns the sniper contract instance
 func (m *Manager) GetSniperContract() *dex.SniperContract {
 	return m.sniperContract
 }
+
+// ID returns the trace ID of a bundle: the keccak256 of the token address,
+// the launch transaction's hash and the hashes of the transactions behind it,
+// in order. The hashes cover each transaction's nonce, fees and calldata, so
+// the same bundle always gets the same ID and any change to it a new one.
+func ID(token common.Address, launchTxHash common.Hash, txs []*types.Transaction) string {
+	data := append([]byte{}, token.Bytes()...)
+	data = append(data, launchTxHash.Bytes()...)
+	for _, tx := range txs {
+		data = append(data, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(data).Hex()
+}
@@ -0,0 +1,155 @@
+package bundle
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/services/bot/db"
+)
+
+// bid returns a snipe bid with the given ID, bribe in wei and creation time
+// as the database returns it
+func bid(t *testing.T, id int64, bribe int64, createdAt string) *SnipeBid {
+	t.Helper()
+	parsed, err := db.ParseTimestamp(createdAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &SnipeBid{SnipeID: id, BribeAmount: big.NewInt(bribe), CreatedAt: parsed}
+}
+
+func snipeIDs(bids []*SnipeBid) []int64 {
+	ids := make([]int64, 0, len(bids))
+	for _, bid := range bids {
+		ids = append(ids, bid.SnipeID)
+	}
+	return ids
+}
+
+func TestSortBids(t *testing.T) {
+	tests := []struct {
+		name string
+		bids []*SnipeBid
+		want []int64
+	}{
+		{
+			"bribe first",
+			[]*SnipeBid{bid(t, 1, 10, "2024-03-09 14:00:00"), bid(t, 2, 30, "2024-03-09 14:00:01"), bid(t, 3, 20, "2024-03-09 14:00:02")},
+			[]int64{2, 3, 1},
+		},
+		{
+			"equal bribes first come, first served",
+			[]*SnipeBid{bid(t, 1, 10, "2024-03-09 14:00:02"), bid(t, 2, 10, "2024-03-09 14:00:00"), bid(t, 3, 10, "2024-03-09 14:00:01")},
+			[]int64{2, 3, 1},
+		},
+		{
+			// As strings, "…:07.5Z" sorts before "…:07Z"
+			"fractional seconds",
+			[]*SnipeBid{bid(t, 1, 10, "2024-03-09T14:05:07.5Z"), bid(t, 2, 10, "2024-03-09T14:05:07Z")},
+			[]int64{2, 1},
+		},
+		{
+			// As strings, "…16:05:07+02:00" sorts after "…14:05:08Z"
+			"offsets",
+			[]*SnipeBid{bid(t, 1, 10, "2024-03-09T14:05:08Z"), bid(t, 2, 10, "2024-03-09T16:05:07+02:00")},
+			[]int64{2, 1},
+		},
+		{
+			"same time by snipe ID",
+			[]*SnipeBid{bid(t, 9, 10, "2024-03-09T14:05:07Z"), bid(t, 4, 10, "2024-03-09 14:05:07"), bid(t, 6, 10, "2024-03-09T14:05:07Z")},
+			[]int64{4, 6, 9},
+		},
+	}
+
+	for _, tt := range tests {
+		SortBids(tt.bids)
+		if got := snipeIDs(tt.bids); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: order %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSelectBidsMixed(t *testing.T) {
+	// Bribe order; 5 and 6 are the earliest placed, 6 to the nanosecond
+	bids := []*SnipeBid{
+		bid(t, 1, 50, "2024-03-09T14:00:04Z"),
+		bid(t, 2, 40, "2024-03-09T14:00:03Z"),
+		bid(t, 3, 30, "2024-03-09T14:00:02Z"),
+		bid(t, 4, 20, "2024-03-09T14:00:01Z"),
+		bid(t, 5, 10, "2024-03-09T14:00:00.000000001Z"),
+		bid(t, 6, 10, "2024-03-09T14:00:00Z"),
+	}
+
+	m := &Manager{config: &config.Config{
+		Gas:              config.GasConfig{MaxBundleGas: 1000, SnipeGasLimit: 150},
+		BundleSelection:  config.BundleSelectionMixed,
+		BundleEarlyShare: 50,
+	}}
+
+	// 400 gas for the launch leaves 4 slots at 150 gas: 2 for the earliest
+	// snipes, then the highest bribes
+	kept, dropped, totalGas := m.SelectBids(400, 0, bids)
+	if got := snipeIDs(kept); !reflect.DeepEqual(got, []int64{1, 2, 5, 6}) {
+		t.Errorf("kept %v, want [1 2 5 6]", got)
+	}
+	if got := snipeIDs(dropped); !reflect.DeepEqual(got, []int64{3, 4}) {
+		t.Errorf("dropped %v, want [3 4]", got)
+	}
+	if totalGas != 1000 {
+		t.Errorf("total gas %d, want 1000", totalGas)
+	}
+
+	// One early slot goes to the earliest snipe only
+	m.config.BundleEarlyShare = 25
+	kept, _, _ = m.SelectBids(400, 0, bids)
+	if got := snipeIDs(kept); !reflect.DeepEqual(got, []int64{1, 2, 3, 6}) {
+		t.Errorf("kept %v at 25%%, want [1 2 3 6]", got)
+	}
+}
+
+func TestSelectBidsGasEstimates(t *testing.T) {
+	// Bribe order, signed with their estimates where they have one
+	gasLimits := []uint64{150, 0, 50, 50, 100}
+	var bids []*SnipeBid
+	for i, gasLimit := range gasLimits {
+		b := bid(t, int64(i+1), int64(50-10*i), "2024-03-09 14:00:00")
+		b.GasLimit = gasLimit
+		bids = append(bids, b)
+	}
+
+	tests := []struct {
+		name        string
+		maxSnipes   int
+		extraGas    uint64
+		wantKept    []int64
+		wantDropped []int64
+		wantGas     uint64
+	}{
+		// 400 gas is left after the launch, where only one snipe would fit at
+		// the 300 gas limit. 2 has no estimate and takes the full 300, so it
+		// doesn't fit after 1, but the lower bribes after it do.
+		{"estimates", 0, 0, []int64{1, 3, 4, 5}, []int64{2}, 950},
+		{"snipe cap", 2, 0, []int64{1, 3}, []int64{2, 4, 5}, 800},
+		// With a commission transfer of 50 gas per snipe, 5 no longer fits
+		{"extra gas", 0, 50, []int64{1, 3, 4}, []int64{2, 5}, 1000},
+	}
+
+	for _, tt := range tests {
+		m := &Manager{config: &config.Config{
+			Gas:             config.GasConfig{MaxBundleGas: 1000, SnipeGasLimit: 300},
+			MaxBundleSnipes: tt.maxSnipes,
+		}}
+		kept, dropped, totalGas := m.SelectBids(600, tt.extraGas, bids)
+		if got := snipeIDs(kept); !reflect.DeepEqual(got, tt.wantKept) {
+			t.Errorf("%s: kept %v, want %v", tt.name, got, tt.wantKept)
+		}
+		if got := snipeIDs(dropped); !reflect.DeepEqual(got, tt.wantDropped) {
+			t.Errorf("%s: dropped %v, want %v", tt.name, got, tt.wantDropped)
+		}
+		if totalGas != tt.wantGas {
+			t.Errorf("%s: total gas %d, want %d", tt.name, totalGas, tt.wantGas)
+		}
+	}
+}
@@ -3,19 +3,30 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"sniper-bot/pkg/eth"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // DB represents the database connection
 type DB struct {
 	*sql.DB
+	dialect Dialect
+
+	// Retry policy of withRetry
+	retryAttempts int
+	retryBackoff  time.Duration
 }
 
-// New creates a new database connection
+// New creates a new database connection, to MySQL or to PostgreSQL as the
+// URL's scheme selects (see DialectFor)
 func New(databaseURL string) (*DB, error) {
-	db, err := sql.Open("mysql", databaseURL)
+	db, dialect, err := Open(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
@@ -24,53 +35,172 @@ func New(databaseURL string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	return &DB{db}, nil
+	return NewFromConn(db, dialect), nil
+}
+
+// NewFromConn wraps an open connection pool whose queries are written in
+// dialect, e.g. one opened with Open or a stub driver
+func NewFromConn(conn *sql.DB, dialect Dialect) *DB {
+	return &DB{DB: conn, dialect: dialect, retryAttempts: defaultRetryAttempts, retryBackoff: defaultRetryBackoff}
 }
 
 // Wallet represents a user's wallet in the database
 type Wallet struct {
-	ID             int64
-	TelegramUserID string
-	WalletAddress  string
-	PrivateKey     string
-	CreatedAt      string
+	ID              int64
+	TelegramUserID  string
+	WalletAddress   string
+	PrivateKey      string        // Empty for wallets derived from the HD seed
+	DerivationIndex sql.NullInt64 // Set for wallets derived from the HD seed
+	CreatedAt       string
 }
 
+// Snipe amount modes
+const (
+	AmountModeETH         = "eth"      // Amount is a fixed ETH amount
+	AmountModePoolPercent = "pool_pct" // Amount is a percent of the pool's ETH liquidity, resolved at LP_ADD time
+)
+
+// Snipe statuses
+const (
+	SnipeStatusPending      = "pending"       // Waiting for the token's LP_ADD
+	SnipeStatusSubmitting   = "submitting"    // Claimed by a bundle about to be sent
+	SnipeStatusSubmitted    = "submitted"     // Sent to the sequencer as part of a bundle
+	SnipeStatusSubmitFailed = "submit_failed" // Rejected by every submission endpoint
+	SnipeStatusLanded       = "landed"        // Mined and succeeded
+	SnipeStatusReverted     = "reverted"      // Mined but reverted
+	SnipeStatusDropped      = "dropped"       // Cut from its bundle to fit the bundle gas cap
+	SnipeStatusSold         = "sold"          // Landed, then sold at its take-profit or stop-loss
+	SnipeStatusCancelled    = "cancelled"     // Replaced unmined after its bundle's LP_ADD didn't land
+)
+
+// Results of simulating a snipe before its bundle is submitted
+const (
+	SimulationPassed = "passed" // Succeeded behind the launch, so it was kept in the bundle
+	SimulationFailed = "failed" // Reverted behind the launch, so it was dropped
+)
+
 // Snipe represents a sniper's bid in the database
 type Snipe struct {
-	ID           int64
-	UserID       string
-	TokenAddress string
-	Amount       string
-	BribeAmount  string
-	Wallet       string
-	CreatedAt    string
-	Status       string
+	ID             int64
+	UserID         string
+	TokenAddress   string
+	Amount         string // ETH, or percent of pool in AmountModePoolPercent
+	AmountMode     string
+	BribeAmount    string
+	Wallet         string
+	CreatedAt      string
+	Status         string
+	Slippage       sql.NullFloat64 // Max slippage in percent, when the user set one
+	TxHash         sql.NullString  // Hash of the submitted snipe transaction
+	BundlePosition sql.NullInt64   // Intended position in the bundle (0 = highest bribe)
+	BundleID       sql.NullString  // Bundle the snipe was submitted in or dropped from
+
+	// From the mined transaction's receipt
+	GasUsed           sql.NullInt64
+	EffectiveGasPrice sql.NullString // wei
+	BlockNumber       sql.NullInt64
+	RevertReason      sql.NullString // Error of replaying a reverted snipe, when it reproduced
+
+	// Auto-sell targets, when the user set them
+	TakeProfitX sql.NullFloat64 // Sell once the position is worth this multiple of the swap
+	StopLossPct sql.NullFloat64 // Sell once the position has lost this percent of the swap
+	SwapWei     sql.NullString  // ETH swapped for tokens, recorded at submission
+	SellTxHash  sql.NullString  // Hash of the auto-sell transaction
+
+	// Delivery of the bought tokens to another address, when the user set one
+	Recipient     sql.NullString // Address the tokens are forwarded to after landing
+	ForwardTxHash sql.NullString // Hash of the forwarding transfer
+
+	// From the SnipeExecuted event of a landed snipe
+	TokensReceived sql.NullString  // In the token's smallest unit
+	EntryPrice     sql.NullFloat64 // ETH swapped per whole token received
+
+	// From simulating the snipe behind its launch, with simulate_snipes on
+	SimulationStatus sql.NullString // SimulationPassed or SimulationFailed
+	SimulationError  sql.NullString // Revert reason of a failed simulation
+}
+
+// timestampLayouts are the forms a timestamp column scans into a string in:
+// RFC 3339 when the driver parses it to a time.Time first (lib/pq, or MySQL
+// with parseTime=true), and MySQL's own DATETIME text otherwise
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999"}
+
+// ParseTimestamp parses a timestamp column scanned into a string, such as
+// Snipe.CreatedAt. Its text isn't fixed-width in every form, so timestamps
+// must be compared parsed, not as strings.
+func ParseTimestamp(value string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// snipeColumns lists the snipes columns in the order scanSnipe expects
+const snipeColumns = `id, user_id, token_address, amount, amount_mode, bribe_amount, wallet, created_at, status, slippage, tx_hash, bundle_position, gas_used, effective_gas_price, take_profit_x, stop_loss_pct, swap_wei, sell_tx_hash, block_number, revert_reason, bundle_id, recipient, forward_tx_hash, tokens_received, entry_price, simulation_status, simulation_error`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSnipe scans a row selected with snipeColumns
+func scanSnipe(row rowScanner) (*Snipe, error) {
+	snipe := &Snipe{}
+	if err := row.Scan(
+		&snipe.ID,
+		&snipe.UserID,
+		&snipe.TokenAddress,
+		&snipe.Amount,
+		&snipe.AmountMode,
+		&snipe.BribeAmount,
+		&snipe.Wallet,
+		&snipe.CreatedAt,
+		&snipe.Status,
+		&snipe.Slippage,
+		&snipe.TxHash,
+		&snipe.BundlePosition,
+		&snipe.GasUsed,
+		&snipe.EffectiveGasPrice,
+		&snipe.TakeProfitX,
+		&snipe.StopLossPct,
+		&snipe.SwapWei,
+		&snipe.SellTxHash,
+		&snipe.BlockNumber,
+		&snipe.RevertReason,
+		&snipe.BundleID,
+		&snipe.Recipient,
+		&snipe.ForwardTxHash,
+		&snipe.TokensReceived,
+		&snipe.EntryPrice,
+		&snipe.SimulationStatus,
+		&snipe.SimulationError,
+	); err != nil {
+		return nil, err
+	}
+	return snipe, nil
 }
 
 // CreateWallet creates a new wallet for a user
 func (db *DB) CreateWallet(wallet *Wallet) error {
 	query := `
-		INSERT INTO wallets (telegram_user_id, wallet_address, private_key, created_at)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO wallets (telegram_user_id, wallet_address, private_key, derivation_index, created_at)
+		VALUES (?, ?, ?, ?, ?)
 	`
 
-	result, err := db.Exec(
+	id, err := db.insert(
 		query,
 		wallet.TelegramUserID,
 		wallet.WalletAddress,
 		wallet.PrivateKey,
+		wallet.DerivationIndex,
 		time.Now(),
 	)
 	if err != nil {
 		return err
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
 	wallet.ID = id
 	return nil
 }
@@ -78,7 +208,7 @@ func (db *DB) CreateWallet(wallet *Wallet) error {
 // GetWalletByTelegramUserID gets a wallet by telegram user ID
 func (db *DB) GetWalletByTelegramUserID(telegramUserID string) (*Wallet, error) {
 	query := `
-		SELECT id, telegram_user_id, wallet_address, private_key, created_at
+		SELECT id, telegram_user_id, wallet_address, private_key, derivation_index, created_at
 		FROM wallets
 		WHERE telegram_user_id = ?
 	`
@@ -89,6 +219,7 @@ func (db *DB) GetWalletByTelegramUserID(telegramUserID string) (*Wallet, error)
 		&wallet.TelegramUserID,
 		&wallet.WalletAddress,
 		&wallet.PrivateKey,
+		&wallet.DerivationIndex,
 		&wallet.CreatedAt,
 	)
 	if err != nil {
@@ -98,28 +229,102 @@ func (db *DB) GetWalletByTelegramUserID(telegramUserID string) (*Wallet, error)
 	return wallet, nil
 }
 
-// CreateSnipe creates a new snipe
-func (db *DB) CreateSnipe(snipe *Snipe) error {
+// GetWalletByAddress gets the wallet with an on-chain address, in any casing.
+// Like GetWalletByTelegramUserID it returns sql.ErrNoRows if there is none.
+func (db *DB) GetWalletByAddress(address string) (*Wallet, error) {
+	address = strings.TrimSpace(address)
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid wallet address %q", address)
+	}
+
 	query := `
-		INSERT INTO snipes (user_id, token_address, amount, bribe_amount, wallet, created_at, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		SELECT id, telegram_user_id, wallet_address, private_key, derivation_index, created_at
+		FROM wallets
+		WHERE wallet_address = ?
 	`
 
-	result, err := db.Exec(
-		query,
-		snipe.UserID,
-		snipe.TokenAddress,
-		snipe.Amount,
-		snipe.BribeAmount,
-		snipe.Wallet,
-		time.Now(),
-		"pending",
+	// Addresses are stored checksummed
+	wallet := &Wallet{}
+	err := db.QueryRow(query, common.HexToAddress(address).Hex()).Scan(
+		&wallet.ID,
+		&wallet.TelegramUserID,
+		&wallet.WalletAddress,
+		&wallet.PrivateKey,
+		&wallet.DerivationIndex,
+		&wallet.CreatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// ForEachWallet calls fn for every wallet, streaming rows rather than loading
+// them all. Iteration stops at the first error fn returns.
+func (db *DB) ForEachWallet(fn func(*Wallet) error) error {
+	query := `
+		SELECT id, telegram_user_id, wallet_address, private_key, derivation_index, created_at
+		FROM wallets
+		ORDER BY id ASC
+	`
+
+	rows, err := db.Query(query)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		wallet := &Wallet{}
+		if err := rows.Scan(
+			&wallet.ID,
+			&wallet.TelegramUserID,
+			&wallet.WalletAddress,
+			&wallet.PrivateKey,
+			&wallet.DerivationIndex,
+			&wallet.CreatedAt,
+		); err != nil {
+			return err
+		}
+		if err := fn(wallet); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// IsDuplicateDerivationIndex reports whether err is CreateWallet refusing a
+// derivation index another wallet took first
+func (db *DB) IsDuplicateDerivationIndex(err error) bool {
+	return db.dialect.DuplicateKey(err, "derivation_index")
+}
+
+// NextDerivationIndex returns the next unused HD derivation index. Two
+// callers can be handed the same one; the unique key lets only the first
+// wallet stored with it in (see IsDuplicateDerivationIndex).
+func (db *DB) NextDerivationIndex() (uint32, error) {
+	query := `
+		SELECT COALESCE(MAX(derivation_index) + 1, 0)
+		FROM wallets
+	`
+
+	var index int64
+	if err := db.QueryRow(query).Scan(&index); err != nil {
+		return 0, err
+	}
+
+	return uint32(index), nil
+}
 
-	id, err := result.LastInsertId()
+// CreateSnipe creates a new snipe
+func (db *DB) CreateSnipe(snipe *Snipe) error {
+	var id int64
+	err := db.withConflictRetry("CreateSnipe", func() (err error) {
+		id, err = db.insert(createSnipeQuery, createSnipeArgs(snipe)...)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -128,52 +333,188 @@ func (db *DB) CreateSnipe(snipe *Snipe) error {
 	return nil
 }
 
-// GetSnipesByToken gets all snipes for a token
+// CreateSnipes creates pending snipes in one transaction: either all of them
+// are created, setting their IDs, or none are
+func (db *DB) CreateSnipes(snipes []*Snipe) error {
+	ids := make([]int64, len(snipes))
+	err := db.withConflictRetry("CreateSnipes", func() error {
+		tx, err := db.begin()
+		if err != nil {
+			return err
+		}
+		for i, snipe := range snipes {
+			if ids[i], err = tx.insert(createSnipeQuery, createSnipeArgs(snipe)...); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to create snipe %d of %d: %w", i+1, len(snipes), err)
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, snipe := range snipes {
+		snipe.ID = ids[i]
+	}
+	return nil
+}
+
+const createSnipeQuery = `
+	INSERT INTO snipes (user_id, token_address, amount, amount_mode, bribe_amount, bribe_wei, wallet, created_at, status, slippage, take_profit_x, stop_loss_pct, recipient)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// createSnipeArgs returns the values createSnipeQuery inserts for snipe
+func createSnipeArgs(snipe *Snipe) []interface{} {
+	if snipe.AmountMode == "" {
+		snipe.AmountMode = AmountModeETH
+	}
+
+	return []interface{}{
+		snipe.UserID,
+		snipe.TokenAddress,
+		snipe.Amount,
+		snipe.AmountMode,
+		snipe.BribeAmount,
+		bribeWei(snipe.BribeAmount),
+		snipe.Wallet,
+		time.Now(),
+		SnipeStatusPending,
+		snipe.Slippage,
+		snipe.TakeProfitX,
+		snipe.StopLossPct,
+		snipe.Recipient,
+	}
+}
+
+// bribeWei converts an ETH bribe to the wei value stored in the indexed
+// bribe_wei column, or NULL if it doesn't parse
+func bribeWei(bribeAmount string) sql.NullString {
+	wei, err := eth.ParseEther(bribeAmount)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: wei.String(), Valid: true}
+}
+
+// backfillBatch is the number of snipes BackfillBribeWei reads at a time
+const backfillBatch = 1000
+
+// BackfillBribeWei sets bribe_wei of snipes created before the column
+// existed, converting bribe_amount the way inserts do, and returns how many
+// it set. Snipes whose bribe doesn't parse are left NULL.
+func (db *DB) BackfillBribeWei() (int64, error) {
+	var backfilled int64
+	var lastID int64
+	for {
+		rows, err := db.Query(`
+			SELECT id, bribe_amount
+			FROM snipes
+			WHERE bribe_wei IS NULL AND id > ?
+			ORDER BY id
+			LIMIT ?`, lastID, backfillBatch)
+		if err != nil {
+			return backfilled, err
+		}
+
+		type bribe struct {
+			id     int64
+			amount string
+		}
+		var batch []bribe
+		for rows.Next() {
+			var b bribe
+			if err := rows.Scan(&b.id, &b.amount); err != nil {
+				rows.Close()
+				return backfilled, err
+			}
+			batch = append(batch, b)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return backfilled, err
+		}
+		if len(batch) == 0 {
+			return backfilled, nil
+		}
+
+		for _, b := range batch {
+			lastID = b.id
+			wei := bribeWei(b.amount)
+			if !wei.Valid {
+				continue
+			}
+			if _, err := db.Exec("UPDATE snipes SET bribe_wei = ? WHERE id = ?", wei, b.id); err != nil {
+				return backfilled, fmt.Errorf("failed to backfill snipe %d: %v", b.id, err)
+			}
+			backfilled++
+		}
+	}
+}
+
+// GetSnipesByToken gets all pending snipes for a token, highest bribe first
+// and equal bribes first come, first served
 func (db *DB) GetSnipesByToken(tokenAddress string) ([]*Snipe, error) {
 	query := `
-		SELECT id, user_id, token_address, amount, bribe_amount, wallet, created_at, status
+		SELECT ` + snipeColumns + `
 		FROM snipes
 		WHERE token_address = ? AND status = 'pending'
-		ORDER BY CAST(bribe_amount AS DECIMAL(20,8)) DESC
+		ORDER BY bribe_wei DESC, created_at ASC, id ASC
 	`
 
-	rows, err := db.Query(query, tokenAddress)
-	if err != nil {
+	var snipes []*Snipe
+	err := db.withRetry("GetSnipesByToken", func() (err error) {
+		snipes, err = db.querySnipes(query, tokenAddress)
+		return err
+	})
+	return snipes, err
+}
+
+// GetSnipeByID gets one of a user's snipes, or nil if the user has no snipe
+// with that ID
+func (db *DB) GetSnipeByID(id int64, userID string) (*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE id = ? AND user_id = ?
+	`
+
+	snipes, err := db.querySnipes(query, id, userID)
+	if err != nil || len(snipes) == 0 {
 		return nil, err
 	}
-	defer rows.Close()
+	return snipes[0], nil
+}
 
-	var snipes []*Snipe
-	for rows.Next() {
-		snipe := &Snipe{}
-		if err := rows.Scan(
-			&snipe.ID,
-			&snipe.UserID,
-			&snipe.TokenAddress,
-			&snipe.Amount,
-			&snipe.BribeAmount,
-			&snipe.Wallet,
-			&snipe.CreatedAt,
-			&snipe.Status,
-		); err != nil {
-			return nil, err
-		}
-		snipes = append(snipes, snipe)
-	}
+// GetPendingSnipeForToken gets a user's oldest pending snipe for a token, or nil if there is none
+func (db *DB) GetPendingSnipeForToken(userID, tokenAddress string) (*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE user_id = ? AND token_address = ? AND status = 'pending'
+		ORDER BY id ASC
+		LIMIT 1
+	`
 
-	return snipes, nil
+	snipes, err := db.querySnipes(query, userID, tokenAddress)
+	if err != nil || len(snipes) == 0 {
+		return nil, err
+	}
+	return snipes[0], nil
 }
 
-// UpdateSnipeStatusAtomic atomically updates snipe status from oldStatus to newStatus
-// Returns true if the update was successful (status was actually oldStatus)
-func (db *DB) UpdateSnipeStatusAtomic(id int64, oldStatus, newStatus string) (bool, error) {
+// UpdatePendingSnipe updates the amount, bribe, slippage and auto-sell targets
+// of a snipe that is still pending. Returns false if the snipe is no longer pending.
+func (db *DB) UpdatePendingSnipe(snipe *Snipe) (bool, error) {
 	query := `
 		UPDATE snipes
-		SET status = ?
-		WHERE id = ? AND status = ?
+		SET amount = ?, bribe_amount = ?, bribe_wei = ?, slippage = ?, take_profit_x = ?, stop_loss_pct = ?
+		WHERE id = ? AND status = 'pending'
 	`
 
-	result, err := db.Exec(query, newStatus, id, oldStatus)
+	result, err := db.Exec(query, snipe.Amount, snipe.BribeAmount, bribeWei(snipe.BribeAmount), snipe.Slippage,
+		snipe.TakeProfitX, snipe.StopLossPct, snipe.ID)
 	if err != nil {
 		return false, err
 	}
@@ -186,14 +527,1171 @@ func (db *DB) UpdateSnipeStatusAtomic(id int64, oldStatus, newStatus string) (bo
 	return rowsAffected > 0, nil
 }
 
-// UpdateSnipeStatus updates the status of a snipe
-func (db *DB) UpdateSnipeStatus(id int64, status string) error {
+// RequeueSnipe places a reverted, dropped, cancelled or submit_failed snipe
+// again as a new
+// pending snipe of the same user, token, amount, wallet, auto-sell targets and
+// recipient.
+// The bribe and slippage come from snipe, so the caller can change them. It
+// returns the new snipe's ID, or 0 if snipe.ID isn't one of the user's
+// snipes in a requeueable status.
+func (db *DB) RequeueSnipe(snipe *Snipe) (int64, error) {
 	query := `
-		UPDATE snipes
-		SET status = ?
-		WHERE id = ?
+		INSERT INTO snipes (user_id, token_address, amount, amount_mode, bribe_amount, bribe_wei, wallet, created_at, status, slippage, take_profit_x, stop_loss_pct, recipient)
+		SELECT user_id, token_address, amount, amount_mode, ?, ?, wallet, ?, ?, ?, take_profit_x, stop_loss_pct, recipient
+		FROM snipes
+		WHERE id = ? AND user_id = ? AND status IN ('reverted', 'dropped', 'cancelled', 'submit_failed')
+	`
+
+	return db.insert(query, snipe.BribeAmount, bribeWei(snipe.BribeAmount), time.Now(), SnipeStatusPending,
+		snipe.Slippage, snipe.ID, snipe.UserID)
+}
+
+// RequeueLaunchSnipe places a snipe whose bundle's LP_ADD reverted again as a
+// new pending snipe with all the same parameters, and its original place in
+// the first come, first served order. The original keeps its status and
+// receipt, so the gas it cost stays in the cost report. It returns the new
+// snipe's ID, or 0 if the snipe isn't submitted, reverted or cancelled.
+func (db *DB) RequeueLaunchSnipe(id int64) (int64, error) {
+	query := `
+		INSERT INTO snipes (user_id, token_address, amount, amount_mode, bribe_amount, bribe_wei, wallet, created_at, status, slippage, take_profit_x, stop_loss_pct, recipient)
+		SELECT user_id, token_address, amount, amount_mode, bribe_amount, bribe_wei, wallet, created_at, ?, slippage, take_profit_x, stop_loss_pct, recipient
+		FROM snipes
+		WHERE id = ? AND status IN ('submitted', 'reverted', 'cancelled')
+	`
+
+	return db.insert(query, SnipeStatusPending, id)
+}
+
+// GetSubmittedSnipesByToken gets the submitted snipes for a token (including
+// those already mined), grouped by bundle and ordered by their intended
+// position in it
+func (db *DB) GetSubmittedSnipesByToken(tokenAddress string) ([]*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE token_address = ? AND tx_hash IS NOT NULL
+		ORDER BY bundle_id ASC, bundle_position ASC
 	`
 
-	_, err := db.Exec(query, status, id)
+	return db.querySnipes(query, tokenAddress)
+}
+
+// SnipeSubmission is a snipe sent in a bundle
+type SnipeSubmission struct {
+	SnipeID  int64
+	TxHash   string
+	Position int      // Position in the bundle (0 = highest bribe)
+	SwapWei  *big.Int // ETH swapped for tokens
+	Accepted bool     // Whether any submission endpoint accepted the transaction
+}
+
+// SnipeSimulation is the result of simulating a snipe before its bundle was
+// submitted
+type SnipeSimulation struct {
+	SnipeID int64
+	Status  string // SimulationPassed or SimulationFailed
+	Error   string // Revert reason, when it failed
+}
+
+// ClaimBundle marks the snipes of a bundle about to be submitted as
+// submitting, with their tx hash, bundle position and swap amount and the
+// bundle's ID, so no other build can pick them up and the confirmer finds
+// their transactions even if RecordBundle later fails. Either every snipe is
+// claimed or none is: if some are no longer pending (cancelled while the
+// bundle was built), nothing changes and their IDs are returned.
+func (db *DB) ClaimBundle(bundleID string, snipes []SnipeSubmission) (unclaimed []int64, err error) {
+	err = db.withRetry("ClaimBundle", func() error {
+		tx, err := db.begin()
+		if err != nil {
+			return err
+		}
+		if unclaimed, err = claimBundle(tx, bundleID, snipes); err != nil || len(unclaimed) > 0 {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+	return unclaimed, err
+}
+
+// claimBundle runs the updates of ClaimBundle within tx, returning the IDs of
+// the snipes that can't be claimed without updating any
+func claimBundle(tx *txn, bundleID string, snipes []SnipeSubmission) ([]int64, error) {
+	if len(snipes) == 0 {
+		return nil, nil
+	}
+
+	// Locking the rows keeps a cancel from slipping in before the UPDATEs. A
+	// snipe already claimed for this bundle is taken as claimed, in case the
+	// COMMIT of an earlier attempt went through.
+	args := make([]interface{}, 0, len(snipes)+3)
+	args = append(args, SnipeStatusPending, SnipeStatusSubmitting, bundleID)
+	for _, s := range snipes {
+		args = append(args, s.SnipeID)
+	}
+	rows, err := tx.Query(`
+		SELECT id
+		FROM snipes
+		WHERE (status = ? OR (status = ? AND bundle_id = ?))
+			AND id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(snipes)), ", ")+`)
+		FOR UPDATE
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock snipes: %w", err)
+	}
+	claimable := make(map[int64]bool, len(snipes))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		claimable[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var unclaimed []int64
+	for _, s := range snipes {
+		if !claimable[s.SnipeID] {
+			unclaimed = append(unclaimed, s.SnipeID)
+		}
+	}
+	if len(unclaimed) > 0 {
+		return unclaimed, nil
+	}
+
+	claimed := func(SnipeSubmission) string { return SnipeStatusSubmitting }
+	if err := updateSubmissions(tx, bundleID, snipes, claimed); err != nil {
+		return nil, fmt.Errorf("failed to claim %d snipes: %w", len(snipes), err)
+	}
+	return nil, nil
+}
+
+// RecordBundle records the outcome of one bundle in a single transaction:
+// the submitted snipes get their tx hash, bundle position and swap amount and
+// become submitted, or submit_failed if no endpoint accepted them, and the
+// snipes cut from the bundle become dropped. All of them get the bundle's
+// ID, and the simulated ones their simulation result. Either every change is
+// committed or none is, so a failure can't leave a bundle's snipes half
+// submitted and half pending. The submitted, the dropped and the simulated
+// snipes are each updated in one statement, the dropped only while still
+// pending.
+func (db *DB) RecordBundle(bundleID string, submitted []SnipeSubmission, droppedIDs []int64, simulations []SnipeSimulation) error {
+	return db.withRetry("RecordBundle", func() error {
+		tx, err := db.begin()
+		if err != nil {
+			return err
+		}
+		if err := recordBundle(tx, bundleID, submitted, droppedIDs, simulations); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// recordBundle runs the updates of RecordBundle within tx
+func recordBundle(tx *txn, bundleID string, submitted []SnipeSubmission, droppedIDs []int64, simulations []SnipeSimulation) error {
+	outcome := func(s SnipeSubmission) string {
+		if !s.Accepted {
+			return SnipeStatusSubmitFailed
+		}
+		return SnipeStatusSubmitted
+	}
+	if err := updateSubmissions(tx, bundleID, submitted, outcome); err != nil {
+		return fmt.Errorf("failed to record submission of %d snipes: %w", len(submitted), err)
+	}
+
+	if _, err := updatePendingSnipes(tx, droppedIDs, SnipeStatusDropped, bundleID); err != nil {
+		return fmt.Errorf("failed to mark %d snipes as dropped: %w", len(droppedIDs), err)
+	}
+
+	if err := updateSimulations(tx, simulations); err != nil {
+		return fmt.Errorf("failed to record simulation of %d snipes: %w", len(simulations), err)
+	}
+
+	return nil
+}
+
+// updateSubmissions sets the tx hash, bundle position and swap amount of each
+// of a bundle's snipes, its status to status(snipe), and the bundle's ID, all
+// in a single UPDATE
+func updateSubmissions(exec execer, bundleID string, snipes []SnipeSubmission, status func(SnipeSubmission) string) error {
+	if len(snipes) == 0 {
+		return nil
+	}
+
+	// PostgreSQL types a CASE of bare placeholders as text, which it won't
+	// assign to a numeric column: positions are inlined, swap amounts cast
+	when := strings.Repeat("WHEN ? THEN ? ", len(snipes))
+	whenWei := strings.Repeat("WHEN ? THEN CAST(? AS DECIMAL(30,0)) ", len(snipes))
+	var positions strings.Builder
+	args := make([]interface{}, 0, 8*len(snipes)+1)
+	for _, s := range snipes {
+		args = append(args, s.SnipeID, s.TxHash)
+	}
+	for _, s := range snipes {
+		positions.WriteString("WHEN ? THEN " + strconv.Itoa(s.Position) + " ")
+		args = append(args, s.SnipeID)
+	}
+	for _, s := range snipes {
+		args = append(args, s.SnipeID, s.SwapWei.String())
+	}
+	for _, s := range snipes {
+		args = append(args, s.SnipeID, status(s))
+	}
+	args = append(args, bundleID)
+	for _, s := range snipes {
+		args = append(args, s.SnipeID)
+	}
+
+	_, err := exec.Exec(`
+		UPDATE snipes
+		SET tx_hash = CASE id `+when+`END,
+			bundle_position = CASE id `+positions.String()+`END,
+			swap_wei = CASE id `+whenWei+`END,
+			status = CASE id `+when+`END,
+			bundle_id = ?
+		WHERE id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(snipes)), ", ")+`)
+	`, args...)
+	return err
+}
+
+// updateSimulations records the simulation result of each snipe in a single
+// UPDATE
+func updateSimulations(exec execer, simulations []SnipeSimulation) error {
+	if len(simulations) == 0 {
+		return nil
+	}
+
+	when := strings.Repeat("WHEN ? THEN ? ", len(simulations))
+	args := make([]interface{}, 0, 5*len(simulations))
+	for _, sim := range simulations {
+		args = append(args, sim.SnipeID, sim.Status)
+	}
+	for _, sim := range simulations {
+		args = append(args, sim.SnipeID, sql.NullString{String: sim.Error, Valid: sim.Error != ""})
+	}
+	for _, sim := range simulations {
+		args = append(args, sim.SnipeID)
+	}
+
+	_, err := exec.Exec(`
+		UPDATE snipes
+		SET simulation_status = CASE id `+when+`END,
+			simulation_error = CASE id `+when+`END
+		WHERE id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(simulations)), ", ")+`)
+	`, args...)
 	return err
 }
+
+// GetUnconfirmedSnipes gets the submitted snipes whose receipt hasn't been
+// recorded yet, including those left submitting when their bundle's outcome
+// couldn't be recorded
+func (db *DB) GetUnconfirmedSnipes() ([]*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE status IN ('submitted', 'submitting') AND tx_hash IS NOT NULL
+	`
+
+	return db.querySnipes(query)
+}
+
+// GetLandedSnipes gets the landed snipes whose swap amount is known, i.e. the
+// positions users currently hold. Snipes with a recipient are left out since
+// their tokens don't stay in the wallet.
+func (db *DB) GetLandedSnipes() ([]*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE status = 'landed' AND swap_wei IS NOT NULL AND recipient IS NULL
+	`
+
+	return db.querySnipes(query)
+}
+
+// GetUserHoldings gets a user's landed and sold snipes, newest first
+func (db *DB) GetUserHoldings(userID string) ([]*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE user_id = ? AND status IN ('landed', 'sold')
+		ORDER BY id DESC
+	`
+
+	return db.querySnipes(query, userID)
+}
+
+// GetAutoSellSnipes gets the landed snipes with a take-profit or stop-loss
+// that haven't been sold yet
+func (db *DB) GetAutoSellSnipes() ([]*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE status = 'landed' AND swap_wei IS NOT NULL
+			AND (take_profit_x IS NOT NULL OR stop_loss_pct IS NOT NULL)
+	`
+
+	return db.querySnipes(query)
+}
+
+// GetUnforwardedSnipes gets the landed snipes with a recipient whose tokens
+// haven't been forwarded yet
+func (db *DB) GetUnforwardedSnipes() ([]*Snipe, error) {
+	query := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE status = 'landed' AND recipient IS NOT NULL AND forward_tx_hash IS NULL
+	`
+
+	return db.querySnipes(query)
+}
+
+// SetSnipeForwardTx records the transfer forwarding a snipe's tokens to its
+// recipient. A NULL hash clears a transfer that failed to send, so it is
+// retried.
+func (db *DB) SetSnipeForwardTx(id int64, forwardTxHash sql.NullString) error {
+	query := `
+		UPDATE snipes
+		SET forward_tx_hash = ?
+		WHERE id = ?
+	`
+
+	_, err := db.Exec(query, forwardTxHash, id)
+	return err
+}
+
+// MarkSnipeSold records the auto-sell transaction of a landed snipe. Returns
+// false if the snipe is no longer landed.
+func (db *DB) MarkSnipeSold(id int64, sellTxHash string) (bool, error) {
+	query := `
+		UPDATE snipes
+		SET status = 'sold', sell_tx_hash = ?
+		WHERE id = ? AND status = 'landed'
+	`
+
+	result, err := db.Exec(query, sellTxHash, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SnipeReceipt is what the receipt of a mined snipe records
+type SnipeReceipt struct {
+	Status            string // SnipeStatusLanded or SnipeStatusReverted
+	BlockNumber       uint64
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	RevertReason      string   // Empty when the snipe landed or the revert didn't reproduce
+	L1Fee             *big.Int // L1 data fee, nil when not known
+	TokensReceived    *big.Int // From the SnipeExecuted event, nil when not known
+	EntryPrice        float64  // ETH swapped per whole token received, 0 when not known
+}
+
+// SetSnipeReceipt records the outcome and gas cost of a mined snipe
+func (db *DB) SetSnipeReceipt(id int64, receipt SnipeReceipt) error {
+	query := `
+		UPDATE snipes
+		SET status = ?, block_number = ?, gas_used = ?, effective_gas_price = ?, revert_reason = ?, l1_fee = ?, tokens_received = ?, entry_price = ?
+		WHERE id = ?
+	`
+
+	revertReason := sql.NullString{String: receipt.RevertReason, Valid: receipt.RevertReason != ""}
+	var l1Fee, tokensReceived sql.NullString
+	if receipt.L1Fee != nil {
+		l1Fee = sql.NullString{String: receipt.L1Fee.String(), Valid: true}
+	}
+	if receipt.TokensReceived != nil {
+		tokensReceived = sql.NullString{String: receipt.TokensReceived.String(), Valid: true}
+	}
+	entryPrice := sql.NullFloat64{Float64: receipt.EntryPrice, Valid: receipt.EntryPrice > 0}
+	_, err := db.Exec(query, receipt.Status, receipt.BlockNumber, receipt.GasUsed, receipt.EffectiveGasPrice.String(), revertReason, l1Fee, tokensReceived, entryPrice, id)
+	return err
+}
+
+// CostReport aggregates what mined snipes cost
+type CostReport struct {
+	Snipes     int64  `json:"snipes"`     // Mined snipes, landed or reverted
+	Reverted   int64  `json:"reverted"`   // Mined snipes that reverted
+	GasUsed    int64  `json:"gasUsed"`    // Total gas used
+	GasCostWei string `json:"gasCostWei"` // Total gas used x effective gas price
+	L1FeeWei   string `json:"l1FeeWei"`   // Total L1 data fees, of the snipes they were recorded for
+	BribeETH   string `json:"bribeEth"`   // Total bribes of landed snipes (reverted snipes pay none)
+}
+
+// GetUserCosts aggregates the gas and bribe cost of a user's mined snipes
+func (db *DB) GetUserCosts(userID string) (*CostReport, error) {
+	return db.getCosts("user_id = ?", userID)
+}
+
+// GetTokenCosts aggregates the gas and bribe cost of all mined snipes for a token
+func (db *DB) GetTokenCosts(tokenAddress string) (*CostReport, error) {
+	return db.getCosts("token_address = ?", tokenAddress)
+}
+
+// getCosts aggregates the cost of the mined snipes matching filter
+func (db *DB) getCosts(filter string, args ...interface{}) (*CostReport, error) {
+	text := db.dialect.TextCast
+	query := `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN status = 'reverted' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(gas_used), 0),
+			` + text("COALESCE(SUM(gas_used * effective_gas_price), 0)") + `,
+			` + text("COALESCE(SUM(l1_fee), 0)") + `,
+			` + text("COALESCE(SUM(CASE WHEN status = 'landed' THEN CAST(bribe_amount AS DECIMAL(38,18)) ELSE 0 END), 0)") + `
+		FROM snipes
+		WHERE ` + filter + ` AND gas_used IS NOT NULL
+	`
+
+	report := &CostReport{}
+	err := db.QueryRow(query, args...).Scan(
+		&report.Snipes,
+		&report.Reverted,
+		&report.GasUsed,
+		&report.GasCostWei,
+		&report.L1FeeWei,
+		&report.BribeETH,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// BribeStats summarizes the bribes of mined snipes with one outcome. Wei
+// amounts are empty when there are no snipes.
+type BribeStats struct {
+	Snipes int64  `json:"snipes"`
+	MinWei string `json:"minWei"`
+	AvgWei string `json:"avgWei"`
+	MaxWei string `json:"maxWei"`
+}
+
+// BribeReport compares the bribes of recently landed and reverted snipes
+type BribeReport struct {
+	Since    time.Time  `json:"since"`
+	Landed   BribeStats `json:"landed"`
+	Reverted BribeStats `json:"reverted"`
+}
+
+// GetBribeReport aggregates the bribes of snipes created since the given time
+// that have been mined, for one token or for all tokens if tokenAddress is empty
+func (db *DB) GetBribeReport(tokenAddress string, since time.Time) (*BribeReport, error) {
+	text := db.dialect.TextCast
+	query := `
+		SELECT
+			status,
+			COUNT(*),
+			` + text("MIN(bribe_wei)") + `,
+			` + text("ROUND(AVG(bribe_wei))") + `,
+			` + text("MAX(bribe_wei)") + `
+		FROM snipes
+		WHERE status IN ('landed', 'reverted') AND bribe_wei IS NOT NULL AND created_at >= ?
+	`
+	args := []interface{}{since}
+	if tokenAddress != "" {
+		query += ` AND token_address = ?`
+		args = append(args, tokenAddress)
+	}
+	query += ` GROUP BY status`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &BribeReport{Since: since}
+	for rows.Next() {
+		var status string
+		var stats BribeStats
+		if err := rows.Scan(&status, &stats.Snipes, &stats.MinWei, &stats.AvgWei, &stats.MaxWei); err != nil {
+			return nil, err
+		}
+
+		if status == SnipeStatusLanded {
+			report.Landed = stats
+		} else {
+			report.Reverted = stats
+		}
+	}
+
+	return report, rows.Err()
+}
+
+// querySnipes runs a query selecting snipeColumns and scans every row
+func (db *DB) querySnipes(query string, args ...interface{}) ([]*Snipe, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snipes []*Snipe
+	for rows.Next() {
+		snipe, err := scanSnipe(rows)
+		if err != nil {
+			return nil, err
+		}
+		snipes = append(snipes, snipe)
+	}
+
+	return snipes, rows.Err()
+}
+
+// UpdateSnipeStatusAtomic atomically updates snipe status from oldStatus to newStatus
+// Returns true if the update was successful (status was actually oldStatus)
+func (db *DB) UpdateSnipeStatusAtomic(id int64, oldStatus, newStatus string) (bool, error) {
+	query := `
+		UPDATE snipes
+		SET status = ?
+		WHERE id = ? AND status = ?
+	`
+
+	result, err := db.Exec(query, newStatus, id, oldStatus)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UpdateSnipeStatus updates the status of a snipe
+func (db *DB) UpdateSnipeStatus(id int64, status string) error {
+	query := `
+		UPDATE snipes
+		SET status = ?
+		WHERE id = ?
+	`
+
+	return db.withRetry("UpdateSnipeStatus", func() error {
+		_, err := db.Exec(query, status, id)
+		return err
+	})
+}
+
+// execer is implemented by *DB and *txn
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// updatePendingSnipes moves the snipes among ids that are still pending to
+// status in a single UPDATE, also setting their bundle ID unless it is empty,
+// and returns how many it moved
+func updatePendingSnipes(exec execer, ids []int64, status, bundleID string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	set := "status = ?"
+	args := make([]interface{}, 0, len(ids)+3)
+	args = append(args, status)
+	if bundleID != "" {
+		set += ", bundle_id = ?"
+		args = append(args, bundleID)
+	}
+	args = append(args, SnipeStatusPending)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := `
+		UPDATE snipes
+		SET ` + set + `
+		WHERE status = ? AND id IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + `)
+	`
+
+	result, err := exec.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetPendingSnipeWallets gets the distinct wallets that have a pending snipe
+func (db *DB) GetPendingSnipeWallets() ([]string, error) {
+	query := `
+		SELECT DISTINCT wallet
+		FROM snipes
+		WHERE status = 'pending'
+	`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []string
+	for rows.Next() {
+		var wallet string
+		if err := rows.Scan(&wallet); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
+}
+
+// Setting names
+const (
+	SettingPaused = "paused" // "true" while sniping is paused by an operator
+)
+
+// GetSetting gets a runtime setting, or "" if it was never set
+func (db *DB) GetSetting(name string) (string, error) {
+	query := `
+		SELECT value
+		FROM settings
+		WHERE name = ?
+	`
+
+	var value string
+	err := db.QueryRow(query, name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSetting creates or updates a runtime setting
+func (db *DB) SetSetting(name, value string) error {
+	query := `
+		INSERT INTO settings (name, value, updated_at)
+		VALUES (?, ?, ?)
+	` + db.dialect.Upsert("name", "value", "updated_at")
+
+	_, err := db.Exec(query, name, value, time.Now())
+	return err
+}
+
+// IsPaused reports whether sniping is paused
+func (db *DB) IsPaused() (bool, error) {
+	value, err := db.GetSetting(SettingPaused)
+	if err != nil {
+		return false, err
+	}
+	return value == "true", nil
+}
+
+// SetPaused pauses or resumes sniping
+func (db *DB) SetPaused(paused bool) error {
+	return db.SetSetting(SettingPaused, strconv.FormatBool(paused))
+}
+
+// UserSettings are a user's defaults for /snipe options they leave out
+type UserSettings struct {
+	UserID   string
+	Slippage sql.NullFloat64 // Percent
+	Tip      sql.NullString  // Bribe in ETH
+	MaxBump  sql.NullString  // Most ETH a snipe may add in tips to outbid competing snipes
+
+	// Whether to DM the user when a token they snipe gets its LP_ADD; on
+	// when not set
+	LaunchAlerts sql.NullBool
+}
+
+// GetUserSettings gets a user's settings, empty if they never saved any
+func (db *DB) GetUserSettings(userID string) (*UserSettings, error) {
+	query := `
+		SELECT slippage, tip, max_bump, launch_alerts
+		FROM user_settings
+		WHERE user_id = ?
+	`
+
+	settings := &UserSettings{UserID: userID}
+	err := db.QueryRow(query, userID).Scan(&settings.Slippage, &settings.Tip, &settings.MaxBump, &settings.LaunchAlerts)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveUserSettings creates or replaces a user's settings
+func (db *DB) SaveUserSettings(settings *UserSettings) error {
+	query := `
+		INSERT INTO user_settings (user_id, slippage, tip, max_bump, launch_alerts, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	` + db.dialect.Upsert("user_id", "slippage", "tip", "max_bump", "launch_alerts", "updated_at")
+
+	_, err := db.Exec(query, settings.UserID, settings.Slippage, settings.Tip, settings.MaxBump, settings.LaunchAlerts, time.Now())
+	return err
+}
+
+// LPEvent is the pool baseline captured when an addLiquidityETH launch is
+// detected, before it lands: what the launch adds and the reserves it adds
+// them to. Token amounts are in the token's smallest unit, ETH in wei.
+type LPEvent struct {
+	ID                 int64
+	TokenAddress       string
+	TxHash             string
+	Creator            string
+	AmountTokenDesired string
+	TokenDecimals      sql.NullInt32 // Scale of AmountTokenDesired and TokenReserveBefore
+	ETHValue           string
+	TokenReserveBefore sql.NullString // NULL when the reserves couldn't be read
+	WETHReserveBefore  sql.NullString
+	BundleID           sql.NullString // Bundle the launch was submitted in, NULL if it wasn't
+	DetectedAt         string
+}
+
+// CreateLPEvent records an LP_ADD baseline
+func (db *DB) CreateLPEvent(event *LPEvent) error {
+	query := `
+		INSERT INTO lp_events (token_address, tx_hash, creator, amount_token_desired, token_decimals, eth_value, token_reserve_before, weth_reserve_before, bundle_id, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	id, err := db.insert(query, event.TokenAddress, event.TxHash, event.Creator, event.AmountTokenDesired,
+		event.TokenDecimals, event.ETHValue, event.TokenReserveBefore, event.WETHReserveBefore, event.BundleID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	return nil
+}
+
+// GetLatestLPEvent gets the most recent LP_ADD baseline of a token, or nil if
+// none was captured
+func (db *DB) GetLatestLPEvent(tokenAddress string) (*LPEvent, error) {
+	query := `
+		SELECT id, token_address, tx_hash, creator, amount_token_desired, token_decimals, eth_value, token_reserve_before, weth_reserve_before, bundle_id, detected_at
+		FROM lp_events
+		WHERE token_address = ?
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	event := &LPEvent{}
+	err := db.QueryRow(query, tokenAddress).Scan(&event.ID, &event.TokenAddress, &event.TxHash, &event.Creator,
+		&event.AmountTokenDesired, &event.TokenDecimals, &event.ETHValue, &event.TokenReserveBefore, &event.WETHReserveBefore, &event.BundleID, &event.DetectedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// LP_ADD notification outbox statuses
+const (
+	LPNotificationPending   = "pending"
+	LPNotificationDelivered = "delivered"
+	LPNotificationExpired   = "expired" // Never delivered before it got too old
+)
+
+// LPNotification is an LP_ADD notification in the RPC proxy's outbox.
+// Payload is the JSON body POSTed to the bot service.
+type LPNotification struct {
+	ID           int64
+	TokenAddress string
+	Payload      string
+	Attempts     int
+}
+
+// CreateLPNotification adds a notification to the outbox. It isn't due for a
+// retry until retryAt, so the first delivery attempt can finish first.
+func (db *DB) CreateLPNotification(tokenAddress, payload string, retryAt time.Time) (int64, error) {
+	query := `
+		INSERT INTO lp_notifications (token_address, payload, status, created_at, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	return db.insert(query, tokenAddress, payload, LPNotificationPending, time.Now(), retryAt)
+}
+
+// GetDueLPNotifications gets the pending notifications created since
+// createdAfter whose next attempt is due, oldest first
+func (db *DB) GetDueLPNotifications(createdAfter time.Time) ([]*LPNotification, error) {
+	query := `
+		SELECT id, token_address, payload, attempts
+		FROM lp_notifications
+		WHERE status = ? AND created_at >= ? AND next_attempt_at <= ?
+		ORDER BY id ASC
+	`
+
+	rows, err := db.Query(query, LPNotificationPending, createdAfter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*LPNotification
+	for rows.Next() {
+		n := &LPNotification{}
+		if err := rows.Scan(&n.ID, &n.TokenAddress, &n.Payload, &n.Attempts); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkLPNotificationDelivered records that the bot service acknowledged a notification
+func (db *DB) MarkLPNotificationDelivered(id int64) error {
+	query := `
+		UPDATE lp_notifications
+		SET status = ?, attempts = attempts + 1, last_error = NULL, delivered_at = ?
+		WHERE id = ?
+	`
+
+	return db.withRetry("MarkLPNotificationDelivered", func() error {
+		_, err := db.Exec(query, LPNotificationDelivered, time.Now(), id)
+		return err
+	})
+}
+
+// RecordLPNotificationFailure records a failed delivery attempt and when the
+// next one is due
+func (db *DB) RecordLPNotificationFailure(id int64, lastError string, retryAt time.Time) error {
+	query := `
+		UPDATE lp_notifications
+		SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ? AND status = ?
+	`
+
+	_, err := db.Exec(query, lastError, retryAt, id, LPNotificationPending)
+	return err
+}
+
+// ExpireLPNotifications marks pending notifications created before
+// createdBefore as expired and returns how many there were
+func (db *DB) ExpireLPNotifications(createdBefore time.Time) (int64, error) {
+	query := `
+		UPDATE lp_notifications
+		SET status = ?
+		WHERE status = ? AND created_at < ?
+	`
+
+	result, err := db.Exec(query, LPNotificationExpired, LPNotificationPending, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// BundleTx is one raw transaction of a submitted bundle, kept for forensic
+// replay
+type BundleTx struct {
+	BundleID     string
+	TokenAddress string
+	Position     int // 0 is the LP_ADD, then the snipes and commission transfers in submission order
+	TxHash       string
+	RawTx        string // 0x-prefixed hex, exactly as submitted
+	Accepted     bool   // Whether any submission endpoint accepted it
+	SubmittedAt  string
+}
+
+// ArchiveBundle stores the raw transactions of a submitted bundle
+func (db *DB) ArchiveBundle(txs []BundleTx) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO bundle_txs (bundle_id, token_address, position, tx_hash, raw_tx, accepted, submitted_at)
+		VALUES ` + strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?, ?, ?), ", len(txs)), ", ")
+
+	now := time.Now()
+	args := make([]interface{}, 0, 7*len(txs))
+	for _, tx := range txs {
+		args = append(args, tx.BundleID, tx.TokenAddress, tx.Position, tx.TxHash, tx.RawTx, tx.Accepted, now)
+	}
+
+	_, err := db.Exec(query, args...)
+	return err
+}
+
+// GetBundleTxs gets the archived transactions of a bundle in submission order
+func (db *DB) GetBundleTxs(bundleID string) ([]*BundleTx, error) {
+	query := `
+		SELECT bundle_id, token_address, position, tx_hash, raw_tx, accepted, submitted_at
+		FROM bundle_txs
+		WHERE bundle_id = ?
+		ORDER BY position ASC
+	`
+
+	rows, err := db.Query(query, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*BundleTx
+	for rows.Next() {
+		tx := &BundleTx{}
+		if err := rows.Scan(&tx.BundleID, &tx.TokenAddress, &tx.Position, &tx.TxHash, &tx.RawTx, &tx.Accepted, &tx.SubmittedAt); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, rows.Err()
+}
+
+// PruneBundleTxs deletes archived bundle transactions submitted before
+// cutoff, returning how many were deleted
+func (db *DB) PruneBundleTxs(cutoff time.Time) (int64, error) {
+	result, err := db.Exec(`DELETE FROM bundle_txs WHERE submitted_at < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// TokenBundle is the latest submitted launch of a token
+type TokenBundle struct {
+	TokenAddress string
+	BundleID     string
+	SubmittedAt  string
+}
+
+// RecordTokenBundle records that a token's launch was just submitted in a
+// bundle, replacing any earlier one
+func (db *DB) RecordTokenBundle(tokenAddress, bundleID string) error {
+	query := `
+		INSERT INTO token_bundles (token_address, bundle_id, submitted_at)
+		VALUES (?, ?, ?)
+	` + db.dialect.Upsert("token_address", "bundle_id", "submitted_at")
+
+	_, err := db.Exec(query, tokenAddress, bundleID, time.Now())
+	return err
+}
+
+// GetTokenBundleSince gets a token's latest submitted launch if it was
+// submitted after since, or nil
+func (db *DB) GetTokenBundleSince(tokenAddress string, since time.Time) (*TokenBundle, error) {
+	query := `
+		SELECT token_address, bundle_id, submitted_at
+		FROM token_bundles
+		WHERE token_address = ? AND submitted_at > ?
+	`
+
+	bundle := &TokenBundle{}
+	err := db.QueryRow(query, tokenAddress, since).Scan(&bundle.TokenAddress, &bundle.BundleID, &bundle.SubmittedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// Position statuses
+const (
+	PositionStatusOpen   = "open"   // Holding tokens bought by landed snipes
+	PositionStatusClosed = "closed" // Sold
+)
+
+// Position is a user's holding of a token, from the snipes that bought it to
+// the sell that closed it. Token amounts are in the token's smallest unit,
+// ETH amounts in wei.
+type Position struct {
+	ID           int64
+	UserID       string
+	Wallet       string
+	TokenAddress string
+	Status       string
+	TokensBought string
+	ETHSpent     string        // Value and gas, L1 fee included, of the snipes that bought in
+	EntryBlock   sql.NullInt64 // Block of the first snipe
+	TokensSold   string
+	ETHReceived  string
+	ExitBlock    sql.NullInt64
+	SellTxHash   sql.NullString // Sell sent but not yet confirmed
+	OpenedAt     string
+	UpdatedAt    string
+	ClosedAt     sql.NullString
+}
+
+// PositionEntry is what a landed snipe adds to its user's position
+type PositionEntry struct {
+	UserID       string
+	Wallet       string
+	TokenAddress string
+	Tokens       *big.Int
+	ETHSpent     *big.Int
+	Block        uint64
+}
+
+// PositionExit is a confirmed sell of a position
+type PositionExit struct {
+	Tokens      *big.Int
+	ETHReceived *big.Int
+	Block       uint64
+}
+
+// positionColumns lists the positions columns in the order scanPosition expects
+const positionColumns = `id, user_id, wallet, token_address, status, tokens_bought, eth_spent, entry_block, tokens_sold, eth_received, exit_block, sell_tx_hash, opened_at, updated_at, closed_at`
+
+func scanPosition(row rowScanner) (*Position, error) {
+	position := &Position{}
+	err := row.Scan(
+		&position.ID,
+		&position.UserID,
+		&position.Wallet,
+		&position.TokenAddress,
+		&position.Status,
+		&position.TokensBought,
+		&position.ETHSpent,
+		&position.EntryBlock,
+		&position.TokensSold,
+		&position.ETHReceived,
+		&position.ExitBlock,
+		&position.SellTxHash,
+		&position.OpenedAt,
+		&position.UpdatedAt,
+		&position.ClosedAt,
+	)
+	return position, err
+}
+
+// OpenPosition adds a landed snipe to its user's open position in the token,
+// opening one if there is none
+func (db *DB) OpenPosition(entry *PositionEntry) error {
+	return db.withConflictRetry("OpenPosition", func() error {
+		tx, err := db.begin()
+		if err != nil {
+			return err
+		}
+		if err := openPosition(tx, entry); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// openPosition runs the read and write of OpenPosition within tx
+func openPosition(tx *txn, entry *PositionEntry) error {
+	var id int64
+	var tokensBought, ethSpent string
+	err := tx.QueryRow(`
+		SELECT id, tokens_bought, eth_spent
+		FROM positions
+		WHERE user_id = ? AND token_address = ? AND status = ?
+		FOR UPDATE
+	`, entry.UserID, entry.TokenAddress, PositionStatusOpen).Scan(&id, &tokensBought, &ethSpent)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(`
+			INSERT INTO positions (user_id, wallet, token_address, status, tokens_bought, eth_spent, entry_block, tokens_sold, eth_received, opened_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, '0', 0, ?, ?)
+		`, entry.UserID, entry.Wallet, entry.TokenAddress, PositionStatusOpen, entry.Tokens.String(), entry.ETHSpent.String(),
+			entry.Block, time.Now(), time.Now())
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	tokens, ok := new(big.Int).SetString(tokensBought, 10)
+	if !ok {
+		return fmt.Errorf("position %d has invalid tokens_bought %q", id, tokensBought)
+	}
+	spent, ok := new(big.Int).SetString(ethSpent, 10)
+	if !ok {
+		return fmt.Errorf("position %d has invalid eth_spent %q", id, ethSpent)
+	}
+	_, err = tx.Exec(`
+		UPDATE positions
+		SET tokens_bought = ?, eth_spent = ?, updated_at = ?
+		WHERE id = ?
+	`, tokens.Add(tokens, entry.Tokens).String(), spent.Add(spent, entry.ETHSpent).String(), time.Now(), id)
+	return err
+}
+
+// SetPositionSellTx records the sell sent for a user's open position in a
+// token, or clears it when sellTxHash is NULL. Without an open position this
+// does nothing.
+func (db *DB) SetPositionSellTx(userID, tokenAddress string, sellTxHash sql.NullString) error {
+	query := `
+		UPDATE positions
+		SET sell_tx_hash = ?, updated_at = ?
+		WHERE user_id = ? AND token_address = ? AND status = ?
+	`
+
+	_, err := db.Exec(query, sellTxHash, time.Now(), userID, tokenAddress, PositionStatusOpen)
+	return err
+}
+
+// GetSellingPositions gets the open positions with a sell awaiting its receipt
+func (db *DB) GetSellingPositions() ([]*Position, error) {
+	query := `
+		SELECT ` + positionColumns + `
+		FROM positions
+		WHERE status = ? AND sell_tx_hash IS NOT NULL
+	`
+
+	return db.queryPositions(query, PositionStatusOpen)
+}
+
+// GetUserPositions gets a user's positions, newest first
+func (db *DB) GetUserPositions(userID string) ([]*Position, error) {
+	query := `
+		SELECT ` + positionColumns + `
+		FROM positions
+		WHERE user_id = ?
+		ORDER BY opened_at DESC, id DESC
+	`
+
+	return db.queryPositions(query, userID)
+}
+
+// queryPositions runs a query selecting positionColumns
+func (db *DB) queryPositions(query string, args ...interface{}) ([]*Position, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []*Position
+	for rows.Next() {
+		position, err := scanPosition(rows)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, rows.Err()
+}
+
+// ClosePosition records the confirmed sell of an open position and closes it
+func (db *DB) ClosePosition(id int64, exit PositionExit) error {
+	query := `
+		UPDATE positions
+		SET status = ?, tokens_sold = ?, eth_received = ?, exit_block = ?, sell_tx_hash = NULL, updated_at = ?, closed_at = ?
+		WHERE id = ? AND status = ?
+	`
+
+	now := time.Now()
+	return db.withRetry("ClosePosition", func() error {
+		_, err := db.Exec(query, PositionStatusClosed, exit.Tokens.String(), exit.ETHReceived.String(), exit.Block, now, now,
+			id, PositionStatusOpen)
+		return err
+	})
+}
@@ -0,0 +1,432 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// benchToken is the token the benchmark's snipes are placed on, and deleted by
+const benchToken = "0x000000000000000000000000000000000000bEEF"
+
+// BenchmarkGetSnipesByToken compares the launch-time ordering of 100k pending
+// snipes by the indexed bribe_wei column with the CAST of the VARCHAR
+// bribe_amount it replaced. It needs a database with the current schema:
+//
+//	SNIPE_BENCH_DATABASE_URL=... go test -run '^$' -bench GetSnipesByToken ./services/bot/db
+func BenchmarkGetSnipesByToken(b *testing.B) {
+	databaseURL := os.Getenv("SNIPE_BENCH_DATABASE_URL")
+	if databaseURL == "" {
+		b.Skip("SNIPE_BENCH_DATABASE_URL is not set")
+	}
+
+	db, err := New(databaseURL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	cleanup := func() {
+		if _, err := db.Exec("DELETE FROM snipes WHERE token_address = ?", benchToken); err != nil {
+			b.Fatal(err)
+		}
+	}
+	cleanup()
+	defer cleanup()
+
+	const rows, batch = 100000, 1000
+	for inserted := 0; inserted < rows; inserted += batch {
+		snipes := make([]*Snipe, batch)
+		for i := range snipes {
+			// 0.00001 to 1 ETH
+			micro := rand.Int63n(1000000) + 10
+			snipes[i] = &Snipe{
+				UserID:       fmt.Sprintf("bench-%d", inserted+i),
+				TokenAddress: benchToken,
+				Amount:       "0.01",
+				BribeAmount:  fmt.Sprintf("%d.%06d", micro/1000000, micro%1000000),
+				Wallet:       "0x0000000000000000000000000000000000000001",
+			}
+		}
+		if err := db.CreateSnipes(snipes); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// The ordering GetSnipesByToken had before bribe_wei
+	castQuery := `
+		SELECT ` + snipeColumns + `
+		FROM snipes
+		WHERE token_address = ? AND status = 'pending'
+		ORDER BY CAST(bribe_amount AS DECIMAL(20,8)) DESC
+	`
+
+	queries := []struct {
+		name string
+		get  func() ([]*Snipe, error)
+	}{
+		{"bribe_wei", func() ([]*Snipe, error) { return db.GetSnipesByToken(benchToken) }},
+		{"cast_bribe_amount", func() ([]*Snipe, error) { return db.querySnipes(castQuery, benchToken) }},
+	}
+	for _, q := range queries {
+		b.Run(q.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				snipes, err := q.get()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(snipes) != rows {
+					b.Fatalf("got %d snipes, want %d", len(snipes), rows)
+				}
+			}
+		})
+	}
+}
+
+// fakeDriver is a database/sql driver that keeps the statements of committed
+// transactions and can fail the Nth UPDATE or INSERT, to test transactions
+// and retries without a database server
+type fakeDriver struct {
+	mu        sync.Mutex
+	committed []string // Statements applied, with their arguments
+	writes    int      // UPDATEs and INSERTs run so far, committed or not
+	failAt    int      // Write that fails with a deadlock, 0 for none
+	rollbacks int
+	selected  []int64 // IDs a SELECT returns, of those it asks for
+	noInsert  bool    // Whether INSERTs insert no row, as an INSERT ... SELECT matching none
+}
+
+var (
+	fakeDrivers   = make(map[string]*fakeDriver)
+	fakeDriversMu sync.Mutex
+	registerFake  sync.Once
+)
+
+// newFakeDB returns a DB on a new fakeDriver, retrying 3 times without backoff
+func newFakeDB(t *testing.T, fake *fakeDriver) *DB {
+	registerFake.Do(func() { sql.Register("fake", fakeConnector{}) })
+
+	fakeDriversMu.Lock()
+	fakeDrivers[t.Name()] = fake
+	fakeDriversMu.Unlock()
+
+	database, err := sql.Open("fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	db := &DB{DB: database, dialect: mysqlDialect{}}
+	db.SetRetryPolicy(3, 0)
+	return db
+}
+
+type fakeConnector struct{}
+
+func (fakeConnector) Open(name string) (driver.Conn, error) {
+	fakeDriversMu.Lock()
+	defer fakeDriversMu.Unlock()
+	return &fakeConn{fake: fakeDrivers[name]}, nil
+}
+
+type fakeConn struct {
+	fake    *fakeDriver
+	pending []string // Statements of the open transaction
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx, c.pending = true, nil
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	c.fake.committed = append(c.fake.committed, c.pending...)
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	c.fake.rollbacks++
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fake := s.conn.fake
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if strings.HasPrefix(s.query, "UPDATE") || strings.HasPrefix(s.query, "INSERT") {
+		fake.writes++
+		if fake.writes == fake.failAt {
+			return nil, &mysql.MySQLError{Number: mysqlErrDeadlock, Message: "Deadlock found when trying to get lock"}
+		}
+	}
+
+	s.record(args)
+	if fake.noInsert && strings.HasPrefix(s.query, "INSERT") {
+		return driver.RowsAffected(0), nil
+	}
+	return fakeResult(fake.writes), nil
+}
+
+// record applies the statement, or adds it to the open transaction
+func (s *fakeStmt) record(args []driver.Value) {
+	statement := fmt.Sprint(s.query, args)
+	if s.conn.inTx {
+		s.conn.pending = append(s.conn.pending, statement)
+	} else {
+		s.conn.fake.committed = append(s.conn.fake.committed, statement)
+	}
+}
+
+// fakeResult is one row written, whose insert ID is the write's number
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	fake := s.conn.fake
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	// An INSERT ... RETURNING id returns the write's number, like LastInsertId
+	if strings.HasPrefix(s.query, "INSERT") {
+		fake.writes++
+		s.record(args)
+		if fake.noInsert {
+			return &fakeRows{}, nil
+		}
+		return &fakeRows{ids: []int64{int64(fake.writes)}}, nil
+	}
+
+	asked := make(map[int64]bool)
+	for _, arg := range args {
+		if id, ok := arg.(int64); ok {
+			asked[id] = true
+		}
+	}
+	rows := &fakeRows{}
+	for _, id := range fake.selected {
+		if asked[id] {
+			rows.ids = append(rows.ids, id)
+		}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	ids []int64
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.ids) == 0 {
+		return io.EOF
+	}
+	dest[0], r.ids = r.ids[0], r.ids[1:]
+	return nil
+}
+
+func TestRecordBundleRollsBackAndRetries(t *testing.T) {
+	submitted := []SnipeSubmission{
+		{SnipeID: 1, TxHash: "0x01", Position: 0, SwapWei: big.NewInt(1e16), Accepted: true},
+		{SnipeID: 2, TxHash: "0x02", Position: 1, SwapWei: big.NewInt(1e16), Accepted: true},
+		{SnipeID: 3, TxHash: "0x03", Position: 2, SwapWei: big.NewInt(1e16), Accepted: false},
+	}
+	dropped := []int64{4, 5}
+	simulations := []SnipeSimulation{
+		{SnipeID: 1, Status: SimulationPassed},
+		{SnipeID: 4, Status: SimulationFailed, Error: "execution reverted"},
+	}
+
+	// However many snipes, one UPDATE each of the submitted, dropped and
+	// simulated snipes per attempt
+	wantPrefixes := []string{
+		"UPDATE snipes SET tx_hash = CASE id",
+		"UPDATE snipes SET status = ?, bundle_id = ?",
+		"UPDATE snipes SET simulation_status = CASE id",
+	}
+	wantSuffixes := []string{" 1 2 3]", " 4 5]", " 1 4]"}
+	for failAt := 1; failAt <= 3; failAt++ {
+		t.Run(fmt.Sprintf("deadlock on UPDATE %d", failAt), func(t *testing.T) {
+			fake := &fakeDriver{failAt: failAt}
+			db := newFakeDB(t, fake)
+
+			if err := db.RecordBundle("0xbundle", submitted, dropped, simulations); err != nil {
+				t.Fatalf("RecordBundle: %v", err)
+			}
+
+			// The failed attempt's UPDATEs were rolled back, so each change
+			// is applied exactly once, by the retry
+			if fake.rollbacks != 1 {
+				t.Errorf("%d rollbacks, want 1", fake.rollbacks)
+			}
+			if len(fake.committed) != 3 {
+				t.Fatalf("%d statements committed, want 3: %q", len(fake.committed), fake.committed)
+			}
+			for i, statement := range fake.committed {
+				if !strings.HasPrefix(statement, wantPrefixes[i]) || !strings.HasSuffix(statement, wantSuffixes[i]) {
+					t.Errorf("statement %d %q, want %q... for snipes%s", i, statement, wantPrefixes[i], wantSuffixes[i])
+				}
+			}
+			if !strings.Contains(fake.committed[0], SnipeStatusSubmitted) || !strings.Contains(fake.committed[0], SnipeStatusSubmitFailed) {
+				t.Errorf("statement %q doesn't record both outcomes", fake.committed[0])
+			}
+			if !strings.Contains(fake.committed[1], SnipeStatusDropped) {
+				t.Errorf("statement %q doesn't drop snipes", fake.committed[1])
+			}
+		})
+	}
+}
+
+func TestRecordBundleGivesUp(t *testing.T) {
+	// Every attempt deadlocks on its first UPDATE
+	fake := &fakeDriver{}
+	db := newFakeDB(t, fake)
+	db.SetRetryPolicy(1, 0)
+	fake.failAt = 1
+
+	err := db.RecordBundle("0xbundle", []SnipeSubmission{{SnipeID: 1, TxHash: "0x01", SwapWei: big.NewInt(1)}}, nil, nil)
+	if !db.dialect.LockConflict(err) {
+		t.Fatalf("error %v doesn't wrap the deadlock", err)
+	}
+	if len(fake.committed) != 0 || fake.rollbacks != 1 {
+		t.Errorf("%d statements committed and %d rollbacks, want none and 1", len(fake.committed), fake.rollbacks)
+	}
+}
+
+func TestClaimBundle(t *testing.T) {
+	snipes := []SnipeSubmission{
+		{SnipeID: 1, TxHash: "0x01", Position: 0, SwapWei: big.NewInt(1e16)},
+		{SnipeID: 2, TxHash: "0x02", Position: 1, SwapWei: big.NewInt(1e16)},
+	}
+
+	t.Run("all pending", func(t *testing.T) {
+		fake := &fakeDriver{selected: []int64{1, 2}}
+		db := newFakeDB(t, fake)
+
+		unclaimed, err := db.ClaimBundle("0xbundle", snipes)
+		if err != nil || len(unclaimed) != 0 {
+			t.Fatalf("ClaimBundle = %v, %v", unclaimed, err)
+		}
+		if len(fake.committed) != 1 {
+			t.Fatalf("%d statements committed, want a single UPDATE: %q", len(fake.committed), fake.committed)
+		}
+		for _, statement := range fake.committed {
+			if !strings.Contains(statement, SnipeStatusSubmitting) {
+				t.Errorf("statement %q doesn't claim", statement)
+			}
+		}
+	})
+
+	t.Run("one cancelled", func(t *testing.T) {
+		fake := &fakeDriver{selected: []int64{2}}
+		db := newFakeDB(t, fake)
+
+		unclaimed, err := db.ClaimBundle("0xbundle", snipes)
+		if err != nil || len(unclaimed) != 1 || unclaimed[0] != 1 {
+			t.Fatalf("ClaimBundle = %v, %v, want [1]", unclaimed, err)
+		}
+		if len(fake.committed) != 0 || fake.rollbacks != 1 {
+			t.Errorf("%d statements committed and %d rollbacks, want none and 1", len(fake.committed), fake.rollbacks)
+		}
+	})
+
+	t.Run("deadlock", func(t *testing.T) {
+		fake := &fakeDriver{selected: []int64{1, 2}, failAt: 1}
+		db := newFakeDB(t, fake)
+
+		if unclaimed, err := db.ClaimBundle("0xbundle", snipes); err != nil || len(unclaimed) != 0 {
+			t.Fatalf("ClaimBundle = %v, %v", unclaimed, err)
+		}
+		if len(fake.committed) != 1 || fake.rollbacks != 1 {
+			t.Errorf("%d statements committed and %d rollbacks, want 1 and 1", len(fake.committed), fake.rollbacks)
+		}
+	})
+}
+
+func TestCreateSnipesRetriesDeadlock(t *testing.T) {
+	fake := &fakeDriver{failAt: 2}
+	db := newFakeDB(t, fake)
+
+	snipes := []*Snipe{
+		{UserID: "1", TokenAddress: benchToken, Amount: "0.01", BribeAmount: "0.001", Wallet: "0x01"},
+		{UserID: "2", TokenAddress: benchToken, Amount: "0.01", BribeAmount: "0.002", Wallet: "0x02"},
+	}
+	if err := db.CreateSnipes(snipes); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first attempt's insert was rolled back; the snipes get the IDs of
+	// the retry's
+	if len(fake.committed) != 2 || fake.rollbacks != 1 {
+		t.Errorf("%d statements committed and %d rollbacks, want 2 and 1", len(fake.committed), fake.rollbacks)
+	}
+	if snipes[0].ID != 3 || snipes[1].ID != 4 {
+		t.Errorf("snipe IDs %d and %d, want 3 and 4", snipes[0].ID, snipes[1].ID)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"mysql datetime", "2024-03-09 14:05:07", want},
+		{"mysql fractional", "2024-03-09 14:05:07.5", want.Add(500 * time.Millisecond)},
+		{"rfc3339", "2024-03-09T14:05:07Z", want},
+		{"rfc3339 offset", "2024-03-09T16:05:07+02:00", want},
+		{"rfc3339 nanos", "2024-03-09T14:05:07.000000123Z", want.Add(123)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.value)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Error("parsed an unrecognized timestamp")
+	}
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// Dialect is the SQL flavor of a database backend. Queries are written for
+// MySQL, with ? placeholders; a dialect rewrites the parts that differ.
+type Dialect interface {
+	// Name is the backend's database/sql driver name
+	Name() string
+
+	// Rebind rewrites the ? placeholders of query into the backend's own
+	Rebind(query string) string
+
+	// Upsert returns the clause that makes an INSERT update the row whose
+	// key already exists instead, setting columns to the inserted values
+	Upsert(key string, columns ...string) string
+
+	// TextCast casts expr to a string
+	TextCast(expr string) string
+
+	// ReturnsID reports whether inserts return their row's ID with RETURNING
+	// rather than through LastInsertId
+	ReturnsID() bool
+
+	// Retryable reports whether err is a transient failure that succeeds
+	// when simply retried
+	Retryable(err error) bool
+
+	// LockConflict reports whether err is a deadlock or lock timeout. The
+	// server rolled the statement back, so even one that isn't idempotent
+	// can be retried.
+	LockConflict(err error) bool
+
+	// DuplicateKey reports whether err is an INSERT or UPDATE refused for
+	// repeating a value of the unique key on column
+	DuplicateKey(err error, column string) bool
+}
+
+// DialectFor picks the dialect of a DATABASE_URL: PostgreSQL for a
+// postgres:// or postgresql:// URL, MySQL for anything else
+func DialectFor(databaseURL string) Dialect {
+	if strings.HasPrefix(databaseURL, "postgres://") || strings.HasPrefix(databaseURL, "postgresql://") {
+		return postgresDialect{}
+	}
+	return mysqlDialect{}
+}
+
+// Open opens the database of a DATABASE_URL with its dialect's driver
+func Open(databaseURL string) (*sql.DB, Dialect, error) {
+	dialect := DialectFor(databaseURL)
+	database, err := sql.Open(dialect.Name(), databaseURL)
+	return database, dialect, err
+}
+
+// mysqlDialect is MySQL 8, the default backend
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Rebind(query string) string { return query }
+
+func (mysqlDialect) Upsert(key string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + " = VALUES(" + column + ")"
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
+
+func (mysqlDialect) TextCast(expr string) string { return "CAST(" + expr + " AS CHAR)" }
+
+func (mysqlDialect) ReturnsID() bool { return false }
+
+// postgresDialect is PostgreSQL, through lib/pq
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+// Rebind numbers the placeholders $1, $2, ... in order. Queries have no ? in
+// string literals, so every ? is a placeholder.
+func (postgresDialect) Rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var rebound strings.Builder
+	rebound.Grow(len(query) + 8)
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			rebound.WriteRune(r)
+			continue
+		}
+		n++
+		rebound.WriteString("$" + strconv.Itoa(n))
+	}
+	return rebound.String()
+}
+
+func (postgresDialect) Upsert(key string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + " = EXCLUDED." + column
+	}
+	return "ON CONFLICT (" + key + ") DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+func (postgresDialect) TextCast(expr string) string { return "CAST(" + expr + " AS TEXT)" }
+
+func (postgresDialect) ReturnsID() bool { return true }
+
+// txn is a transaction whose queries are rebound like those of DB
+type txn struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+func (tx *txn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.dialect.Rebind(query), args...)
+}
+
+func (tx *txn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.dialect.Rebind(query), args...)
+}
+
+func (tx *txn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.dialect.Rebind(query), args...)
+}
+
+// Dialect returns the dialect of the database backend
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// Exec, Query and QueryRow shadow those of the embedded *sql.DB, rebinding
+// the query to the backend's dialect first
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.dialect.Rebind(query), args...)
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.dialect.Rebind(query), args...)
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.dialect.Rebind(query), args...)
+}
+
+// begin starts a transaction with rebound queries
+func (db *DB) begin() (*txn, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, dialect: db.dialect}, nil
+}
+
+// inserter is implemented by *DB and *txn
+type inserter interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// insert runs an INSERT and returns the ID of the row it inserted, or 0 if it
+// inserted none, as an INSERT ... SELECT matching no row does
+func (db *DB) insert(query string, args ...interface{}) (int64, error) {
+	return insertRow(db, db.dialect, query, args...)
+}
+
+// insert runs an INSERT within the transaction, like DB.insert
+func (tx *txn) insert(query string, args ...interface{}) (int64, error) {
+	return insertRow(tx, tx.dialect, query, args...)
+}
+
+func insertRow(exec inserter, dialect Dialect, query string, args ...interface{}) (int64, error) {
+	if dialect.ReturnsID() {
+		var id int64
+		err := exec.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return id, err
+	}
+
+	result, err := exec.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
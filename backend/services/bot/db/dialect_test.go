@@ -0,0 +1,80 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{"mysql", mysqlDialect{}, "SELECT id FROM snipes WHERE user_id = ? AND status = ?", "SELECT id FROM snipes WHERE user_id = ? AND status = ?"},
+		{"postgres", postgresDialect{}, "SELECT id FROM snipes WHERE user_id = ? AND status = ?", "SELECT id FROM snipes WHERE user_id = $1 AND status = $2"},
+		{"postgres IN list", postgresDialect{}, "UPDATE snipes SET status = ? WHERE id IN (?, ?, ?)", "UPDATE snipes SET status = $1 WHERE id IN ($2, $3, $4)"},
+		{"postgres past nine", postgresDialect{}, strings.Repeat("?,", 10) + "?", "$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11"},
+		{"postgres without placeholders", postgresDialect{}, "SELECT COUNT(*) FROM wallets", "SELECT COUNT(*) FROM wallets"},
+		{"postgres multibyte", postgresDialect{}, "SELECT '⏸️' WHERE name = ?", "SELECT '⏸️' WHERE name = $1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.Rebind(tt.query); got != tt.want {
+			t.Errorf("%s: Rebind = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		key     string
+		columns []string
+		want    string
+	}{
+		{"mysql one column", mysqlDialect{}, "name", []string{"value"}, "ON DUPLICATE KEY UPDATE value = VALUES(value)"},
+		{"mysql columns", mysqlDialect{}, "name", []string{"value", "updated_at"}, "ON DUPLICATE KEY UPDATE value = VALUES(value), updated_at = VALUES(updated_at)"},
+		{"postgres one column", postgresDialect{}, "name", []string{"value"}, "ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value"},
+		{"postgres columns", postgresDialect{}, "user_id", []string{"slippage", "tip"}, "ON CONFLICT (user_id) DO UPDATE SET slippage = EXCLUDED.slippage, tip = EXCLUDED.tip"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.Upsert(tt.key, tt.columns...); got != tt.want {
+			t.Errorf("%s: Upsert = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInsertRow(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   Dialect
+		noInsert  bool
+		want      int64
+		statement string
+	}{
+		{"mysql", mysqlDialect{}, false, 1, "INSERT INTO settings (name, value) VALUES (?, ?)[paused true]"},
+		{"mysql none inserted", mysqlDialect{}, true, 0, "INSERT INTO settings (name, value) VALUES (?, ?)[paused true]"},
+		{"postgres", postgresDialect{}, false, 1, "INSERT INTO settings (name, value) VALUES ($1, $2) RETURNING id[paused true]"},
+		{"postgres none inserted", postgresDialect{}, true, 0, "INSERT INTO settings (name, value) VALUES ($1, $2) RETURNING id[paused true]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeDriver{noInsert: tt.noInsert}
+			db := newFakeDB(t, fake)
+			db.dialect = tt.dialect
+
+			id, err := db.insert("INSERT INTO settings (name, value) VALUES (?, ?)", "paused", "true")
+			if err != nil || id != tt.want {
+				t.Errorf("insert = %d, %v, want %d", id, err, tt.want)
+			}
+			if len(fake.committed) != 1 || fake.committed[0] != tt.statement {
+				t.Errorf("statements %q, want %q", fake.committed, tt.statement)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Server error numbers of failures that succeed when simply retried
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+)
+
+// Server error number and SQLSTATE of a unique key violation
+const (
+	mysqlErrDuplicateEntry     = 1062
+	postgresErrUniqueViolation = "23505"
+)
+
+// SQLSTATE codes of the same failures in PostgreSQL
+const (
+	postgresErrSerialization    = "40001"
+	postgresErrDeadlock         = "40P01"
+	postgresErrLockNotAvailable = "55P03"
+)
+
+// Default retry policy, used until SetRetryPolicy is called
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 100 * time.Millisecond
+)
+
+// SetRetryPolicy sets how many times retryable operations are attempted and
+// the backoff before the first retry, which doubles after each one. Attempts
+// below 1 are treated as 1 (no retries).
+func (db *DB) SetRetryPolicy(attempts int, backoff time.Duration) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	db.retryAttempts = attempts
+	db.retryBackoff = backoff
+}
+
+// retrySleep waits out a retry's backoff; tests replace it
+var retrySleep = time.Sleep
+
+// withRetry runs op, retrying it with exponential backoff while it fails with
+// a transient database error (deadlock, lock wait timeout, lost connection)
+func (db *DB) withRetry(name string, op func() error) error {
+	return db.retry(name, db.dialect.Retryable, op)
+}
+
+// withConflictRetry runs an op that isn't safe to repeat, such as an INSERT,
+// retrying it only while it fails with a lock conflict. A lost connection
+// isn't retried: the statement, or the COMMIT, may have been applied with
+// only its reply lost, and running it again would apply it twice.
+func (db *DB) withConflictRetry(name string, op func() error) error {
+	return db.retry(name, db.dialect.LockConflict, op)
+}
+
+// retry runs op, retrying it with exponential backoff while retryable(err)
+func (db *DB) retry(name string, retryable func(error) bool, op func() error) error {
+	backoff := db.retryBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if err == nil || !retryable(err) || attempt >= db.retryAttempts {
+			return err
+		}
+
+		log.Printf("⚠️ %s failed (attempt %d of %d), retrying in %s: %v", name, attempt, db.retryAttempts, backoff, err)
+		retrySleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Retryable reports whether err is a transient MySQL failure
+func (d mysqlDialect) Retryable(err error) bool {
+	// The driver reports a dropped connection (server gone away, lost
+	// connection during query) as one of these
+	return d.LockConflict(err) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn)
+}
+
+// LockConflict reports whether err is a MySQL deadlock or lock wait timeout
+func (mysqlDialect) LockConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) &&
+		(mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout)
+}
+
+// Retryable reports whether err is a transient PostgreSQL failure
+func (d postgresDialect) Retryable(err error) bool {
+	return d.LockConflict(err) || errors.Is(err, driver.ErrBadConn)
+}
+
+// LockConflict reports whether err is a PostgreSQL deadlock, serialization
+// failure or lock that wasn't available
+func (postgresDialect) LockConflict(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case postgresErrSerialization, postgresErrDeadlock, postgresErrLockNotAvailable:
+		return true
+	}
+	return false
+}
+
+// DuplicateKey reports whether err is a MySQL duplicate entry error for the
+// unique key on column. Keys declared inline with the column are named after
+// it.
+func (mysqlDialect) DuplicateKey(err error, column string) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != mysqlErrDuplicateEntry {
+		return false
+	}
+	// MySQL 8 names the key <table>.<key>, older servers just <key>
+	return strings.HasSuffix(mysqlErr.Message, "'"+column+"'") || strings.HasSuffix(mysqlErr.Message, "."+column+"'")
+}
+
+// DuplicateKey reports whether err is a PostgreSQL unique violation of the
+// key on column, whose constraint is named <table>_<column>_key
+func (postgresDialect) DuplicateKey(err error, column string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == postgresErrUniqueViolation &&
+		strings.HasSuffix(pqErr.Constraint, "_"+column+"_key")
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		err     error
+		want    bool
+	}{
+		{"mysql 8 key", mysqlDialect{}, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '3' for key 'wallets.derivation_index'"}, true},
+		{"mysql 5.7 key", mysqlDialect{}, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '3' for key 'derivation_index'"}, true},
+		{"mysql wrapped", mysqlDialect{}, fmt.Errorf("failed to create wallet: %w", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '3' for key 'wallets.derivation_index'"}), true},
+		{"mysql other key", mysqlDialect{}, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '0xab' for key 'wallets.address'"}, false},
+		{"mysql other error", mysqlDialect{}, &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, false},
+		{"postgres key", postgresDialect{}, &pq.Error{Code: "23505", Constraint: "wallets_derivation_index_key"}, true},
+		{"postgres wrapped", postgresDialect{}, fmt.Errorf("failed to create wallet: %w", &pq.Error{Code: "23505", Constraint: "wallets_derivation_index_key"}), true},
+		{"postgres other key", postgresDialect{}, &pq.Error{Code: "23505", Constraint: "wallets_address_key"}, false},
+		{"postgres other error", postgresDialect{}, &pq.Error{Code: "40P01"}, false},
+		{"nil", mysqlDialect{}, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.DuplicateKey(tt.err, "derivation_index"); got != tt.want {
+			t.Errorf("%s: DuplicateKey = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	tests := []struct {
+		name         string
+		dialect      Dialect
+		err          error
+		retryable    bool
+		lockConflict bool
+	}{
+		{"mysql deadlock", mysqlDialect{}, deadlock, true, true},
+		{"mysql deadlock wrapped", mysqlDialect{}, fmt.Errorf("failed to create snipe 2 of 3: %w", deadlock), true, true},
+		{"mysql lock wait timeout", mysqlDialect{}, &mysql.MySQLError{Number: 1205}, true, true},
+		{"mysql duplicate entry", mysqlDialect{}, &mysql.MySQLError{Number: 1062}, false, false},
+		{"mysql bad conn", mysqlDialect{}, driver.ErrBadConn, true, false},
+		{"mysql invalid conn", mysqlDialect{}, fmt.Errorf("commit: %w", mysql.ErrInvalidConn), true, false},
+		{"mysql other error", mysqlDialect{}, errors.New("syntax error"), false, false},
+		{"postgres deadlock", postgresDialect{}, &pq.Error{Code: "40P01"}, true, true},
+		{"postgres serialization", postgresDialect{}, fmt.Errorf("update: %w", &pq.Error{Code: "40001"}), true, true},
+		{"postgres lock not available", postgresDialect{}, &pq.Error{Code: "55P03"}, true, true},
+		{"postgres unique violation", postgresDialect{}, &pq.Error{Code: "23505"}, false, false},
+		{"postgres bad conn", postgresDialect{}, driver.ErrBadConn, true, false},
+		{"postgres other error", postgresDialect{}, errors.New("syntax error"), false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.Retryable(tt.err); got != tt.retryable {
+			t.Errorf("%s: Retryable = %v, want %v", tt.name, got, tt.retryable)
+		}
+		if got := tt.dialect.LockConflict(tt.err); got != tt.lockConflict {
+			t.Errorf("%s: LockConflict = %v, want %v", tt.name, got, tt.lockConflict)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	var slept []time.Duration
+	retrySleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { retrySleep = time.Sleep }()
+
+	deadlock := &mysql.MySQLError{Number: 1213}
+	tests := []struct {
+		name     string
+		conflict bool    // withConflictRetry rather than withRetry
+		errs     []error // Errors of successive attempts; nil from then on
+		attempts int
+		err      error
+	}{
+		{"success", false, nil, 1, nil},
+		{"deadlock then success", false, []error{deadlock}, 2, nil},
+		{"deadlocks until out of attempts", false, []error{deadlock, deadlock, deadlock, deadlock}, 3, deadlock},
+		{"lost connection then success", false, []error{driver.ErrBadConn}, 2, nil},
+		{"not retryable", false, []error{errors.New("syntax error")}, 1, errors.New("syntax error")},
+		{"insert deadlock then success", true, []error{deadlock}, 2, nil},
+		{"insert lost connection", true, []error{mysql.ErrInvalidConn}, 1, mysql.ErrInvalidConn},
+	}
+
+	for _, tt := range tests {
+		db := &DB{dialect: mysqlDialect{}}
+		db.SetRetryPolicy(3, 100*time.Millisecond)
+		slept = nil
+
+		attempts := 0
+		op := func() error {
+			attempts++
+			if attempts <= len(tt.errs) {
+				return tt.errs[attempts-1]
+			}
+			return nil
+		}
+
+		var err error
+		if tt.conflict {
+			err = db.withConflictRetry(tt.name, op)
+		} else {
+			err = db.withRetry(tt.name, op)
+		}
+
+		if fmt.Sprint(err) != fmt.Sprint(tt.err) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+		}
+		if attempts != tt.attempts {
+			t.Errorf("%s: %d attempts, want %d", tt.name, attempts, tt.attempts)
+		}
+		// The backoff doubles before each retry
+		if len(slept) != attempts-1 {
+			t.Errorf("%s: slept %d times over %d attempts", tt.name, len(slept), attempts)
+		}
+		for i, d := range slept {
+			if want := 100 * time.Millisecond << i; d != want {
+				t.Errorf("%s: backoff %d is %s, want %s", tt.name, i+1, d, want)
+			}
+		}
+	}
+}
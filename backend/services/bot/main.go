@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -9,9 +11,12 @@ import (
 	"sniper-bot/services/bot/api"
 	"sniper-bot/services/bot/bot"
 	"sniper-bot/services/bot/db"
+	"sniper-bot/services/bot/positions"
+	"sniper-bot/services/bot/selftest"
 	"sniper-bot/services/bot/wallet"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -24,15 +29,26 @@ func main() {
 
 	cfg := config.Load()
 
+	// "bot selftest" checks the bot's dependencies and exits instead of starting
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(runSelftest(cfg))
+	}
+
+	log.Printf("🚩 Features: %s", cfg.Features)
+
 	// Initialize database
 	database, err := db.New(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	database.SetRetryPolicy(cfg.DBRetryAttempts, cfg.DBRetryBackoff)
 	defer database.Close()
 
 	// Initialize wallet manager with database
-	walletManager := wallet.NewManager(database)
+	walletManager, err := wallet.NewManager(database, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create wallet manager: %v", err)
+	}
 
 	// Initialize ethereum client for balance checks
 	ethClient, err := eth.NewClient(cfg.BaseRPCURL)
@@ -40,17 +56,23 @@ func main() {
 		log.Fatalf("Failed to create eth client: %v", err)
 	}
 
+	// Price landed positions for /positions and take-profit / stop-loss
+	monitor := positions.NewMonitor(ethClient, database, cfg)
+	monitor.Start()
+
 	// Initialize bot service
-	botService, err := bot.NewService(walletManager, database, ethClient)
+	botService, err := bot.NewService(walletManager, database, ethClient, monitor)
 	if err != nil {
 		log.Fatalf("Failed to create bot service: %v", err)
 	}
 
 	// Initialize API service
-	apiService, err := api.NewService(walletManager, database)
+	apiService, err := api.NewService(walletManager, database, monitor)
 	if err != nil {
 		log.Fatalf("Failed to create API service: %v", err)
 	}
+	apiService.SetNotifier(botService)
+	botService.SetWalletSender(apiService)
 
 	// Use WaitGroup to manage both services
 	var wg sync.WaitGroup
@@ -83,6 +105,8 @@ func main() {
 	log.Println("📥 Shutdown signal received, stopping services...")
 
 	// Stop services gracefully
+	monitor.Stop()
+
 	if err := botService.Stop(); err != nil {
 		log.Printf("Error stopping bot service: %v", err)
 	}
@@ -93,3 +117,21 @@ func main() {
 
 	log.Println("✅ Services stopped successfully")
 }
+
+// runSelftest prints the result of every self-test check and returns the
+// process exit code: 0 if all passed, 1 otherwise
+func runSelftest(cfg *config.Config) int {
+	results := selftest.Run(context.Background(), cfg)
+	for _, result := range results {
+		status := "✅ PASS"
+		if !result.OK {
+			status = "❌ FAIL"
+		}
+		fmt.Printf("%s  %-28s %8s  %s\n", status, result.Name, result.Latency.Round(time.Millisecond), result.Detail)
+	}
+
+	if !selftest.Passed(results) {
+		return 1
+	}
+	return 0
+}
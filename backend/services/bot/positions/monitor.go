@@ -0,0 +1,363 @@
+package positions
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/pkg/eth"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Position is a wallet's holding of a sniped token, priced at the pool's
+// reserves when it was last refreshed
+type Position struct {
+	Token     common.Address
+	Wallet    common.Address
+	UserID    string
+	SnipeIDs  []int64  // Landed snipes that bought the holding
+	SwapWei   *big.Int // ETH spent by those snipes
+	EntryWei  *big.Int // SwapWei plus the bribes paid for it
+	Balance   *big.Int // Token balance of the wallet
+	Decimals  uint8    // Token decimals, for displaying Balance
+	ValueWei  *big.Int // What selling the whole balance would return now
+	UpdatedAt time.Time
+}
+
+// Multiple returns the value of the position as a multiple of what it cost
+func (p *Position) Multiple() float64 {
+	if p.SwapWei.Sign() == 0 {
+		return 0
+	}
+	multiple, _ := new(big.Float).Quo(new(big.Float).SetInt(p.ValueWei), new(big.Float).SetInt(p.SwapWei)).Float64()
+	return multiple
+}
+
+// PnL returns the unrealized profit (or loss, if negative) over the entry cost
+func (p *Position) PnL() *big.Int {
+	return new(big.Int).Sub(p.ValueWei, p.EntryWei)
+}
+
+// PnLPercent returns PnL as a percentage of the entry cost
+func (p *Position) PnLPercent() float64 {
+	if p.EntryWei.Sign() == 0 {
+		return 0
+	}
+	percent, _ := new(big.Float).Quo(new(big.Float).SetInt(p.PnL()), new(big.Float).SetInt(p.EntryWei)).Float64()
+	return percent * 100
+}
+
+// pool is the token/WETH pair of a token and the token's decimals, none of
+// which change once the pair exists
+type pool struct {
+	pair     *dex.UniswapV2PairContract
+	token0   common.Address // Orients the pair's reserves (see dex.OrientReserves)
+	decimals uint8
+}
+
+// Monitor polls the pools of tokens users hold landed positions in and caches
+// what each position is worth. Each token's reserves are read once per poll
+// however many users hold it.
+type Monitor struct {
+	client   *eth.Client
+	db       *db.DB
+	factory  common.Address
+	enabled  bool
+	interval time.Duration
+	budget   int
+
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// Only touched by the polling goroutine
+	pools      map[common.Address]*pool
+	refreshed  map[common.Address]time.Time
+	lastDefers int
+
+	mu        sync.RWMutex
+	positions map[string]*Position // map[positionKey]*Position
+	dropped   map[string]bool      // Sold positions ignored until their snipes are no longer landed
+}
+
+// NewMonitor creates a price monitor using the node behind client
+func NewMonitor(client *eth.Client, database *db.DB, cfg *config.Config) *Monitor {
+	return &Monitor{
+		client:    client,
+		db:        database,
+		factory:   common.HexToAddress(cfg.UniswapV2Factory),
+		enabled:   cfg.Enabled(config.FeaturePriceMonitor),
+		interval:  cfg.PricePollInterval,
+		budget:    cfg.PriceRPCBudget,
+		pools:     make(map[common.Address]*pool),
+		refreshed: make(map[common.Address]time.Time),
+		stop:      make(chan struct{}),
+		positions: make(map[string]*Position),
+		dropped:   make(map[string]bool),
+	}
+}
+
+// positionKey identifies a wallet's holding of a token
+func positionKey(wallet, token common.Address) string {
+	return wallet.Hex() + "/" + token.Hex()
+}
+
+// Start polls every PricePollInterval until Stop is called. It does nothing
+// when the price_monitor feature is off or the interval is 0.
+func (m *Monitor) Start() {
+	if !m.enabled || m.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		log.Printf("📈 Pricing landed positions every %s (RPC budget %d calls)", m.interval, m.budget)
+
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.poll()
+			}
+		}
+	}()
+}
+
+// Stop stops polling
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// Position returns the cached position of a wallet in a token
+func (m *Monitor) Position(wallet, token common.Address) (*Position, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	position, ok := m.positions[positionKey(wallet, token)]
+	return position, ok
+}
+
+// UserPositions returns the cached positions of a user, most valuable first
+func (m *Monitor) UserPositions(userID string) []*Position {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var positions []*Position
+	for _, position := range m.positions {
+		if position.UserID == userID {
+			positions = append(positions, position)
+		}
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].ValueWei.Cmp(positions[j].ValueWei) > 0
+	})
+	return positions
+}
+
+// Drop forgets a position that has been sold, so it isn't acted on again
+// before its snipes stop being landed
+func (m *Monitor) Drop(wallet, token common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := positionKey(wallet, token)
+	delete(m.positions, key)
+	m.dropped[key] = true
+}
+
+// poll refreshes the positions of the least recently refreshed tokens until
+// the poll's RPC budget is spent. Positions whose tokens don't fit keep their
+// previous values and are refreshed first next time.
+func (m *Monitor) poll() {
+	ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+	defer cancel()
+
+	snipes, err := m.db.GetLandedSnipes()
+	if err != nil {
+		log.Printf("⚠️ Failed to load landed snipes: %v", err)
+		return
+	}
+
+	// Group the snipes into positions and the positions by token
+	holdings := make(map[string]*Position)
+	byToken := make(map[common.Address][]*Position)
+	for _, snipe := range snipes {
+		swapWei, ok := new(big.Int).SetString(snipe.SwapWei.String, 10)
+		if !ok {
+			continue
+		}
+
+		token := common.HexToAddress(snipe.TokenAddress)
+		wallet := common.HexToAddress(snipe.Wallet)
+		key := positionKey(wallet, token)
+
+		position, ok := holdings[key]
+		if !ok {
+			position = &Position{Token: token, Wallet: wallet, UserID: snipe.UserID, SwapWei: new(big.Int), EntryWei: new(big.Int)}
+			holdings[key] = position
+			byToken[token] = append(byToken[token], position)
+		}
+		position.SnipeIDs = append(position.SnipeIDs, snipe.ID)
+		position.SwapWei.Add(position.SwapWei, swapWei)
+		position.EntryWei.Add(position.EntryWei, swapWei)
+		if bribe, err := eth.ParseEther(snipe.BribeAmount); err == nil {
+			position.EntryWei.Add(position.EntryWei, bribe)
+		}
+	}
+
+	tokens := make([]common.Address, 0, len(byToken))
+	for token := range byToken {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return m.refreshed[tokens[i]].Before(m.refreshed[tokens[j]])
+	})
+
+	calls := 0
+	deferred := 0
+	for _, token := range tokens {
+		// getReserves plus a balanceOf per holder, and the pair and decimals
+		// lookups the first time a token is seen
+		cost := 1 + len(byToken[token])
+		if m.pools[token] == nil {
+			cost += 3
+		}
+		// The first token is always refreshed so a budget below one token's
+		// cost can't stall the monitor
+		if calls > 0 && calls+cost > m.budget {
+			deferred++
+			continue
+		}
+		calls += cost
+
+		if err := m.refresh(ctx, token, byToken[token]); err != nil {
+			log.Printf("⚠️ Failed to price positions in %s: %v", token.Hex(), err)
+			continue
+		}
+		m.refreshed[token] = time.Now()
+	}
+
+	if deferred > 0 && deferred != m.lastDefers {
+		log.Printf("⏳ RPC budget of %d calls reached, %d of %d tokens left for the next poll", m.budget, deferred, len(tokens))
+	}
+	m.lastDefers = deferred
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, position := range holdings {
+		if m.dropped[key] {
+			continue
+		}
+		if cached, ok := m.positions[key]; ok && position.ValueWei == nil {
+			// Not refreshed this poll: keep the last price with the current snipes
+			position.Balance, position.Decimals = cached.Balance, cached.Decimals
+			position.ValueWei, position.UpdatedAt = cached.ValueWei, cached.UpdatedAt
+		}
+		if position.ValueWei != nil {
+			m.positions[key] = position
+		}
+	}
+	for key := range m.positions {
+		if holdings[key] == nil {
+			delete(m.positions, key)
+		}
+	}
+	for key := range m.dropped {
+		if holdings[key] == nil {
+			delete(m.dropped, key)
+		}
+	}
+}
+
+// refresh reads a token's reserves once and prices every position in it
+func (m *Monitor) refresh(ctx context.Context, token common.Address, positions []*Position) error {
+	opts := &bind.CallOpts{Context: ctx}
+
+	p, err := m.pool(opts, token)
+	if err != nil {
+		return err
+	}
+
+	reserve0, reserve1, err := p.pair.GetReserves(opts)
+	if err != nil {
+		return fmt.Errorf("failed to get reserves: %v", err)
+	}
+	tokenReserve, wethReserve := dex.OrientReserves(p.token0, common.HexToAddress(config.WETHAddress), reserve0, reserve1)
+
+	tokenContract, err := dex.NewERC20PermitContract(m.client.Client, token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, position := range positions {
+		balance, err := tokenContract.BalanceOf(opts, position.Wallet)
+		if err != nil {
+			log.Printf("⚠️ Failed to get %s balance of %s: %v", token.Hex(), position.Wallet.Hex(), err)
+			continue
+		}
+
+		position.Balance = balance
+		position.Decimals = p.decimals
+		position.ValueWei = dex.GetAmountOut(balance, tokenReserve, wethReserve)
+		position.UpdatedAt = now
+	}
+
+	return nil
+}
+
+// pool returns the token's pair, looking it up the first time
+func (m *Monitor) pool(opts *bind.CallOpts, token common.Address) (*pool, error) {
+	if p, ok := m.pools[token]; ok {
+		return p, nil
+	}
+
+	factory, err := dex.NewUniswapV2FactoryContract(m.client.Client, m.factory)
+	if err != nil {
+		return nil, err
+	}
+
+	weth := common.HexToAddress(config.WETHAddress)
+	pairAddress, err := factory.GetPair(opts, weth, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair: %v", err)
+	}
+	if pairAddress == (common.Address{}) {
+		return nil, fmt.Errorf("no WETH pair")
+	}
+
+	pair, err := dex.NewUniswapV2PairContract(m.client.Client, pairAddress)
+	if err != nil {
+		return nil, err
+	}
+	token0, err := dex.PairToken0(opts, pair, token, weth)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenContract, err := dex.NewERC20PermitContract(m.client.Client, token)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := tokenContract.Decimals(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decimals: %v", err)
+	}
+
+	p := &pool{pair: pair, token0: token0, decimals: decimals}
+	m.pools[token] = p
+	return p, nil
+}
@@ -0,0 +1,187 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// checkTimeout bounds each check, so one dead dependency can't hang the run
+const checkTimeout = 10 * time.Second
+
+// Result is the outcome of one check
+type Result struct {
+	Name    string
+	OK      bool
+	Detail  string // What was found, or why the check failed
+	Latency time.Duration
+}
+
+// Run checks every dependency the bot needs to snipe: the database, the node,
+// each submission endpoint and the sniper contract. It opens its own
+// connections, so it works before the services start and while they run.
+func Run(ctx context.Context, cfg *config.Config) []Result {
+	var results []Result
+	results = append(results, check(ctx, "database", func(ctx context.Context) (string, error) {
+		return checkDatabase(ctx, cfg.DatabaseURL)
+	}))
+
+	client, err := ethclient.DialContext(ctx, cfg.BaseRPCURL)
+	if err != nil {
+		results = append(results, Result{Name: "node", Detail: fmt.Sprintf("failed to dial node: %v", err)})
+		return append(results, Result{Name: "sequencer", Detail: "skipped, node unavailable"},
+			Result{Name: "sniper contract", Detail: "skipped, node unavailable"})
+	}
+	defer client.Close()
+
+	var chainID *big.Int
+	results = append(results, check(ctx, "node", func(ctx context.Context) (string, error) {
+		id, detail, err := checkNode(ctx, client)
+		chainID = id
+		return detail, err
+	}))
+
+	urls, err := cfg.SubmitURLs()
+	if err != nil {
+		results = append(results, Result{Name: "sequencer", Detail: err.Error()})
+	} else if len(urls) == 0 {
+		results = append(results, Result{Name: "sequencer", Detail: "no submission endpoint configured"})
+	}
+	for _, endpoint := range urls {
+		results = append(results, check(ctx, "sequencer "+hostOf(endpoint), func(ctx context.Context) (string, error) {
+			return checkSequencer(ctx, endpoint, chainID)
+		}))
+	}
+
+	results = append(results, check(ctx, "sniper contract", func(ctx context.Context) (string, error) {
+		return checkContract(ctx, client, cfg)
+	}))
+
+	// HTTP errors quote the full URL, API key included
+	for i := range results {
+		for _, endpoint := range append(urls, cfg.BaseRPCURL) {
+			if endpoint != "" {
+				results[i].Detail = strings.ReplaceAll(results[i].Detail, endpoint, hostOf(endpoint))
+			}
+		}
+	}
+	return results
+}
+
+// Passed reports whether every check passed
+func Passed(results []Result) bool {
+	for _, result := range results {
+		if !result.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// check runs fn under checkTimeout and times it
+func check(ctx context.Context, name string, fn func(context.Context) (string, error)) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := fn(ctx)
+	result := Result{Name: name, OK: err == nil, Detail: detail, Latency: time.Since(start)}
+	if err != nil {
+		result.Detail = err.Error()
+	}
+	return result
+}
+
+func checkDatabase(ctx context.Context, databaseURL string) (string, error) {
+	database, dialect, err := db.Open(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.PingContext(ctx); err != nil {
+		return "", fmt.Errorf("failed to ping database: %v", err)
+	}
+	return dialect.Name() + " ping ok", nil
+}
+
+func checkNode(ctx context.Context, client *ethclient.Client) (*big.Int, string, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get chain ID: %v", err)
+	}
+	block, err := client.BlockNumber(ctx)
+	if err != nil {
+		return chainID, "", fmt.Errorf("failed to get block number: %v", err)
+	}
+	return chainID, fmt.Sprintf("chain %s, block %d", chainID, block), nil
+}
+
+// checkSequencer asks a submission endpoint for its chain ID, which reaches
+// the endpoint without sending anything
+func checkSequencer(ctx context.Context, endpoint string, chainID *big.Int) (string, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	var result hexutil.Big
+	if err := client.CallContext(ctx, &result, "eth_chainId"); err != nil {
+		return "", fmt.Errorf("eth_chainId failed: %v", err)
+	}
+	if chainID != nil && result.ToInt().Cmp(chainID) != 0 {
+		return "", fmt.Errorf("chain %s, but the node is on chain %s", result.ToInt(), chainID)
+	}
+	return fmt.Sprintf("chain %s", result.ToInt()), nil
+}
+
+// checkContract checks that the sniper contract is deployed and that its code
+// dispatches the snipeWithBribe selector of the configured ABI
+func checkContract(ctx context.Context, client *ethclient.Client, cfg *config.Config) (string, error) {
+	abiJSON, err := cfg.SniperABIJSON()
+	if err != nil {
+		return "", err
+	}
+	sniperABI, err := dex.ParseSniperABI(abiJSON)
+	if err != nil {
+		return "", err
+	}
+
+	address := common.HexToAddress(cfg.SniperContract)
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get code: %v", err)
+	}
+	if len(code) == 0 {
+		return "", fmt.Errorf("no code at %s", address.Hex())
+	}
+
+	method := sniperABI.Methods["snipeWithBribe"]
+	if !bytes.Contains(code, method.ID) {
+		return "", fmt.Errorf("code at %s has no %s (selector %s)", address.Hex(), method.Sig, hexutil.Encode(method.ID))
+	}
+	return fmt.Sprintf("%d bytes at %s, %s found", len(code), address.Hex(), method.Sig), nil
+}
+
+// hostOf names an endpoint by its host; providers put API keys in the path
+func hostOf(endpoint string) string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return "(invalid URL)"
+	}
+	return parsed.Host
+}
@@ -0,0 +1,46 @@
+package validation
+
+import "math/big"
+
+// SettingsRequest holds the raw user input for /settings. Empty fields are
+// left unchanged.
+type SettingsRequest struct {
+	Slippage string `json:"slippage"` // Percent
+	Tip      string `json:"tip"`      // Bribe in ETH
+	MaxBump  string `json:"max_bump"` // ETH per snipe for outbidding competitors
+}
+
+// Settings are validated user settings
+type Settings struct {
+	Slippage float64  // Percent; 0 when not given
+	Tip      *big.Int // wei; nil when not given
+	MaxBump  *big.Int // wei; nil when not given
+}
+
+// ValidateSettings validates a settings request, returning every invalid field
+func ValidateSettings(req SettingsRequest) (*Settings, Errors) {
+	var errs Errors
+	settings := &Settings{}
+
+	var err *FieldError
+	if req.Slippage != "" {
+		if settings.Slippage, err = parseSlippage(req.Slippage); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if req.Tip != "" {
+		if settings.Tip, err = parsePositiveEther(FieldTip, req.Tip); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if req.MaxBump != "" {
+		if settings.MaxBump, err = parsePositiveEther(FieldMaxBump, req.MaxBump); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return settings, nil
+}
@@ -0,0 +1,44 @@
+package validation
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SimulationRequest holds the raw user input for /simulate
+type SimulationRequest struct {
+	TokenAddress string
+	Amount       string // ETH, e.g. "0.1"
+}
+
+// Simulation is a validated buy to simulate
+type Simulation struct {
+	TokenAddress common.Address
+	Amount       *big.Int // Wei
+}
+
+// ValidateSimulation validates a buy to simulate. It returns every invalid
+// field rather than stopping at the first one.
+func ValidateSimulation(req SimulationRequest) (*Simulation, Errors) {
+	var errs Errors
+	simulation := &Simulation{}
+
+	if common.IsHexAddress(req.TokenAddress) && strings.HasPrefix(req.TokenAddress, "0x") {
+		simulation.TokenAddress = common.HexToAddress(req.TokenAddress)
+	} else {
+		errs = append(errs, FieldError{FieldTokenAddress, "must be a valid Ethereum address (0x...)"})
+	}
+
+	amount, fieldErr := parsePositiveEther(FieldAmount, req.Amount)
+	if fieldErr != nil {
+		errs = append(errs, *fieldErr)
+	}
+	simulation.Amount = amount
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return simulation, nil
+}
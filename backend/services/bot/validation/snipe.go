@@ -0,0 +1,211 @@
+package validation
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"sniper-bot/pkg/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Snipe request fields, used as FieldError.Field
+const (
+	FieldTokenAddress = "token_address"
+	FieldAmount       = "amount"
+	FieldBribeAmount  = "bribe_amount"
+	FieldSlippage     = "slippage"
+	FieldTakeProfit   = "take_profit_x"
+	FieldStopLoss     = "stop_loss_pct"
+	FieldRecipient    = "recipient"
+	FieldTip          = "tip"
+	FieldMaxBump      = "max_bump"
+	FieldBalance      = "balance"
+	FieldUserID       = "user_id"
+)
+
+// MaxPoolPercent is the largest share of the pool's ETH liquidity a single
+// snipe may buy with; beyond this the price impact makes the buy pointless
+const MaxPoolPercent = 50
+
+// fieldLabels are the user-facing names of the snipe request fields
+var fieldLabels = map[string]string{
+	FieldTokenAddress: "token address",
+	FieldAmount:       "amount",
+	FieldBribeAmount:  "bribe amount",
+	FieldSlippage:     "slippage",
+	FieldTakeProfit:   "take-profit",
+	FieldStopLoss:     "stop-loss",
+	FieldRecipient:    "recipient",
+	FieldTip:          "tip",
+	FieldMaxBump:      "max bump",
+	FieldBalance:      "balance",
+	FieldUserID:       "user",
+}
+
+// FieldError describes why a single field of a request is invalid
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// Message renders the error for a user, e.g. "Invalid bribe amount: must be greater than 0"
+func (e FieldError) Message() string {
+	label, ok := fieldLabels[e.Field]
+	if !ok {
+		label = e.Field
+	}
+	return fmt.Sprintf("Invalid %s: %s", label, e.Reason)
+}
+
+// Errors is a list of field errors returned by a validation
+type Errors []FieldError
+
+// Error implements the error interface
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// SnipeRequest holds the raw user input for a snipe
+type SnipeRequest struct {
+	TokenAddress string `json:"tokenAddress"`
+	Amount       string `json:"amount"`      // ETH, or percent of pool with a "%" suffix (e.g. "2.5%")
+	BribeAmount  string `json:"bribeAmount"` // ETH
+	Slippage     string `json:"slippage"`    // Percent, optional
+	TakeProfit   string `json:"takeProfitX"` // Multiple of the swap amount, optional
+	StopLoss     string `json:"stopLossPct"` // Percent of the swap amount, optional
+	Recipient    string `json:"recipient"`   // Address to forward the tokens to, optional
+}
+
+// Snipe is a validated snipe request
+type Snipe struct {
+	TokenAddress common.Address
+	Amount       *big.Int        // wei; nil when PoolPercent is set
+	PoolPercent  string          // Percent of the pool's ETH liquidity, resolved at LP_ADD time
+	BribeAmount  *big.Int        // wei
+	Slippage     float64         // Percent; 0 when not given
+	TakeProfitX  float64         // Sell at this multiple of the swap amount; 0 when not given
+	StopLossPct  float64         // Sell after losing this percent of the swap amount; 0 when not given
+	Recipient    *common.Address // Forward the bought tokens here; nil keeps them in the wallet
+}
+
+// ValidateSnipe validates a snipe request. When balance is non-nil it must
+// cover the swap amount plus the bribe (only the bribe for a pool-percent
+// amount, which isn't known until launch). It returns every invalid field rather
+// than stopping at the first one.
+func ValidateSnipe(req SnipeRequest, balance *big.Int) (*Snipe, Errors) {
+	var errs Errors
+	snipe := &Snipe{}
+
+	if !common.IsHexAddress(req.TokenAddress) || !strings.HasPrefix(req.TokenAddress, "0x") {
+		errs = append(errs, FieldError{FieldTokenAddress, "must be a valid Ethereum address (0x...)"})
+	} else {
+		snipe.TokenAddress = common.HexToAddress(req.TokenAddress)
+	}
+
+	var err *FieldError
+	if percent, ok := strings.CutSuffix(req.Amount, "%"); ok {
+		value, parseErr := strconv.ParseFloat(percent, 64)
+		if parseErr != nil || !(value > 0 && value <= MaxPoolPercent) {
+			errs = append(errs, FieldError{FieldAmount, fmt.Sprintf("pool percentage must be between 0 and %d%%", MaxPoolPercent)})
+		} else {
+			snipe.PoolPercent = percent
+		}
+	} else if snipe.Amount, err = parsePositiveEther(FieldAmount, req.Amount); err != nil {
+		errs = append(errs, *err)
+	}
+	if snipe.BribeAmount, err = parsePositiveEther(FieldBribeAmount, req.BribeAmount); err != nil {
+		errs = append(errs, *err)
+	}
+
+	if req.Slippage != "" {
+		if snipe.Slippage, err = parseSlippage(req.Slippage); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	if req.TakeProfit != "" {
+		takeProfit, parseErr := strconv.ParseFloat(strings.TrimSuffix(req.TakeProfit, "x"), 64)
+		if parseErr != nil || !(takeProfit > 1) {
+			errs = append(errs, FieldError{FieldTakeProfit, "must be a multiple greater than 1 (e.g. 2x)"})
+		} else {
+			snipe.TakeProfitX = takeProfit
+		}
+	}
+
+	if req.StopLoss != "" {
+		stopLoss, parseErr := strconv.ParseFloat(strings.TrimSuffix(req.StopLoss, "%"), 64)
+		if parseErr != nil || !(stopLoss > 0 && stopLoss < 100) {
+			errs = append(errs, FieldError{FieldStopLoss, "must be a percentage between 0 and 100"})
+		} else {
+			snipe.StopLossPct = stopLoss
+		}
+	}
+
+	// Forwarded tokens leave the wallet, so there is nothing left to auto-sell
+	if req.Recipient != "" {
+		recipient := common.HexToAddress(req.Recipient)
+		switch {
+		case !common.IsHexAddress(req.Recipient) || !strings.HasPrefix(req.Recipient, "0x"):
+			errs = append(errs, FieldError{FieldRecipient, "must be a valid Ethereum address (0x...)"})
+		case recipient == (common.Address{}):
+			errs = append(errs, FieldError{FieldRecipient, "must not be the zero address"})
+		case req.TakeProfit != "" || req.StopLoss != "":
+			errs = append(errs, FieldError{FieldRecipient, "can't be combined with a take-profit or stop-loss"})
+		default:
+			snipe.Recipient = &recipient
+		}
+	}
+
+	if balance != nil && snipe.BribeAmount != nil && (snipe.Amount != nil || snipe.PoolPercent != "") {
+		required := new(big.Int).Set(snipe.BribeAmount)
+		if snipe.Amount != nil {
+			required.Add(required, snipe.Amount)
+		}
+		if balance.Cmp(required) < 0 {
+			errs = append(errs, FieldError{FieldBalance, fmt.Sprintf("insufficient funds: need %s, have %s",
+				eth.FormatEther(required), eth.FormatEther(balance))})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return snipe, nil
+}
+
+// parseSlippage parses a slippage percentage, with or without a "%" suffix
+func parseSlippage(value string) (float64, *FieldError) {
+	slippage, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || !(slippage > 0 && slippage <= 100) {
+		return 0, &FieldError{FieldSlippage, "must be a percentage between 0 and 100"}
+	}
+	return slippage, nil
+}
+
+// parsePositiveEther parses an ETH amount that must be greater than zero
+func parsePositiveEther(field, amount string) (*big.Int, *FieldError) {
+	if amount == "" {
+		return nil, &FieldError{field, "is required"}
+	}
+
+	wei, err := eth.ParseEther(amount)
+	if err != nil {
+		return nil, &FieldError{field, "must be a number (e.g. 0.1)"}
+	}
+	if wei.Sign() <= 0 {
+		return nil, &FieldError{field, "must be greater than 0"}
+	}
+	return wei, nil
+}
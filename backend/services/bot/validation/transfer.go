@@ -0,0 +1,98 @@
+package validation
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"sniper-bot/pkg/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TransferAll is the /send amount that moves the wallet's whole balance
+const TransferAll = "all"
+
+// TransferRequest holds the raw user input for /send
+type TransferRequest struct {
+	TokenAddress string
+	Amount       string // Whole tokens, e.g. "1500.5", or TransferAll
+	Recipient    string
+}
+
+// Transfer is a validated token transfer
+type Transfer struct {
+	TokenAddress common.Address
+	Amount       *big.Int // Token units
+	Recipient    common.Address
+}
+
+// ValidateTransfer validates a token transfer from wallet, whose balance of
+// the token is balance in units of decimals. The amount is only checked once
+// the token address is valid. It returns every invalid field rather than
+// stopping at the first one.
+func ValidateTransfer(req TransferRequest, wallet common.Address, decimals uint8, balance *big.Int) (*Transfer, Errors) {
+	var errs Errors
+	transfer := &Transfer{}
+
+	tokenValid := common.IsHexAddress(req.TokenAddress) && strings.HasPrefix(req.TokenAddress, "0x")
+	if !tokenValid {
+		errs = append(errs, FieldError{FieldTokenAddress, "must be a valid Ethereum address (0x...)"})
+	} else {
+		transfer.TokenAddress = common.HexToAddress(req.TokenAddress)
+	}
+
+	recipient := common.HexToAddress(req.Recipient)
+	switch {
+	case !common.IsHexAddress(req.Recipient) || !strings.HasPrefix(req.Recipient, "0x"):
+		errs = append(errs, FieldError{FieldRecipient, "must be a valid Ethereum address (0x...)"})
+	case recipient == (common.Address{}):
+		errs = append(errs, FieldError{FieldRecipient, "must not be the zero address"})
+	case recipient == wallet:
+		errs = append(errs, FieldError{FieldRecipient, "is your own wallet"})
+	default:
+		transfer.Recipient = recipient
+	}
+
+	if tokenValid {
+		amount, err := parseTokenAmount(req.Amount, decimals, balance)
+		if err != nil {
+			errs = append(errs, *err)
+		}
+		transfer.Amount = amount
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return transfer, nil
+}
+
+// parseTokenAmount parses a whole-token amount into units of decimals,
+// rejecting more precision than the token has, and checks it against balance
+func parseTokenAmount(amount string, decimals uint8, balance *big.Int) (*big.Int, *FieldError) {
+	if amount == TransferAll {
+		if balance.Sign() == 0 {
+			return nil, &FieldError{FieldAmount, "you don't hold any of this token"}
+		}
+		return new(big.Int).Set(balance), nil
+	}
+
+	units, err := eth.ParseUnits(amount, int(decimals))
+	if err != nil {
+		return nil, &FieldError{FieldAmount, fmt.Sprintf("must be a number of tokens (e.g. 1000) or %q", TransferAll)}
+	}
+	// ParseUnits truncates, so an amount that doesn't survive the round trip
+	// had more decimals than the token
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	if exact, _ := new(big.Rat).SetString(amount); exact.Cmp(new(big.Rat).SetFrac(units, scale)) != 0 {
+		return nil, &FieldError{FieldAmount, fmt.Sprintf("this token has %d decimals", decimals)}
+	}
+	if units.Sign() <= 0 {
+		return nil, &FieldError{FieldAmount, "must be greater than 0"}
+	}
+	if units.Cmp(balance) > 0 {
+		return nil, &FieldError{FieldBalance, fmt.Sprintf("insufficient tokens: have %s", eth.FormatTokens(balance, decimals))}
+	}
+	return units, nil
+}
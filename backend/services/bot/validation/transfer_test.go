@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestValidateTransferSixDecimals(t *testing.T) {
+	const token = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913" // USDC on Base
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	recipient := "0x00000000000000000000000000000000000000bb"
+	balance := big.NewInt(2_500_000) // 2.5 tokens
+
+	tests := []struct {
+		name   string
+		amount string
+		want   int64  // Units transferred, when valid
+		field  string // Field of the expected error
+		reason string
+	}{
+		{"whole", "2", 2_000_000, "", ""},
+		{"fraction", "1.25", 1_250_000, "", ""},
+		{"smallest unit", "0.000001", 1, "", ""},
+		{"all", TransferAll, 2_500_000, "", ""},
+		{"too precise", "0.0000001", 0, FieldAmount, "this token has 6 decimals"},
+		{"over balance", "2.500001", 0, FieldBalance, "insufficient tokens: have 2.5"},
+		{"zero", "0", 0, FieldAmount, "must be greater than 0"},
+	}
+
+	for _, tt := range tests {
+		transfer, errs := ValidateTransfer(TransferRequest{TokenAddress: token, Amount: tt.amount, Recipient: recipient}, wallet, 6, balance)
+		if tt.field == "" {
+			if len(errs) > 0 {
+				t.Errorf("%s: unexpected errors %v", tt.name, errs)
+				continue
+			}
+			if transfer.Amount.Int64() != tt.want {
+				t.Errorf("%s: amount %s, want %d", tt.name, transfer.Amount, tt.want)
+			}
+			continue
+		}
+
+		if len(errs) != 1 || errs[0].Field != tt.field || errs[0].Reason != tt.reason {
+			t.Errorf("%s: errors %v, want %s: %s", tt.name, errs, tt.field, tt.reason)
+		}
+	}
+}
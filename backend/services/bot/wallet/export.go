@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/google/uuid"
+)
+
+// MinExportPassphraseLength is the shortest passphrase accepted for a wallet export
+const MinExportPassphraseLength = 12
+
+// ExportedWallet is one entry of a wallet export: the owner and a standard
+// Web3 Secret Storage (V3) keystore that any Ethereum wallet can import
+type ExportedWallet struct {
+	TelegramUserID string          `json:"telegramUserId"`
+	Keystore       json.RawMessage `json:"keystore"`
+}
+
+// ExportKeystores writes every wallet to w as a JSON array of ExportedWallet,
+// each key encrypted under passphrase. Wallets are read and written one at a
+// time, so the export is never held in memory. Returns the number of wallets
+// written.
+//
+// Keys use the light scrypt parameters: with the standard ones an export of
+// thousands of wallets would take hours. Use a long passphrase.
+func (m *Manager) ExportKeystores(w io.Writer, passphrase string) (int, error) {
+	if len(passphrase) < MinExportPassphraseLength {
+		return 0, fmt.Errorf("passphrase must be at least %d characters", MinExportPassphraseLength)
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := m.db.ForEachWallet(func(dbWallet *db.Wallet) error {
+		wallet, err := m.walletFromDB(dbWallet)
+		if err != nil {
+			return fmt.Errorf("wallet of user %s: %v", dbWallet.TelegramUserID, err)
+		}
+
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return err
+		}
+
+		keyJSON, err := keystore.EncryptKey(&keystore.Key{
+			Id:         id,
+			Address:    wallet.Address,
+			PrivateKey: wallet.PrivateKey,
+		}, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt wallet of user %s: %v", dbWallet.TelegramUserID, err)
+		}
+
+		entry, err := json.Marshal(ExportedWallet{TelegramUserID: dbWallet.TelegramUserID, Keystore: keyJSON})
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(entry); err != nil {
+			return err
+		}
+
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
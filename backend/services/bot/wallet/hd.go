@@ -0,0 +1,107 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// hdKey is an extended private key (BIP32)
+type hdKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+// seedFromMnemonic validates a BIP39 mnemonic and returns its seed
+func seedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid BIP39 mnemonic")
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// newMasterKey derives the BIP32 master key from a seed
+func newMasterKey(seed []byte) (*hdKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	if !validScalar(sum[:32]) {
+		return nil, errors.New("invalid master key")
+	}
+
+	return &hdKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// child derives the child key at the given index (hardened if index >= 2^31)
+func (k *hdKey) child(index uint32) (*hdKey, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0x00}, k.key...)
+	} else {
+		privateKey, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = crypto.CompressPubkey(&privateKey.PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	if !validScalar(sum[:32]) {
+		return nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+
+	// child key = (IL + parent key) mod n
+	n := crypto.S256().Params().N
+	childKey := new(big.Int).SetBytes(sum[:32])
+	childKey.Add(childKey, new(big.Int).SetBytes(k.key))
+	childKey.Mod(childKey, n)
+	if childKey.Sign() == 0 {
+		return nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+
+	return &hdKey{key: childKey.FillBytes(make([]byte, 32)), chainCode: sum[32:]}, nil
+}
+
+// derivePrivateKey walks the derivation path from the seed and returns the leaf key
+func derivePrivateKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range path {
+		key, err = key.child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return crypto.ToECDSA(key.key)
+}
+
+// walletPath returns the BIP44 path m/44'/60'/0'/0/index
+func walletPath(index uint32) accounts.DerivationPath {
+	path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+	copy(path, accounts.DefaultBaseDerivationPath)
+	path[len(path)-1] = index
+	return path
+}
+
+// validScalar checks that b is a valid secp256k1 private key scalar
+func validScalar(b []byte) bool {
+	v := new(big.Int).SetBytes(b)
+	return v.Sign() > 0 && v.Cmp(crypto.S256().Params().N) < 0
+}
@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// hardened is the offset of hardened child indexes
+const hardened = 0x80000000
+
+// bip32Vectors are test vectors 1 to 3 of BIP32: each seed with the extended
+// private keys of a chain of children
+var bip32Vectors = []struct {
+	seed  string
+	chain []struct {
+		path []uint32
+		xprv string
+	}
+}{
+	{
+		seed: "000102030405060708090a0b0c0d0e0f",
+		chain: []struct {
+			path []uint32
+			xprv string
+		}{
+			{nil, "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"},
+			{[]uint32{hardened}, "xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7"},
+			{[]uint32{hardened, 1}, "xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs"},
+			{[]uint32{hardened, 1, hardened + 2}, "xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM"},
+			{[]uint32{hardened, 1, hardened + 2, 2}, "xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334"},
+			{[]uint32{hardened, 1, hardened + 2, 2, 1000000000}, "xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76"},
+		},
+	},
+	{
+		seed: "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+		chain: []struct {
+			path []uint32
+			xprv string
+		}{
+			{nil, "xprv9s21ZrQH143K31xYSDQpPDxsXRTUcvj2iNHm5NUtrGiGG5e2DtALGdso3pGz6ssrdK4PFmM8NSpSBHNqPqm55Qn3LqFtT2emdEXVYsCzC2U"},
+			{[]uint32{0}, "xprv9vHkqa6EV4sPZHYqZznhT2NPtPCjKuDKGY38FBWLvgaDx45zo9WQRUT3dKYnjwih2yJD9mkrocEZXo1ex8G81dwSM1fwqWpWkeS3v86pgKt"},
+			{[]uint32{0, hardened + 2147483647}, "xprv9wSp6B7kry3Vj9m1zSnLvN3xH8RdsPP1Mh7fAaR7aRLcQMKTR2vidYEeEg2mUCTAwCd6vnxVrcjfy2kRgVsFawNzmjuHc2YmYRmagcEPdU9"},
+			{[]uint32{0, hardened + 2147483647, 1}, "xprv9zFnWC6h2cLgpmSA46vutJzBcfJ8yaJGg8cX1e5StJh45BBciYTRXSd25UEPVuesF9yog62tGAQtHjXajPPdbRCHuWS6T8XA2ECKADdw4Ef"},
+			{[]uint32{0, hardened + 2147483647, 1, hardened + 2147483646}, "xprvA1RpRA33e1JQ7ifknakTFpgNXPmW2YvmhqLQYMmrj4xJXXWYpDPS3xz7iAxn8L39njGVyuoseXzU6rcxFLJ8HFsTjSyQbLYnMpCqE2VbFWc"},
+			{[]uint32{0, hardened + 2147483647, 1, hardened + 2147483646, 2}, "xprvA2nrNbFZABcdryreWet9Ea4LvTJcGsqrMzxHx98MMrotbir7yrKCEXw7nadnHM8Dq38EGfSh6dqA9QWTyefMLEcBYJUuekgW4BYPJcr9E7j"},
+		},
+	},
+	{
+		// Leading zeros of a child key must be kept
+		seed: "4b381541583be4423346c643850da4b320e46a87ae3d2a4e6da11eba819cd4acba45d239319ac14f863b8d5ab5a0d0c64d2e8a1e7d1457df2e5a3c51c73235be",
+		chain: []struct {
+			path []uint32
+			xprv string
+		}{
+			{nil, "xprv9s21ZrQH143K25QhxbucbDDuQ4naNntJRi4KUfWT7xo4EKsHt2QJDu7KXp1A3u7Bi1j8ph3EGsZ9Xvz9dGuVrtHHs7pXeTzjuxBrCmmhgC6"},
+			{[]uint32{hardened}, "xprv9uPDJpEQgRQfDcW7BkF7eTya6RPxXeJCqCJGHuCJ4GiRVLzkTXBAJMu2qaMWPrS7AANYqdq6vcBcBUdJCVVFceUvJFjaPdGZ2y9WACViL4L"},
+		},
+	},
+}
+
+func TestBIP32Vectors(t *testing.T) {
+	for _, vector := range bip32Vectors {
+		seed, err := hex.DecodeString(vector.seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range vector.chain {
+			chainCode, privateKey := decodeXprv(t, want.xprv)
+
+			key, err := newMasterKey(seed)
+			if err != nil {
+				t.Fatalf("seed %s: %v", vector.seed, err)
+			}
+			for _, index := range want.path {
+				if key, err = key.child(index); err != nil {
+					t.Fatalf("seed %s path %v: %v", vector.seed, want.path, err)
+				}
+			}
+
+			if !bytes.Equal(key.chainCode, chainCode) {
+				t.Errorf("seed %s path %v: chain code %x, want %x", vector.seed, want.path, key.chainCode, chainCode)
+			}
+			if !bytes.Equal(key.key, privateKey) {
+				t.Errorf("seed %s path %v: key %x, want %x", vector.seed, want.path, key.key, privateKey)
+			}
+		}
+	}
+}
+
+func TestDerivePrivateKeyMatchesDefaultPath(t *testing.T) {
+	// The "abandon ... about" mnemonic's first Ethereum account, as wallets
+	// such as MetaMask derive it
+	seed, err := seedFromMnemonic(strings.Repeat("abandon ", 11)+"about", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := derivePrivateKey(seed, walletPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+	if got := crypto.PubkeyToAddress(privateKey.PublicKey).Hex(); got != want {
+		t.Errorf("m/44'/60'/0'/0/0 address %s, want %s", got, want)
+	}
+	if path := walletPath(7).String(); path != "m/44'/60'/0'/0/7" {
+		t.Errorf("walletPath(7) = %s", path)
+	}
+	if accounts.DefaultBaseDerivationPath.String() != "m/44'/60'/0'/0/0" {
+		t.Errorf("base derivation path changed to %s", accounts.DefaultBaseDerivationPath)
+	}
+}
+
+// decodeXprv returns the chain code and private key of a base58check
+// encoded extended private key
+func decodeXprv(t *testing.T, xprv string) (chainCode, privateKey []byte) {
+	t.Helper()
+
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	n := new(big.Int)
+	for _, c := range xprv {
+		digit := strings.IndexRune(alphabet, c)
+		if digit < 0 {
+			t.Fatalf("invalid base58 in %s", xprv)
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	// version 4, depth 1, fingerprint 4, child number 4, chain code 32,
+	// 0x00 and key 32, checksum 4
+	raw := n.FillBytes(make([]byte, 82))
+	payload, checksum := raw[:78], raw[78:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		t.Fatalf("bad checksum in %s", xprv)
+	}
+	return payload[13:45], payload[46:78]
+}
@@ -5,15 +5,22 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sniper-bot/pkg/config"
 	"sniper-bot/services/bot/db"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// maxIndexAttempts bounds how often CreateWallet takes a new derivation index
+// after losing one to a wallet created at the same time
+const maxIndexAttempts = 5
+
 // Manager handles the creation and management of sniper wallets
 type Manager struct {
-	db *db.DB
+	db   *db.DB
+	seed []byte // BIP39 seed; nil when HD derivation is disabled
 }
 
 // Wallet represents a sniper's wallet
@@ -24,29 +31,113 @@ type Wallet struct {
 }
 
 // NewManager creates a new wallet manager
-func NewManager(database *db.DB) *Manager {
-	return &Manager{
+func NewManager(database *db.DB, cfg *config.Config) (*Manager, error) {
+	manager := &Manager{
 		db: database,
 	}
+
+	if cfg.HDWalletMnemonic != "" {
+		seed, err := seedFromMnemonic(cfg.HDWalletMnemonic, cfg.HDWalletPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load HD wallet seed: %v", err)
+		}
+		manager.seed = seed
+	}
+
+	return manager, nil
+}
+
+// DeriveWallet derives the wallet at m/44'/60'/0'/0/index from the HD seed
+func (m *Manager) DeriveWallet(index uint32) (*Wallet, error) {
+	if m.seed == nil {
+		return nil, errors.New("HD wallet seed not configured")
+	}
+
+	privateKey, err := derivePrivateKey(m.seed, walletPath(index))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive wallet %d: %v", index, err)
+	}
+
+	return &Wallet{
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}, nil
 }
 
-// CreateWallet creates a new wallet for a sniper
+// CreateWallet creates a new wallet for a sniper. When an HD seed is configured
+// the wallet is derived from it and only the derivation index is stored;
+// otherwise a random key is generated.
 func (m *Manager) CreateWallet(userID string) (*Wallet, error) {
-	// Check if user already has a wallet
-	existingWallet, err := m.db.GetWalletByTelegramUserID(userID)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to check existing wallet: %v", err)
+	if err := m.checkNoWallet(userID); err != nil {
+		return nil, err
 	}
-	if existingWallet != nil {
-		return nil, errors.New("user already has a wallet")
+
+	if m.seed == nil {
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		return m.storeKeyWallet(userID, privateKey)
 	}
 
-	// Generate new private key
-	privateKey, err := crypto.GenerateKey()
-	if err != nil {
+	// Wallets created at the same time can be handed the same index; all but
+	// the first to store it get a unique key error and take the next one
+	for attempt := 1; ; attempt++ {
+		index, err := m.db.NextDerivationIndex()
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate derivation index: %v", err)
+		}
+
+		wallet, err := m.DeriveWallet(index)
+		if err != nil {
+			return nil, err
+		}
+		wallet.UserID = userID
+
+		dbWallet := &db.Wallet{
+			TelegramUserID:  userID,
+			WalletAddress:   wallet.Address.Hex(),
+			DerivationIndex: sql.NullInt64{Int64: int64(index), Valid: true},
+		}
+
+		err = m.db.CreateWallet(dbWallet)
+		if err == nil {
+			return wallet, nil
+		}
+		if !m.db.IsDuplicateDerivationIndex(err) || attempt >= maxIndexAttempts {
+			return nil, fmt.Errorf("failed to store wallet in database: %v", err)
+		}
+	}
+}
+
+// ImportWallet stores an existing private key as the user's wallet
+func (m *Manager) ImportWallet(userID string, privateKeyHex string) (*Wallet, error) {
+	if err := m.checkNoWallet(userID); err != nil {
 		return nil, err
 	}
 
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+
+	return m.storeKeyWallet(userID, privateKey)
+}
+
+// checkNoWallet returns an error if the user already has a wallet
+func (m *Manager) checkNoWallet(userID string) error {
+	existingWallet, err := m.db.GetWalletByTelegramUserID(userID)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check existing wallet: %v", err)
+	}
+	if existingWallet != nil {
+		return errors.New("user already has a wallet")
+	}
+	return nil
+}
+
+// storeKeyWallet stores a wallet backed by a raw private key
+func (m *Manager) storeKeyWallet(userID string, privateKey *ecdsa.PrivateKey) (*Wallet, error) {
 	// Get the address
 	address := crypto.PubkeyToAddress(privateKey.PublicKey)
 
@@ -80,6 +171,26 @@ func (m *Manager) GetWallet(userID string) (*Wallet, error) {
 		return nil, fmt.Errorf("failed to get wallet from database: %v", err)
 	}
 
+	return m.walletFromDB(dbWallet)
+}
+
+// walletFromDB loads the private key of a stored wallet
+func (m *Manager) walletFromDB(dbWallet *db.Wallet) (*Wallet, error) {
+	userID := dbWallet.TelegramUserID
+
+	// Derived wallets store only their index
+	if dbWallet.DerivationIndex.Valid {
+		wallet, err := m.DeriveWallet(uint32(dbWallet.DerivationIndex.Int64))
+		if err != nil {
+			return nil, err
+		}
+		if wallet.Address != common.HexToAddress(dbWallet.WalletAddress) {
+			return nil, fmt.Errorf("derived address mismatch for wallet %d", dbWallet.DerivationIndex.Int64)
+		}
+		wallet.UserID = userID
+		return wallet, nil
+	}
+
 	// Parse private key from hex string
 	privateKey, err := crypto.HexToECDSA(dbWallet.PrivateKey)
 	if err != nil {
@@ -19,12 +19,14 @@ func main() {
 	}
 
 	cfg := config.Load()
+	log.Printf("🚩 Features: %s", cfg.Features)
 
 	// Initialize database
 	database, err := db.New(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	database.SetRetryPolicy(cfg.DBRetryAttempts, cfg.DBRetryBackoff)
 	defer database.Close()
 
 	// Initialize RPC service
@@ -0,0 +1,166 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cacheableMethods are the read methods whose results may be cached: they
+// take no parameters and their result depends only on time
+var cacheableMethods = map[string]bool{
+	"eth_chainId":              true,
+	"net_version":              true,
+	"eth_gasPrice":             true,
+	"eth_maxPriorityFeePerGas": true,
+	"eth_blockNumber":          true,
+}
+
+// cacheEntry is a cached upstream result
+type cacheEntry struct {
+	result    json.RawMessage
+	expiresAt time.Time
+}
+
+// CacheStats counts the cache lookups of one method
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// rpcCache holds recent upstream results of cacheable methods
+type rpcCache struct {
+	ttls map[string]time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	stats   map[string]*CacheStats
+}
+
+// newRPCCache creates a cache for the methods with a positive TTL. Methods
+// that aren't safe to cache are ignored with a warning.
+func newRPCCache(ttls map[string]time.Duration) *rpcCache {
+	c := &rpcCache{
+		ttls:    make(map[string]time.Duration),
+		entries: make(map[string]cacheEntry),
+		stats:   make(map[string]*CacheStats),
+	}
+
+	for method, ttl := range ttls {
+		if !cacheableMethods[method] {
+			log.Printf("⚠️ Not caching %s: only parameterless read methods can be cached", method)
+			continue
+		}
+		if ttl > 0 {
+			c.ttls[method] = ttl
+			c.stats[method] = &CacheStats{}
+		}
+	}
+
+	return c
+}
+
+// cacheable reports whether a request's result may be served from the cache
+func (c *rpcCache) cacheable(method string, params json.RawMessage) bool {
+	if _, ok := c.ttls[method]; !ok {
+		return false
+	}
+
+	params = bytes.TrimSpace(params)
+	return len(params) == 0 || bytes.Equal(params, []byte("[]")) || bytes.Equal(params, []byte("null"))
+}
+
+// get returns the cached result of a method, counting the hit or miss
+func (c *rpcCache) get(method string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[method]
+	if !ok || time.Now().After(entry.expiresAt) {
+		c.stats[method].Misses++
+		return nil, false
+	}
+
+	c.stats[method].Hits++
+	return entry.result, true
+}
+
+// set caches the result of a method for its TTL
+func (c *rpcCache) set(method string, result json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[method] = cacheEntry{result: result, expiresAt: time.Now().Add(c.ttls[method])}
+}
+
+// snapshot returns a copy of the per-method hit and miss counts
+func (c *rpcCache) snapshot() map[string]CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make(map[string]CacheStats, len(c.stats))
+	for method, s := range c.stats {
+		stats[method] = *s
+	}
+	return stats
+}
+
+// serveCached answers a cacheable request from the cache, or forwards it to
+// Base and caches a successful result
+func (s *Service) serveCached(w http.ResponseWriter, id interface{}, method string, requestBody []byte) {
+	if result, ok := s.cache.get(method); ok {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      interface{}     `json:"id"`
+			Result  json.RawMessage `json:"result"`
+		}{"2.0", id, result})
+		return
+	}
+
+	resp, err := http.Post(s.config.BaseRPCURL, "application/json", bytes.NewReader(requestBody))
+	if err != nil {
+		log.Printf("Error forwarding to Base: %v", err)
+		writeRPCError(w, id, rpcErrInternal, "Failed to forward request to Base")
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading response from Base: %v", err)
+		writeRPCError(w, id, rpcErrInternal, "Failed to read response from Base")
+		return
+	}
+
+	var upstream struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if resp.StatusCode == http.StatusOK && json.Unmarshal(body, &upstream) == nil &&
+		len(upstream.Result) > 0 && len(upstream.Error) == 0 {
+		s.cache.set(method, upstream.Result)
+	}
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
+
+// handleMetrics serves GET /metrics with the proxy's cache hit counts. It is
+// routed behind requireAuth.
+func (s *Service) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"cache": s.cache.snapshot(),
+	})
+}
@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// sequencerReply is a response of the sequencer to eth_sendRawTransaction
+type sequencerReply struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// rpcErrorMessage returns the message of the JSON-RPC error in the reply, or
+// "" if it carries a result
+func (r *sequencerReply) rpcErrorMessage() string {
+	var resp struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(r.body, &resp) != nil || resp.Error == nil {
+		return ""
+	}
+	return resp.Error.Message
+}
+
+// forwardLaunch forwards a launch transaction the bot service won't submit
+// to the sequencer. Unlike other requests it is retried, up to
+// LaunchForwardAttempts times with backoff, while the sequencer times out or
+// answers with HTTP 429 or 5xx: a launch that doesn't land loses the whole
+// snipe. LaunchForwardDeadline bounds the attempts and backoff together. A
+// retry that finds the transaction "already known" means an earlier attempt
+// got through, and is answered with its hash.
+func (s *Service) forwardLaunch(ctx context.Context, w http.ResponseWriter, id interface{}, requestBody []byte, txHash common.Hash) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.LaunchForwardDeadline)
+	defer cancel()
+
+	client := &http.Client{Timeout: s.config.LaunchForwardTimeout}
+	backoff := s.config.LaunchForwardBackoff
+
+	var err error
+	attempt := 0
+	for attempt < s.config.LaunchForwardAttempts {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+			}
+			backoff *= 2
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("gave up after %s: %v", s.config.LaunchForwardDeadline, ctx.Err())
+			break
+		}
+		attempt++
+
+		var reply *sequencerReply
+		reply, err = postSequencer(ctx, client, s.config.BaseSequencerRPCURL, requestBody)
+		if err != nil {
+			log.Printf("⚠️ Forwarding launch tx %s to the sequencer failed (attempt %d of %d): %v",
+				txHash.Hex(), attempt, s.config.LaunchForwardAttempts, err)
+			continue
+		}
+
+		message := reply.rpcErrorMessage()
+		switch {
+		case message == "":
+			log.Printf("🚀 Launch tx %s reached the sequencer on attempt %d", txHash.Hex(), attempt)
+		case attempt > 1 && strings.Contains(strings.ToLower(message), "already known"):
+			log.Printf("🚀 Launch tx %s reached the sequencer on an earlier attempt", txHash.Hex())
+			writeRPCResult(w, id, txHash.Hex())
+			return
+		default:
+			log.Printf("❌ Sequencer rejected launch tx %s: %s", txHash.Hex(), message)
+		}
+
+		for key, values := range reply.header {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+		w.WriteHeader(reply.status)
+		w.Write(reply.body)
+		return
+	}
+
+	log.Printf("❌ Launch tx %s didn't reach the sequencer after %d attempts: %v", txHash.Hex(), attempt, err)
+	writeRPCError(w, id, rpcErrInternal, "Failed to forward launch transaction to the sequencer")
+}
+
+// postSequencer sends a request to the sequencer and reads its reply. Timeouts,
+// connection failures and HTTP 429 or 5xx answers are returned as errors, to
+// be retried.
+func postSequencer(ctx context.Context, client *http.Client, url string, requestBody []byte) (*sequencerReply, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		reason := string(body)
+		if len(reason) > maxOutboxError {
+			reason = reason[:maxOutboxError]
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, reason)
+	}
+	return &sequencerReply{status: resp.StatusCode, header: resp.Header, body: body}, nil
+}
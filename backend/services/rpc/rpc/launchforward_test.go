@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sniper-bot/pkg/config"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestForwardLaunch(t *testing.T) {
+	txHash := common.HexToHash("0x6e3c5b0d0f5f7b4a8c1e2d3f4a5b6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7")
+	requestBody := []byte(`{"jsonrpc":"2.0","id":9,"method":"eth_sendRawTransaction","params":["0x02"]}`)
+
+	// Sequencer answers, by attempt from 1
+	accepted := func(w http.ResponseWriter, r *http.Request, attempt int32) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":9,"result":"%s"}`, txHash.Hex())
+	}
+	unavailableThenKnown := func(w http.ResponseWriter, r *http.Request, attempt int32) {
+		if attempt == 1 {
+			http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":9,"error":{"code":-32000,"message":"already known"}}`)
+	}
+	// Answers well after the forward stopped waiting
+	slow := func(w http.ResponseWriter, r *http.Request, attempt int32) {
+		time.Sleep(time.Second)
+		accepted(w, r, attempt)
+	}
+	unavailable := func(w http.ResponseWriter, r *http.Request, attempt int32) {
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	}
+	rejected := func(w http.ResponseWriter, r *http.Request, attempt int32) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":9,"error":{"code":-32000,"message":"nonce too low"}}`)
+	}
+
+	tests := []struct {
+		name      string
+		sequencer func(w http.ResponseWriter, r *http.Request, attempt int32)
+		attempts  int
+		timeout   time.Duration
+		backoff   time.Duration
+		deadline  time.Duration
+		calls     int32  // Attempts the sequencer receives; 0 checks only that it got fewer than attempts
+		result    string // Result the wallet gets back, or "" for an error
+		errorCode int
+		elapsed   time.Duration // Longest the forward may take
+	}{
+		{
+			name:      "accepted first time",
+			sequencer: accepted,
+			attempts:  3, timeout: time.Second, backoff: 10 * time.Millisecond, deadline: 5 * time.Second,
+			calls:  1,
+			result: txHash.Hex(),
+		},
+		{
+			name:      "503 then already known",
+			sequencer: unavailableThenKnown,
+			attempts:  3, timeout: time.Second, backoff: 10 * time.Millisecond, deadline: 5 * time.Second,
+			calls:  2,
+			result: txHash.Hex(),
+		},
+		{
+			name:      "rejected",
+			sequencer: rejected,
+			attempts:  3, timeout: time.Second, backoff: 10 * time.Millisecond, deadline: 5 * time.Second,
+			calls:     1,
+			errorCode: -32000,
+		},
+		{
+			name:      "every attempt times out",
+			sequencer: slow,
+			attempts:  3, timeout: 50 * time.Millisecond, backoff: 10 * time.Millisecond, deadline: 5 * time.Second,
+			calls:     3,
+			errorCode: rpcErrInternal,
+			elapsed:   500 * time.Millisecond,
+		},
+		{
+			name:      "deadline before the attempts run out",
+			sequencer: unavailable,
+			attempts:  100, timeout: time.Second, backoff: 20 * time.Millisecond, deadline: 200 * time.Millisecond,
+			errorCode: rpcErrInternal,
+			elapsed:   time.Second,
+		},
+		{
+			name:      "deadline during an attempt",
+			sequencer: slow,
+			attempts:  3, timeout: time.Second, backoff: 10 * time.Millisecond, deadline: 100 * time.Millisecond,
+			calls:     1,
+			errorCode: rpcErrInternal,
+			elapsed:   500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		var calls atomic.Int32
+		sequencer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tt.sequencer(w, r, calls.Add(1))
+		}))
+
+		s := &Service{config: &config.Config{
+			BaseSequencerRPCURL:   sequencer.URL,
+			LaunchForwardAttempts: tt.attempts,
+			LaunchForwardTimeout:  tt.timeout,
+			LaunchForwardBackoff:  tt.backoff,
+			LaunchForwardDeadline: tt.deadline,
+		}}
+
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		s.forwardLaunch(context.Background(), rec, 9, requestBody, txHash)
+		elapsed := time.Since(start)
+		sequencer.Close()
+
+		var resp struct {
+			Result string `json:"result"`
+			Error  *struct {
+				Code int `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Errorf("%s: response %q isn't JSON-RPC: %v", tt.name, rec.Body, err)
+			continue
+		}
+		switch {
+		case tt.result != "" && resp.Result != tt.result:
+			t.Errorf("%s: response %s, want result %s", tt.name, rec.Body, tt.result)
+		case tt.result == "" && (resp.Error == nil || resp.Error.Code != tt.errorCode):
+			t.Errorf("%s: response %s, want error code %d", tt.name, rec.Body, tt.errorCode)
+		}
+
+		if got := calls.Load(); tt.calls != 0 && got != tt.calls {
+			t.Errorf("%s: sequencer got %d attempts, want %d", tt.name, got, tt.calls)
+		} else if tt.calls == 0 && (got < 2 || got >= int32(tt.attempts)) {
+			t.Errorf("%s: sequencer got %d attempts, want a few before the deadline", tt.name, got)
+		}
+		if tt.elapsed > 0 && elapsed > tt.elapsed {
+			t.Errorf("%s: took %s, want at most %s", tt.name, elapsed, tt.elapsed)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// notificationTimeout bounds one delivery attempt of an LP_ADD notification
+const notificationTimeout = 5 * time.Second
+
+// maxOutboxError is the size of the last_error column
+const maxOutboxError = 255
+
+// sendThroughOutbox records an LP_ADD notification in the outbox and delivers
+// it. If delivery fails the notification stays pending and runOutbox retries
+// it until the bot service acknowledges it or it exceeds LP_OUTBOX_MAX_AGE.
+// A notification that can't be recorded is still delivered once.
+func (s *Service) sendThroughOutbox(tokenAddress common.Address, jsonData []byte) error {
+	// Not due for a retry before this attempt has had its chance
+	id, err := s.db.CreateLPNotification(tokenAddress.Hex(), string(jsonData), time.Now().Add(notificationTimeout))
+	if err != nil {
+		log.Printf("⚠️ Failed to record LP_ADD notification for token %s in the outbox: %v", tokenAddress.Hex(), err)
+		return s.postNotification(jsonData)
+	}
+
+	err = s.postNotification(jsonData)
+	s.recordDelivery(id, err)
+	return err
+}
+
+// recordDelivery stores the outcome of a delivery attempt
+func (s *Service) recordDelivery(id int64, deliveryErr error) {
+	if deliveryErr == nil {
+		if err := s.db.MarkLPNotificationDelivered(id); err != nil {
+			log.Printf("⚠️ Failed to mark LP_ADD notification %d delivered: %v", id, err)
+		}
+		return
+	}
+
+	reason := deliveryErr.Error()
+	if len(reason) > maxOutboxError {
+		reason = reason[:maxOutboxError]
+	}
+	if err := s.db.RecordLPNotificationFailure(id, reason, time.Now().Add(s.config.OutboxRetryInterval)); err != nil {
+		log.Printf("⚠️ Failed to record failed delivery of LP_ADD notification %d: %v", id, err)
+	}
+}
+
+// runOutbox retries undelivered LP_ADD notifications each
+// OutboxRetryInterval until stop is closed
+func (s *Service) runOutbox(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.OutboxRetryInterval)
+	defer ticker.Stop()
+
+	log.Printf("📮 Retrying undelivered LP_ADD notifications every %s for up to %s",
+		s.config.OutboxRetryInterval, s.config.OutboxMaxAge)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.retryOutbox()
+		}
+	}
+}
+
+// retryOutbox expires notifications older than OutboxMaxAge and attempts
+// every other pending one that is due
+func (s *Service) retryOutbox() {
+	cutoff := time.Now().Add(-s.config.OutboxMaxAge)
+
+	expired, err := s.db.ExpireLPNotifications(cutoff)
+	if err != nil {
+		log.Printf("⚠️ Failed to expire old LP_ADD notifications: %v", err)
+	} else if expired > 0 {
+		log.Printf("⌛ Gave up on %d LP_ADD notifications older than %s", expired, s.config.OutboxMaxAge)
+	}
+
+	notifications, err := s.db.GetDueLPNotifications(cutoff)
+	if err != nil {
+		log.Printf("⚠️ Failed to load undelivered LP_ADD notifications: %v", err)
+		return
+	}
+
+	for _, n := range notifications {
+		err := s.postNotification([]byte(n.Payload))
+		s.recordDelivery(n.ID, err)
+		if err != nil {
+			log.Printf("❌ Retry %d of LP_ADD notification for token %s failed: %v", n.Attempts, n.TokenAddress, err)
+			continue
+		}
+		log.Printf("📮 Delivered LP_ADD notification for token %s on retry %d", n.TokenAddress, n.Attempts)
+	}
+}
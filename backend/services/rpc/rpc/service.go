@@ -11,9 +11,13 @@ import (
 	"net/http"
 	"os"
 	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
 	"sniper-bot/services/bot/db"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -30,6 +34,13 @@ type Service struct {
 	mu         sync.RWMutex
 	snipeBids  map[string][]*SnipeBid // map[tokenAddress][]*SnipeBid
 	botAPIURL  string
+	cache      *rpcCache      // Short-lived results of parameterless read methods
+	weth       common.Address // Quote token of sniped pairs, the router's WETH
+	stop       chan struct{}
+
+	// Sequence of the last LP_ADD notification, seeded from the clock so it
+	// keeps increasing across restarts
+	notifySeq atomic.Uint64
 }
 
 // SnipeBid represents a sniper's bid for a token
@@ -42,9 +53,14 @@ type SnipeBid struct {
 
 // LPAddNotificationPayload represents the payload sent to bot service
 type LPAddNotificationPayload struct {
-	TokenAddress   string `json:"tokenAddress"`
-	CreatorAddress string `json:"creatorAddress"`
-	TxCallData     string `json:"txCallData"`
+	Trigger          string `json:"trigger"` // Launch transaction type, a config.TriggerStrategy
+	TokenAddress     string `json:"tokenAddress"`
+	CreatorAddress   string `json:"creatorAddress"`   // Bribe recipient, chosen by CREATOR_SOURCE
+	SenderAddress    string `json:"senderAddress"`    // Signer of the LP_ADD transaction
+	RecipientAddress string `json:"recipientAddress"` // `to` argument of addLiquidityETH
+	TxCallData       string `json:"txCallData"`
+	TxHash           string `json:"txHash"`   // Hash of the LP_ADD transaction
+	Sequence         uint64 `json:"sequence"` // Increases with each notification; kept on outbox retries
 }
 
 // Function selectors for Uniswap V2
@@ -68,23 +84,123 @@ func NewService(cfg *config.Config, database *db.DB) (*Service, error) {
 		botAPIURL = "http://localhost:8080" // Default for local development
 	}
 
-	return &Service{
+	weth := common.HexToAddress(config.WETHAddress)
+	if !cfg.RPCMethodAllowed("eth_sendRawTransaction") {
+		log.Printf("⚠️ eth_sendRawTransaction is not allowed, LP_ADD transactions will not be detected")
+	} else if weth, err = checkLaunchContract(client, cfg); err != nil {
+		return nil, err
+	}
+
+	s := &Service{
 		config:     cfg,
 		db:         database,
 		baseClient: client,
 		snipeBids:  make(map[string][]*SnipeBid),
 		botAPIURL:  botAPIURL,
-	}, nil
+		cache:      newRPCCache(cfg.RPCCacheTTLs),
+		weth:       weth,
+		stop:       make(chan struct{}),
+	}
+	s.notifySeq.Store(uint64(time.Now().UnixNano()))
+	return s, nil
 }
 
-// Start starts the RPC service
-func (s *Service) Start() error {
+// checkLaunchContract verifies that the contract launches are detected on,
+// the router or the factory depending on TRIGGER_STRATEGY, is set and has
+// code on the connected chain, and logs exactly what is watched. A wrong
+// address otherwise fails silently: no transaction ever matches it. It
+// returns the quote token of sniped pairs, read from the router's WETH() so a
+// createPair on another chain is matched against that chain's WETH.
+func checkLaunchContract(client *ethclient.Client, cfg *config.Config) (common.Address, error) {
+	name, envVar, address, method := "router", "UNISWAP_V2_ROUTER", cfg.UniswapV2Router, "addLiquidityETH"
+	if cfg.TriggerStrategy == config.TriggerCreatePair {
+		name, envVar, address, method = "factory", "UNISWAP_V2_FACTORY", cfg.UniswapV2Factory, "createPair"
+	}
+
+	if !common.IsHexAddress(address) || common.HexToAddress(address) == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%s must be set to a valid address to detect %s launches, got %q", envVar, cfg.TriggerStrategy, address)
+	}
+	contract := common.HexToAddress(address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The node can be briefly unreachable at startup; only a definite answer
+	// that the contract is missing stops the proxy
+	chain := "unknown"
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to get chain ID, not checking the %s: %v", name, err)
+	} else {
+		chain = chainID.String()
+		code, err := client.CodeAt(ctx, contract, nil)
+		if err != nil {
+			log.Printf("⚠️ Failed to get code of the %s %s: %v", name, contract.Hex(), err)
+		} else if len(code) == 0 {
+			return common.Address{}, fmt.Errorf("%s %s has no code on chain %s, check %s", name, contract.Hex(), chainID, envVar)
+		}
+	}
+
+	weth := routerWETH(ctx, client, cfg)
+	log.Printf("👀 Detecting %s launches: %s calls to the %s %s on chain %s (quote token WETH %s)",
+		cfg.TriggerStrategy, method, name, contract.Hex(), chain, weth.Hex())
+	if cfg.TriggerStrategy == config.TriggerCreatePair {
+		log.Printf("⚠️ Snipes go out right behind createPair and revert unless the liquidity is sequenced ahead of them")
+	}
+	return weth, nil
+}
+
+// routerWETH reads the WETH of UNISWAP_V2_ROUTER, the token the snipe
+// contract swaps from. Without a router to ask, Base's WETH is assumed.
+func routerWETH(ctx context.Context, client *ethclient.Client, cfg *config.Config) common.Address {
+	weth := common.HexToAddress(config.WETHAddress)
+	if !common.IsHexAddress(cfg.UniswapV2Router) || common.HexToAddress(cfg.UniswapV2Router) == (common.Address{}) {
+		log.Printf("⚠️ UNISWAP_V2_ROUTER is not set, assuming WETH is %s", weth.Hex())
+		return weth
+	}
+
+	router, err := dex.NewUniswapV2RouterContract(client, common.HexToAddress(cfg.UniswapV2Router))
+	if err == nil {
+		var routerWETH common.Address
+		if routerWETH, err = router.WETH(&bind.CallOpts{Context: ctx}); err == nil {
+			return routerWETH
+		}
+	}
+	log.Printf("⚠️ Failed to read WETH from the router, assuming %s: %v", weth.Hex(), err)
+	return weth
+}
+
+// routes returns the proxy's HTTP handler. Wallets reach the JSON-RPC
+// endpoint without credentials; /metrics needs AUTH_KEY.
+func (s *Service) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleRPC)
+	mux.HandleFunc("/metrics", s.requireAuth(s.handleMetrics))
+	return mux
+}
+
+// requireAuth wraps an endpoint so it only runs for requests carrying AUTH_KEY
+// as a bearer token. Without AUTH_KEY set, the endpoint stays closed.
+func (s *Service) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.AuthKey == "" || r.Header.Get("Authorization") != "Bearer "+s.config.AuthKey {
+			log.Printf("🚨 Unauthorized request to %s from %s", r.URL.Path, r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
 
+// Start starts the RPC service
+func (s *Service) Start() error {
 	s.server = &http.Server{
 		Addr:    ":8545",
-		Handler: mux,
+		Handler: s.routes(),
+	}
+
+	if s.config.OutboxRetryInterval > 0 {
+		go s.runOutbox(s.stop)
 	}
 
 	log.Printf("Starting RPC proxy on :8545")
@@ -93,6 +209,7 @@ func (s *Service) Start() error {
 
 // Stop stops the RPC service
 func (s *Service) Stop() error {
+	close(s.stop)
 	return s.server.Shutdown(context.Background())
 }
 
@@ -105,7 +222,7 @@ func (s *Service) handleRPC(w http.ResponseWriter, r *http.Request) {
 	// Read the request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		writeRPCError(w, nil, rpcErrInvalidRequest, "Failed to read request body")
 		return
 	}
 	defer r.Body.Close()
@@ -117,26 +234,44 @@ func (s *Service) handleRPC(w http.ResponseWriter, r *http.Request) {
 		Params  json.RawMessage `json:"params"`
 	}
 
-	if err := json.Unmarshal(body, &req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	if !json.Valid(body) {
+		writeRPCError(w, nil, rpcErrParse, "Parse error")
+		return
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Method == "" {
+		// Also rejects batches, which the proxy doesn't support
+		writeRPCError(w, req.ID, rpcErrInvalidRequest, "Invalid request")
+		return
+	}
+
+	// Refuse locked-down methods without touching the upstream
+	if !s.config.RPCMethodAllowed(req.Method) {
+		log.Printf("🚫 Refusing RPC method %q from %s", req.Method, r.RemoteAddr)
+		writeRPCError(w, req.ID, rpcErrMethodNotFound, fmt.Sprintf("the method %s is not supported", req.Method))
+		return
+	}
+
+	// Serve repeated reads like eth_chainId from the cache
+	if s.config.Enabled(config.FeatureRPCCache) && s.cache.cacheable(req.Method, req.Params) {
+		s.serveCached(w, req.ID, req.Method, body)
 		return
 	}
 
 	// Forward non-eth_sendRawTransaction requests to Base
 	if req.Method != "eth_sendRawTransaction" {
-		s.forwardToBase(w, body, false)
+		s.forwardToBase(w, req.ID, body, false)
 		return
 	}
 
 	// Handle eth_sendRawTransaction
 	var params []string
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		http.Error(w, "Invalid transaction parameters", http.StatusBadRequest)
+		writeRPCError(w, req.ID, rpcErrInvalidParams, "Invalid transaction parameters")
 		return
 	}
 
 	if len(params) == 0 {
-		http.Error(w, "Missing transaction data", http.StatusBadRequest)
+		writeRPCError(w, req.ID, rpcErrInvalidParams, "Missing transaction data")
 		return
 	}
 
@@ -145,42 +280,97 @@ func (s *Service) handleRPC(w http.ResponseWriter, r *http.Request) {
 	// Decode transaction
 	txData, err := hexutil.Decode(txCallData)
 	if err != nil {
-		http.Error(w, "Invalid transaction hex", http.StatusBadRequest)
+		writeRPCError(w, req.ID, rpcErrInvalidParams, "Invalid transaction hex")
 		return
 	}
 
 	tx := new(types.Transaction)
 	if err := tx.UnmarshalBinary(txData); err != nil {
-		http.Error(w, "Invalid transaction data", http.StatusBadRequest)
+		writeRPCError(w, req.ID, rpcErrInvalidParams, "Invalid transaction data")
 		return
 	}
 
+	// In create_pair mode the pair creation fires the bundle instead of the LP_ADD
+	if s.config.TriggerStrategy == config.TriggerCreatePair && s.isCreatePairTransaction(tx) {
+		if s.handleCreatePair(tx, txCallData) {
+			writeRPCResult(w, req.ID, tx.Hash().Hex())
+			return
+		}
+	}
+
 	// Check if this is an addLiquidityETH transaction
-	if s.isAddLiquidityTransaction(tx) {
+	if s.config.TriggerStrategy == config.TriggerAddLiquidity && s.isAddLiquidityTransaction(tx) {
 		token, err := s.extractTokenFromAddLiquidity(tx)
 		if err != nil {
 			log.Printf("Error extracting token from addLiquidity: %v", err)
 		} else {
+			recipient, recipientErr := s.extractRecipientFromAddLiquidity(tx)
+
 			// Extract the sender (token creator) from the transaction
 			sender, err := s.extractSenderFromTransaction(tx)
+			senderKnown := err == nil
 			if err != nil {
 				log.Printf("Error extracting sender from addLiquidity: %v", err)
-			} else {
+			}
+
+			if recipientErr != nil {
+				log.Printf("⚠️ Error extracting recipient from addLiquidity, using sender: %v", recipientErr)
+				recipient = sender
+			}
+
+			creator, notify := sender, true
+			if s.config.CreatorSource == config.CreatorSourceRecipient {
+				creator = recipient
+			}
+			if !senderKnown {
+				creator, notify = s.fallbackCreator(recipient, recipientErr == nil)
+			}
+
+			if notify {
 				log.Printf("🎯 ADD_LIQUIDITY transaction detected: %s", tx.Hash().Hex())
 				log.Printf("   Token: %s", token.Hex())
-				log.Printf("   Creator (Sender): %s", sender.Hex())
-
-				if err := s.notifyBotService(token, sender, txCallData); err != nil {
-					log.Printf("❌ Failed to notify bot service: %v", err)
+				log.Printf("   Sender: %s", sender.Hex())
+				log.Printf("   LP Recipient: %s", recipient.Hex())
+				log.Printf("   Creator (%s): %s", s.config.CreatorSource, creator.Hex())
+
+				// The bot service submits the LP_ADD with the bundle, so the wallet
+				// gets the hash eth_sendRawTransaction would have returned. If it
+				// can't (e.g. degraded), forward the transaction so the launch isn't lost.
+				if err := s.notifyBotService(config.TriggerAddLiquidity, tx.Hash(), token, creator, sender, recipient, txCallData); err != nil {
+					log.Printf("❌ Failed to notify bot service, forwarding the transaction: %v", err)
+				} else {
+					writeRPCResult(w, req.ID, tx.Hash().Hex())
+					return
 				}
-
-				return
 			}
 		}
 	}
 
+	// Snipe transactions must not leak to a public endpoint in private-only mode
+	if s.config.Enabled(config.FeaturePrivateOnly) && s.isSniperTransaction(tx) {
+		submitURL, err := s.config.SubmitURL()
+		if err != nil {
+			log.Printf("❌ Refusing to relay sniper transaction %s: %v", tx.Hash().Hex(), err)
+			writeRPCError(w, req.ID, rpcErrServer, "Private submission endpoint not configured")
+			return
+		}
+		s.forwardTo(w, req.ID, body, submitURL)
+		return
+	}
+
+	// The launch itself is retried if the sequencer is slow
+	if s.isAddLiquidityTransaction(tx) {
+		s.forwardLaunch(r.Context(), w, req.ID, body, tx.Hash())
+		return
+	}
+
 	// Forward the transaction to Base
-	s.forwardToBase(w, body, true)
+	s.forwardToBase(w, req.ID, body, true)
+}
+
+// isSniperTransaction reports whether tx calls the sniper contract
+func (s *Service) isSniperTransaction(tx *types.Transaction) bool {
+	return tx.To() != nil && *tx.To() == common.HexToAddress(s.config.SniperContract)
 }
 
 func (s *Service) isAddLiquidityTransaction(tx *types.Transaction) bool {
@@ -200,35 +390,105 @@ func (s *Service) isAddLiquidityTransaction(tx *types.Transaction) bool {
 	return bytes.Equal(selector, addLiquidityETHSelector)
 }
 
-func (s *Service) extractTokensFromCreatePair(tx *types.Transaction) (tokenA, tokenB common.Address, err error) {
-	if len(tx.Data()) < 68 { // 4 bytes selector + 32 bytes tokenA + 32 bytes tokenB
-		return common.Address{}, common.Address{}, fmt.Errorf("insufficient data length")
+// isCreatePairTransaction reports whether tx calls createPair on the factory
+func (s *Service) isCreatePairTransaction(tx *types.Transaction) bool {
+	if len(tx.Data()) < 4 || tx.To() == nil {
+		return false
 	}
 
-	// Skip the 4-byte selector
-	data := tx.Data()[4:]
+	if *tx.To() != common.HexToAddress(s.config.UniswapV2Factory) {
+		return false
+	}
 
-	// Extract tokenA (first 32 bytes, but address is in the last 20 bytes)
-	tokenA = common.BytesToAddress(data[12:32])
+	return bytes.Equal(tx.Data()[:4], createPairSelector)
+}
 
-	// Extract tokenB (second 32 bytes, but address is in the last 20 bytes)
-	tokenB = common.BytesToAddress(data[44:64])
+// handleCreatePair notifies the bot service about a token/WETH pair creation.
+// Like an LP_ADD, the transaction is handed to the bot service, which submits
+// it ahead of the snipes. Returns false if tx isn't a WETH pair and should be
+// forwarded as usual.
+func (s *Service) handleCreatePair(tx *types.Transaction, txCallData string) bool {
+	tokenA, tokenB, err := s.extractTokensFromCreatePair(tx)
+	if err != nil {
+		log.Printf("Error extracting tokens from createPair: %v", err)
+		return false
+	}
+
+	var token common.Address
+	switch s.weth {
+	case tokenA:
+		token = tokenB
+	case tokenB:
+		token = tokenA
+	default:
+		// Not an ETH pair, nothing to snipe
+		return false
+	}
+
+	sender, err := s.extractSenderFromTransaction(tx)
+	if err != nil {
+		log.Printf("Error extracting sender from createPair: %v", err)
+
+		var notify bool
+		if sender, notify = s.fallbackCreator(common.Address{}, false); !notify {
+			return false
+		}
+	}
 
-	return tokenA, tokenB, nil
+	log.Printf("🎯 CREATE_PAIR transaction detected: %s", tx.Hash().Hex())
+	log.Printf("   Token: %s", token.Hex())
+	log.Printf("   Creator (Sender): %s", sender.Hex())
+
+	// createPair has no LP recipient; the sender is the creator either way
+	if err := s.notifyBotService(config.TriggerCreatePair, tx.Hash(), token, sender, sender, sender, txCallData); err != nil {
+		log.Printf("❌ Failed to notify bot service: %v", err)
+		return false
+	}
+
+	return true
 }
 
-func (s *Service) extractTokenFromAddLiquidity(tx *types.Transaction) (token common.Address, err error) {
-	if len(tx.Data()) < 36 { // 4 bytes selector + 32 bytes token address
-		return common.Address{}, fmt.Errorf("insufficient data length")
+// fallbackCreator picks the creator of a launch whose signer couldn't be
+// recovered according to SENDER_FALLBACK. The zero address means no creator.
+// Returns false if the launch should not be notified.
+func (s *Service) fallbackCreator(recipient common.Address, hasRecipient bool) (common.Address, bool) {
+	switch s.config.SenderFallback {
+	case config.SenderFallbackSkip:
+		log.Printf("⚠️ Sender unknown, not notifying the bot service")
+		return common.Address{}, false
+	case config.SenderFallbackRecipient:
+		if hasRecipient {
+			log.Printf("⚠️ Sender unknown, using the LP recipient %s as creator", recipient.Hex())
+			return recipient, true
+		}
 	}
 
-	// Skip the 4-byte selector
-	data := tx.Data()[4:]
+	log.Printf("⚠️ Sender unknown, notifying without a creator")
+	return common.Address{}, true
+}
+
+// extractTokensFromCreatePair decodes the two tokens of a createPair call
+func (s *Service) extractTokensFromCreatePair(tx *types.Transaction) (tokenA, tokenB common.Address, err error) {
+	return dex.DecodeCreatePair(tx.Data())
+}
 
-	// Extract token address (first parameter, last 20 bytes of first 32 bytes)
-	token = common.BytesToAddress(data[12:32])
+// extractTokenFromAddLiquidity decodes the token of an addLiquidityETH call
+func (s *Service) extractTokenFromAddLiquidity(tx *types.Transaction) (token common.Address, err error) {
+	args, err := dex.DecodeAddLiquidityETH(tx.Data())
+	if err != nil {
+		return common.Address{}, err
+	}
+	return args.Token, nil
+}
 
-	return token, nil
+// extractRecipientFromAddLiquidity returns the `to` argument of addLiquidityETH,
+// the address that receives the LP tokens
+func (s *Service) extractRecipientFromAddLiquidity(tx *types.Transaction) (common.Address, error) {
+	args, err := dex.DecodeAddLiquidityETH(tx.Data())
+	if err != nil {
+		return common.Address{}, err
+	}
+	return args.To, nil
 }
 
 func (s *Service) extractSenderFromTransaction(tx *types.Transaction) (common.Address, error) {
@@ -260,12 +520,17 @@ func (s *Service) extractSenderFromTransaction(tx *types.Transaction) (common.Ad
 }
 
 // notifyBotService sends LP_ADD notification to the bot service
-func (s *Service) notifyBotService(tokenAddress, creatorAddress common.Address, txCallData string) error {
+func (s *Service) notifyBotService(trigger config.TriggerStrategy, txHash common.Hash, tokenAddress, creatorAddress, senderAddress, recipientAddress common.Address, txCallData string) error {
 	// Prepare payload
 	payload := LPAddNotificationPayload{
-		TokenAddress:   tokenAddress.Hex(),
-		CreatorAddress: creatorAddress.Hex(),
-		TxCallData:     txCallData,
+		Trigger:          string(trigger),
+		TokenAddress:     tokenAddress.Hex(),
+		CreatorAddress:   creatorAddress.Hex(),
+		SenderAddress:    senderAddress.Hex(),
+		RecipientAddress: recipientAddress.Hex(),
+		TxCallData:       txCallData,
+		TxHash:           txHash.Hex(),
+		Sequence:         s.notifySeq.Add(1),
 	}
 
 	// Convert to JSON
@@ -274,20 +539,24 @@ func (s *Service) notifyBotService(tokenAddress, creatorAddress common.Address,
 		return fmt.Errorf("failed to marshal payload: %v", err)
 	}
 
+	return s.sendThroughOutbox(tokenAddress, jsonData)
+}
+
+// postNotification POSTs an LP_ADD notification payload to the bot service
+func (s *Service) postNotification(jsonData []byte) error {
 	// Create HTTP request
 	url := s.botAPIURL + "/api/lp-add"
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
-	cfg := config.Load()
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+cfg.AuthKey)
+	req.Header.Set("Authorization", "Bearer "+s.config.AuthKey)
 
 	// Make the request
-	client := &http.Client{}
+	client := &http.Client{Timeout: notificationTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %v", err)
@@ -304,17 +573,67 @@ func (s *Service) notifyBotService(tokenAddress, creatorAddress common.Address,
 	return nil
 }
 
-func (s *Service) forwardToBase(w http.ResponseWriter, requestBody []byte, isToSequencer bool) {
+// JSON-RPC 2.0 error codes
+const (
+	rpcErrParse          = -32700
+	rpcErrInvalidRequest = -32600
+	rpcErrMethodNotFound = -32601
+	rpcErrInvalidParams  = -32602
+	rpcErrInternal       = -32603
+	rpcErrServer         = -32000 // Proxy misconfiguration
+)
+
+// rpcErrorResponse is a JSON-RPC 2.0 error response
+type rpcErrorResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      interface{} `json:"id"`
+	Error   struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// rpcResultResponse is a JSON-RPC 2.0 success response
+type rpcResultResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      interface{} `json:"id"`
+	Result  interface{} `json:"result"`
+}
+
+// writeRPCResult answers a request the proxy handles itself with a JSON-RPC result
+func writeRPCResult(w http.ResponseWriter, id interface{}, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rpcResultResponse{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+// writeRPCError answers a request with a JSON-RPC error. As with other
+// JSON-RPC servers the HTTP status is 200; the error is in the body.
+func writeRPCError(w http.ResponseWriter, id interface{}, code int, message string) {
+	resp := rpcErrorResponse{JSONRPC: "2.0", ID: id}
+	resp.Error.Code = code
+	resp.Error.Message = message
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Service) forwardToBase(w http.ResponseWriter, id interface{}, requestBody []byte, isToSequencer bool) {
 	// Forward the request to Base
 
 	rpcURL := s.config.BaseRPCURL
 	if isToSequencer {
 		rpcURL = s.config.BaseSequencerRPCURL
 	}
+	s.forwardTo(w, id, requestBody, rpcURL)
+}
+
+// forwardTo relays the request with the given id to rpcURL and copies the
+// response back
+func (s *Service) forwardTo(w http.ResponseWriter, id interface{}, requestBody []byte, rpcURL string) {
 	resp, err := http.Post(rpcURL, "application/json", bytes.NewReader(requestBody))
 	if err != nil {
 		log.Printf("Error forwarding to Base: %v", err)
-		http.Error(w, "Failed to forward request to Base", http.StatusInternalServerError)
+		writeRPCError(w, id, rpcErrInternal, "Failed to forward request to Base")
 		return
 	}
 	defer resp.Body.Close()
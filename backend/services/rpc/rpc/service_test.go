@@ -0,0 +1,365 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sniper-bot/pkg/config"
+	"sniper-bot/pkg/dex"
+	"sniper-bot/services/bot/db"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func TestHandleRPCErrors(t *testing.T) {
+	s := &Service{config: &config.Config{RPCDeniedMethods: []string{"debug_traceTransaction"}}}
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		id   string
+	}{
+		{"malformed JSON", `{"jsonrpc":"2.0","id":1,`, rpcErrParse, "null"},
+		{"no method", `{"jsonrpc":"2.0","id":7}`, rpcErrInvalidRequest, "7"},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}]`, rpcErrInvalidRequest, "null"},
+		{"denied method", `{"jsonrpc":"2.0","id":"abc","method":"debug_traceTransaction","params":[]}`, rpcErrMethodNotFound, `"abc"`},
+		{"params not a list", `{"jsonrpc":"2.0","id":2,"method":"eth_sendRawTransaction","params":{"tx":"0x"}}`, rpcErrInvalidParams, "2"},
+		{"no transaction", `{"jsonrpc":"2.0","id":3,"method":"eth_sendRawTransaction","params":[]}`, rpcErrInvalidParams, "3"},
+		{"transaction not hex", `{"jsonrpc":"2.0","id":4,"method":"eth_sendRawTransaction","params":["xyz"]}`, rpcErrInvalidParams, "4"},
+		{"transaction not RLP", `{"jsonrpc":"2.0","id":5,"method":"eth_sendRawTransaction","params":["0x1234"]}`, rpcErrInvalidParams, "5"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.handleRPC(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: HTTP status %d, want 200", tt.name, rec.Code)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("%s: Content-Type %q", tt.name, contentType)
+		}
+
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: response %q isn't a JSON object: %v", tt.name, rec.Body, err)
+		}
+		if string(resp["jsonrpc"]) != `"2.0"` {
+			t.Errorf("%s: jsonrpc %s", tt.name, resp["jsonrpc"])
+		}
+		if string(resp["id"]) != tt.id {
+			t.Errorf("%s: id %s, want %s", tt.name, resp["id"], tt.id)
+		}
+		if _, ok := resp["result"]; ok {
+			t.Errorf("%s: error response has a result", tt.name)
+		}
+
+		var rpcErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(resp["error"], &rpcErr); err != nil {
+			t.Fatalf("%s: error %s: %v", tt.name, resp["error"], err)
+		}
+		if rpcErr.Code != tt.code || rpcErr.Message == "" {
+			t.Errorf("%s: error %+v, want code %d", tt.name, rpcErr, tt.code)
+		}
+	}
+}
+
+func TestMetricsRequireAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		authKey string
+		header  string
+		status  int
+	}{
+		{"no credential", "auth-key", "", http.StatusUnauthorized},
+		{"unknown key", "auth-key", "Bearer guess", http.StatusUnauthorized},
+		{"key without Bearer", "auth-key", "auth-key", http.StatusUnauthorized},
+		{"AUTH_KEY", "auth-key", "Bearer auth-key", http.StatusOK},
+		{"AUTH_KEY unset", "", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		s := &Service{
+			config: &config.Config{AuthKey: tt.authKey},
+			cache:  newRPCCache(map[string]time.Duration{"eth_chainId": time.Minute}),
+		}
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if served := strings.Contains(rec.Body.String(), `"cache"`); served != (tt.status == http.StatusOK) {
+			t.Errorf("%s: body %q", tt.name, rec.Body.String())
+		}
+	}
+}
+
+// Addresses of the launch contracts in the forwarding tests
+var (
+	testRouter  = common.HexToAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24")
+	testFactory = common.HexToAddress("0x8909Dc15e40173Ff4699343b6eB8132c65e18eC6")
+	testWETH    = common.HexToAddress(config.WETHAddress)
+	testToken   = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+)
+
+// stubNode is a JSON-RPC endpoint standing in for Base or its sequencer. It
+// answers eth_chainId itself and everything else with reply.
+type stubNode struct {
+	*httptest.Server
+	mu      sync.Mutex
+	methods []string // Methods received other than eth_chainId, in order
+}
+
+func newStubNode(t *testing.T, reply func(w http.ResponseWriter, id json.RawMessage, method string)) *stubNode {
+	node := &stubNode{}
+	node.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &req)
+
+		if req.Method == "eth_chainId" {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x2105"}`, req.ID)
+			return
+		}
+		node.mu.Lock()
+		node.methods = append(node.methods, req.Method)
+		node.mu.Unlock()
+		reply(w, req.ID, req.Method)
+	}))
+	t.Cleanup(node.Close)
+	return node
+}
+
+func (n *stubNode) received() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.methods...)
+}
+
+// downDriver is a database/sql driver that can't connect, so the outbox
+// falls back to delivering notifications directly
+type downDriver struct{}
+
+func (downDriver) Open(string) (driver.Conn, error) { return nil, errors.New("database down") }
+
+var registerDownDriver sync.Once
+
+func newDownDB(t *testing.T) *db.DB {
+	registerDownDriver.Do(func() { sql.Register("rpctest-down", downDriver{}) })
+	conn, err := sql.Open("rpctest-down", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return db.NewFromConn(conn, db.DialectFor(""))
+}
+
+// signedRawTx signs a transaction of key's to to with data and returns it
+// with its eth_sendRawTransaction request
+func signedRawTx(t *testing.T, key *ecdsa.PrivateKey, to common.Address, data []byte) (*types.Transaction, string) {
+	t.Helper()
+	tx, err := types.SignNewTx(key, types.NewLondonSigner(big.NewInt(8453)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(8453),
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(2e9),
+		Gas:       300000,
+		To:        &to,
+		Value:     big.NewInt(1e18),
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx, fmt.Sprintf(`{"jsonrpc":"2.0","id":9,"method":"eth_sendRawTransaction","params":["%s"]}`, hexutil.Encode(raw))
+}
+
+// launchCalldata packs a call to method of the router or factory
+func launchCalldata(t *testing.T, method string, args ...interface{}) []byte {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(dex.UniswapV2LaunchABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parsed.Pack(method, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestHandleRPCForwards(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	creator := crypto.PubkeyToAddress(key.PublicKey)
+
+	_, transfer := signedRawTx(t, key, common.HexToAddress("0x00000000000000000000000000000000000000bb"), nil)
+	addLiquidity, addLiquidityReq := signedRawTx(t, key, testRouter, launchCalldata(t, "addLiquidityETH",
+		testToken, big.NewInt(1e18), big.NewInt(1e18), big.NewInt(1e18), creator, big.NewInt(time.Now().Add(time.Hour).Unix())))
+	createPair, createPairReq := signedRawTx(t, key, testFactory, launchCalldata(t, "createPair", testToken, testWETH))
+	_, otherPairReq := signedRawTx(t, key, testFactory, launchCalldata(t, "createPair", testToken, common.HexToAddress("0x00000000000000000000000000000000000000cc")))
+
+	upstreamReply := func(w http.ResponseWriter, id json.RawMessage, method string) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"upstream %s"}`, id, method)
+	}
+
+	tests := []struct {
+		name      string
+		trigger   config.TriggerStrategy
+		body      string
+		botStatus int      // Status of the bot service's answer to an LP_ADD notification
+		result    string   // Result the wallet gets back
+		base      []string // Methods Base receives
+		sequencer []string // Methods the sequencer receives
+		notified  string   // Hash of the launch the bot service is notified of
+	}{
+		{
+			name:    "read method",
+			trigger: config.TriggerAddLiquidity,
+			body:    `{"jsonrpc":"2.0","id":9,"method":"eth_blockNumber","params":[]}`,
+			result:  "upstream eth_blockNumber",
+			base:    []string{"eth_blockNumber"},
+		},
+		{
+			name:      "transaction",
+			trigger:   config.TriggerAddLiquidity,
+			body:      transfer,
+			result:    "upstream eth_sendRawTransaction",
+			sequencer: []string{"eth_sendRawTransaction"},
+		},
+		{
+			name:      "intercepted addLiquidityETH",
+			trigger:   config.TriggerAddLiquidity,
+			body:      addLiquidityReq,
+			botStatus: http.StatusOK,
+			result:    addLiquidity.Hash().Hex(),
+			notified:  addLiquidity.Hash().Hex(),
+		},
+		{
+			name:      "addLiquidityETH the bot service refuses",
+			trigger:   config.TriggerAddLiquidity,
+			body:      addLiquidityReq,
+			botStatus: http.StatusServiceUnavailable,
+			result:    "upstream eth_sendRawTransaction",
+			sequencer: []string{"eth_sendRawTransaction"},
+			notified:  addLiquidity.Hash().Hex(),
+		},
+		{
+			name:      "intercepted createPair",
+			trigger:   config.TriggerCreatePair,
+			body:      createPairReq,
+			botStatus: http.StatusOK,
+			result:    createPair.Hash().Hex(),
+			notified:  createPair.Hash().Hex(),
+		},
+		{
+			name:      "createPair without WETH",
+			trigger:   config.TriggerCreatePair,
+			body:      otherPairReq,
+			result:    "upstream eth_sendRawTransaction",
+			sequencer: []string{"eth_sendRawTransaction"},
+		},
+	}
+
+	for _, tt := range tests {
+		base := newStubNode(t, upstreamReply)
+		sequencer := newStubNode(t, upstreamReply)
+
+		var notified []LPAddNotificationPayload
+		var mu sync.Mutex
+		bot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload LPAddNotificationPayload
+			json.NewDecoder(r.Body).Decode(&payload)
+			mu.Lock()
+			notified = append(notified, payload)
+			mu.Unlock()
+			w.WriteHeader(tt.botStatus)
+		}))
+		defer bot.Close()
+
+		client, err := ethclient.Dial(base.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &Service{
+			config: &config.Config{
+				BaseRPCURL:            base.URL,
+				BaseSequencerRPCURL:   sequencer.URL,
+				TriggerStrategy:       tt.trigger,
+				UniswapV2Router:       testRouter.Hex(),
+				UniswapV2Factory:      testFactory.Hex(),
+				CreatorSource:         config.CreatorSourceSender,
+				LaunchForwardAttempts: 1,
+				LaunchForwardTimeout:  time.Second,
+				LaunchForwardDeadline: 5 * time.Second,
+			},
+			db:         newDownDB(t),
+			baseClient: client,
+			botAPIURL:  bot.URL,
+			weth:       testWETH,
+		}
+
+		rec := httptest.NewRecorder()
+		s.handleRPC(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+		var resp struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Result  string          `json:"result"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Errorf("%s: response %q isn't JSON-RPC: %v", tt.name, rec.Body, err)
+			continue
+		}
+		if resp.JSONRPC != "2.0" || string(resp.ID) != "9" || resp.Result != tt.result {
+			t.Errorf("%s: response %s, want result %q for id 9", tt.name, rec.Body, tt.result)
+		}
+
+		if got := base.received(); strings.Join(got, ",") != strings.Join(tt.base, ",") {
+			t.Errorf("%s: Base received %v, want %v", tt.name, got, tt.base)
+		}
+		if got := sequencer.received(); strings.Join(got, ",") != strings.Join(tt.sequencer, ",") {
+			t.Errorf("%s: sequencer received %v, want %v", tt.name, got, tt.sequencer)
+		}
+
+		switch {
+		case tt.notified == "" && len(notified) > 0:
+			t.Errorf("%s: bot service notified of %s", tt.name, notified[0].TxHash)
+		case tt.notified != "" && (len(notified) != 1 || notified[0].TxHash != tt.notified):
+			t.Errorf("%s: bot service notified of %+v, want launch %s", tt.name, notified, tt.notified)
+		case tt.notified != "" && notified[0].TokenAddress != testToken.Hex():
+			t.Errorf("%s: notified token %s, want %s", tt.name, notified[0].TokenAddress, testToken.Hex())
+		}
+	}
+}